@@ -45,6 +45,7 @@ type ApplyContext struct {
 	CfaInlineActions     []types.Action
 	PendingConsoleOutput string
 	AccountRamDeltas     AccountDeltaSet
+	InstructionWeight    uint64 // weight of the instructions of the contract of the current receiver
 	ilog                 log.Logger
 
 	// PseudoStart common.TimePoint
@@ -91,6 +92,12 @@ func NewApplyContext(control *Controller, trxContext *TransactionContext, act *t
 
 }
 
+// iteratorCaches are the iterator caches of the primary and the secondary indexes
+func (a *ApplyContext) iteratorCaches() []**iteratorCache {
+	return []**iteratorCache{&a.KeyvalCache, &a.idx64.itrCache, &a.idx128.itrCache, &a.idx256.itrCache,
+		&a.idxDouble.itrCache, &a.idxLongDouble.itrCache}
+}
+
 type pairTableIterator struct {
 	tableIDObject *entity.TableIdObject
 	iterator      int
@@ -115,6 +122,39 @@ func NewIteratorCache() *iteratorCache {
 	return &i
 }
 
+// clone copies the cache with copies of the rows it holds, the rows of the copy can be modified without the cache
+func (i *iteratorCache) clone() *iteratorCache {
+	copies := make(map[interface{}]interface{})
+	copyOf := func(obj interface{}) interface{} {
+		if obj == nil {
+			return nil
+		}
+		if c, ok := copies[obj]; ok {
+			return c
+		}
+		v := reflect.ValueOf(obj)
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(v.Elem())
+		copies[obj] = c.Interface()
+		return c.Interface()
+	}
+
+	c := NewIteratorCache()
+	for id, pair := range i.tableCache {
+		c.tableCache[id] = &pairTableIterator{copyOf(pair.tableIDObject).(*entity.TableIdObject), pair.iterator}
+	}
+	for _, tobj := range i.endIteratorToTable {
+		c.endIteratorToTable = append(c.endIteratorToTable, copyOf(tobj).(*entity.TableIdObject))
+	}
+	for _, obj := range i.iteratorToObject {
+		c.iteratorToObject = append(c.iteratorToObject, copyOf(obj))
+	}
+	for obj, itr := range i.objectToIterator {
+		c.objectToIterator[copyOf(obj)] = itr
+	}
+	return c
+}
+
 func (i *iteratorCache) endIteratorToIndex(ei int) int    { return (-ei - 2) }
 func (i *iteratorCache) IndexToEndIterator(index int) int { return -(index + 2) }
 func (i *iteratorCache) cacheTable(tobj *entity.TableIdObject) int {
@@ -305,6 +345,8 @@ func (a *ApplyContext) FinalizeTrace(trace *types.ActionTrace, start *common.Tim
 	//a.AccountRamDeltas.clear()
 	trace.Console = a.PendingConsoleOutput
 	a.resetConsole()
+	trace.InstructionWeight = a.InstructionWeight
+	a.InstructionWeight = 0
 	trace.Elapsed = common.Now().Sub(*start)
 
 }
@@ -892,17 +934,15 @@ func (a *ApplyContext) DbPreviousI64(iterator int, primary *uint64) int {
 
 		obj := entity.KeyValueObject{TId: tab.ID}
 
-		itr, _ := idx.UpperBound(&obj, database.SKIP_ONE)
-		if idx.CompareIterator(idx.Begin(), idx.End()) || idx.CompareBegin(itr) {
-			a.ilog.Info("iterator is the begin(nil), iteratorIn:%d iteratorOut:%d", iterator, -1) // Empty table
-			return -1
-		}
-
-		itr.Prev()
+		upper, _ := idx.UpperBound(&obj, database.SKIP_ONE)
 		objPrev := entity.KeyValueObject{}
-		itr.Data(&objPrev)
+		found := false
+		idx.ReverseRange(nil, upper, func(itr database.Iterator) bool {
+			found = itr.Data(&objPrev) == nil
+			return false
+		})
 
-		if objPrev.TId != tab.ID {
+		if !found || objPrev.TId != tab.ID {
 			a.ilog.Info("previous iterator out of tid, iteratorIn:%d iteratorOut:%d", iterator, -1) // Empty table
 			return -1
 		}
@@ -917,11 +957,9 @@ func (a *ApplyContext) DbPreviousI64(iterator int, primary *uint64) int {
 
 	obj := (a.KeyvalCache.get(iterator)).(*entity.KeyValueObject)
 	itr := idx.IteratorTo(obj)
-	if idx.CompareBegin(itr) {
+	if !itr.Prev() {
 		return -1 // cannot decrement past beginning iterator of table
 	}
-
-	itr.Prev()
 	objPrev := entity.KeyValueObject{}
 	itr.Data(&objPrev)
 
@@ -1420,6 +1458,16 @@ func (a *ApplyContext) ResumeBillingTimer() {
 
 }
 
+func (a *ApplyContext) AddInstructionWeight(weight uint64) {
+	a.InstructionWeight += weight
+}
+
+// GasLimited holds the speculative and produced transactions to the gas limit, the limit is subjective and the
+// blocks of other producers are validated without it
+func (a *ApplyContext) GasLimited() bool {
+	return a.Control.IsProducingBlock()
+}
+
 func (a *ApplyContext) ContextFreeAction() bool {
 	return a.ContextFree
 }
@@ -0,0 +1,219 @@
+package chain
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+)
+
+/**
+ * A block archive is a portable copy of a range of irreversible blocks, it does not depend on the layout of blocks.log.
+ * The archive is gzip compressed.
+ *
+ * layout:
+ *   magic number (uint32) | version (uint32) | header | block ...
+ * header:
+ *   chain id | first block num (uint32) | last block num (uint32)
+ * block:
+ *   size (uint32) | rlp packed signed block | sha256 of the packed block
+ */
+const (
+	blockArchiveMagicNumber = uint32(0x41524b42)
+	blockArchiveVersion     = uint32(1)
+	maxArchivedBlockSize    = uint32(32 * 1024 * 1024) // far above the packed size of any block the chain accepts
+)
+
+type BlockArchiveHeader struct {
+	ChainId       common.ChainIdType
+	FirstBlockNum uint32
+	LastBlockNum  uint32
+}
+
+type BlockArchiveWriter struct {
+	out    *gzip.Writer
+	Header BlockArchiveHeader
+	next   uint32
+}
+
+func NewBlockArchiveWriter(out io.Writer, header BlockArchiveHeader) *BlockArchiveWriter {
+	EosAssert(header.FirstBlockNum > 0 && header.FirstBlockNum <= header.LastBlockNum, &BlockLogException{},
+		"invalid block range [%d,%d] of block archive", header.FirstBlockNum, header.LastBlockNum)
+
+	w := &BlockArchiveWriter{out: gzip.NewWriter(out), Header: header, next: header.FirstBlockNum}
+	w.write(blockArchiveMagicNumber)
+	w.write(blockArchiveVersion)
+	w.write(&header)
+	return w
+}
+
+func (w *BlockArchiveWriter) write(v interface{}) {
+	data, err := rlp.EncodeToBytes(v)
+	Throw(err)
+	_, err = w.out.Write(data)
+	Throw(err)
+}
+
+func (w *BlockArchiveWriter) Append(block *types.SignedBlock) {
+	EosAssert(block.BlockNumber() == w.next, &BlockLogException{},
+		"block %d appended to block archive, expected block %d", block.BlockNumber(), w.next)
+
+	data, err := rlp.EncodeToBytes(block)
+	Throw(err)
+	checksum := sha256.Sum256(data)
+
+	w.write(uint32(len(data)))
+	_, err = w.out.Write(data)
+	Throw(err)
+	_, err = w.out.Write(checksum[:])
+	Throw(err)
+	w.next++
+}
+
+/**
+ * flushes the compressed stream, all the blocks of the header range must have been appended
+ */
+func (w *BlockArchiveWriter) Close() {
+	EosAssert(w.next == w.Header.LastBlockNum+1, &BlockLogException{},
+		"block archive is incomplete, blocks after %d are missing", w.next-1)
+	Throw(w.out.Close())
+}
+
+type BlockArchiveReader struct {
+	in     *gzip.Reader
+	Header BlockArchiveHeader
+	next   uint32
+}
+
+func NewBlockArchiveReader(in io.Reader) *BlockArchiveReader {
+	gz, err := gzip.NewReader(in)
+	EosAssert(err == nil, &BlockLogException{}, "block archive is not compressed as expected: %s", err)
+
+	r := &BlockArchiveReader{in: gz}
+	EosAssert(r.readUint32() == blockArchiveMagicNumber, &BlockLogException{}, "Block archive has unexpected magic number!")
+	version := r.readUint32()
+	EosAssert(version == blockArchiveVersion, &BlockLogUnsupportedVersion{},
+		"Block archive is an unsupported version. Expected : %d, Got: %d", blockArchiveVersion, version)
+
+	header := make([]byte, 32+SizeOfInt32*2)
+	r.readFull(header)
+	Throw(rlp.DecodeBytes(header, &r.Header))
+	EosAssert(r.Header.FirstBlockNum > 0 && r.Header.FirstBlockNum <= r.Header.LastBlockNum, &BlockLogException{},
+		"invalid block range [%d,%d] of block archive", r.Header.FirstBlockNum, r.Header.LastBlockNum)
+	r.next = r.Header.FirstBlockNum
+	return r
+}
+
+func (r *BlockArchiveReader) readFull(buf []byte) {
+	_, err := io.ReadFull(r.in, buf)
+	EosAssert(err == nil, &BlockLogException{}, "unexpected end of block archive: %s", err)
+}
+
+func (r *BlockArchiveReader) readUint32() uint32 {
+	buf := make([]byte, SizeOfInt32)
+	r.readFull(buf)
+	var v uint32
+	Throw(rlp.DecodeBytes(buf, &v))
+	return v
+}
+
+/**
+ * reads the next block and verifies its checksum, returns nil after the last block of the archive
+ */
+func (r *BlockArchiveReader) Next() *types.SignedBlock {
+	if r.next > r.Header.LastBlockNum {
+		return nil
+	}
+
+	size := r.readUint32()
+	EosAssert(size <= maxArchivedBlockSize, &BlockLogException{},
+		"block %d in block archive has size %d, larger than the maximum block size %d", r.next, size, maxArchivedBlockSize)
+	data := make([]byte, size)
+	r.readFull(data)
+	checksum := make([]byte, sha256.Size)
+	r.readFull(checksum)
+	expected := sha256.Sum256(data)
+	EosAssert(bytes.Equal(checksum, expected[:]), &BlockLogException{}, "checksum mismatch of block %d in block archive", r.next)
+
+	block := &types.SignedBlock{}
+	Throw(rlp.DecodeBytes(data, block))
+	EosAssert(block.BlockNumber() == r.next, &BlockLogException{},
+		"block archive contains block %d, expected block %d", block.BlockNumber(), r.next)
+	r.next++
+	return block
+}
+
+/**
+ * exports the blocks [firstBlockNum, lastBlockNum] of the block log in blocksDir, 0 selects the first block and
+ * the head block of the log respectively
+ */
+func ExportBlocks(blocksDir string, out io.Writer, firstBlockNum uint32, lastBlockNum uint32) BlockArchiveHeader {
+	EosAssert(common.FileExist(blocksDir+"/blocks.log"), &BlockLogNotFound{}, "Block log not found in '%s'", blocksDir)
+	blog := NewBlockLog(blocksDir)
+	defer blog.Close()
+	EosAssert(blog.Head() != nil, &BlockLogException{}, "Block log in '%s' has no blocks", blocksDir)
+
+	if firstBlockNum == 0 {
+		firstBlockNum = blog.firstBlockNum
+	}
+	if lastBlockNum == 0 {
+		lastBlockNum = blog.Head().BlockNumber()
+	}
+	EosAssert(firstBlockNum >= blog.firstBlockNum && lastBlockNum <= blog.Head().BlockNumber(), &BlockLogException{},
+		"block range [%d,%d] is not in the block log which has blocks [%d,%d]",
+		firstBlockNum, lastBlockNum, blog.firstBlockNum, blog.Head().BlockNumber())
+
+	gs := ExtractGenesisState(blocksDir)
+	writer := NewBlockArchiveWriter(out, BlockArchiveHeader{
+		ChainId:       gs.ComputeChainID(),
+		FirstBlockNum: firstBlockNum,
+		LastBlockNum:  lastBlockNum,
+	})
+	for num := firstBlockNum; num <= lastBlockNum; num++ {
+		block := blog.ReadBlockByNum(num)
+		EosAssert(block != nil, &BlockLogException{}, "block %d could not be read from the block log", num)
+		writer.Append(block)
+		if num%1000 == 0 {
+			log.Info("Exported block %d", num)
+		}
+	}
+	writer.Close()
+
+	return writer.Header
+}
+
+/**
+ * validates the blocks of the archive and pushes the blocks after the head block, the blocks the chain already
+ * has must be the same. Returns the number of blocks applied.
+ */
+func (c *Controller) ImportBlocks(archive *BlockArchiveReader) uint32 {
+	EosAssert(archive.Header.ChainId == c.ChainID, &ChainIdTypeException{},
+		"block archive chain id %s does not match the chain id %s of the controller", archive.Header.ChainId, c.ChainID)
+
+	c.AbortBlock()
+	applied := uint32(0)
+	for block := archive.Next(); block != nil; block = archive.Next() {
+		num := block.BlockNumber()
+		if num <= c.HeadBlockNum() {
+			EosAssert(c.GetBlockIdForNum(num) == block.BlockID(), &BlockValidateException{},
+				"block %d of the archive does not match the block of the chain", num)
+			continue
+		}
+
+		EosAssert(block.Previous == c.HeadBlockId(), &UnlinkableBlockException{},
+			"block %d of the archive does not link to the head block %d", num, c.HeadBlockNum())
+		c.PushBlock(block, types.Complete)
+		applied++
+		if num%1000 == 0 {
+			log.Info("Imported block %d", num)
+		}
+	}
+	return applied
+}
@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockArchive(t *testing.T) {
+	dataDir := "/tmp/block_archive_test/blocks"
+	defer os.RemoveAll("/tmp/block_archive_test")
+	blocks := newTestBlockLog(t, dataDir, 10)
+
+	buf := &bytes.Buffer{}
+	header := ExportBlocks(dataDir, buf, 3, 0)
+	assert.Equal(t, uint32(3), header.FirstBlockNum)
+	assert.Equal(t, uint32(10), header.LastBlockNum)
+
+	reader := NewBlockArchiveReader(bytes.NewReader(buf.Bytes()))
+	assert.Equal(t, header, reader.Header)
+	count := 0
+	for block := reader.Next(); block != nil; block = reader.Next() {
+		assert.Equal(t, blocks[block.BlockNumber()-1].BlockID(), block.BlockID())
+		count++
+	}
+	assert.Equal(t, 8, count)
+
+	// a damaged archive is detected
+	compressed := &bytes.Buffer{}
+	writer := NewBlockArchiveWriter(compressed, BlockArchiveHeader{FirstBlockNum: 1, LastBlockNum: 1})
+	writer.out.Write([]byte{0x10, 0x00, 0x00, 0x00})
+	writer.out.Write(make([]byte, 16+32))
+	writer.next = 2
+	writer.Close()
+
+	returning := false
+	Try(func() {
+		NewBlockArchiveReader(bytes.NewReader(compressed.Bytes())).Next()
+	}).Catch(func(e *BlockLogException) {
+		returning = true
+	}).End()
+	assert.True(t, returning)
+
+	// an oversized length is refused before the block is read
+	compressed = &bytes.Buffer{}
+	writer = NewBlockArchiveWriter(compressed, BlockArchiveHeader{FirstBlockNum: 1, LastBlockNum: 1})
+	writer.out.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	writer.next = 2
+	writer.Close()
+
+	message := ""
+	Try(func() {
+		NewBlockArchiveReader(bytes.NewReader(compressed.Bytes())).Next()
+	}).Catch(func(e *BlockLogException) {
+		message = e.DetailMessage()
+	}).End()
+	assert.Contains(t, message, "larger than the maximum block size")
+}
+
+func TestController_ImportBlocks(t *testing.T) {
+	con := newSnapshotTestController(path + "import_a/")
+	defer os.RemoveAll(path + "import_a/")
+	con.Startup()
+	for i := 0; i < 5; i++ {
+		produceProcess(con)
+	}
+	con.Close()
+
+	blog := NewBlockLog(path + "import_a/" + NewConfig().BlocksDir)
+	lastBlock := blog.Head()
+	blog.Close()
+	assert.NotNil(t, lastBlock)
+
+	buf := &bytes.Buffer{}
+	ExportBlocks(path+"import_a/"+NewConfig().BlocksDir, buf, 0, 0)
+
+	imported := newSnapshotTestController(path + "import_b/")
+	defer os.RemoveAll(path + "import_b/")
+	imported.Startup()
+	applied := imported.ImportBlocks(NewBlockArchiveReader(bytes.NewReader(buf.Bytes())))
+
+	assert.Equal(t, lastBlock.BlockNumber()-1, applied)
+	assert.Equal(t, lastBlock.BlockID(), imported.HeadBlockId())
+	imported.Close()
+}
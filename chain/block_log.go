@@ -1,13 +1,17 @@
 package chain
 
 import (
+	"fmt"
 	"github.com/eosspark/eos-go/chain/types"
 	"github.com/eosspark/eos-go/common"
 	"github.com/eosspark/eos-go/crypto/rlp"
 	. "github.com/eosspark/eos-go/exception"
 	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
 )
 
 type BlockLog struct {
@@ -23,12 +27,17 @@ type BlockLog struct {
 	//indexWrite bool
 
 	genesisWriteToBlockLog bool
+
+	version       uint32
+	firstBlockNum uint32 // the log of a node started from a snapshot begins after the snapshot block
+	firstBlockPos int64
 }
 
 const (
 	/// static field
-	nPos             = math.MaxUint64
-	supportedVersion = uint32(1)
+	nPos                = math.MaxUint64
+	minSupportedVersion = uint32(1)
+	maxSupportedVersion = uint32(2) // version 2 records the number of the first block of the log
 
 	/// sizeof
 	SizeOfInt32 = 4
@@ -78,7 +87,7 @@ func NewBlockLog(dataDir string) *BlockLog {
 
 	_, err := os.Stat(dataDir)
 	if err != nil {
-		os.Mkdir(dataDir, os.ModePerm)
+		os.MkdirAll(dataDir, os.ModePerm)
 	}
 
 	blockLog.blockFile = dataDir + "/blocks.log"
@@ -105,24 +114,23 @@ func NewBlockLog(dataDir string) *BlockLog {
 	if logSize > 0 {
 
 		blockLog.blockStream.Seek(0, 0)
-		var version uint32 = 0
-		bytes := make([]byte, SizeOfInt32)
-		blockLog.blockStream.Read(bytes)
-		rlp.DecodeBytes(bytes, &version)
-
-		EosAssert(version > 0, &BlockLogAppendFail{}, "Block log was not setup properly with genesis information.")
-		EosAssert(version == supportedVersion,
-			&BlockLogUnsupportedVersion{},
-			"Unsupported version of block log. Block log version is %d while code supports version %d",
-			version, supportedVersion)
+		blockLog.version, blockLog.firstBlockNum = readBlockLogHeader(blockLog.blockStream)
+		blockLog.firstBlockPos = skipGenesisState(blockLog.blockStream)
 
 		blockLog.genesisWriteToBlockLog = true
 		blockLog.head = blockLog.ReadHead()
-		blockLog.headId = blockLog.head.BlockID()
+		if blockLog.head != nil {
+			blockLog.headId = blockLog.head.BlockID()
+		}
 
-		if indexSize > 0 {
+		if blockLog.head == nil {
+			if indexSize > 0 {
+				//ilog("Log has no blocks, recreate the index")
+				blockLog.ConstructIndex()
+			}
+		} else if indexSize > 0 {
 			var blockPos int64 = 0
-			bytes = make([]byte, SizeOfInt64)
+			bytes := make([]byte, SizeOfInt64)
 			blockLog.blockStream.Seek(-SizeOfInt64, end) //sizeof(blockPos)
 			blockLog.blockStream.Read(bytes)
 			rlp.DecodeBytes(bytes, &blockPos)
@@ -154,6 +162,43 @@ func NewBlockLog(dataDir string) *BlockLog {
 	return blockLog
 }
 
+/**
+ * reads the version and the first block number from the beginning of a block log
+ */
+func readBlockLogHeader(blockStream *os.File) (uint32, uint32) {
+	var version uint32 = 0
+	bytes := make([]byte, SizeOfInt32)
+	blockStream.Read(bytes)
+	rlp.DecodeBytes(bytes, &version)
+
+	EosAssert(version > 0, &BlockLogAppendFail{}, "Block log was not setup properly with genesis information.")
+	EosAssert(version >= minSupportedVersion && version <= maxSupportedVersion,
+		&BlockLogUnsupportedVersion{},
+		"Unsupported version of block log. Block log version is %d while code supports version(s) [%d,%d]",
+		version, minSupportedVersion, maxSupportedVersion)
+
+	firstBlockNum := uint32(1)
+	if version != 1 {
+		blockStream.Read(bytes)
+		rlp.DecodeBytes(bytes, &firstBlockNum)
+	}
+	return version, firstBlockNum
+}
+
+/**
+ * skips the genesis state following the header, returns the position of the first block
+ */
+func skipGenesisState(blockStream *os.File) int64 {
+	var gsSize uint32
+	gsSizeBytes := make([]byte, SizeOfInt32)
+	blockStream.Read(gsSizeBytes)
+	rlp.DecodeBytes(gsSizeBytes, &gsSize)
+
+	pos, err := blockStream.Seek(int64(gsSize), cur)
+	Throw(err)
+	return pos
+}
+
 func (b *BlockLog) Close() {
 	if b != nil {
 		b.flush()
@@ -172,8 +217,10 @@ func (b *BlockLog) Append(block *types.SignedBlock) uint64 {
 	indexPos, err := b.indexStream.Seek(0, end)
 	Throw(err)
 
-	EosAssert(indexPos == int64(SizeOfInt64*(block.BlockNumber()-1)), &BlockLogAppendFail{},
-		"Append to index file occuring at wrong position. position %d expected %d", indexPos, SizeOfInt64*(block.BlockNumber()-1))
+	EosAssert(block.BlockNumber() >= b.firstBlockNum, &BlockLogAppendFail{},
+		"Append of block %d to a block log starting at block %d", block.BlockNumber(), b.firstBlockNum)
+	EosAssert(indexPos == int64(SizeOfInt64*(block.BlockNumber()-b.firstBlockNum)), &BlockLogAppendFail{},
+		"Append to index file occuring at wrong position. position %d expected %d", indexPos, SizeOfInt64*(block.BlockNumber()-b.firstBlockNum))
 
 	data, _ := rlp.EncodeToBytes(block)
 
@@ -196,6 +243,13 @@ func (b *BlockLog) flush() {
 	b.indexStream.Sync()
 }
 func (b *BlockLog) ResetToGenesis(gs *types.GenesisState, benesisBlock *types.SignedBlock) uint64 {
+	return b.Reset(gs, benesisBlock, 1)
+}
+
+/**
+ * starts a new block log at firstBlockNum, firstBlock may be nil when the state was loaded from a snapshot
+ */
+func (b *BlockLog) Reset(gs *types.GenesisState, firstBlock *types.SignedBlock, firstBlockNum uint32) uint64 {
 	var err error
 
 	if b.blockStream != nil {
@@ -217,6 +271,9 @@ func (b *BlockLog) ResetToGenesis(gs *types.GenesisState, benesisBlock *types.Si
 	bytes, _ := rlp.EncodeToBytes(version)
 	b.blockStream.Write(bytes)
 
+	bytes, _ = rlp.EncodeToBytes(firstBlockNum)
+	b.blockStream.Write(bytes)
+
 	bytes, _ = rlp.EncodeToBytes(gs)
 
 	size := uint32(len(bytes))
@@ -225,7 +282,17 @@ func (b *BlockLog) ResetToGenesis(gs *types.GenesisState, benesisBlock *types.Si
 	b.blockStream.Write(bytes)
 	b.genesisWriteToBlockLog = true
 
-	ret := b.Append(benesisBlock)
+	b.version = maxSupportedVersion
+	b.firstBlockNum = firstBlockNum
+	b.firstBlockPos, err = b.blockStream.Seek(0, cur)
+	Throw(err)
+	b.head = nil
+	b.headId = common.BlockIdType{}
+
+	ret := uint64(b.firstBlockPos)
+	if firstBlock != nil {
+		ret = b.Append(firstBlock)
+	}
 	_, err = b.blockStream.Seek(0, end)
 	Throw(err)
 
@@ -233,7 +300,7 @@ func (b *BlockLog) ResetToGenesis(gs *types.GenesisState, benesisBlock *types.Si
 	b.blockStream, err = os.OpenFile(b.blockFile, os.O_RDWR, os.ModePerm)
 	Throw(err)
 
-	bytes, _ = rlp.EncodeToBytes(supportedVersion)
+	bytes, _ = rlp.EncodeToBytes(maxSupportedVersion)
 	b.blockStream.Write(bytes)
 
 	b.flush()
@@ -288,13 +355,13 @@ func (b *BlockLog) ReadBlockById(id *common.BlockIdType) *types.SignedBlock {
 
 func (b *BlockLog) GetBlockPos(blockNum uint32) uint64 {
 
-	if !(b.head != nil && blockNum <= types.NumFromID(&b.headId) && blockNum > 0) {
+	if !(b.head != nil && blockNum <= types.NumFromID(&b.headId) && blockNum >= b.firstBlockNum) {
 		return nPos
 	}
 
 	var pos uint64
 	bytes := make([]byte, SizeOfInt64)
-	b.indexStream.Seek(SizeOfInt64*(int64(blockNum)-1), beg)
+	b.indexStream.Seek(SizeOfInt64*int64(blockNum-b.firstBlockNum), beg)
 	b.indexStream.Read(bytes)
 	rlp.DecodeBytes(bytes, &pos)
 
@@ -303,7 +370,7 @@ func (b *BlockLog) GetBlockPos(blockNum uint32) uint64 {
 func (b *BlockLog) ReadHead() *types.SignedBlock {
 
 	s, _ := b.blockStream.Seek(0, end)
-	if s <= SizeOfInt64 {
+	if s <= SizeOfInt64 || s <= b.firstBlockPos {
 		return nil
 	}
 
@@ -328,57 +395,185 @@ func (b *BlockLog) ConstructIndex() {
 	b.indexStream.Close()
 	b.indexStream, _ = os.OpenFile(b.indexFile, os.O_RDWR, os.ModePerm)
 
-	var gsSize uint32
-	b.blockStream.Seek(4, beg)
-	gsSizeBytes := make([]byte, SizeOfInt32)
-	b.blockStream.Read(gsSizeBytes)
-	rlp.DecodeBytes(gsSizeBytes, &gsSize)
-	pos, _ := b.blockStream.Seek(int64(gsSize), cur)
-
-	bytes, _ := rlp.EncodeToBytes(pos)
-
-	if pos == 0 {
-		return
-	}
+	endPos, _ := b.blockStream.Seek(0, end)
 
-	for pos > 0 {
+	for pos := b.firstBlockPos; pos > 0 && pos < endPos; {
+		bytes, _ := rlp.EncodeToBytes(pos)
 		b.indexStream.Write(bytes)
 
 		var size uint32
 		sizeBytes := make([]byte, SizeOfInt32)
+		b.blockStream.Seek(pos, beg)
 		b.blockStream.Read(sizeBytes)
 		rlp.DecodeBytes(sizeBytes, &size)
 		if size == 0 {
 			break
 		}
 
-		pos, _ = b.blockStream.Seek(int64(size), cur)
-		if pos == 0 {
+		pos += SizeOfInt32 + int64(size) + SizeOfInt64 //size, block, 8 bytes pos
+	}
+
+	return
+}
+
+/**
+ * moves the blocks directory dataDir to a backup directory and recovers the irreversible blocks of the backed up
+ * log into a new dataDir/blocks.log. Every block must be followed by its position and link to the previous block,
+ * the damaged tail is written to dataDir/blocks-bad-tail-<time>.log. The recovery stops after truncateAtBlock
+ * when it is not 0. Returns the path of the backup directory.
+ */
+func RepairLog(dataDir string, truncateAtBlock uint32) string {
+	log.Info("Recovering Block Log...")
+	blocksDir, err := filepath.Abs(dataDir)
+	Throw(err)
+	EosAssert(common.FileExist(blocksDir+"/blocks.log"), &BlockLogNotFound{}, "Block log not found in '%s'", blocksDir)
+
+	now := common.Now().String()
+	backupDir := blocksDir + "-" + now
+	EosAssert(!common.FileExist(backupDir), &BlockLogBackupDirExist{},
+		"Cannot move existing blocks directory to already existing directory '%s'", backupDir)
+
+	Throw(os.Rename(blocksDir, backupDir))
+	log.Info("Moved existing blocks directory to backup location: '%s'", backupDir)
+
+	Throw(os.MkdirAll(blocksDir, os.ModePerm))
+	blockLogPath := blocksDir + "/blocks.log"
+	log.Info("Reconstructing '%s' from backed up block log", blockLogPath)
+
+	oldBlockStream, err := os.Open(backupDir + "/blocks.log")
+	Throw(err)
+	defer oldBlockStream.Close()
+	newBlockStream, err := os.Create(blockLogPath)
+	Throw(err)
+	defer newBlockStream.Close()
+
+	endPos, err := oldBlockStream.Seek(0, end)
+	Throw(err)
+	_, err = oldBlockStream.Seek(0, beg)
+	Throw(err)
+
+	// the header and the genesis state are copied as they are
+	_, firstBlockNum := readBlockLogHeader(oldBlockStream)
+	firstBlockPos := skipGenesisState(oldBlockStream)
+	header := make([]byte, firstBlockPos)
+	_, err = oldBlockStream.ReadAt(header, 0)
+	Throw(err)
+	_, err = newBlockStream.Write(header)
+	Throw(err)
+
+	var (
+		blockNum uint32
+		previous common.BlockIdType
+		badTail  string
+	)
+
+	pos := firstBlockPos
+	for pos < endPos {
+		block, nextPos, reason := readBlockForRepair(oldBlockStream, pos, endPos)
+		if reason == "" {
+			if blockNum == 0 {
+				if block.BlockNumber() != firstBlockNum {
+					reason = fmt.Sprintf("block %d is not the first block %d of the log", block.BlockNumber(), firstBlockNum)
+				}
+			} else if block.BlockNumber() != blockNum+1 {
+				reason = fmt.Sprintf("block %d skips blocks, previous block in block log is block %d", block.BlockNumber(), blockNum)
+			} else if block.Previous != previous {
+				reason = fmt.Sprintf("block %d does not link back to previous block %s", block.BlockNumber(), previous)
+			}
+		}
+		if reason != "" {
+			badTail = reason
 			break
 		}
 
-		pos += 8 //8 bytes pos
-		bytes, _ = rlp.EncodeToBytes(pos)
+		data := make([]byte, nextPos-pos)
+		_, err = oldBlockStream.ReadAt(data, pos)
+		Throw(err)
+		_, err = newBlockStream.Write(data)
+		Throw(err)
+
+		blockNum = block.BlockNumber()
+		previous = block.BlockID()
+		if blockNum%1000 == 0 {
+			log.Info("Recovered block %d", blockNum)
+		}
+		pos = nextPos
+		if blockNum == truncateAtBlock {
+			break
+		}
 	}
 
-	return
+	if badTail != "" {
+		tailPath := blocksDir + "/blocks-bad-tail-" + now + ".log"
+		tail := make([]byte, endPos-pos)
+		_, err = oldBlockStream.ReadAt(tail, pos)
+		Throw(err)
+		Throw(ioutil.WriteFile(tailPath, tail, os.ModePerm))
+		log.Info("Recovered only up to block number %d. The block %d could not be recovered from the block log: %s\n"+
+			"The rest of the log from that point on has been written to '%s'.", blockNum, blockNum+1, badTail, tailPath)
+	} else if blockNum == truncateAtBlock && pos < endPos {
+		log.Info("Stopped recovery of block log early at specified block number: %d.", truncateAtBlock)
+	} else {
+		log.Info("Existing block log was undamaged. Recovered all irreversible blocks up to block number %d.", blockNum)
+	}
+
+	// opening the recovered log rebuilds blocks.index
+	Throw(newBlockStream.Sync())
+	NewBlockLog(blocksDir).Close()
+
+	return backupDir
+}
+
+/**
+ * reads the block at pos and checks the position trailing it, returns the reason when the block is damaged
+ */
+func readBlockForRepair(blockStream *os.File, pos int64, endPos int64) (block *types.SignedBlock, nextPos int64, reason string) {
+	if pos+SizeOfInt32 > endPos {
+		return nil, 0, "incomplete block size"
+	}
+	sizeBytes := make([]byte, SizeOfInt32)
+	_, err := blockStream.ReadAt(sizeBytes, pos)
+	Throw(err)
+	var size uint32
+	Throw(rlp.DecodeBytes(sizeBytes, &size))
+
+	nextPos = pos + SizeOfInt32 + int64(size) + SizeOfInt64
+	if size == 0 || nextPos > endPos {
+		return nil, 0, "incomplete block data"
+	}
+
+	data := make([]byte, size)
+	_, err = blockStream.ReadAt(data, pos+SizeOfInt32)
+	Throw(err)
+	block = &types.SignedBlock{}
+	Try(func() {
+		if err := rlp.DecodeBytes(data, block); err != nil {
+			reason = "block could not be deserialized: " + err.Error()
+		}
+	}).Catch(func(e interface{}) {
+		reason = fmt.Sprintf("block could not be deserialized: %v", e)
+	}).End()
+	if reason != "" {
+		return nil, 0, reason
+	}
+
+	posBytes := make([]byte, SizeOfInt64)
+	_, err = blockStream.ReadAt(posBytes, nextPos-SizeOfInt64)
+	Throw(err)
+	var trailer int64
+	Throw(rlp.DecodeBytes(posBytes, &trailer))
+	if trailer != pos {
+		return nil, 0, fmt.Sprintf("block %d was not properly committed, position %d expected %d", block.BlockNumber(), trailer, pos)
+	}
+
+	return block, nextPos, ""
 }
-func repairLog(dataDir string, truncateAtBlock uint32) string { return "" }
 
 func ExtractGenesisState(dataDir string) types.GenesisState {
 
 	blockStream, _ := os.OpenFile(dataDir+"/blocks.log", os.O_RDWR, os.ModePerm)
 	blockStream.Seek(0, beg)
-	var version uint32
-	bytes := make([]byte, SizeOfInt32)
-	blockStream.Read(bytes)
-	rlp.DecodeBytes(bytes, &version)
-
-	EosAssert(version > 0, &BlockLogAppendFail{}, "Block log was not setup properly with genesis information.")
-	EosAssert(version == supportedVersion,
-		&BlockLogUnsupportedVersion{},
-		"Unsupported version of block log. Block log version is %d while code supports version %d",
-		version, supportedVersion)
+	readBlockLogHeader(blockStream)
 
 	var gsSize uint32
 	gsSizeBytes := make([]byte, SizeOfInt32)
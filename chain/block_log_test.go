@@ -7,6 +7,7 @@ import (
 	"github.com/eosspark/eos-go/crypto/rlp"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -49,3 +50,66 @@ func TestBlockTest(t *testing.T) {
 	})
 
 }
+
+func newTestBlockLog(t *testing.T, dataDir string, count int) []*types.SignedBlock {
+	os.RemoveAll(dataDir)
+	os.MkdirAll(dataDir, os.ModePerm)
+	blockLog := NewBlockLog(dataDir)
+
+	blocks := make([]*types.SignedBlock, 0, count)
+	var previous common.BlockIdType
+	for i := 0; i < count; i++ {
+		block := &types.SignedBlock{}
+		block.Timestamp = types.BlockTimeStamp(i + 1)
+		block.Producer = common.DefaultConfig.SystemAccountName
+		block.Previous = previous
+		if i == 0 {
+			blockLog.ResetToGenesis(types.NewGenesisState(), block)
+		} else {
+			blockLog.Append(block)
+		}
+		previous = block.BlockID()
+		blocks = append(blocks, block)
+	}
+	blockLog.Close()
+	return blocks
+}
+
+func TestRepairLog(t *testing.T) {
+	dataDir := "/tmp/repair_log_test/blocks"
+	defer os.RemoveAll("/tmp/repair_log_test")
+	blocks := newTestBlockLog(t, dataDir, 10)
+
+	// a torn write at the tail of the log
+	f, err := os.OpenFile(dataDir+"/blocks.log", os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	assert.NoError(t, err)
+	f.Write([]byte{0x40, 0x00, 0x00, 0x00, 0x01, 0x02})
+	f.Close()
+
+	backupDir := RepairLog(dataDir, 0)
+	assert.True(t, common.FileExist(backupDir+"/blocks.log"))
+
+	blockLog := NewBlockLog(dataDir)
+	assert.Equal(t, blocks[9].BlockID(), blockLog.Head().BlockID())
+	assert.Equal(t, blocks[4].BlockID(), blockLog.ReadBlockByNum(5).BlockID())
+	blockLog.Close()
+
+	tails, _ := filepath.Glob(dataDir + "/blocks-bad-tail-*.log")
+	assert.Equal(t, 1, len(tails))
+}
+
+func TestRepairLogTruncate(t *testing.T) {
+	dataDir := "/tmp/repair_log_test/blocks"
+	defer os.RemoveAll("/tmp/repair_log_test")
+	blocks := newTestBlockLog(t, dataDir, 10)
+
+	RepairLog(dataDir, 6)
+
+	blockLog := NewBlockLog(dataDir)
+	assert.Equal(t, blocks[5].BlockID(), blockLog.Head().BlockID())
+	assert.Nil(t, blockLog.ReadBlockByNum(7))
+	blockLog.Close()
+
+	tails, _ := filepath.Glob(dataDir + "/blocks-bad-tail-*.log")
+	assert.Equal(t, 0, len(tails))
+}
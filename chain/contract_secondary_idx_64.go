@@ -233,17 +233,15 @@ func (i *Idx64) previous(iterator int, primary *uint64) int {
 
 		objTId := entity.Idx64Object{TId: tab.ID}
 
-		itr, _ := idx.UpperBound(&objTId, database.SKIP_TWO)
-		if idx.CompareIterator(idx.Begin(), idx.End()) || idx.CompareBegin(itr) {
-			i.context.ilog.Info("iterator is the begin(nil) of index, iteratorIn:%d iteratorOut:%d", iterator, -1)
-			return -1
-		}
-
-		itr.Prev()
-		objPrev := entity.KeyValueObject{}
-		itr.Data(&objPrev)
+		upper, _ := idx.UpperBound(&objTId, database.SKIP_TWO)
+		objPrev := entity.Idx64Object{}
+		found := false
+		idx.ReverseRange(nil, upper, func(itr database.Iterator) bool {
+			found = itr.Data(&objPrev) == nil
+			return false
+		})
 
-		if objPrev.TId != tab.ID {
+		if !found || objPrev.TId != tab.ID {
 			i.context.ilog.Info("previous iterator out of tid, iteratorIn:%d iteratorOut:%d", iterator, -1)
 			return -1
 		}
@@ -257,11 +255,9 @@ func (i *Idx64) previous(iterator int, primary *uint64) int {
 	obj := (i.itrCache.get(iterator)).(*entity.Idx64Object)
 	itr := idx.IteratorTo(obj)
 
-	if idx.CompareBegin(itr) {
+	if !itr.Prev() {
 		return -1
 	}
-
-	itr.Prev()
 	objPrev := entity.Idx64Object{}
 	itr.Data(&objPrev)
 	i.context.ilog.Debug("Idx64Object objPrev:%v", objPrev)
@@ -384,16 +380,15 @@ func (i *Idx64) previousPrimary(iterator int, primary *uint64) int {
 
 		objTId := entity.Idx64Object{TId: tab.ID}
 
-		itr, _ := idx.UpperBound(&objTId, database.SKIP_ONE)
-		if idx.CompareIterator(idx.Begin(), idx.End()) || idx.CompareBegin(itr) {
-			return -1
-		}
-
-		itr.Prev()
+		upper, _ := idx.UpperBound(&objTId, database.SKIP_ONE)
 		objPrev := entity.Idx64Object{}
-		itr.Data(&objPrev)
+		found := false
+		idx.ReverseRange(nil, upper, func(itr database.Iterator) bool {
+			found = itr.Data(&objPrev) == nil
+			return false
+		})
 
-		if objPrev.TId != tab.ID {
+		if !found || objPrev.TId != tab.ID {
 			return -1
 		}
 
@@ -404,11 +399,9 @@ func (i *Idx64) previousPrimary(iterator int, primary *uint64) int {
 	obj := (i.itrCache.get(iterator)).(*entity.Idx64Object)
 	itr := idx.IteratorTo(obj)
 
-	if idx.CompareBegin(itr) {
+	if !itr.Prev() {
 		return -1
 	}
-
-	itr.Prev()
 	objNext := entity.Idx64Object{}
 	itr.Data(&objNext)
 
@@ -234,17 +234,15 @@ func (i *IdxLongDouble) previous(iterator int, primary *uint64) int {
 
 		objTId := entity.IdxLongDoubleObject{TId: tab.ID}
 
-		itr, _ := idx.UpperBound(&objTId, database.SKIP_TWO)
-		if idx.CompareIterator(idx.Begin(), idx.End()) || idx.CompareBegin(itr) {
-			i.context.ilog.Info("iterator is the begin(nil) of index, iteratorIn:%d iteratorOut:%d", iterator, -1)
-			return -1
-		}
-
-		itr.Prev()
-		objPrev := entity.KeyValueObject{}
-		itr.Data(&objPrev)
+		upper, _ := idx.UpperBound(&objTId, database.SKIP_TWO)
+		objPrev := entity.IdxLongDoubleObject{}
+		found := false
+		idx.ReverseRange(nil, upper, func(itr database.Iterator) bool {
+			found = itr.Data(&objPrev) == nil
+			return false
+		})
 
-		if objPrev.TId != tab.ID {
+		if !found || objPrev.TId != tab.ID {
 			i.context.ilog.Info("previous iterator out of tid, iteratorIn:%d iteratorOut:%d", iterator, -1)
 			return -1
 		}
@@ -258,11 +256,9 @@ func (i *IdxLongDouble) previous(iterator int, primary *uint64) int {
 	obj := (i.itrCache.get(iterator)).(*entity.IdxLongDoubleObject)
 	itr := idx.IteratorTo(obj)
 
-	if idx.CompareBegin(itr) {
+	if !itr.Prev() {
 		return -1
 	}
-
-	itr.Prev()
 	objPrev := entity.IdxLongDoubleObject{}
 	itr.Data(&objPrev)
 	i.context.ilog.Debug("IdxLongDoubleObject objPrev:%v", objPrev)
@@ -385,16 +381,15 @@ func (i *IdxLongDouble) previousPrimary(iterator int, primary *uint64) int {
 
 		objTId := entity.IdxLongDoubleObject{TId: tab.ID}
 
-		itr, _ := idx.UpperBound(&objTId, database.SKIP_ONE)
-		if idx.CompareIterator(idx.Begin(), idx.End()) || idx.CompareBegin(itr) {
-			return -1
-		}
-
-		itr.Prev()
+		upper, _ := idx.UpperBound(&objTId, database.SKIP_ONE)
 		objPrev := entity.IdxLongDoubleObject{}
-		itr.Data(&objPrev)
+		found := false
+		idx.ReverseRange(nil, upper, func(itr database.Iterator) bool {
+			found = itr.Data(&objPrev) == nil
+			return false
+		})
 
-		if objPrev.TId != tab.ID {
+		if !found || objPrev.TId != tab.ID {
 			return -1
 		}
 
@@ -405,11 +400,9 @@ func (i *IdxLongDouble) previousPrimary(iterator int, primary *uint64) int {
 	obj := (i.itrCache.get(iterator)).(*entity.IdxLongDoubleObject)
 	itr := idx.IteratorTo(obj)
 
-	if idx.CompareBegin(itr) {
+	if !itr.Prev() {
 		return -1
 	}
-
-	itr.Prev()
 	objNext := entity.IdxLongDoubleObject{}
 	itr.Data(&objNext)
 
@@ -17,6 +17,8 @@ import (
 	"github.com/eosspark/eos-go/plugins/appbase/app/include"
 	"github.com/eosspark/eos-go/plugins/chain_interface"
 	"github.com/eosspark/eos-go/wasmgo"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"time"
 )
 
 type DBReadMode int8
@@ -98,10 +100,17 @@ type Config struct {
 	TrustedProducers        AccountNameSet
 	BlocksDir               string
 	StateDir                string
+	DbBackend               string
 	StateSize               uint64
 	StateGuardSize          uint64
 	ReversibleCacheSize     uint64
 	ReversibleGuardSize     uint64
+	WasmCacheSize           uint64               // memory budget of the compiled contracts
+	WasmCacheDir            string               // directory of the compiled code of the contracts, none keeps it in memory only
+	WasmGasPolicy           compiler.GasPolicy   // costs of the instructions of the contracts, none does not meter them
+	WasmGasLimit            uint64               // weight of the instructions a speculative or produced action may execute, zero is unbounded
+	WasmProfileAccounts     []common.AccountName // accounts whose contracts are profiled
+	WasmDebug               bool                 // pauses the contracts on the breakpoints of the wasm debugger, for local test chains
 	ReadOnly                bool
 	ForceAllChecks          bool
 	DisableReplayOpts       bool
@@ -109,7 +118,7 @@ type Config struct {
 	ContractsConsole        bool
 	AllowRamBillingInNotify bool
 	Genesis                 *types.GenesisState
-	VmType                  wasmgo.WasmGo
+	VmType                  wasmgo.VmType // runtime of the contracts
 	ReadMode                DBReadMode
 	BlockValidationMode     ValidationMode
 }
@@ -123,15 +132,18 @@ func NewConfig() *Config {
 	return &Config{
 		BlocksDir:               common.DefaultConfig.DefaultBlocksDirName,
 		StateDir:                common.DefaultConfig.DefaultStateDirName,
+		DbBackend:               database.LevelDBBackend,
 		StateSize:               common.DefaultConfig.DefaultStateSize,
 		StateGuardSize:          common.DefaultConfig.DefaultStateGuardSize,
 		ReversibleCacheSize:     common.DefaultConfig.DefaultReversibleCacheSize,
 		ReversibleGuardSize:     common.DefaultConfig.DefaultReversibleGuardSize,
+		WasmCacheSize:           common.DefaultConfig.DefaultWasmCacheSize,
 		ReadOnly:                false,
 		ForceAllChecks:          false,
 		DisableReplayOpts:       false,
 		ContractsConsole:        false,
 		AllowRamBillingInNotify: false,
+		VmType:                  wasmgo.WASMGO,
 		ReadMode:                SPECULATIVE,
 		BlockValidationMode:     FULL,
 		Genesis:                 types.NewGenesisState(),
@@ -157,7 +169,7 @@ type Controller struct {
 	Pending                        *PendingState
 	Head                           *types.BlockState
 	ForkDB                         *ForkDatabase
-	WasmIf                         *wasmgo.WasmGo
+	WasmIf                         wasmgo.Runtime
 	ResourceLimits                 *ResourceLimitsManager
 	Authorization                  *AuthorizationManager
 	Config                         Config //local	Config
@@ -181,16 +193,16 @@ type Controller struct {
 }
 
 func NewController(cfg *Config) *Controller {
-	db, err := database.NewDataBase(cfg.StateDir)
-	reversibleDB, err := database.NewDataBase(cfg.BlocksDir + "/" + common.DefaultConfig.DefaultReversibleBlocksDirName)
-
-	if err != nil {
-		log.Error("newController create database is error :%s", err)
-		return nil
-	}
+	db, err := database.OpenDataBase(cfg.DbBackend, cfg.StateDir)
+	EosAssert(err == nil, &DatabaseException{}, "cannot open the chain state database in '%s': %s", cfg.StateDir, err)
+	reversibleDir := cfg.BlocksDir + "/" + common.DefaultConfig.DefaultReversibleBlocksDirName
+	reversibleDB, err := database.OpenDataBase(cfg.DbBackend, reversibleDir)
+	EosAssert(err == nil, &DatabaseException{}, "cannot open the reversible blocks database in '%s': %s", reversibleDir, err)
 	con := &Controller{InTrxRequiringChecks: false, RePlaying: false, TrustedProducerLightValidation: false}
 	con.DB = db
+	con.DB.SetMaxSize(cfg.StateSize)
 	con.ReversibleBlocks = reversibleDB
+	con.ReversibleBlocks.SetMaxSize(cfg.ReversibleCacheSize)
 
 	con.Blog = NewBlockLog(cfg.BlocksDir)
 
@@ -200,7 +212,7 @@ func NewController(cfg *Config) *Controller {
 
 	con.ReadMode = cfg.ReadMode
 	con.ApplyHandlers = make(map[string]v)
-	con.WasmIf = wasmgo.NewWasmGo()
+	con.WasmIf = newWasmRuntime(cfg)
 
 	con.Config = *cfg
 
@@ -229,14 +241,20 @@ func NewController(cfg *Config) *Controller {
 	return con
 }
 
-func (c *Controller) Startup() {
+func (c *Controller) Startup(snapshot ...*SnapshotReader) {
 	//TODO c.AddIndices()
 
 	c.Head = c.ForkDB.Head
-	if c.Head == nil {
-		log.Warn("No head block in fork db, perhaps we need to replay")
+	if len(snapshot) > 0 && snapshot[0] != nil {
+		c.initialize(snapshot[0])
+	} else {
+		if c.Head == nil {
+			log.Warn("No head block in fork db, perhaps we need to replay")
+		}
+		c.initialize(nil)
 	}
-	c.initialize()
+	headBlockNumGauge.Set(float64(c.HeadBlockNum()))
+	lastIrreversibleBlockNumGauge.Set(float64(c.LastIrreversibleBlockNum()))
 }
 
 func (c *Controller) PopBlock() {
@@ -256,14 +274,16 @@ func (c *Controller) PopBlock() {
 	}
 
 	if c.ReadMode == SPECULATIVE {
-		//version 1.4
-		//EosAssert(c.Head.SignedBlock!=nil, &BlockValidateException{}, "attempting to pop a block that was sparsely loaded from a snapshot")
+		EosAssert(!c.Head.Sparse, &BlockValidateException{},
+			"attempting to pop a block that was sparsely loaded from a snapshot")
 		for _, trx := range c.Head.Trxs {
 			c.UnappliedTransactions[crypto.Sha256(trx.SignedID)] = *trx
 		}
 	}
 	c.Head = prev
 	c.DB.Undo()
+	headBlockNumGauge.Set(float64(c.Head.BlockNum))
+	c.updateUnappliedTransactionsGauge()
 }
 
 func (c *Controller) SetApplayHandler(receiver common.AccountName, contract common.AccountName, action common.ActionName, handler func(a *ApplyContext)) {
@@ -282,18 +302,31 @@ func (c *Controller) FindApplyHandler(receiver common.AccountName,
 }
 
 func (c *Controller) OnIrreversible(s *types.BlockState) {
+	lastIrreversibleBlockNumGauge.Set(float64(s.BlockNum))
 	if common.Empty(c.Blog.head) {
 		c.Blog.ReadHead()
 	}
 	logHead := c.Blog.head
-	EosAssert(logHead != nil, &BlockLogException{}, "block log head can not be found")
-	lhBlockNum := logHead.BlockNumber()
-	c.DB.Commit(int64(s.BlockNum))
-	if s.BlockNum <= lhBlockNum {
-		return
+	if common.Empty(logHead) {
+		// a block log started from a snapshot has no blocks until the block after the snapshot becomes irreversible
+		if s.Sparse {
+			EosAssert(s.BlockNum == c.Blog.firstBlockNum-1, &BlockLogException{},
+				"block log has no blocks and is not properly set up to start after the snapshot")
+			c.DB.Commit(int64(s.BlockNum))
+			return
+		}
+		EosAssert(s.BlockNum == c.Blog.firstBlockNum, &BlockLogException{},
+			"block log has no blocks and is appending the wrong first block. Expected %d, but received: %d", c.Blog.firstBlockNum, s.BlockNum)
+		c.DB.Commit(int64(s.BlockNum))
+	} else {
+		lhBlockNum := logHead.BlockNumber()
+		c.DB.Commit(int64(s.BlockNum))
+		if s.BlockNum <= lhBlockNum {
+			return
+		}
+		EosAssert(s.BlockNum-1 == lhBlockNum, &UnlinkableBlockException{}, "unlinkable block:%d,%d", s.BlockNum, lhBlockNum)
+		EosAssert(s.SignedBlock.Previous == logHead.BlockID(), &UnlinkableBlockException{}, "irreversible doesn't link to block log head")
 	}
-	EosAssert(s.BlockNum-1 == lhBlockNum, &UnlinkableBlockException{}, "unlinkable block:%d,%d", s.BlockNum, lhBlockNum)
-	EosAssert(s.SignedBlock.Previous == logHead.BlockID(), &UnlinkableBlockException{}, "irreversible doesn't link to block log head")
 	c.Blog.Append(s.SignedBlock)
 	rbi := entity.ReversibleBlockObject{}
 	ubi, err := c.ReversibleBlocks.GetIndex("byNum", &rbi)
@@ -335,11 +368,12 @@ func (c *Controller) AbortBlock() {
 		}
 		c.Pending = c.Pending.Reset()
 	}
+	c.updateUnappliedTransactionsGauge()
 }
 func (c *Controller) StartBlock(when types.BlockTimeStamp, confirmBlockCount uint16) {
+	c.ValidateDbAvailableSize()
 	pbi := common.BlockIdType(crypto.NewSha256Nil())
 	c.startBlock(when, confirmBlockCount, types.Incomplete, &pbi)
-	c.ValidateDbAvailableSize()
 }
 func (c *Controller) startBlock(when types.BlockTimeStamp, confirmBlockCount uint16, s types.BlockStatus, producerBlockId *common.BlockIdType) {
 	EosAssert(c.Pending == nil, &BlockValidateException{}, "pending block already exists")
@@ -516,7 +550,9 @@ func (c *Controller) pushTransaction(trx *types.TransactionMetadata, deadLine co
 
 			if !trx.Implicit {
 				delete(c.UnappliedTransactions, crypto.Sha256(trx.SignedID))
+				c.updateUnappliedTransactionsGauge()
 			}
+			transactionsCounter.WithLabelValues("applied").Inc()
 
 			returning = true
 		}).Catch(func(ex Exception) {
@@ -528,7 +564,9 @@ func (c *Controller) pushTransaction(trx *types.TransactionMetadata, deadLine co
 		}
 		if !failureIsSubjective(trace.Except) {
 			delete(c.UnappliedTransactions, crypto.Sha256(trx.SignedID))
+			c.updateUnappliedTransactionsGauge()
 		}
+		transactionsCounter.WithLabelValues("failed").Inc()
 		c.AcceptedTransaction.Emit(trx)
 		c.AppliedTransaction.Emit(trace)
 		return
@@ -536,6 +574,61 @@ func (c *Controller) pushTransaction(trx *types.TransactionMetadata, deadLine co
 	return trace
 }
 
+/**
+ * SimulateTransaction executes trx against the pending state in a session that is always undone, nothing is added to
+ * the pending block and no signal is emitted. The authorization is not checked when skipAuthorization is set, trx
+ * may then be unsigned. billed lists the accounts the cpu and net of trx were billed to, none when it failed.
+ */
+func (c *Controller) SimulateTransaction(trx *types.TransactionMetadata, deadLine common.TimePoint, skipAuthorization bool) (trace *types.TransactionTrace, billed []common.AccountName) {
+	EosAssert(c.Pending != nil, &BlockValidateException{}, "no pending block")
+	EosAssert(trx != nil && !trx.Implicit && !trx.Scheduled, &TransactionTypeException{}, "Implicit/Scheduled transaction not allowed")
+	EosAssert(deadLine != common.TimePoint(0), &TransactionException{}, "deadline cannot be uninitialized")
+
+	session := c.DB.StartSession()
+	defer session.Undo()
+
+	trxContext := NewTransactionContext(c, trx.Trx, trx.ID, common.Now())
+	defer trxContext.Undo()
+	trxContext.Deadline = deadLine
+
+	trace = trxContext.Trace
+	Try(func() {
+		trxContext.InitForInputTrx(uint64(trx.PackedTrx.GetUnprunableSize()), uint64(trx.PackedTrx.GetPrunableSize()),
+			uint32(len(trx.Trx.Signatures)), false)
+		if trxContext.CanSubjectivelyFail && c.Pending.BlockStatus == types.Incomplete {
+			c.CheckActorList(&trxContext.BillToAccounts)
+		}
+		trxContext.Delay = common.Seconds(int64(trx.Trx.DelaySec))
+		if !skipAuthorization && !c.SkipAuthCheck() {
+			checkTime := func() {}
+			c.Authorization.CheckAuthorization(trx.Trx.Actions,
+				trx.RecoverKeys(&c.ChainID),
+				NewPermissionLevelSet(),
+				trxContext.Delay,
+				&checkTime,
+				false)
+		}
+		trxContext.Exec()
+		trxContext.Finalize()
+
+		trace.Receipt.Status = types.TransactionStatusExecuted
+		if trxContext.Delay != common.Microseconds(0) {
+			trace.Receipt.Status = types.TransactionStatusDelayed
+		}
+		trace.Receipt.CpuUsageUs = uint32(trxContext.BilledCpuTimeUs)
+		trace.Receipt.NetUsageWords = common.Vuint32(trace.NetUsage / 8)
+
+		itr := trxContext.BillToAccounts.Iterator()
+		for itr.Next() {
+			billed = append(billed, itr.Value())
+		}
+	}).Catch(func(ex Exception) {
+		trace.Except = ex
+		trace.ExceptPtr = ex
+	}).End()
+	return trace, billed
+}
+
 func (c *Controller) GetGlobalProperties() *entity.GlobalPropertyObject {
 
 	gpo := entity.GlobalPropertyObject{}
@@ -628,10 +721,12 @@ func (c *Controller) GetUnappliedTransactions() []*types.TransactionMetadata {
 
 func (c *Controller) DropUnappliedTransaction(metadata *types.TransactionMetadata) {
 	delete(c.UnappliedTransactions, crypto.Sha256(metadata.SignedID))
+	c.updateUnappliedTransactionsGauge()
 }
 
 func (c *Controller) DropAllUnAppliedTransactions() {
 	c.UnappliedTransactions = make(map[crypto.Sha256]types.TransactionMetadata)
+	c.updateUnappliedTransactionsGauge()
 }
 func (c *Controller) GetScheduledTransactions() []common.TransactionIdType {
 
@@ -880,7 +975,8 @@ func failureIsSubjective(e Exception) bool {
 		code == ContractWhitelistException{}.Code() ||
 		code == ContractBlacklistException{}.Code() ||
 		code == ActionBlacklistException{}.Code() ||
-		code == KeyBlacklistException{}.Code()
+		code == KeyBlacklistException{}.Code() ||
+		code == WasmGasExceeded{}.Code()
 
 }
 
@@ -952,6 +1048,7 @@ func (c *Controller) SignBlock(signerCallback func(sha256 crypto.Sha256) ecc.Sig
 }
 
 func (c *Controller) applyBlock(b *types.SignedBlock, s types.BlockStatus) {
+	start := time.Now()
 	Try(func() {
 		EosAssert(len(b.BlockExtensions) == 0, &BlockValidateException{}, "no supported extensions")
 		producerBlockId := b.BlockID()
@@ -994,6 +1091,7 @@ func (c *Controller) applyBlock(b *types.SignedBlock, s types.BlockStatus) {
 
 		c.Pending.PendingBlockState.Header.ProducerSignature = b.ProducerSignature
 		c.CommitBlock(false)
+		blockApplySeconds.Observe(time.Since(start).Seconds())
 		return
 	}).Catch(func(ex Exception) {
 		log.Error("controller ApplyBlock is error:%s", ex.DetailMessage())
@@ -1033,10 +1131,14 @@ func (c *Controller) CommitBlock(addToForkDb bool) {
 	}).End()
 	c.Pending.Push()
 	c.Pending.PendingValid = true
+	headBlockNumGauge.Set(float64(c.Pending.PendingBlockState.BlockNum))
+	blockTransactions.Observe(float64(len(c.Pending.PendingBlockState.SignedBlock.Transactions)))
 	//log.Info("commitBlock success!")
 }
 
 func (c *Controller) PushBlock(b *types.SignedBlock, s types.BlockStatus) {
+	c.ValidateDbAvailableSize()
+	c.ValidateReversibleAvailableSize()
 	EosAssert(c.Pending == nil, &BlockValidateException{}, "it is not valid to push a block when there is a pending block")
 	defer func() {
 		c.TrustedProducerLightValidation = false
@@ -1086,6 +1188,7 @@ func (c *Controller) maybeSwitchForks(s types.BlockStatus) {
 		}).End()
 	} else if newHead.BlockId != c.Head.BlockId {
 		log.Info("switching forks from: %v (block number %v) to %v (block number %v)", c.Head.BlockId, c.Head.BlockNum, newHead.BlockId, newHead.BlockNum)
+		forkSwitchesCounter.Inc()
 		branches := c.ForkDB.FetchBranchFrom(&newHead.BlockId, &c.Head.BlockId)
 
 		for i := 0; i < len(branches.second); i++ {
@@ -1433,15 +1536,15 @@ func (c *Controller) ValidateTapos(t *types.Transaction) {
 }
 
 func (c *Controller) ValidateDbAvailableSize() {
-	/*const auto free = db().get_segment_manager()->get_free_memory();
-	const auto guard = my->conf.state_guard_size;
-	EOS_ASSERT(free >= guard, database_guard_exception, "database free: ${f}, guard size: ${g}", ("f", free)("g",guard));*/
+	free := c.DB.FreeSize()
+	guard := c.Config.StateGuardSize
+	EosAssert(free >= guard, &DatabaseGuardException{}, "database free: %d, guard size: %d", free, guard)
 }
 
 func (c *Controller) ValidateReversibleAvailableSize() {
-	/*const auto free = my->reversible_blocks.get_segment_manager()->get_free_memory();
-	const auto guard = my->conf.reversible_guard_size;
-	EOS_ASSERT(free >= guard, reversible_guard_exception, "reversible free: ${f}, guard size: ${g}", ("f", free)("g",guard));*/
+	free := c.ReversibleBlocks.FreeSize()
+	guard := c.Config.ReversibleGuardSize
+	EosAssert(free >= guard, &ReversibleGuardException{}, "reversible free: %d, guard size: %d", free, guard)
 }
 
 func (c *Controller) IsKnownUnexpiredTransaction(id *common.TransactionIdType) bool {
@@ -1513,7 +1616,7 @@ func (c *Controller) SetSubjectiveCpuLeeway(leeway common.Microseconds) {
 	c.SubjectiveCupLeeway = leeway
 }
 
-func (c *Controller) GetWasmInterface() *wasmgo.WasmGo {
+func (c *Controller) GetWasmInterface() wasmgo.Runtime {
 	return c.WasmIf
 }
 
@@ -1642,8 +1745,20 @@ func (c *Controller) initializeDatabase() {
 	//log.Info("initializeDatabase print:%v,%v", majorityPermission.ID, minorityPermission.ID)
 }
 
-func (c *Controller) initialize() {
-	if common.Empty(c.Head) {
+func (c *Controller) initialize(snapshot *SnapshotReader) {
+	if snapshot != nil {
+		EosAssert(common.Empty(c.Head), &ForkDatabaseException{}, "Snapshot can only be used to initialize an empty database.")
+		snapshot.Validate()
+		c.readFromSnapshot(snapshot)
+
+		end := c.Blog.ReadHead()
+		if common.Empty(end) {
+			c.Blog.Reset(c.Config.Genesis, nil, c.Head.BlockNum+1)
+		} else {
+			EosAssert(end.BlockNumber() == c.Head.BlockNum, &ForkDatabaseException{},
+				"Block log is provided with snapshot but does not contain the head block from the snapshot")
+		}
+	} else if common.Empty(c.Head) {
 		c.initializeForkDB()
 		end := c.Blog.ReadHead()
 		if !common.Empty(end) && end.BlockNumber() > 1 {
@@ -1693,9 +1808,11 @@ func (c *Controller) initialize() {
 		EosAssert(r.BlockNum == c.Head.BlockNum, &ForkDatabaseException{},
 			"reversible block database is inconsistent with fork database, replay blockchain %d,%d", c.Head.BlockNum, r.BlockNum)
 	} else {
-		end := c.Blog.ReadHead()
-		EosAssert(end != nil && end.BlockNumber() == c.Head.BlockNum, &ForkDatabaseException{},
-			"fork database exists but reversible block database does not, replay blockchain %d,%d", end.BlockNumber(), c.Head.BlockNum)
+		// the block log is empty when the state was just loaded from a snapshot
+		if end := c.Blog.ReadHead(); end != nil {
+			EosAssert(end.BlockNumber() == c.Head.BlockNum, &ForkDatabaseException{},
+				"fork database exists but reversible block database does not, replay blockchain %d,%d", end.BlockNumber(), c.Head.BlockNum)
+		}
 	}
 	EosAssert(uint32(c.DB.Revision()) >= c.Head.BlockNum, &ForkDatabaseException{}, "fork database is inconsistent with shared memory %d,%d", c.DB.Revision(), c.Head.BlockNum)
 	for uint32(c.DB.Revision()) > c.Head.BlockNum {
@@ -1786,6 +1903,7 @@ func (c *Controller) initConfig() *Controller {
 		StateGuardSize:          common.DefaultConfig.DefaultStateGuardSize,
 		ReversibleCacheSize:     common.DefaultConfig.DefaultReversibleCacheSize,
 		ReversibleGuardSize:     common.DefaultConfig.DefaultReversibleGuardSize,
+		WasmCacheSize:           common.DefaultConfig.DefaultWasmCacheSize,
 		ReadOnly:                false,
 		ForceAllChecks:          false,
 		DisableReplayOpts:       false,
@@ -0,0 +1,29 @@
+package chain
+
+import (
+	"github.com/eosspark/eos-go/libraries/metrics"
+)
+
+/**
+ * metrics of the controller, shared by every controller of the process
+ */
+var (
+	blockApplySeconds = metrics.NewHistogram("eosgo_chain_block_apply_seconds",
+		"Time to apply a block that was not produced locally", metrics.ExponentialBuckets(0.001, 2, 12))
+	blockTransactions = metrics.NewHistogram("eosgo_chain_block_transactions",
+		"Number of transactions in a committed block", []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000})
+	headBlockNumGauge = metrics.NewGauge("eosgo_chain_head_block_num",
+		"Number of the last committed block")
+	lastIrreversibleBlockNumGauge = metrics.NewGauge("eosgo_chain_last_irreversible_block_num",
+		"Number of the last irreversible block")
+	forkSwitchesCounter = metrics.NewCounter("eosgo_chain_fork_switches_total",
+		"Number of switches to a better fork")
+	unappliedTransactionsGauge = metrics.NewGauge("eosgo_chain_unapplied_transactions",
+		"Number of transactions waiting to be applied again after their block was aborted")
+	transactionsCounter = metrics.NewCounterVec("eosgo_chain_transactions_total",
+		"Number of transactions pushed to pending blocks, by result", "result")
+)
+
+func (c *Controller) updateUnappliedTransactionsGauge() {
+	unappliedTransactionsGauge.Set(float64(len(c.UnappliedTransactions)))
+}
@@ -252,3 +252,50 @@ func TestController_UpdateProducersAuthority(t *testing.T) {
 	c.updateProducersAuthority()
 	c.Close()
 }
+
+func TestController_GuardSize(t *testing.T) {
+	producer := newSnapshotTestController(path + "guard_a/")
+	defer os.RemoveAll(path + "guard_a/")
+	producer.Startup()
+	produceProcess(producer)
+	block := producer.FetchBlockByNumber(producer.HeadBlockNum())
+	producer.Close()
+
+	con := newSnapshotTestController(path + "guard_b/")
+	defer os.RemoveAll(path + "guard_b/")
+	con.Startup()
+	defer con.Close()
+	con.AbortBlock()
+
+	guarded := func(f func()) (dbGuard bool, reversibleGuard bool) {
+		try.Try(func() {
+			f()
+		}).Catch(func(e *exception.DatabaseGuardException) {
+			dbGuard = true
+		}).Catch(func(e *exception.ReversibleGuardException) {
+			reversibleGuard = true
+		}).End()
+		return
+	}
+	startBlock := func() { con.StartBlock(types.NewBlockTimeStamp(block.Timestamp.ToTimePoint()), 0) }
+	pushBlock := func() { con.PushBlock(block, types.Complete) }
+
+	stateGuard, reversibleGuard := con.Config.StateGuardSize, con.Config.ReversibleGuardSize
+
+	con.Config.StateGuardSize = con.DB.FreeSize() + 1
+	dbGuard, _ := guarded(startBlock)
+	assert.True(t, dbGuard, "StartBlock must throw DatabaseGuardException")
+	assert.Nil(t, con.Pending)
+	dbGuard, _ = guarded(pushBlock)
+	assert.True(t, dbGuard, "PushBlock must throw DatabaseGuardException")
+	con.Config.StateGuardSize = stateGuard
+
+	con.Config.ReversibleGuardSize = con.ReversibleBlocks.FreeSize() + 1
+	_, revGuard := guarded(pushBlock)
+	assert.True(t, revGuard, "PushBlock must throw ReversibleGuardException")
+	con.Config.ReversibleGuardSize = reversibleGuard
+
+	// with the guards back to their defaults the same block is accepted
+	pushBlock()
+	assert.Equal(t, block.BlockID(), con.HeadBlockId())
+}
@@ -14,6 +14,11 @@ import (
 	"strconv"
 )
 
+const (
+	forkDbMagic   = uint32(0x30510FDB) // the first word of the fork database file
+	forkDbVersion = uint32(1)          // version 1 records the block states loaded sparsely from a snapshot
+)
+
 type ForkDatabase struct {
 	Index      *forkdb_multi_index.MultiIndex
 	Head       *types.BlockState `json:"head"`
@@ -37,6 +42,13 @@ func NewForkDatabase(dataDir string) *ForkDatabase {
 		Throw(err)
 
 		decode := rlp.NewDecoder(content)
+		var magic, version uint32
+		decode.Decode(&magic)
+		decode.Decode(&version)
+		EosAssert(magic == forkDbMagic && version == forkDbVersion, &ForkDatabaseException{},
+			"%s is not a fork database of version %d, it was written by an older build. Remove it and restart from a "+
+				"snapshot or replay the block log", forkDbDat, forkDbVersion)
+
 		var size uint
 		decode.Decode(&size)
 
@@ -67,6 +79,8 @@ func (f *ForkDatabase) Close() {
 	Throw(err)
 
 	out := rlp.NewEncoder(file)
+	out.Encode(forkDbMagic)
+	out.Encode(forkDbVersion)
 
 	numBlockInForkDB := uint(f.Index.Size())
 	out.Encode(numBlockInForkDB)
@@ -0,0 +1,293 @@
+package chain
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/entity"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+)
+
+/**
+ * A snapshot is a portable copy of the chain state at a block.
+ *
+ * layout:
+ *   magic number (uint32) | version (uint32) | section ...
+ * section:
+ *   name size (uint32) | name | row count (uint32) | row ...
+ * row:
+ *   size (uint32) | rlp packed object
+ */
+const (
+	snapshotMagicNumber = uint32(0x30510550)
+	snapshotVersion     = uint32(1)
+
+	SnapshotGenesisStateSection = "eosio::chain::genesis_state"
+	SnapshotBlockStateSection   = "eosio::chain::block_state"
+)
+
+// snapshotTables lists every table of the chain state, in the order they are written to a snapshot
+var snapshotTables = []interface{}{
+	entity.AccountObject{},
+	entity.AccountSequenceObject{},
+	entity.GlobalPropertyObject{},
+	entity.DynamicGlobalPropertyObject{},
+	entity.BlockSummaryObject{},
+	entity.TransactionObject{},
+	entity.GeneratedTransactionObject{},
+
+	entity.TableIdObject{},
+	entity.KeyValueObject{},
+	entity.Idx64Object{},
+	entity.Idx128Object{},
+	entity.Idx256Object{},
+	entity.IdxDoubleObject{},
+	entity.IdxLongDoubleObject{},
+
+	entity.PermissionObject{},
+	entity.PermissionUsageObject{},
+	entity.PermissionLinkObject{},
+
+	entity.ResourceLimitsObject{},
+	entity.ResourceUsageObject{},
+	entity.ResourceLimitsStateObject{},
+	entity.ResourceLimitsConfigObject{},
+}
+
+func snapshotSectionName(table interface{}) string {
+	return "eosio::chain::" + reflect.TypeOf(table).Name()
+}
+
+type SnapshotWriter struct {
+	out io.WriteSeeker
+}
+
+type SnapshotSectionWriter struct {
+	writer   *SnapshotWriter
+	rowCount uint32
+}
+
+func NewSnapshotWriter(out io.WriteSeeker) *SnapshotWriter {
+	w := &SnapshotWriter{out: out}
+	w.write(snapshotMagicNumber)
+	w.write(snapshotVersion)
+	return w
+}
+
+func (w *SnapshotWriter) write(v interface{}) {
+	bytes, err := rlp.EncodeToBytes(v)
+	Throw(err)
+	_, err = w.out.Write(bytes)
+	Throw(err)
+}
+
+func (w *SnapshotWriter) writeBytes(bytes []byte) {
+	w.write(uint32(len(bytes)))
+	_, err := w.out.Write(bytes)
+	Throw(err)
+}
+
+func (w *SnapshotWriter) WriteSection(name string, f func(section *SnapshotSectionWriter)) {
+	w.writeBytes([]byte(name))
+
+	countPos, err := w.out.Seek(0, io.SeekCurrent)
+	Throw(err)
+	w.write(uint32(0))
+
+	section := &SnapshotSectionWriter{writer: w}
+	f(section)
+
+	endPos, err := w.out.Seek(0, io.SeekCurrent)
+	Throw(err)
+	_, err = w.out.Seek(countPos, io.SeekStart)
+	Throw(err)
+	w.write(section.rowCount)
+	_, err = w.out.Seek(endPos, io.SeekStart)
+	Throw(err)
+}
+
+func (s *SnapshotSectionWriter) AddRow(row interface{}) {
+	bytes, err := rlp.EncodeToBytes(row)
+	Throw(err)
+	s.writer.writeBytes(bytes)
+	s.rowCount++
+}
+
+type snapshotSection struct {
+	pos      int64
+	rowCount uint32
+}
+
+type SnapshotReader struct {
+	in       io.ReadSeeker
+	sections map[string]snapshotSection
+}
+
+type SnapshotSectionReader struct {
+	reader    *SnapshotReader
+	remaining uint32
+}
+
+func NewSnapshotReader(in io.ReadSeeker) *SnapshotReader {
+	return &SnapshotReader{in: in, sections: make(map[string]snapshotSection)}
+}
+
+func (r *SnapshotReader) readUint32() uint32 {
+	bytes := make([]byte, SizeOfInt32)
+	_, err := io.ReadFull(r.in, bytes)
+	EosAssert(err == nil, &SnapshotValidationException{}, "unexpected end of snapshot: %s", err)
+
+	var v uint32
+	Throw(rlp.DecodeBytes(bytes, &v))
+	return v
+}
+
+func (r *SnapshotReader) readBytes() []byte {
+	bytes := make([]byte, r.readUint32())
+	_, err := io.ReadFull(r.in, bytes)
+	EosAssert(err == nil, &SnapshotValidationException{}, "unexpected end of snapshot: %s", err)
+	return bytes
+}
+
+func (r *SnapshotReader) skipBytes() {
+	size := r.readUint32()
+	_, err := r.in.Seek(int64(size), io.SeekCurrent)
+	Throw(err)
+}
+
+/**
+ * checks the header of the snapshot and indexes its sections
+ */
+func (r *SnapshotReader) Validate() {
+	_, err := r.in.Seek(0, io.SeekStart)
+	Throw(err)
+
+	EosAssert(r.readUint32() == snapshotMagicNumber, &SnapshotValidationException{}, "Binary snapshot has unexpected magic number!")
+	version := r.readUint32()
+	EosAssert(version == snapshotVersion, &SnapshotValidationException{},
+		"Binary snapshot is an unsupported version. Expected : %d, Got: %d", snapshotVersion, version)
+
+	end, err := r.in.Seek(0, io.SeekEnd)
+	Throw(err)
+	pos, err := r.in.Seek(8, io.SeekStart)
+	Throw(err)
+
+	for pos < end {
+		name := string(r.readBytes())
+		section := snapshotSection{rowCount: r.readUint32()}
+		section.pos, err = r.in.Seek(0, io.SeekCurrent)
+		Throw(err)
+
+		for i := uint32(0); i < section.rowCount; i++ {
+			r.skipBytes()
+		}
+		r.sections[name] = section
+
+		pos, err = r.in.Seek(0, io.SeekCurrent)
+		Throw(err)
+	}
+	EosAssert(pos == end, &SnapshotValidationException{}, "Binary snapshot has trailing data")
+}
+
+func (r *SnapshotReader) HasSection(name string) bool {
+	_, ok := r.sections[name]
+	return ok
+}
+
+func (r *SnapshotReader) ReadSection(name string, f func(section *SnapshotSectionReader)) {
+	s, ok := r.sections[name]
+	EosAssert(ok, &SnapshotException{}, "Binary snapshot has no section named %s", name)
+
+	_, err := r.in.Seek(s.pos, io.SeekStart)
+	Throw(err)
+	f(&SnapshotSectionReader{reader: r, remaining: s.rowCount})
+}
+
+func (s *SnapshotSectionReader) Empty() bool {
+	return s.remaining == 0
+}
+
+func (s *SnapshotSectionReader) ReadRow(row interface{}) {
+	EosAssert(s.remaining > 0, &SnapshotException{}, "read past the end of a snapshot section")
+	s.remaining--
+	Throw(rlp.DecodeBytes(s.reader.readBytes(), row))
+}
+
+/**
+ * reads the genesis state stored in a snapshot, it determines the chain id of the snapshot
+ */
+func (r *SnapshotReader) GenesisState() *types.GenesisState {
+	gs := types.NewGenesisState()
+	r.ReadSection(SnapshotGenesisStateSection, func(section *SnapshotSectionReader) {
+		section.ReadRow(gs)
+	})
+	return gs
+}
+
+func (c *Controller) addToSnapshot(snapshot *SnapshotWriter) {
+	snapshot.WriteSection(SnapshotGenesisStateSection, func(section *SnapshotSectionWriter) {
+		section.AddRow(c.Config.Genesis)
+	})
+
+	snapshot.WriteSection(SnapshotBlockStateSection, func(section *SnapshotSectionWriter) {
+		section.AddRow(&c.Head.BlockHeaderState)
+	})
+
+	for _, table := range snapshotTables {
+		snapshot.WriteSection(snapshotSectionName(table), func(section *SnapshotSectionWriter) {
+			idx, err := c.DB.GetIndex("id", table)
+			Throw(err)
+			if idx.Empty() {
+				return
+			}
+
+			for itr := idx.Begin(); !idx.CompareEnd(itr); itr.Next() {
+				row := reflect.New(reflect.TypeOf(table))
+				Throw(itr.Data(row.Interface()))
+				section.AddRow(row.Interface())
+			}
+		})
+	}
+}
+
+func (c *Controller) readFromSnapshot(snapshot *SnapshotReader) {
+	accounts, err := c.DB.GetIndex("id", entity.AccountObject{})
+	Throw(err)
+	EosAssert(accounts.Empty(), &SnapshotException{}, "Snapshot can only be used to initialize an empty database.")
+
+	chainId := snapshot.GenesisState().ComputeChainID()
+	EosAssert(chainId == c.ChainID, &SnapshotException{},
+		"snapshot chain id %s does not match the chain id %s of the controller", chainId, c.ChainID)
+
+	snapshot.ReadSection(SnapshotBlockStateSection, func(section *SnapshotSectionReader) {
+		headHeaderState := types.BlockHeaderState{}
+		section.ReadRow(&headHeaderState)
+
+		c.Head = types.NewBlockState(&headHeaderState)
+		c.Head.Sparse = true
+		c.ForkDB.SetHead(c.Head)
+		c.DB.SetRevision(int64(c.Head.BlockNum))
+	})
+
+	for _, table := range snapshotTables {
+		snapshot.ReadSection(snapshotSectionName(table), func(section *SnapshotSectionReader) {
+			for !section.Empty() {
+				row := reflect.New(reflect.TypeOf(table))
+				section.ReadRow(row.Interface())
+				EosAssert(c.DB.Restore(row.Interface()) == nil, &SnapshotException{},
+					"failed to restore %s from snapshot", reflect.TypeOf(table).Name())
+			}
+		})
+	}
+}
+
+/**
+ * writes the state of the head block to the snapshot, the pending block must be aborted first
+ */
+func (c *Controller) WriteSnapshot(snapshot *SnapshotWriter) {
+	EosAssert(c.Pending == nil, &BlockValidateException{}, "cannot take a consistent snapshot with a pending block")
+	c.addToSnapshot(snapshot)
+}
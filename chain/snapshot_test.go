@@ -0,0 +1,87 @@
+package chain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/eosspark/eos-go/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSnapshotTestController(dir string) *Controller {
+	os.RemoveAll(dir)
+	cfg := NewConfig()
+	cfg.BlocksDir = dir + cfg.BlocksDir
+	cfg.StateDir = dir + cfg.StateDir
+	return NewController(cfg)
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	snapshotPath := path + "snapshot.bin"
+	defer os.Remove(snapshotPath)
+
+	con := newSnapshotTestController(path + "snapshot_a/")
+	defer os.RemoveAll(path + "snapshot_a/")
+	con.Startup()
+	for i := 0; i < 5; i++ {
+		produceProcess(con)
+	}
+	con.AbortBlock()
+
+	out, err := os.Create(snapshotPath)
+	assert.NoError(t, err)
+	con.WriteSnapshot(NewSnapshotWriter(out))
+	out.Close()
+
+	headId, headNum := con.HeadBlockId(), con.HeadBlockNum()
+	accounts := countRows(t, con, entity.AccountObject{})
+	permissions := countRows(t, con, entity.PermissionObject{})
+	con.Close()
+
+	in, err := os.Open(snapshotPath)
+	assert.NoError(t, err)
+	defer in.Close()
+
+	reader := NewSnapshotReader(in)
+	reader.Validate()
+	assert.True(t, reader.HasSection(SnapshotGenesisStateSection))
+	assert.True(t, reader.HasSection(SnapshotBlockStateSection))
+
+	restored := newSnapshotTestController(path + "snapshot_b/")
+	defer os.RemoveAll(path + "snapshot_b/")
+	restored.Config.Genesis = reader.GenesisState()
+	restored.ChainID = restored.Config.Genesis.ComputeChainID()
+	restored.Startup(reader)
+
+	assert.Equal(t, headId, restored.HeadBlockId())
+	assert.Equal(t, headNum, restored.HeadBlockNum())
+	assert.Equal(t, accounts, countRows(t, restored, entity.AccountObject{}))
+	assert.Equal(t, permissions, countRows(t, restored, entity.PermissionObject{}))
+	assert.True(t, restored.Head.Sparse)
+
+	// the head stays sparse through a restart
+	cfg := restored.Config
+	restored.Close()
+	restored = NewController(&cfg)
+	restored.Startup()
+	assert.Equal(t, headId, restored.HeadBlockId())
+	assert.True(t, restored.Head.Sparse)
+
+	// the restored chain keeps producing on top of the snapshot
+	produceProcess(restored)
+	assert.Equal(t, headNum+1, restored.HeadBlockNum())
+	restored.Close()
+}
+
+func countRows(t *testing.T, con *Controller, table interface{}) int {
+	idx, err := con.DB.GetIndex("id", table)
+	assert.NoError(t, err)
+	count := 0
+	if idx.Empty() {
+		return count
+	}
+	for itr := idx.Begin(); !idx.CompareEnd(itr); itr.Next() {
+		count++
+	}
+	return count
+}
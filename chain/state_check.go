@@ -0,0 +1,181 @@
+package chain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
+	"github.com/eosspark/eos-go/entity"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+)
+
+// stateTables lists every table kept in the chain state database, the snapshot tables and the history tables
+var stateTables = append(append([]interface{}{}, snapshotTables...),
+	entity.AccountHistoryObject{},
+	entity.ActionHistoryObject{},
+	entity.AccountControlHistoryObject{},
+	entity.PublicKeyHistoryObject{},
+)
+
+// contractTables are the tables of the contracts, keyed by the index that starts with the table id
+var contractTables = map[string]string{
+	"KeyValueObject":      "byScopePrimary",
+	"Idx64Object":         "byPrimary",
+	"Idx128Object":        "byPrimary",
+	"Idx256Object":        "byPrimary",
+	"IdxDoubleObject":     "byPrimary",
+	"IdxLongDoubleObject": "byPrimary",
+}
+
+// StateReport is what CheckState found in the databases of a stopped node
+type StateReport struct {
+	HeadBlockNum uint32
+	Revision     int64
+	StateHash    crypto.Sha256
+	Problems     []error
+}
+
+/**
+ * CheckState opens the chain state and reversible blocks databases of cfg read only and verifies the indexes of all
+ * their tables, their undo sessions and that the state is not behind the head block. The head block is the last
+ * reversible block, or the head of the block log when there is none.
+ */
+func CheckState(cfg *Config) *StateReport {
+	db, err := database.OpenReadOnlyDataBase(cfg.DbBackend, cfg.StateDir)
+	EosAssert(err == nil, &DatabaseException{}, "cannot open the chain state database in '%s': %s", cfg.StateDir, err)
+	defer db.Close()
+	reversibleDir := cfg.BlocksDir + "/" + common.DefaultConfig.DefaultReversibleBlocksDirName
+	reversibleDB, err := database.OpenReadOnlyDataBase(cfg.DbBackend, reversibleDir)
+	EosAssert(err == nil, &DatabaseException{}, "cannot open the reversible blocks database in '%s': %s", reversibleDir, err)
+	defer reversibleDB.Close()
+
+	report := &StateReport{Revision: db.Revision()}
+	for _, table := range stateTables {
+		report.Problems = append(report.Problems, db.CheckIndexes(table)...)
+	}
+	report.Problems = append(report.Problems, db.CheckUndo()...)
+	report.Problems = append(report.Problems, reversibleDB.CheckIndexes(entity.ReversibleBlockObject{})...)
+	report.Problems = append(report.Problems, reversibleDB.CheckUndo()...)
+
+	if head := lastReversibleBlock(reversibleDB); head != nil {
+		report.HeadBlockNum = head.BlockNum
+	} else if common.FileExist(cfg.BlocksDir + "/blocks.log") {
+		blog := NewBlockLog(cfg.BlocksDir)
+		if blog.Head() != nil {
+			report.HeadBlockNum = blog.Head().BlockNumber()
+		}
+		blog.Close()
+	}
+	if report.Revision < int64(report.HeadBlockNum) {
+		report.Problems = append(report.Problems,
+			fmt.Errorf("the revision %d of the chain state is behind the head block %d", report.Revision, report.HeadBlockNum))
+	}
+
+	report.StateHash = StateHash(db)
+	return report
+}
+
+func lastReversibleBlock(reversibleDB database.DataBase) *entity.ReversibleBlockObject {
+	idx, err := reversibleDB.GetIndex("byNum", &entity.ReversibleBlockObject{})
+	Throw(err)
+	itr := idx.End()
+	if idx.CompareBegin(itr) {
+		return nil
+	}
+	itr.Prev()
+	r := &entity.ReversibleBlockObject{}
+	Throw(itr.Data(r))
+	return r
+}
+
+/**
+ * StateHash hashes the rows of the snapshot tables in id order, two nodes at the same block have the same hash.
+ * The history tables depend on the configuration of the node and are left out.
+ */
+func StateHash(db database.DataBase) crypto.Sha256 {
+	enc := crypto.NewSha256()
+	size := make([]byte, 4)
+	for _, table := range snapshotTables {
+		enc.Write([]byte(snapshotSectionName(table)))
+		idx, err := db.GetIndex("id", table)
+		Throw(err)
+		if idx.Empty() {
+			continue
+		}
+		for itr := idx.Begin(); !idx.CompareEnd(itr); itr.Next() {
+			row := reflect.New(reflect.TypeOf(table))
+			Throw(itr.Data(row.Interface()))
+			bytes, err := rlp.EncodeToBytes(row.Interface())
+			Throw(err)
+			binary.LittleEndian.PutUint32(size, uint32(len(bytes)))
+			enc.Write(size)
+			enc.Write(bytes)
+		}
+	}
+	return *crypto.NewSha256Byte(enc.Sum(nil))
+}
+
+/**
+ * DumpTable writes the rows of a table of the chain state to out as JSON. The table is named by its type,
+ * e.g. AccountObject, the contract tables take the table to dump as KeyValueObject:code:scope:table.
+ */
+func DumpTable(db database.DataBase, table string, out io.Writer) {
+	parts := strings.Split(table, ":")
+	var tableType reflect.Type
+	for _, t := range stateTables {
+		if reflect.TypeOf(t).Name() == parts[0] {
+			tableType = reflect.TypeOf(t)
+		}
+	}
+	EosAssert(tableType != nil, &DatabaseException{}, "unknown table %s", parts[0])
+
+	rows := make([]interface{}, 0)
+	collect := func(itr database.Iterator, idx *database.MultiIndex, keep func(row reflect.Value) bool) {
+		for ; !idx.CompareEnd(itr); itr.Next() {
+			row := reflect.New(tableType)
+			Throw(itr.Data(row.Interface()))
+			if !keep(row.Elem()) {
+				break
+			}
+			rows = append(rows, row.Interface())
+		}
+	}
+
+	if index, ok := contractTables[parts[0]]; ok && len(parts) > 1 {
+		EosAssert(len(parts) == 4, &DatabaseException{}, "%s takes code:scope:table, not %s", parts[0], table)
+		tid := entity.TableIdObject{Code: common.N(parts[1]), Scope: common.N(parts[2]), Table: common.N(parts[3])}
+		if db.Find("byCodeScopeTable", tid, &tid) == nil {
+			idx, err := db.GetIndex(index, reflect.New(tableType).Interface())
+			Throw(err)
+			lower := reflect.New(tableType)
+			lower.Elem().FieldByName("TId").Set(reflect.ValueOf(tid.ID))
+			itr, err := idx.LowerBound(lower.Interface())
+			Throw(err)
+			collect(itr, idx, func(row reflect.Value) bool {
+				return row.FieldByName("TId").Interface() == tid.ID
+			})
+		}
+	} else {
+		EosAssert(len(parts) == 1, &DatabaseException{}, "only the contract tables take code:scope:table, not %s", parts[0])
+		idx, err := db.GetIndex("id", reflect.New(tableType).Interface())
+		Throw(err)
+		if !idx.Empty() {
+			collect(idx.Begin(), idx, func(reflect.Value) bool { return true })
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	Throw(encoder.Encode(struct {
+		Table string        `json:"table"`
+		Rows  []interface{} `json:"rows"`
+	}{table, rows}))
+}
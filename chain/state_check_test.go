@@ -0,0 +1,78 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/eosspark/eos-go/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateCheck(t *testing.T) {
+	snapshotPath := path + "state_check.bin"
+	defer os.Remove(snapshotPath)
+
+	con := newSnapshotTestController(path + "state_check_a/")
+	defer os.RemoveAll(path + "state_check_a/")
+	con.Startup()
+	for i := 0; i < 5; i++ {
+		produceProcess(con)
+	}
+	con.AbortBlock()
+	hash := StateHash(con.DB)
+	headNum := con.HeadBlockNum()
+
+	out, err := os.Create(snapshotPath)
+	assert.NoError(t, err)
+	con.WriteSnapshot(NewSnapshotWriter(out))
+	out.Close()
+	cfg := con.Config
+	con.Close()
+
+	stateFiles := readFiles(t, cfg.StateDir)
+	report := CheckState(&cfg)
+	assert.Equal(t, stateFiles, readFiles(t, cfg.StateDir), "the check must not write to the state it inspects")
+	assert.Empty(t, report.Problems)
+	assert.Equal(t, headNum, report.HeadBlockNum)
+	assert.Equal(t, hash, report.StateHash)
+
+	// the state restored from a snapshot has the same rows with the same ids
+	in, err := os.Open(snapshotPath)
+	assert.NoError(t, err)
+	defer in.Close()
+	reader := NewSnapshotReader(in)
+	reader.Validate()
+	restored := newSnapshotTestController(path + "state_check_b/")
+	defer os.RemoveAll(path + "state_check_b/")
+	restored.Config.Genesis = reader.GenesisState()
+	restored.ChainID = restored.Config.Genesis.ComputeChainID()
+	restored.Startup(reader)
+	assert.Equal(t, hash, StateHash(restored.DB))
+
+	dump := &bytes.Buffer{}
+	DumpTable(restored.DB, "AccountObject", dump)
+	accounts := struct {
+		Table string
+		Rows  []struct{ Name string }
+	}{}
+	assert.NoError(t, json.Unmarshal(dump.Bytes(), &accounts))
+	assert.Equal(t, "AccountObject", accounts.Table)
+	assert.Equal(t, countRows(t, restored, entity.AccountObject{}), len(accounts.Rows))
+	assert.Equal(t, "eosio", accounts.Rows[0].Name)
+	restored.Close()
+}
+
+func readFiles(t *testing.T, dir string) map[string][]byte {
+	infos, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, info := range infos {
+		content, err := ioutil.ReadFile(dir + "/" + info.Name())
+		assert.NoError(t, err)
+		files[info.Name()] = content
+	}
+	return files
+}
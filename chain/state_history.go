@@ -0,0 +1,260 @@
+package chain
+
+import (
+	"reflect"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+	"github.com/eosspark/eos-go/plugins/chain_interface"
+)
+
+/**
+ * State history records, for every accepted block, the traces of its transactions in the trace log and the rows of
+ * the chain state it changed in the chain state log. Entries are rlp packed, the traces of a block are a list of
+ * StateHistoryTransactionTrace and the deltas a list of StateHistoryTableDelta.
+ */
+const (
+	TraceHistoryLogName      = "trace_history"
+	ChainStateHistoryLogName = "chain_state_history"
+)
+
+type StateHistoryActionTrace struct {
+	Receipt          types.ActionReceipt
+	Act              types.Action
+	ContextFree      bool
+	Elapsed          common.Microseconds
+	Console          string
+	AccountRamDeltas []common.AccountDelta
+	Except           string
+	InlineTraces     []StateHistoryActionTrace
+}
+
+type StateHistoryTransactionTrace struct {
+	ID              common.TransactionIdType
+	Status          types.TransactionStatus
+	CpuUsageUs      uint32
+	NetUsageWords   common.Vuint32
+	Elapsed         common.Microseconds
+	NetUsage        uint64
+	Scheduled       bool
+	ActionTraces    []StateHistoryActionTrace
+	Except          string
+	FailedDtrxTrace *StateHistoryTransactionTrace `eos:"optional"`
+}
+
+type StateHistoryRow struct {
+	Present bool
+	Data    []byte // rlp packed object, the last value of a removed row
+}
+
+type StateHistoryTableDelta struct {
+	Name string
+	Rows []StateHistoryRow
+}
+
+var stateHistoryTables = make(map[string]reflect.Type)
+
+func init() {
+	for _, table := range snapshotTables {
+		stateHistoryTables[reflect.TypeOf(table).Name()] = reflect.TypeOf(table)
+	}
+}
+
+func exceptString(e Exception) string {
+	if e == nil {
+		return ""
+	}
+	return e.DetailMessage()
+}
+
+func NewStateHistoryActionTrace(at *types.ActionTrace) StateHistoryActionTrace {
+	trace := StateHistoryActionTrace{
+		Receipt:          at.Receipt,
+		Act:              at.Act,
+		ContextFree:      at.ContextFree,
+		Elapsed:          at.Elapsed,
+		Console:          at.Console,
+		AccountRamDeltas: at.AccountRamDeltas.Values(),
+		Except:           exceptString(at.Except),
+	}
+	for i := range at.InlineTraces {
+		trace.InlineTraces = append(trace.InlineTraces, NewStateHistoryActionTrace(&at.InlineTraces[i]))
+	}
+	return trace
+}
+
+func NewStateHistoryTransactionTrace(t *types.TransactionTrace) StateHistoryTransactionTrace {
+	trace := StateHistoryTransactionTrace{
+		ID:            t.ID,
+		Status:        t.Receipt.Status,
+		CpuUsageUs:    t.Receipt.CpuUsageUs,
+		NetUsageWords: t.Receipt.NetUsageWords,
+		Elapsed:       t.Elapsed,
+		NetUsage:      t.NetUsage,
+		Scheduled:     t.Scheduled,
+		Except:        exceptString(t.Except),
+	}
+	for i := range t.ActionTraces {
+		trace.ActionTraces = append(trace.ActionTraces, NewStateHistoryActionTrace(&t.ActionTraces[i]))
+	}
+	if t.FailedDtrxTrace != nil {
+		failed := NewStateHistoryTransactionTrace(t.FailedDtrxTrace)
+		trace.FailedDtrxTrace = &failed
+	}
+	return trace
+}
+
+/**
+ * the rows changed by the block being committed, or every row of the chain state when full is set
+ */
+func (c *Controller) StateDeltas(full bool) []StateHistoryTableDelta {
+	deltas := make([]StateHistoryTableDelta, 0)
+
+	if full {
+		for _, table := range snapshotTables {
+			delta := StateHistoryTableDelta{Name: reflect.TypeOf(table).Name()}
+			idx, err := c.DB.GetIndex("id", table)
+			Throw(err)
+			if !idx.Empty() {
+				for itr := idx.Begin(); !idx.CompareEnd(itr); itr.Next() {
+					row := reflect.New(reflect.TypeOf(table))
+					Throw(itr.Data(row.Interface()))
+					data, err := rlp.EncodeToBytes(row.Interface())
+					Throw(err)
+					delta.Rows = append(delta.Rows, StateHistoryRow{Present: true, Data: data})
+				}
+			}
+			if len(delta.Rows) > 0 {
+				deltas = append(deltas, delta)
+			}
+		}
+		return deltas
+	}
+
+	for _, table := range c.DB.LastSessionDeltas() {
+		typ, ok := stateHistoryTables[table.Name]
+		if !ok {
+			continue
+		}
+		delta := StateHistoryTableDelta{Name: table.Name}
+		for _, r := range table.Rows {
+			row := reflect.New(typ)
+			Throw(database.DecodeBytes(r.Value, row.Interface()))
+			data, err := rlp.EncodeToBytes(row.Interface())
+			Throw(err)
+			delta.Rows = append(delta.Rows, StateHistoryRow{Present: r.Present, Data: data})
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
+
+type StateHistory struct {
+	control       *Controller
+	TraceLog      *StateHistoryLog
+	ChainStateLog *StateHistoryLog
+
+	cachedTraces map[common.TransactionIdType]*types.TransactionTrace
+	onblockTrace *types.TransactionTrace
+}
+
+/**
+ * opens the logs of dir and records the blocks accepted by control from now on, a log is nil when it is disabled
+ */
+func NewStateHistory(control *Controller, dir string, traceHistory bool, chainStateHistory bool) *StateHistory {
+	h := &StateHistory{control: control, cachedTraces: make(map[common.TransactionIdType]*types.TransactionTrace)}
+	if traceHistory {
+		h.TraceLog = NewStateHistoryLog(dir, TraceHistoryLogName)
+	}
+	if chainStateHistory {
+		h.ChainStateLog = NewStateHistoryLog(dir, ChainStateHistoryLogName)
+	}
+
+	control.AppliedTransaction.Connect(&chain_interface.AppliedTransactionCaller{Caller: h.onAppliedTransaction})
+	control.AcceptedBlock.Connect(&chain_interface.AcceptedBlockCaller{Caller: h.onAcceptedBlock})
+	return h
+}
+
+func isOnblock(t *types.TransactionTrace) bool {
+	if len(t.ActionTraces) != 1 {
+		return false
+	}
+	act := &t.ActionTraces[0].Act
+	return act.Account == common.DefaultConfig.SystemAccountName && act.Name == common.N("onblock") &&
+		len(act.Authorization) == 1 && act.Authorization[0].Actor == common.DefaultConfig.SystemAccountName
+}
+
+func (h *StateHistory) onAppliedTransaction(t *types.TransactionTrace) {
+	if h.TraceLog == nil {
+		return
+	}
+	if isOnblock(t) {
+		h.onblockTrace = t
+	} else {
+		h.cachedTraces[t.ID] = t
+	}
+}
+
+/**
+ * a failure to record a block is logged, it must not fail the block
+ */
+func (h *StateHistory) onAcceptedBlock(bs *types.BlockState) {
+	Try(func() {
+		if h.TraceLog != nil {
+			h.storeTraces(bs)
+		}
+		if h.ChainStateLog != nil {
+			h.storeChainState(bs)
+		}
+	}).FcLogAndDrop().End()
+}
+
+func (h *StateHistory) storeTraces(bs *types.BlockState) {
+	defer func() {
+		h.cachedTraces = make(map[common.TransactionIdType]*types.TransactionTrace)
+		h.onblockTrace = nil
+	}()
+
+	traces := make([]StateHistoryTransactionTrace, 0, len(bs.SignedBlock.Transactions)+1)
+	if h.onblockTrace != nil {
+		traces = append(traces, NewStateHistoryTransactionTrace(h.onblockTrace))
+	}
+	for _, receipt := range bs.SignedBlock.Transactions {
+		id := receipt.Trx.TransactionID
+		if receipt.Trx.PackedTransaction != nil {
+			id = receipt.Trx.PackedTransaction.ID()
+		}
+		trace, ok := h.cachedTraces[id]
+		EosAssert(ok, &PluginException{}, "missing trace for transaction %s of block %d", id, bs.BlockNum)
+		traces = append(traces, NewStateHistoryTransactionTrace(trace))
+	}
+
+	payload, err := rlp.EncodeToBytes(traces)
+	Throw(err)
+	h.TraceLog.Write(bs.BlockNum, bs.BlockId, payload)
+}
+
+func (h *StateHistory) storeChainState(bs *types.BlockState) {
+	full := h.ChainStateLog.Empty()
+	if full {
+		log.Info("placing initial state in block %d", bs.BlockNum)
+	}
+
+	payload, err := rlp.EncodeToBytes(h.control.StateDeltas(full))
+	Throw(err)
+	h.ChainStateLog.Write(bs.BlockNum, bs.BlockId, payload)
+}
+
+func (h *StateHistory) Close() {
+	if h.TraceLog != nil {
+		h.TraceLog.Close()
+	}
+	if h.ChainStateLog != nil {
+		h.ChainStateLog.Close()
+	}
+}
@@ -0,0 +1,229 @@
+package chain
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+)
+
+/**
+ * A state history log keeps one entry per block, the entries of blocks that are forked out are truncated.
+ *
+ * <name>.log layout:
+ *   entry ...
+ * entry:
+ *   header | payload | position of the entry (uint64)
+ * header:
+ *   magic number (uint32) | block num (uint32) | block id | payload size (uint32)
+ *
+ * <name>.index holds the position of the entry of every block (uint64).
+ * The log is written as blocks are accepted and read by the clients of state_history_plugin, all accesses are locked.
+ */
+const (
+	stateHistoryMagicNumber = uint32(0x48545353)
+	stateHistoryHeaderSize  = SizeOfInt32*3 + 32
+)
+
+type StateHistoryHeader struct {
+	Magic       uint32
+	BlockNum    uint32
+	BlockId     common.BlockIdType
+	PayloadSize uint32
+}
+
+type StateHistoryLog struct {
+	name        string
+	logStream   *os.File
+	indexStream *os.File
+
+	lock          sync.Mutex
+	firstBlockNum uint32
+	endBlockNum   uint32 // one past the last block of the log
+}
+
+func NewStateHistoryLog(dir string, name string) *StateHistoryLog {
+	EosAssert(os.MkdirAll(dir, os.ModePerm) == nil, &PluginConfigException{}, "cannot create state history dir %s", dir)
+
+	l := &StateHistoryLog{name: name}
+	var err error
+	l.logStream, err = os.OpenFile(dir+"/"+name+".log", os.O_RDWR|os.O_CREATE, 0644)
+	Throw(err)
+	l.indexStream, err = os.OpenFile(dir+"/"+name+".index", os.O_RDWR|os.O_CREATE, 0644)
+	Throw(err)
+
+	logSize, err := l.logStream.Seek(0, io.SeekEnd)
+	Throw(err)
+	if logSize == 0 {
+		Throw(l.indexStream.Truncate(0))
+		return l
+	}
+
+	first := l.readHeader(0)
+	l.firstBlockNum = first.BlockNum
+	last := l.readHeader(l.readPos(logSize - SizeOfInt64))
+	l.endBlockNum = last.BlockNum + 1
+
+	indexSize, err := l.indexStream.Seek(0, io.SeekEnd)
+	Throw(err)
+	if indexSize != int64(l.endBlockNum-l.firstBlockNum)*SizeOfInt64 {
+		log.Warn("%s.index does not match %s.log, regenerating the index", name, name)
+		l.constructIndex(logSize)
+	}
+	return l
+}
+
+func (l *StateHistoryLog) readAt(pos int64, buf []byte) {
+	_, err := l.logStream.ReadAt(buf, pos)
+	EosAssert(err == nil, &BlockLogException{}, "%s.log is corrupted at %d: %s", l.name, pos, err)
+}
+
+func (l *StateHistoryLog) readPos(pos int64) int64 {
+	buf := make([]byte, SizeOfInt64)
+	l.readAt(pos, buf)
+	var entryPos int64
+	Throw(rlp.DecodeBytes(buf, &entryPos))
+	return entryPos
+}
+
+func (l *StateHistoryLog) readHeader(pos int64) *StateHistoryHeader {
+	buf := make([]byte, stateHistoryHeaderSize)
+	l.readAt(pos, buf)
+	header := &StateHistoryHeader{}
+	Throw(rlp.DecodeBytes(buf, header))
+	EosAssert(header.Magic == stateHistoryMagicNumber, &BlockLogException{}, "%s.log has an unexpected magic number at %d", l.name, pos)
+	return header
+}
+
+func (l *StateHistoryLog) constructIndex(logSize int64) {
+	Throw(l.indexStream.Truncate(0))
+	_, err := l.indexStream.Seek(0, io.SeekStart)
+	Throw(err)
+
+	for pos, num := int64(0), l.firstBlockNum; pos < logSize; num++ {
+		header := l.readHeader(pos)
+		EosAssert(header.BlockNum == num, &BlockLogException{}, "%s.log has block %d, expected block %d", l.name, header.BlockNum, num)
+		l.writeIndex(pos)
+		pos += stateHistoryHeaderSize + int64(header.PayloadSize) + SizeOfInt64
+	}
+}
+
+func (l *StateHistoryLog) writeIndex(pos int64) {
+	data, err := rlp.EncodeToBytes(pos)
+	Throw(err)
+	_, err = l.indexStream.Write(data)
+	Throw(err)
+}
+
+func (l *StateHistoryLog) entryPos(blockNum uint32) int64 {
+	buf := make([]byte, SizeOfInt64)
+	_, err := l.indexStream.ReadAt(buf, int64(blockNum-l.firstBlockNum)*SizeOfInt64)
+	EosAssert(err == nil, &BlockLogException{}, "%s.index is corrupted: %s", l.name, err)
+	var pos int64
+	Throw(rlp.DecodeBytes(buf, &pos))
+	return pos
+}
+
+/**
+ * the log holds the blocks [BeginBlock, EndBlock)
+ */
+func (l *StateHistoryLog) BeginBlock() uint32 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.firstBlockNum
+}
+
+func (l *StateHistoryLog) EndBlock() uint32 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.endBlockNum
+}
+
+func (l *StateHistoryLog) Empty() bool {
+	return l.EndBlock() == 0
+}
+
+/**
+ * appends the entry of a block, the entries from blockNum on are dropped first when the block replaces them
+ */
+func (l *StateHistoryLog) Write(blockNum uint32, blockId common.BlockIdType, payload []byte) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.endBlockNum != 0 && blockNum < l.endBlockNum {
+		EosAssert(blockNum >= l.firstBlockNum, &BlockLogException{},
+			"block %d is before the first block %d of %s.log", blockNum, l.firstBlockNum, l.name)
+		l.truncate(blockNum)
+	}
+	if l.endBlockNum == 0 {
+		l.firstBlockNum = blockNum
+	} else {
+		EosAssert(blockNum == l.endBlockNum, &BlockLogAppendFail{},
+			"%s.log is at block %d, cannot append block %d", l.name, l.endBlockNum-1, blockNum)
+	}
+
+	pos, err := l.logStream.Seek(0, io.SeekEnd)
+	Throw(err)
+	header, err := rlp.EncodeToBytes(&StateHistoryHeader{
+		Magic:       stateHistoryMagicNumber,
+		BlockNum:    blockNum,
+		BlockId:     blockId,
+		PayloadSize: uint32(len(payload)),
+	})
+	Throw(err)
+	trailer, err := rlp.EncodeToBytes(pos)
+	Throw(err)
+
+	for _, data := range [][]byte{header, payload, trailer} {
+		_, err = l.logStream.Write(data)
+		Throw(err)
+	}
+	_, err = l.indexStream.Seek(0, io.SeekEnd)
+	Throw(err)
+	l.writeIndex(pos)
+	l.endBlockNum = blockNum + 1
+}
+
+func (l *StateHistoryLog) truncate(blockNum uint32) {
+	if blockNum == l.firstBlockNum {
+		Throw(l.logStream.Truncate(0))
+		Throw(l.indexStream.Truncate(0))
+		l.firstBlockNum, l.endBlockNum = 0, 0
+		return
+	}
+	Throw(l.logStream.Truncate(l.entryPos(blockNum)))
+	Throw(l.indexStream.Truncate(int64(blockNum-l.firstBlockNum) * SizeOfInt64))
+	l.endBlockNum = blockNum
+}
+
+/**
+ * reads the entry of a block, ok is false when the log does not have the block
+ */
+func (l *StateHistoryLog) Read(blockNum uint32) (blockId common.BlockIdType, payload []byte, ok bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if blockNum < l.firstBlockNum || blockNum >= l.endBlockNum {
+		return
+	}
+	pos := l.entryPos(blockNum)
+	header := l.readHeader(pos)
+	EosAssert(header.BlockNum == blockNum, &BlockLogException{},
+		"%s.log has block %d at the position of block %d", l.name, header.BlockNum, blockNum)
+
+	payload = make([]byte, header.PayloadSize)
+	l.readAt(pos+stateHistoryHeaderSize, payload)
+	return header.BlockId, payload, true
+}
+
+func (l *StateHistoryLog) Close() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.logStream.Close()
+	l.indexStream.Close()
+}
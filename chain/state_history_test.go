@@ -0,0 +1,112 @@
+package chain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateHistoryLog(t *testing.T) {
+	dir := path + "state_history_log/"
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	l := NewStateHistoryLog(dir, "test")
+	assert.True(t, l.Empty())
+	for num := uint32(5); num < 10; num++ {
+		l.Write(num, *crypto.Hash256(num), []byte{byte(num), byte(num)})
+	}
+	assert.Equal(t, uint32(5), l.BeginBlock())
+	assert.Equal(t, uint32(10), l.EndBlock())
+
+	id, payload, ok := l.Read(7)
+	assert.True(t, ok)
+	assert.Equal(t, *crypto.Hash256(uint32(7)), id)
+	assert.Equal(t, []byte{7, 7}, payload)
+	_, _, ok = l.Read(4)
+	assert.False(t, ok)
+	_, _, ok = l.Read(10)
+	assert.False(t, ok)
+
+	// block 8 of another fork replaces blocks 8 and 9
+	l.Write(8, *crypto.Hash256("fork"), []byte{0xff})
+	assert.Equal(t, uint32(9), l.EndBlock())
+	id, payload, ok = l.Read(8)
+	assert.True(t, ok)
+	assert.Equal(t, *crypto.Hash256("fork"), id)
+	assert.Equal(t, []byte{0xff}, payload)
+	assert.Panics(t, func() { l.Write(10, *crypto.Hash256("gap"), nil) })
+	l.Close()
+
+	// a lost index is rebuilt from the log
+	assert.NoError(t, os.Remove(dir+"test.index"))
+	l = NewStateHistoryLog(dir, "test")
+	assert.Equal(t, uint32(5), l.BeginBlock())
+	assert.Equal(t, uint32(9), l.EndBlock())
+	_, payload, ok = l.Read(6)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{6, 6}, payload)
+	l.Close()
+}
+
+func TestStateHistory_AcceptedBlocks(t *testing.T) {
+	dir := path + "state_history/"
+	con := newSnapshotTestController(dir)
+	defer os.RemoveAll(dir)
+	history := NewStateHistory(con, dir+"history", true, true)
+	con.Startup()
+	for i := 0; i < 3; i++ {
+		produceProcess(con)
+	}
+	con.AbortBlock()
+	head := con.HeadBlockNum()
+
+	assert.Equal(t, head+1, history.TraceLog.EndBlock())
+	assert.Equal(t, head+1, history.ChainStateLog.EndBlock())
+	first := history.ChainStateLog.BeginBlock()
+
+	// the first entry holds the whole chain state
+	id, payload, ok := history.ChainStateLog.Read(first)
+	assert.True(t, ok)
+	assert.Equal(t, con.FetchBlockByNumber(first).BlockID(), id)
+	var deltas []StateHistoryTableDelta
+	assert.NoError(t, rlp.DecodeBytes(payload, &deltas))
+	rows := make(map[string]int)
+	for _, delta := range deltas {
+		rows[delta.Name] = len(delta.Rows)
+	}
+	assert.Equal(t, countRows(t, con, entity.AccountObject{}), rows["AccountObject"])
+	assert.Equal(t, countRows(t, con, entity.PermissionObject{}), rows["PermissionObject"])
+
+	// the next ones only the rows changed by their block
+	_, payload, ok = history.ChainStateLog.Read(head)
+	assert.True(t, ok)
+	deltas = nil
+	assert.NoError(t, rlp.DecodeBytes(payload, &deltas))
+	assert.NotEqual(t, 0, len(deltas))
+	dynamic := 0
+	for _, delta := range deltas {
+		assert.NotEqual(t, "AccountObject", delta.Name)
+		if delta.Name == "DynamicGlobalPropertyObject" {
+			dynamic++
+			assert.Equal(t, 1, len(delta.Rows))
+			assert.True(t, delta.Rows[0].Present)
+			assert.NoError(t, rlp.DecodeBytes(delta.Rows[0].Data, &entity.DynamicGlobalPropertyObject{}))
+		}
+	}
+	assert.Equal(t, 1, dynamic)
+
+	id, payload, ok = history.TraceLog.Read(head)
+	assert.True(t, ok)
+	assert.Equal(t, con.HeadBlockId(), id)
+	var traces []StateHistoryTransactionTrace
+	assert.NoError(t, rlp.DecodeBytes(payload, &traces))
+	assert.Equal(t, 1, len(traces), "the onblock transaction")
+
+	history.Close()
+	con.Close()
+}
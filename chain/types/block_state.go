@@ -11,12 +11,13 @@ type BlockState struct {
 	SignedBlock      *SignedBlock `multiIndex:"inline"`
 	Validated        bool         `json:"validated"`
 	InCurrentChain   bool         `json:"in_current_chain"`
+	Sparse           bool         `json:"sparse"` // loaded from a snapshot with its block header state only, it has no block
 	Trxs             []*TransactionMetadata
 }
 
 func NewBlockState(cur *BlockHeaderState) *BlockState {
 	return &BlockState{*cur, &SignedBlock{},
-		false, false, make([]*TransactionMetadata, 0)}
+		false, false, false, make([]*TransactionMetadata, 0)}
 }
 
 func NewBlockState2(prev *BlockHeaderState, when BlockTimeStamp) *BlockState {
@@ -8,18 +8,19 @@ import (
 
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/common/container/treeset" AccountDeltaSet(AccountDelta,CompareAccountDelta,false)
 type BaseActionTrace struct {
-	Receipt          ActionReceipt
-	Act              Action
-	ContextFree      bool //default false
-	Elapsed          common.Microseconds
-	CpuUsage         uint64
-	Console          string
-	TotalCpuUsage    uint64                   /// total of inline_traces[x].cpu_usage + cpu_usage
-	TrxId            common.TransactionIdType ///< the transaction that generated this action
-	BlockNum         uint32
-	BlockTime        BlockTimeStamp
-	ProducerBlockId  common.BlockIdType
-	AccountRamDeltas AccountDeltaSet
+	Receipt           ActionReceipt
+	Act               Action
+	ContextFree       bool //default false
+	Elapsed           common.Microseconds
+	CpuUsage          uint64
+	Console           string
+	TotalCpuUsage     uint64                   /// total of inline_traces[x].cpu_usage + cpu_usage
+	TrxId             common.TransactionIdType ///< the transaction that generated this action
+	BlockNum          uint32
+	BlockTime         BlockTimeStamp
+	ProducerBlockId   common.BlockIdType
+	AccountRamDeltas  AccountDeltaSet
+	InstructionWeight uint64 `eos:"-"` // weight of the wasm instructions executed, by the gas policy of the chain
 
 	Except Exception
 }
@@ -0,0 +1,243 @@
+package chain
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/eosspark/eos-go/chain/types"
+	. "github.com/eosspark/eos-go/chain/types/generated_containers"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/database"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+	"github.com/eosspark/eos-go/wasmgo"
+)
+
+func newWasmRuntime(cfg *Config) wasmgo.Runtime {
+	wasmGo := wasmgo.NewWasmGo()
+	wasmGo.SetCodeCache(cfg.WasmCacheSize, cfg.WasmCacheDir)
+	wasmGo.SetGasPolicy(cfg.WasmGasPolicy, cfg.WasmGasLimit)
+	wasmGo.SetProfiledAccounts(cfg.WasmProfileAccounts)
+	if cfg.WasmDebug {
+		wasmGo.SetDebugger(wasmgo.NewDebugger())
+	} else {
+		wasmGo.SetDebugger(nil)
+	}
+
+	wagon := wasmgo.NewWagon()
+	wagon.SetGasPolicy(cfg.WasmGasPolicy, cfg.WasmGasLimit)
+
+	switch cfg.VmType {
+	case wasmgo.WAGON:
+		return wagon
+	case wasmgo.DIFFERENTIAL:
+		return NewDifferentialRuntime(wasmGo, wagon)
+	default:
+		return wasmGo
+	}
+}
+
+// WasmProfiles returns the profiles of the contracts of the accounts in Config.WasmProfileAccounts, the profiles are
+// recorded by the wasmgo runtime only
+func (c *Controller) WasmProfiles() []*wasmgo.Profile {
+	switch runtime := c.WasmIf.(type) {
+	case *wasmgo.WasmGo:
+		return runtime.Profiles()
+	case *DifferentialRuntime:
+		if wasmGo, ok := runtime.runtime.(*wasmgo.WasmGo); ok {
+			return wasmGo.Profiles()
+		}
+	}
+	return nil
+}
+
+// WasmDebugger returns the debugger of the contracts when Config.WasmDebug, the contracts are debugged on the wasmgo
+// runtime only
+func (c *Controller) WasmDebugger() *wasmgo.Debugger {
+	switch runtime := c.WasmIf.(type) {
+	case *wasmgo.WasmGo:
+		return runtime.Debugger()
+	case *DifferentialRuntime:
+		if wasmGo, ok := runtime.runtime.(*wasmgo.WasmGo); ok {
+			return wasmGo.Debugger()
+		}
+	}
+	return nil
+}
+
+/**
+ * DifferentialRuntime runs the code of every action on a reference runtime and then on the runtime of the chain,
+ * and fails the action where their results differ: the exception, the console output, the notified accounts, the
+ * inline actions, the ram deltas and the rows of the state each run changed. The reference runs without billing in
+ * an undo session that is undone and on a copy of the transaction context, the chain keeps what the runtime of the
+ * chain did.
+ */
+type DifferentialRuntime struct {
+	runtime     wasmgo.Runtime
+	reference   wasmgo.Runtime
+	divergences []WasmDivergence
+	ilog        log.Logger
+}
+
+type WasmDivergence struct {
+	Receiver common.AccountName
+	Account  common.AccountName
+	Action   common.ActionName
+	What     string
+}
+
+func (d WasmDivergence) String() string {
+	return fmt.Sprintf("%s on %s::%s: %s", d.Receiver, d.Account, d.Action, d.What)
+}
+
+// the results of an action compared by a DifferentialRuntime
+type applyResult struct {
+	Except           string
+	Console          string
+	Notified         []common.AccountName
+	InlineActions    []types.Action
+	CfaInlineActions []types.Action
+	RamDeltas        []common.AccountDelta
+	State            []database.TableDelta
+}
+
+func NewDifferentialRuntime(runtime, reference wasmgo.Runtime) *DifferentialRuntime {
+	d := &DifferentialRuntime{runtime: runtime, reference: reference, divergences: []WasmDivergence{}}
+	d.ilog = log.New("differential")
+	d.ilog.SetHandler(log.StreamHandler(os.Stdout, log.TerminalFormat(true)))
+	return d
+}
+
+// Divergences returns the actions on which the runtimes differed, in the order they ran
+func (d *DifferentialRuntime) Divergences() []WasmDivergence {
+	return d.divergences
+}
+
+func (d *DifferentialRuntime) Apply(codeId *crypto.Sha256, code []byte, context wasmgo.EnvContext) {
+	a, ok := context.(*ApplyContext)
+	if !ok {
+		d.runtime.Apply(codeId, code, context)
+		return
+	}
+
+	saved := *a
+	caches := a.iteratorCaches()
+	originals := make([]*iteratorCache, len(caches))
+	for i, cache := range caches {
+		originals[i] = *cache
+		*cache = (*cache).clone()
+	}
+	a.UsedAuthorizations = append([]bool{}, saved.UsedAuthorizations...)
+	a.Notified = append([]common.AccountName{}, saved.Notified...)
+	a.InlineActions = append([]types.Action{}, saved.InlineActions...)
+	a.CfaInlineActions = append([]types.Action{}, saved.CfaInlineActions...)
+	a.AccountRamDeltas = *CopyFromAccountDeltaSet(&saved.AccountRamDeltas)
+
+	a.PauseBillingTimer()
+	a.TrxContext = copyTrxContext(saved.TrxContext)
+	want, referenceExcept := d.run(d.reference, a, unbilledContext{a}, codeId, code, false)
+	a.ResumeBillingTimer()
+
+	*a = saved
+	for i, cache := range caches {
+		*cache = originals[i]
+	}
+
+	// the transaction ran out of time on the reference, the runtime of the chain would only run out of it as well
+	if isTimeException(referenceExcept) {
+		Throw(referenceExcept)
+	}
+
+	got, except := d.run(d.runtime, a, a, codeId, code, true)
+	divergences := len(d.divergences)
+	d.compare(a, &want, &got)
+	EosAssert(len(d.divergences) == divergences, &WasmExecutionError{}, "wasm runtimes diverge, %v",
+		d.divergences[divergences:])
+	Throw(except)
+}
+
+// copyTrxContext copies what the apply of an action changes in t, the reference runs on the copy
+func copyTrxContext(t *TransactionContext) *TransactionContext {
+	c := *t
+	netUsage := *t.netUsage
+	c.netUsage = &netUsage
+	c.Executed = append([]types.ActionReceipt{}, t.Executed...)
+	c.BillToAccounts = *CopyFromAccountNameSet(&t.BillToAccounts)
+	c.ValidateRamUsage = *CopyFromAccountNameSet(&t.ValidateRamUsage)
+	return &c
+}
+
+// isTimeException reports whether except is thrown by TransactionContext.CheckTime
+func isTimeException(except interface{}) bool {
+	e, ok := except.(Exception)
+	if !ok {
+		return false
+	}
+	switch e.Code() {
+	case DeadlineException{}.Code(), BlockCpuUsageExceeded{}.Code(), TxCpuUsageExceeded{}.Code(),
+		GreylistCpuUsageExceeded{}.Code(), LeewayDeadlineException{}.Code():
+		return true
+	}
+	return false
+}
+
+// unbilledContext keeps the billing timer paused while the reference runs
+type unbilledContext struct {
+	*ApplyContext
+}
+
+func (unbilledContext) PauseBillingTimer()  {}
+func (unbilledContext) ResumeBillingTimer() {}
+
+// run applies the action of a on runtime in an undo session, the session is squashed when keep and undone otherwise
+func (d *DifferentialRuntime) run(runtime wasmgo.Runtime, a *ApplyContext, context wasmgo.EnvContext, codeId *crypto.Sha256, code []byte,
+	keep bool) (applyResult, interface{}) {
+	var except interface{}
+	session := a.DB.StartSession()
+	Try(func() {
+		runtime.Apply(codeId, code, context)
+	}).Catch(func(e interface{}) {
+		except = e
+	}).End()
+
+	result := applyResult{
+		Console:          a.PendingConsoleOutput,
+		Notified:         a.Notified,
+		InlineActions:    a.InlineActions,
+		CfaInlineActions: a.CfaInlineActions,
+		RamDeltas:        a.AccountRamDeltas.Values(),
+		State:            a.DB.LastSessionDeltas(),
+	}
+	if e, ok := except.(Exception); ok {
+		result.Except = fmt.Sprintf("%s %d", e.Name(), e.Code())
+	} else if except != nil {
+		result.Except = fmt.Sprintf("%v", except)
+	}
+
+	if keep {
+		session.Squash()
+	} else {
+		session.Undo()
+	}
+	return result, except
+}
+
+func (d *DifferentialRuntime) compare(a *ApplyContext, want, got *applyResult) {
+	w, g := reflect.ValueOf(want).Elem(), reflect.ValueOf(got).Elem()
+	for i := 0; i < w.NumField(); i++ {
+		if reflect.DeepEqual(w.Field(i).Interface(), g.Field(i).Interface()) {
+			continue
+		}
+		divergence := WasmDivergence{
+			Receiver: a.Receiver,
+			Account:  a.Act.Account,
+			Action:   a.Act.Name,
+			What:     fmt.Sprintf("%s %v, reference %v", w.Type().Field(i).Name, g.Field(i), w.Field(i)),
+		}
+		d.divergences = append(d.divergences, divergence)
+		d.ilog.Error("wasm runtimes diverge, %s", divergence)
+	}
+}
@@ -96,11 +96,14 @@ func init() {
 	DefaultConfig.DefaultBlocksDirName = "blocks"
 	DefaultConfig.DefaultReversibleBlocksDirName = "reversible"
 	DefaultConfig.DefaultStateDirName = "state"
+	DefaultConfig.DefaultCodeCacheDirName = "code_cache"
+	DefaultConfig.DefaultWasmProfileDirName = "wasm_profiles"
 
 	DefaultConfig.DefaultStateSize = 1 * 1024 * 1024 * 1024
 	DefaultConfig.DefaultStateGuardSize = 128 * 1024 * 1024
 	DefaultConfig.DefaultReversibleCacheSize = 340 * 1024 * 1024
 	DefaultConfig.DefaultReversibleGuardSize = 2 * 1024 * 1024
+	DefaultConfig.DefaultWasmCacheSize = 512 * 1024 * 1024
 	DefaultConfig.MinNetUsageDeltaBetweenBaseAndMaxForTrx = 10 * 1024
 }
 
@@ -190,10 +193,13 @@ type Config struct {
 	DefaultBlocksDirName           string
 	DefaultReversibleBlocksDirName string
 	DefaultStateDirName            string
+	DefaultCodeCacheDirName        string
+	DefaultWasmProfileDirName      string
 	DefaultStateSize               uint64
 	DefaultStateGuardSize          uint64
 	DefaultReversibleCacheSize     uint64
 	DefaultReversibleGuardSize     uint64
+	DefaultWasmCacheSize           uint64
 	//FixedNetOverheadOfPackedTrx uint32 // TODO: C++ default value 16 and is this reasonable?
 }
 
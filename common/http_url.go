@@ -1,7 +1,9 @@
 package common
 
 const (
-	HttpEndPoint = "http://127.0.0.1:8888"
+	HttpEndPoint          = "http://127.0.0.1:8888"
+	WasmDebugEndPoint     = "http://127.0.0.1:8898"
+	WasmDebugListenString = "127.0.0.1:8898"
 )
 
 const (
@@ -25,6 +27,7 @@ const (
 	GetProducersFunc        string = ChainFuncBase + "/get_producers"
 	GetScheduleFunc         string = ChainFuncBase + "/get_producer_schedule"
 	GetRequiredKeys         string = ChainFuncBase + "/get_required_keys"
+	SimulateTxnFunc         string = ChainFuncBase + "/simulate_transaction"
 
 	HistoryFuncBase           string = "/v1/history"
 	GetActionsFunc            string = HistoryFuncBase + "/get_actions"
@@ -54,6 +57,7 @@ const (
 	WalletRemoveKey  string = WalletFuncBase + "/remove_key"
 	WalletCreateKey  string = WalletFuncBase + "/create_key"
 	WalletSignTrx    string = WalletFuncBase + "/sign_transaction"
+	WalletSignDigest string = WalletFuncBase + "/sign_digest"
 
 	// keosdStop string = "/v1/keosd/stop"
 
@@ -70,4 +74,20 @@ const (
 	ProducerSetWhitelistBlacklist  string = ProducerFuncBase + "/set_whitelist_blacklist"
 	ProducerGetIntegrityHash       string = ProducerFuncBase + "/get_integrity_hash"
 	ProducerCreateSnapshot         string = ProducerFuncBase + "/create_snapshot"
+	ProducerGetWatermarks          string = ProducerFuncBase + "/get_watermarks"
+
+	WasmDebugFuncBase         string = "/v1/wasm_debug"
+	WasmDebugAddBreakpoint    string = WasmDebugFuncBase + "/add_breakpoint"
+	WasmDebugRemoveBreakpoint string = WasmDebugFuncBase + "/remove_breakpoint"
+	WasmDebugGetBreakpoints   string = WasmDebugFuncBase + "/get_breakpoints"
+	WasmDebugGetState         string = WasmDebugFuncBase + "/get_state"
+	WasmDebugContinue         string = WasmDebugFuncBase + "/continue"
+	WasmDebugStep             string = WasmDebugFuncBase + "/step"
+	WasmDebugStepOver         string = WasmDebugFuncBase + "/step_over"
+	WasmDebugStepOut          string = WasmDebugFuncBase + "/step_out"
+	WasmDebugAbort            string = WasmDebugFuncBase + "/abort"
+	WasmDebugReadMemory       string = WasmDebugFuncBase + "/read_memory"
+	WasmDebugDisassemble      string = WasmDebugFuncBase + "/disassemble"
+
+	StreamFunc string = "/v1/stream"
 )
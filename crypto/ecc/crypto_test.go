@@ -3,12 +3,14 @@ package ecc_test
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/asn1"
 	"encoding/hex"
 	"fmt"
 	"github.com/eosspark/eos-go/crypto"
 	"github.com/eosspark/eos-go/crypto/ecc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math/big"
 	"testing"
 )
 
@@ -61,7 +63,25 @@ func TestR1PrivateToPublic(t *testing.T) {
 	pubKey := privKey.PublicKey()
 
 	pubKeyString := pubKey.String()
-	assert.Equal(t, "PUB_R1_0000000000000000000000000000000000000000000000", pubKeyString)
+	assert.Equal(t, "PUB_R1_6RJ9pXJNe1wk6p2yiJcuJ8QPo7WTudHya9z8vu1VPk44fhBz79", pubKeyString)
+	assert.Equal(t, encoded_privKey, privKey.String())
+
+	key, err := ecc.NewPublicKey(pubKeyString)
+	require.NoError(t, err)
+	assert.Equal(t, ecc.CurveR1, key.Curve)
+	assert.True(t, key.Compare(pubKey))
+	assert.True(t, key.Valid())
+}
+
+func TestNewRandomR1PrivateKey(t *testing.T) {
+	key, err := ecc.NewRandomR1PrivateKey()
+	require.NoError(t, err)
+	assert.Regexp(t, "^PVT_R1_.*", key.String())
+	assert.Regexp(t, "^PUB_R1_.*", key.PublicKey().String())
+
+	decoded, err := ecc.NewPrivateKey(key.String())
+	require.NoError(t, err)
+	assert.True(t, decoded.PublicKey().Compare(key.PublicKey()))
 }
 
 func TestNewPublicKeyAndSerializeCompress(t *testing.T) {
@@ -139,9 +159,57 @@ func TestR1Signature(t *testing.T) {
 
 	cnt := []byte("hi")
 	digest := sigDigest([]byte{}, cnt, nil)
-	_, err = privKey.Sign(digest)
-	assert.Error(t, err)
-	assert.Equal(t, "R1 not supported", err.Error())
+	signature, err := privKey.Sign(digest)
+	require.NoError(t, err)
+	assert.Equal(t, ecc.CurveR1, signature.Curve)
+	assert.True(t, signature.Verify(digest, privKey.PublicKey()))
+
+	recovered, err := signature.PublicKey(digest)
+	require.NoError(t, err)
+	assert.Equal(t, "PUB_R1_6RJ9pXJNe1wk6p2yiJcuJ8QPo7WTudHya9z8vu1VPk44fhBz79", recovered.String())
+
+	packed, err := signature.Pack()
+	require.NoError(t, err)
+	var unpacked ecc.Signature
+	_, err = unpacked.Unpack(packed)
+	require.NoError(t, err)
+	assert.True(t, unpacked.Verify(digest, privKey.PublicKey()))
+
+	fromText, err := ecc.NewSignature(signature.String())
+	require.NoError(t, err)
+	assert.Equal(t, signature.Content, fromText.Content)
+}
+
+// TestR1SignatureFromOpenSSL recovers the key of a signature made outside of this package:
+//
+//	$ openssl ecparam -name prime256v1 -genkey -noout -out key.pem
+//	$ echo -n b493d48364afe44d11c0165cf470a4164d1e2609911ef998be868d46ade3de4e | xxd -r -p > digest.bin
+//	$ openssl pkeyutl -sign -inkey key.pem -in digest.bin | xxd -p
+//	$ openssl ec -in key.pem -pubout -conv_form compressed -outform DER | tail -c 33 | xxd -p
+//
+// the SIG_R1 is the DER r and s with the recovery id that gives back the OpenSSL key
+func TestR1SignatureFromOpenSSL(t *testing.T) {
+	digest, err := hex.DecodeString("b493d48364afe44d11c0165cf470a4164d1e2609911ef998be868d46ade3de4e")
+	require.NoError(t, err)
+	der, err := hex.DecodeString("304402203bee707479023e523821b17cbb492a5cee3fd30bceba75a5b7dc50e3d861c566022036a6bdd044b5d4f4ecaa9dd720201970e82d24fa4a6238e745a79b7a6aba95fe")
+	require.NoError(t, err)
+	opensslKey, err := hex.DecodeString("02ec0e554404caf641dec6e80f6988dcef8060c6811cd689e9207705b92b553840")
+	require.NoError(t, err)
+
+	var rs struct{ R, S *big.Int }
+	_, err = asn1.Unmarshal(der, &rs)
+	require.NoError(t, err)
+
+	signature, err := ecc.NewSignature("SIG_R1_K36YDKrVPFDj82GzXLJdY4W48qNMyUK7CGxx9E5zDy2mWyav8nTLWLu1hNGGx1vxCkT3KB6TfE79qvtbqd9D3UZqM1oX78")
+	require.NoError(t, err)
+	assert.Equal(t, rs.R.Bytes(), signature.Content[1:33])
+	assert.Equal(t, rs.S.Bytes(), signature.Content[33:65])
+
+	pubKey, err := signature.PublicKey(digest)
+	require.NoError(t, err)
+	assert.Equal(t, opensslKey, pubKey.Content[:])
+	assert.Equal(t, "PUB_R1_6gT615Yw7aTBeHJ8JDFAnMe5MNMikFG1CEcXbdKGWRj6WsvJ5D", pubKey.String())
+	assert.True(t, signature.Verify(digest, pubKey))
 }
 
 func TestNewDeterministicPrivateKey(t *testing.T) {
@@ -1,11 +1,13 @@
 package ecc
 
 import (
+	"bytes"
 	cryptorand "crypto/rand"
 	"encoding/json"
 	"fmt"
 	"github.com/eosspark/eos-go/crypto/btcsuite/btcd/btcec"
 	"github.com/eosspark/eos-go/crypto/btcsuite/btcutil"
+	"github.com/eosspark/eos-go/crypto/btcsuite/btcutil/base58"
 	"io"
 	"strings"
 )
@@ -36,6 +38,25 @@ func newRandomPrivateKey(randSource io.Reader) (*PrivateKey, error) {
 	return &PrivateKey{Curve: CurveK1, inner: inner}, nil
 }
 
+// NewRandomR1PrivateKey generates a private key on the secp256r1 (NIST P-256) curve
+func NewRandomR1PrivateKey() (*PrivateKey, error) {
+	return newRandomR1PrivateKey(cryptorand.Reader)
+}
+
+func newRandomR1PrivateKey(randSource io.Reader) (*PrivateKey, error) {
+	for {
+		rawPrivKey := make([]byte, 32)
+		if _, err := io.ReadFull(randSource, rawPrivKey); err != nil {
+			return nil, fmt.Errorf("error feeding crypto-rand numbers to seed ephemeral private key: %s", err)
+		}
+
+		// retry in the unlikely case the random number is not below the order of the curve
+		if inner, err := newR1PrivateKeyFromBytes(rawPrivKey); err == nil {
+			return &PrivateKey{Curve: CurveR1, inner: inner}, nil
+		}
+	}
+}
+
 func NewPrivateKey(wif string) (*PrivateKey, error) {
 	// Strip potential prefix, and set curve
 	var privKeyMaterial string
@@ -56,7 +77,18 @@ func NewPrivateKey(wif string) (*PrivateKey, error) {
 			return &PrivateKey{Curve: CurveK1, inner: inner}, nil
 		case "R1_":
 
-			inner := &innerR1PrivateKey{}
+			decoded := base58.Decode(privKeyMaterial)
+			if len(decoded) != 36 {
+				return nil, fmt.Errorf("invalid R1 private key length")
+			}
+			raw, checksum := decoded[:32], decoded[32:]
+			if !bytes.Equal(Ripemd160checksumHashCurve(raw, CurveR1), checksum) {
+				return nil, fmt.Errorf("checksum mismatch")
+			}
+			inner, err := newR1PrivateKeyFromBytes(raw)
+			if err != nil {
+				return nil, err
+			}
 			return &PrivateKey{Curve: CurveR1, inner: inner}, nil
 
 		default:
@@ -1,29 +1,81 @@
 package ecc
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
 	"fmt"
+	"math/big"
+
+	"github.com/eosspark/eos-go/crypto/btcsuite/btcutil/base58"
 )
 
 type innerR1PrivateKey struct {
+	privKey *ecdsa.PrivateKey
+}
+
+func newR1PrivateKeyFromBytes(rawPrivKey []byte) (*innerR1PrivateKey, error) {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(rawPrivKey)
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid secp256r1 private key")
+	}
+
+	privKey := &ecdsa.PrivateKey{D: d}
+	privKey.PublicKey.Curve = curve
+	privKey.PublicKey.X, privKey.PublicKey.Y = curve.ScalarBaseMult(rawPrivKey)
+	return &innerR1PrivateKey{privKey: privKey}, nil
 }
 
 func (k *innerR1PrivateKey) publicKey() PublicKey {
-	//TODO: Finish R1 support here - for now we  return bogus key
-	//var pubKeyData []byte
-	//pubKeyData = append(pubKeyData, byte(1))
-	//pubKeyData = append(pubKeyData, bytes.Repeat([]byte{0}, 33)...)
-	var content [33]byte
-	content[0] = 1
+	content := compressR1Point(k.privKey.X, k.privKey.Y)
 	return PublicKey{Curve: CurveR1, Content: content, inner: &innerR1PublicKey{}}
 }
 
+// sign produces a compact signature: recovery id | r | s, s is normalized to the lower half of the order
 func (k *innerR1PrivateKey) sign(hash []byte) (out Signature, err error) {
-	return out, fmt.Errorf("R1 not supported")
+	if len(hash) != 32 {
+		return out, fmt.Errorf("hash should be 32 bytes")
+	}
+
+	params := k.privKey.Curve.Params()
+	halfOrder := new(big.Int).Rsh(params.N, 1)
+	pubKey := k.publicKey()
+
+	r, s, err := ecdsa.Sign(cryptorand.Reader, k.privKey, hash)
+	if err != nil {
+		return out, fmt.Errorf("sign: %s", err)
+	}
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(params.N, s)
+	}
+
+	for recId := 0; recId < 4; recId++ {
+		x, y, err := recoverR1Point(r, s, hash, recId)
+		if err != nil {
+			continue
+		}
+		if compressR1Point(x, y) == pubKey.Content {
+			content := make([]byte, 65)
+			content[0] = byte(27 + 4 + recId) // compressed
+			rBytes, sBytes := r.Bytes(), s.Bytes()
+			copy(content[33-len(rBytes):33], rBytes)
+			copy(content[65-len(sBytes):], sBytes)
+			return Signature{Curve: CurveR1, Content: content, innerSignature: &innerR1Signature{}}, nil
+		}
+	}
+	return out, fmt.Errorf("unable to construct recoverable key")
 }
 
 func (k *innerR1PrivateKey) string() string {
-	return "PVT_R1_PLACE_HOLDER"
+	raw := k.Serialize()
+	checksum := Ripemd160checksumHashCurve(raw, CurveR1)
+	return PrivateKeyPrefix + CurveR1.StringPrefix() + base58.Encode(append(raw, checksum...))
 }
+
 func (k *innerR1PrivateKey) Serialize() []byte {
-	return nil
+	raw := make([]byte, 32)
+	d := k.privKey.D.Bytes()
+	copy(raw[32-len(d):], d)
+	return raw
 }
@@ -93,7 +93,15 @@ func NewPublicKey(pubKey string) (out PublicKey, err error) {
 
 	if strings.HasPrefix(pubKey, PublicKeyR1Prefix) {
 		pubKeyMaterial := pubKey[len(PublicKeyR1Prefix):] // strip "PUB_R1_"
-		decodedPubKey = base58.Decode(pubKeyMaterial)
+		curveID = CurveR1
+		decoded := base58.Decode(pubKeyMaterial)
+		if len(decoded) != 37 {
+			return out, fmt.Errorf("invalid format")
+		}
+		decodedPubKey = decoded[:33]
+		if !bytes.Equal(Ripemd160checksumHashCurve(decodedPubKey, curveID), decoded[33:]) {
+			return out, fmt.Errorf("checkDecode: invalid checksum")
+		}
 		inner = &innerR1PublicKey{}
 	} else if strings.HasPrefix(pubKey, PublicKeyK1Prefix) {
 		pubKeyMaterial := pubKey[len(PublicKeyK1Prefix):] // strip "PUB_K1_"
@@ -180,6 +188,9 @@ func (p PublicKey) Key() (*btcec.PublicKey, error) {
 }
 
 func (p PublicKey) String() string {
+	if p.Curve == CurveR1 {
+		return (&innerR1PublicKey{}).string(p.Content[:], p.Curve)
+	}
 
 	hash := ripemd160checksum(p.Content[:], p.Curve)
 
@@ -220,7 +231,7 @@ func (p PublicKey) Valid() bool {
 			return false
 		}
 	case CurveR1:
-		_, err := btcec.ParsePubKey(p.Content[:], btcec.S256R1())
+		_, _, err := decompressR1Point(p.Content[:])
 		if err != nil {
 			return false
 		}
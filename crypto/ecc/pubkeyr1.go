@@ -1,23 +1,73 @@
 package ecc
 
 import (
+	"crypto/elliptic"
 	"fmt"
+	"math/big"
 
 	"github.com/eosspark/eos-go/crypto/btcsuite/btcd/btcec"
+	"github.com/eosspark/eos-go/crypto/btcsuite/btcutil/base58"
 )
 
 type innerR1PublicKey struct {
 }
 
 func (p *innerR1PublicKey) key(content []byte) (*btcec.PublicKey, error) {
-	key, err := btcec.ParsePubKey(content, btcec.S256())
+	x, y, err := decompressR1Point(content)
 	if err != nil {
 		return nil, fmt.Errorf("parsePubKey: %s", err)
 	}
 
-	return key, nil
+	return &btcec.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
 }
 
 func (p *innerR1PublicKey) string(content []byte, curveID CurveID) string {
-	return PublicKeyR1Prefix + "0000000000000000000000000000000000000000000000"
+	checksum := Ripemd160checksumHashCurve(content, CurveR1)
+
+	rawKey := make([]byte, 37)
+	copy(rawKey, content[:33])
+	copy(rawKey[33:], checksum[:4])
+
+	return PublicKeyR1Prefix + base58.Encode(rawKey)
+}
+
+// compressR1Point serializes a point of secp256r1 in the 33 bytes compressed form
+func compressR1Point(x, y *big.Int) (out [33]byte) {
+	out[0] = 2 + byte(y.Bit(0))
+	xBytes := x.Bytes()
+	copy(out[33-len(xBytes):], xBytes)
+	return
+}
+
+// decompressR1Point parses a compressed point of secp256r1, y is recovered from y^2 = x^3 - 3x + b
+func decompressR1Point(data []byte) (x, y *big.Int, err error) {
+	if len(data) != 33 {
+		return nil, nil, fmt.Errorf("compressed point should be 33 bytes, was %d", len(data))
+	}
+	if data[0] != 2 && data[0] != 3 {
+		return nil, nil, fmt.Errorf("invalid compressed point format %d", data[0])
+	}
+
+	params := elliptic.P256().Params()
+	x = new(big.Int).SetBytes(data[1:])
+	if x.Cmp(params.P) >= 0 {
+		return nil, nil, fmt.Errorf("point x is not in the field")
+	}
+
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	y2.Sub(y2, threeX)
+	y2.Add(y2, params.B)
+	y2.Mod(y2, params.P)
+
+	y = new(big.Int).ModSqrt(y2, params.P)
+	if y == nil {
+		return nil, nil, fmt.Errorf("point is not on the curve")
+	}
+	if y.Bit(0) != uint(data[0]&1) {
+		y.Sub(params.P, y)
+	}
+	return x, y, nil
 }
@@ -122,8 +122,18 @@ func NewSignature(fromText string) (Signature, error) {
 	case "R1_":
 
 		fromText = fromText[3:] // strip R1_
-		content := base58.Decode(fromText)
-		//todo: stuff here
+
+		sigbytes := base58.Decode(fromText)
+		if len(sigbytes) != 69 {
+			return Signature{}, fmt.Errorf("invalid signature length")
+		}
+
+		content := sigbytes[:len(sigbytes)-4]
+		checksum := sigbytes[len(sigbytes)-4:]
+		verifyChecksum := Ripemd160checksumHashCurve(content, CurveR1)
+		if !bytes.Equal(verifyChecksum, checksum) {
+			return Signature{}, fmt.Errorf("signature checksum failed, found %x expected %x", verifyChecksum, checksum)
+		}
 
 		return Signature{Curve: CurveR1, Content: content, innerSignature: &innerR1Signature{}}, nil
 
@@ -1,7 +1,9 @@
 package ecc
 
 import (
+	"crypto/elliptic"
 	"fmt"
+	"math/big"
 
 	"github.com/eosspark/eos-go/crypto/btcsuite/btcutil/base58"
 )
@@ -9,14 +11,84 @@ import (
 type innerR1Signature struct {
 }
 
+// verify checks the signature against the pubKey. `hash` is a sha256
+// hash of the payload to verify.
 func (s innerR1Signature) verify(content []byte, hash []byte, pubKey PublicKey) bool {
-	return false
+	recoveredKey, err := s.publicKey(content, hash)
+	if err != nil {
+		return false
+	}
+	return recoveredKey.Compare(pubKey)
 }
 
-func (s *innerR1Signature) publicKey(content []byte, hash []byte) (out PublicKey, err error) {
-	return out, fmt.Errorf("R1 not supported")
+func (s innerR1Signature) publicKey(content []byte, hash []byte) (out PublicKey, err error) {
+	if len(content) != 65 {
+		return out, fmt.Errorf("invalid compact signature size")
+	}
+	recId := int(content[0]) - 27
+	if recId < 0 || recId > 7 {
+		return out, fmt.Errorf("invalid compact signature recovery code")
+	}
+	recId &= 3 // strip the compressed flag
+
+	r := new(big.Int).SetBytes(content[1:33])
+	sig := new(big.Int).SetBytes(content[33:65])
+	x, y, err := recoverR1Point(r, sig, hash, recId)
+	if err != nil {
+		return out, err
+	}
+
+	return PublicKey{
+		Curve:   CurveR1,
+		Content: compressR1Point(x, y),
+		inner:   &innerR1PublicKey{},
+	}, nil
 }
 
 func (s innerR1Signature) string(content []byte) string {
-	return "SIG_R1_" + base58.Encode(content)
+	checksum := Ripemd160checksumHashCurve(content, CurveR1)
+	buf := append(content[:], checksum...)
+	return "SIG_R1_" + base58.Encode(buf)
+}
+
+// recoverR1Point recovers the public key from a signature (r, s) of hash, see SEC 1 v2 section 4.1.6
+func recoverR1Point(r, s *big.Int, hash []byte, recId int) (x, y *big.Int, err error) {
+	curve := elliptic.P256()
+	params := curve.Params()
+	if r.Sign() == 0 || r.Cmp(params.N) >= 0 || s.Sign() == 0 || s.Cmp(params.N) >= 0 {
+		return nil, nil, fmt.Errorf("signature values out of range")
+	}
+
+	// 1.1 x = r + jn
+	rx := new(big.Int).Mul(params.N, big.NewInt(int64(recId/2)))
+	rx.Add(rx, r)
+	if rx.Cmp(params.P) >= 0 {
+		return nil, nil, fmt.Errorf("calculated Rx is larger than curve P")
+	}
+
+	// 1.3 R is the point with x and the parity of recId
+	var compressed [33]byte
+	compressed[0] = 2 + byte(recId&1)
+	rxBytes := rx.Bytes()
+	copy(compressed[33-len(rxBytes):], rxBytes)
+	Rx, Ry, err := decompressR1Point(compressed[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 1.5 e from the hash, 1.6 Q = r^-1 (sR - eG)
+	e := new(big.Int).SetBytes(hash)
+	e.Mod(e, params.N)
+	minusE := new(big.Int).Sub(params.N, e)
+	minusE.Mod(minusE, params.N)
+	invR := new(big.Int).ModInverse(r, params.N)
+
+	sRx, sRy := curve.ScalarMult(Rx, Ry, s.Bytes())
+	eGx, eGy := curve.ScalarBaseMult(minusE.Bytes())
+	qx, qy := curve.Add(sRx, sRy, eGx, eGy)
+	qx, qy = curve.ScalarMult(qx, qy, invR.Bytes())
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, nil, fmt.Errorf("recovered point is at infinity")
+	}
+	return qx, qy, nil
 }
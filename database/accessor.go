@@ -0,0 +1,188 @@
+package database
+
+import (
+	"encoding/binary"
+	"reflect"
+
+	"github.com/eosspark/eos-go/exception"
+	"github.com/eosspark/eos-go/exception/try"
+)
+
+/*
+*	ObjectAccessor gives the database the type name, id, value and index keys of an object.
+*	dbgen generates it for the entities from their multiIndex tags so that the database does not have to walk the
+*	tags with reflect on every call, objects without generated code get an accessor built with reflect.
+*	DbIndexKey returns the field values of an index (__value0__value1...) without the last skip ones,
+*	DbEncode returns the same bytes as EncodeToBytes
+ */
+
+type ObjectAccessor interface {
+	DbTypeName() string
+
+	DbId() int64
+
+	DbEncode() ([]byte, error)
+
+	DbIndexes() []string
+
+	DbIndexKey(tag string, skip int) ([]byte, error)
+}
+
+/*
+*	MutableObjectAccessor is the accessor of an object the database can give an id to
+ */
+
+type MutableObjectAccessor interface {
+	ObjectAccessor
+
+	DbSetId(id int64)
+}
+
+/*
+*	ReflectAccessor builds the accessor of an object from its multiIndex tags with reflect,
+*	it is what the database uses for objects without generated code
+ */
+
+func ReflectAccessor(in interface{}) (MutableObjectAccessor, error) {
+	ref := reflect.ValueOf(in)
+	if !ref.IsValid() || reflect.Indirect(ref).Kind() != reflect.Struct {
+		return nil, ErrBadType
+	}
+	cfg, err := extractObjectTagInfo(&ref)
+	if err != nil {
+		return nil, err
+	}
+	return &reflectAccessor{in: in, cfg: cfg}, nil
+}
+
+func objectAccessor(in interface{}) (ObjectAccessor, error) {
+	if obj, ok := in.(ObjectAccessor); ok {
+		return obj, nil
+	}
+	return ReflectAccessor(in)
+}
+
+func mutableObjectAccessor(in interface{}) (MutableObjectAccessor, error) {
+	if obj, ok := in.(MutableObjectAccessor); ok {
+		return obj, nil
+	}
+	cfg, err := parseObjectToCfg(in)
+	if err != nil {
+		return nil, err
+	}
+	return &reflectAccessor{in: in, cfg: cfg}, nil
+}
+
+func hasIndex(obj ObjectAccessor, tag string) bool {
+	for _, index := range obj.DbIndexes() {
+		if index == tag {
+			return true
+		}
+	}
+	return false
+}
+
+type reflectAccessor struct {
+	in  interface{}
+	cfg *structInfo
+}
+
+func (r *reflectAccessor) DbTypeName() string {
+	return r.cfg.Name
+}
+
+func (r *reflectAccessor) DbId() int64 {
+	if r.cfg.rId == nil {
+		return 0
+	}
+	objId, err := EncodeToBytes(r.cfg.rId.Interface())
+	if err != nil {
+		return 0
+	}
+	id := int64(0)
+	DecodeBytes(objId, &id)
+	return id
+}
+
+func (r *reflectAccessor) DbSetId(id int64) {
+	r.cfg.rId.Set(reflect.ValueOf(id).Convert(r.cfg.rId.Type()))
+}
+
+func (r *reflectAccessor) DbEncode() ([]byte, error) {
+	return EncodeToBytes(r.in)
+}
+
+func (r *reflectAccessor) DbIndexes() []string {
+	tags := make([]string, 0, len(r.cfg.Fields))
+	for tag := range r.cfg.Fields {
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func (r *reflectAccessor) DbIndexKey(tag string, skip int) ([]byte, error) {
+	fields, ok := r.cfg.Fields[tag]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return fieldValueToByte(fields, SkipSuffix(skip))
+}
+
+/*
+*	The encoding of the basic types, used by the generated accessors to build the same bytes as EncodeToBytes
+ */
+
+func AppendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 1)
+	}
+	return append(b, 0)
+}
+
+func AppendUint8(b []byte, v uint8) []byte {
+	return append(b, v)
+}
+
+func AppendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func AppendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func AppendUint64(b []byte, v uint64) []byte {
+	return append(b, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func AppendUvarint(b []byte, v int) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	l := binary.PutUvarint(buf[:], uint64(v))
+	return append(b, buf[:l]...)
+}
+
+func AppendString(b []byte, s string) []byte {
+	try.EosAssert(len(s) <= MAX_SIZE_OF_BYTE_ARRAYS, &exception.AssertException{}, "rlp encode ByteArray")
+	return append(AppendUvarint(b, len(s)), s...)
+}
+
+func AppendSliceLen(b []byte, l int) []byte {
+	try.EosAssert(l <= MAX_NUM_ARRAY_ELEMENT, &exception.AssertException{}, "the length of slice is too big")
+	return AppendUvarint(b, l)
+}
+
+func AppendBytes(b []byte, v []byte) []byte {
+	return append(AppendSliceLen(b, len(v)), v...)
+}
+
+/*
+*	AppendValue encodes the values the generated accessors have no code for with EncodeToBytes
+ */
+
+func AppendValue(b []byte, v interface{}) ([]byte, error) {
+	value, err := EncodeToBytes(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, value...), nil
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+/*
+*	The integrity checks read the keys the database wrote, they do not stop at the first problem but return one
+*	error for each problem found so that a broken database can be looked at as a whole
+ */
+
+/*
+*	CheckIndexes walks the keys of the type of in: every row has the entries of all its indexes,
+*	every index entry points to a row that is in the index and every id is below the next id of the type
+ */
+
+func (ldb *LDataBase) CheckIndexes(in interface{}) []error {
+	obj, err := objectAccessor(in)
+	if err != nil {
+		return []error{err}
+	}
+	typeName := obj.DbTypeName()
+	prefix := splicingString([]byte(typeName), nil)
+	tags := obj.DbIndexes()
+	rowType := reflect.Indirect(reflect.ValueOf(in)).Type()
+
+	problems := []error{}
+	it := ldb.db.NewIterator(util.BytesPrefix(prefix))
+	defer it.Release()
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if tag := keyTag(key[len(prefix):], tags); tag != "" {
+			if err := ldb.checkIndexEntry(rowType, typeName, tag, key, value); err != nil {
+				problems = append(problems, err)
+			}
+		} else if len(key) == len(prefix)+8 {
+			problems = append(problems, ldb.checkRow(rowType, typeName, key, value)...)
+		} else {
+			problems = append(problems, fmt.Errorf("%s: unknown key %x", typeName, key))
+		}
+	}
+	return problems
+}
+
+/* keyTag returns the index of an index entry key without its typeName__, "" for a row key */
+func keyTag(key []byte, tags []string) string {
+	for _, tag := range tags {
+		if len(key) > len(tag)+1 && string(key[:len(tag)]) == tag && key[len(tag)] == '_' && key[len(tag)+1] == '_' {
+			return tag
+		}
+	}
+	return ""
+}
+
+func decodeRow(rowType reflect.Type, value []byte) (ObjectAccessor, error) {
+	row := reflect.New(rowType).Interface()
+	if err := DecodeBytes(value, row); err != nil {
+		return nil, err
+	}
+	return objectAccessor(row)
+}
+
+func (ldb *LDataBase) checkRow(rowType reflect.Type, typeName string, key, value []byte) []error {
+	id := int64(binary.BigEndian.Uint64(key[len(key)-8:]))
+	obj, err := decodeRow(rowType, value)
+	if err != nil {
+		return []error{fmt.Errorf("%s: row %d cannot be decoded: %s", typeName, id, err)}
+	}
+	if obj.DbId() != id {
+		return []error{fmt.Errorf("%s: row %d has the id %d", typeName, id, obj.DbId())}
+	}
+
+	problems := []error{}
+	if id >= ldb.nextId[typeName] {
+		problems = append(problems, fmt.Errorf("%s: row %d is not below the next id %d", typeName, id, ldb.nextId[typeName]))
+	}
+	dbKV := &dbKeyValue{}
+	if err := objectKV(obj, dbKV); err != nil {
+		return append(problems, fmt.Errorf("%s: row %d has no index keys: %s", typeName, id, err))
+	}
+	for _, entry := range dbKV.Index {
+		rowId, err := ldb.db.Get(entry.Key)
+		if err == leveldb.ErrNotFound {
+			problems = append(problems, fmt.Errorf("%s: row %d has no entry %x", typeName, id, entry.Key))
+		} else if err != nil {
+			problems = append(problems, err)
+		} else if !bytes.Equal(rowId, entry.Value) {
+			problems = append(problems, fmt.Errorf("%s: the entry %x of row %d points to row %x", typeName, entry.Key, id, rowId))
+		}
+	}
+	return problems
+}
+
+func (ldb *LDataBase) checkIndexEntry(rowType reflect.Type, typeName, tag string, key, rowId []byte) error {
+	if len(rowId) != 8 {
+		return fmt.Errorf("%s: the entry %x of %s points to row %x", typeName, key, tag, rowId)
+	}
+	id := binary.BigEndian.Uint64(rowId)
+	value, err := ldb.db.Get(splicingString([]byte(typeName), rowId))
+	if err == leveldb.ErrNotFound {
+		return fmt.Errorf("%s: the entry %x of %s points to the missing row %d", typeName, key, tag, id)
+	} else if err != nil {
+		return err
+	}
+	obj, err := decodeRow(rowType, value)
+	if err != nil {
+		return fmt.Errorf("%s: row %d cannot be decoded: %s", typeName, id, err)
+	}
+	suffix, err := obj.DbIndexKey(tag, 0)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(indexEntryKey([]byte(typeName), tag, suffix), key) {
+		return fmt.Errorf("%s: the entry %x of %s points to row %d which is not in it", typeName, key, tag, id)
+	}
+	return nil
+}
+
+/*
+*	CheckUndo verifies that the undo sessions follow each other up to the revision, that they inserted rows from
+*	the next id they started with, and that the rows they last changed are in the database as they left them
+ */
+
+func (ldb *LDataBase) CheckUndo() []error {
+	problems := []error{}
+	items := ldb.stack.Items()
+	sessions := make([]*undoContainer, 0, len(items))
+	for _, item := range items {
+		session, ok := item.(*undoContainer)
+		if !ok {
+			problems = append(problems, fmt.Errorf("undo stack holds a %T", item))
+			continue
+		}
+		if len(sessions) > 0 && session.Reversion != sessions[len(sessions)-1].Reversion+1 {
+			problems = append(problems, fmt.Errorf("undo session %d follows session %d", session.Reversion, sessions[len(sessions)-1].Reversion))
+		}
+		sessions = append(sessions, session)
+	}
+	if len(sessions) > 0 && sessions[len(sessions)-1].Reversion != ldb.reversion {
+		problems = append(problems, fmt.Errorf("the newest undo session is %d but the revision is %d", sessions[len(sessions)-1].Reversion, ldb.reversion))
+	}
+
+	checked := make(map[string]bool) /* only the newest session that changed a row knows how it must be */
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		typeNames := make([]string, 0, len(session.Undo))
+		for typeName := range session.Undo {
+			typeNames = append(typeNames, typeName)
+		}
+		sort.Strings(typeNames)
+
+		for _, typeName := range typeNames {
+			state := session.Undo[typeName]
+			for id := range state.NewValue {
+				if id < session.OldIds[typeName] {
+					problems = append(problems, fmt.Errorf("undo session %d inserted row %d of %s below the next id %d it started with",
+						session.Reversion, id, typeName, session.OldIds[typeName]))
+				}
+			}
+			for _, changed := range []struct {
+				values  map[int64]*modifyValue
+				present bool
+			}{{state.NewValue, true}, {state.OldValue, true}, {state.RemoveValue, false}} {
+				for id, value := range changed.values {
+					if value.NewKv == nil || len(value.NewKv.Idk.Key) == 0 {
+						problems = append(problems, fmt.Errorf("undo session %d has no keys for row %d of %s", session.Reversion, id, typeName))
+						continue
+					}
+					if checked[string(value.NewKv.Idk.Key)] {
+						continue
+					}
+					checked[string(value.NewKv.Idk.Key)] = true
+					if err := ldb.checkUndoRow(session.Reversion, typeName, id, value.NewKv, changed.present); err != nil {
+						problems = append(problems, err)
+					}
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func (ldb *LDataBase) checkUndoRow(reversion int64, typeName string, id int64, dbKV *dbKeyValue, present bool) error {
+	value, err := ldb.db.Get(dbKV.Idk.Key)
+	switch {
+	case err != nil && err != leveldb.ErrNotFound:
+		return err
+	case present && err == leveldb.ErrNotFound:
+		return fmt.Errorf("row %d of %s changed by undo session %d is missing", id, typeName, reversion)
+	case present && !bytes.Equal(value, dbKV.Idk.Value):
+		return fmt.Errorf("row %d of %s is not as undo session %d left it", id, typeName, reversion)
+	case !present && err == nil:
+		return fmt.Errorf("row %d of %s removed by undo session %d is in the database", id, typeName, reversion)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func openCheckDataBase(t *testing.T) (*LDataBase, string) {
+	dir, err := ioutil.TempDir("", "check_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenDataBase(LevelDBBackend, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db.(*LDataBase), dir
+}
+
+func checkNoProblems(t *testing.T, step string, problems []error) {
+	for _, problem := range problems {
+		t.Errorf("%s: %s", step, problem)
+	}
+	if len(problems) > 0 {
+		t.FailNow()
+	}
+}
+
+func TestUndoRestoresIndexes(t *testing.T) {
+	db, dir := openCheckDataBase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	first := DbHouse{Area: 1, Name: "first", Carnivore: Carnivore{1, 1}}
+	second := DbHouse{Area: 2, Name: "second", Carnivore: Carnivore{2, 2}}
+	db.Insert(&first)
+	db.Insert(&second)
+	size := db.Size()
+
+	session := db.StartSession()
+	db.Modify(&first, func(house *DbHouse) { house.Name = "renamed" })
+	db.Modify(&first, func(house *DbHouse) { house.Name = "renamed again" })
+	db.Modify(&second, func(house *DbHouse) { house.Name = "removed" })
+	db.Remove(&second)
+	inserted := DbHouse{Area: 3, Name: "inserted", Carnivore: Carnivore{3, 3}}
+	db.Insert(&inserted)
+	db.Modify(&inserted, func(house *DbHouse) { house.Name = "modified" })
+	checkNoProblems(t, "session", append(db.CheckIndexes(&DbHouse{}), db.CheckUndo()...))
+
+	nested := db.StartSession()
+	db.Modify(&first, func(house *DbHouse) { house.Name = "nested" })
+	db.Modify(&inserted, func(house *DbHouse) { house.Name = "nested modified" })
+	nested.Squash()
+	checkNoProblems(t, "squash", append(db.CheckIndexes(&DbHouse{}), db.CheckUndo()...))
+
+	session.Undo()
+	checkNoProblems(t, "undo", db.CheckIndexes(&DbHouse{}))
+	checkSize(t, "undo", db)
+	if db.Size() != size {
+		t.Fatalf("size %d after undo, want %d", db.Size(), size)
+	}
+	for _, want := range []DbHouse{first, second} {
+		found := DbHouse{}
+		if err := db.Find("Area", want, &found); err != nil || found.Name != map[uint64]string{1: "first", 2: "second"}[want.Area] {
+			t.Fatalf("house %d is %v after undo: %v", want.Area, found, err)
+		}
+	}
+
+	again := DbHouse{Area: 3, Name: "again", Carnivore: Carnivore{3, 3}}
+	db.Insert(&again)
+	if again.Id != inserted.Id {
+		t.Fatalf("the id %d given after undo is not the undone id %d", again.Id, inserted.Id)
+	}
+}
+
+/*
+The ids given after an undo are part of the state the nodes agree on. Before the undo sessions kept their own copy of
+the next ids, an undone insert still used up its id: the next insert got id 3 instead of 2 after the undone ones.
+*/
+func TestUndoIdAssignment(t *testing.T) {
+	cases := []struct {
+		name    string
+		session func(db *LDataBase)
+		want    uint64
+	}{
+		{"no session", func(db *LDataBase) {}, 2},
+		{"undone insert", func(db *LDataBase) {
+			session := db.StartSession()
+			db.Insert(&DbHouse{Area: 10, Name: "undone", Carnivore: Carnivore{10, 10}})
+			session.Undo()
+		}, 2},
+		{"undone nested squash", func(db *LDataBase) {
+			session := db.StartSession()
+			nested := db.StartSession()
+			db.Insert(&DbHouse{Area: 10, Name: "undone", Carnivore: Carnivore{10, 10}})
+			nested.Squash()
+			session.Undo()
+		}, 2},
+		{"kept insert", func(db *LDataBase) {
+			db.StartSession()
+			db.Insert(&DbHouse{Area: 10, Name: "kept", Carnivore: Carnivore{10, 10}})
+		}, 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, dir := openCheckDataBase(t)
+			defer os.RemoveAll(dir)
+			defer db.Close()
+
+			db.Insert(&DbHouse{Area: 1, Name: "first", Carnivore: Carnivore{1, 1}})
+			db.Insert(&DbHouse{Area: 2, Name: "second", Carnivore: Carnivore{2, 2}})
+			c.session(db)
+
+			next := DbHouse{Area: 3, Name: "next", Carnivore: Carnivore{3, 3}}
+			db.Insert(&next)
+			if next.Id != c.want {
+				t.Fatalf("id %d, want %d", next.Id, c.want)
+			}
+			checkNoProblems(t, "insert", db.CheckIndexes(&DbHouse{}))
+		})
+	}
+}
+
+func TestCheckIndexesFindsBrokenKeys(t *testing.T) {
+	db, dir := openCheckDataBase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	objs, _ := Objects()
+	for i := range objs {
+		db.Insert(&objs[i])
+	}
+	checkNoProblems(t, "insert", db.CheckIndexes(&DbTableIdObject{}))
+
+	dbKV := &dbKeyValue{}
+	obj, _ := objectAccessor(&objs[0])
+	objectKV(obj, dbKV)
+	db.db.Delete(dbKV.Index[0].Key)
+	if problems := db.CheckIndexes(&DbTableIdObject{}); len(problems) != 1 {
+		t.Fatalf("deleted entry: %v", problems)
+	}
+	db.db.Put(dbKV.Index[0].Key, dbKV.Index[0].Value)
+
+	db.db.Put(dbKV.Index[0].Key, AppendUint64(nil, uint64(objs[1].ID)))
+	if problems := db.CheckIndexes(&DbTableIdObject{}); len(problems) != 2 { /* the entry and the row it was taken from */
+		t.Fatalf("entry pointing to another row: %v", problems)
+	}
+	db.db.Put(dbKV.Index[0].Key, dbKV.Index[0].Value)
+
+	db.nextId["DbTableIdObject"] = int64(objs[len(objs)-1].ID)
+	if problems := db.CheckIndexes(&DbTableIdObject{}); len(problems) != 1 {
+		t.Fatalf("id above the next id: %v", problems)
+	}
+}
+
+func TestCheckUndoAfterReopen(t *testing.T) {
+	db, dir := openCheckDataBase(t)
+	defer os.RemoveAll(dir)
+
+	house := DbHouse{Area: 1, Name: "before", Carnivore: Carnivore{1, 1}}
+	db.Insert(&house)
+	db.SetRevision(5)
+	db.StartSession()
+	db.Modify(&house, func(house *DbHouse) { house.Name = "after" })
+	db.Close()
+
+	reopened, err := OpenDataBase(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ldb := reopened.(*LDataBase)
+	defer ldb.Close()
+	checkNoProblems(t, "reopen", ldb.CheckUndo())
+
+	ldb.reversion++
+	if problems := ldb.CheckUndo(); len(problems) != 1 {
+		t.Fatalf("revision ahead of the undo sessions: %v", problems)
+	}
+	ldb.reversion--
+
+	ldb.Undo()
+	found := DbHouse{}
+	if err := ldb.Find("Area", house, &found); err != nil || found.Name != "before" {
+		t.Fatalf("house is %v after undo: %v", found, err)
+	}
+}
+
+func TestUndoStackFormat(t *testing.T) {
+	db, dir := openCheckDataBase(t)
+	defer os.RemoveAll(dir)
+
+	house := DbHouse{Area: 1, Name: "first", Carnivore: Carnivore{1, 1}}
+	db.Insert(&house)
+	db.StartSession()
+	db.Modify(&house, func(house *DbHouse) { house.Name = "second" })
+	db.StartSession()
+	db.Modify(&house, func(house *DbHouse) { house.Name = "third" })
+	db.Close()
+
+	reopened, err := OpenDataBase(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ldb := reopened.(*LDataBase)
+	for _, name := range []string{"second", "first"} { /* the sessions are undone from the newest */
+		ldb.Undo()
+		found := DbHouse{}
+		if err := ldb.Find("Area", house, &found); err != nil || found.Name != name {
+			t.Fatalf("house is %v after undo, want %s: %v", found, name, err)
+		}
+	}
+	ldb.Close()
+
+	kv, err := OpenKVStore(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kv.Get([]byte(undoKey)); err != leveldb.ErrNotFound {
+		t.Fatalf("an empty undo stack is left in the database: %v", err)
+	}
+
+	/* a stack written before the version key is refused, and nothing is removed from the database */
+	stack, _ := EncodeToBytes([][]byte{})
+	kv.Put([]byte(undoKey), stack)
+	increment, _ := kv.Get([]byte(dbIncrement))
+	kv.Close()
+	if _, err := OpenDataBase(LevelDBBackend, dir); !errors.Is(err, ErrUndoStackVersion) {
+		t.Fatalf("undo stack without a version opened: %v", err)
+	}
+	kv, err = OpenKVStore(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, err := kv.Get([]byte(dbIncrement)); err != nil || !bytes.Equal(val, increment) {
+		t.Fatalf("next ids changed by a refused open: %v", err)
+	}
+
+	/* a stack that does not decode is an error */
+	version, _ := EncodeToBytes(undoStackVersion)
+	kv.Put([]byte(undoVersionKey), version)
+	kv.Put([]byte(undoKey), []byte{0xff})
+	kv.Close()
+	if _, err := OpenDataBase(LevelDBBackend, dir); err == nil {
+		t.Fatal("undo stack that does not decode opened")
+	}
+}
+
+func TestReadOnlyOpen(t *testing.T) {
+	db, dir := openCheckDataBase(t)
+	defer os.RemoveAll(dir)
+
+	house := DbHouse{Area: 1, Name: "house", Carnivore: Carnivore{1, 1}}
+	db.Insert(&house)
+	db.SetRevision(3)
+	db.StartSession()
+	db.Modify(&house, func(house *DbHouse) { house.Name = "modified" })
+	db.Close()
+	files := dirContents(t, dir)
+
+	readOnly, err := OpenReadOnlyDataBase(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNoProblems(t, "read only", readOnly.CheckIndexes(&DbHouse{}))
+	checkNoProblems(t, "read only", readOnly.CheckUndo())
+	found := DbHouse{}
+	if err := readOnly.Find("Area", house, &found); err != nil || found.Name != "modified" {
+		t.Fatalf("house is %v: %v", found, err)
+	}
+	if err := readOnly.Insert(&DbHouse{Area: 2, Name: "other", Carnivore: Carnivore{2, 2}}); err == nil {
+		t.Fatal("insert into a read only database")
+	}
+	readOnly.Close()
+
+	after := dirContents(t, dir)
+	for name, content := range files {
+		if !bytes.Equal(after[name], content) {
+			t.Errorf("%s changed by a read only open", name)
+		}
+	}
+	if len(after) != len(files) {
+		t.Errorf("%d files after a read only open, %d before", len(after), len(files))
+	}
+
+	if _, err := OpenReadOnlyDataBase(LevelDBBackend, dir+"/missing"); err == nil {
+		t.Error("read only open created a database")
+	}
+}
+
+func dirContents(t *testing.T, dir string) map[string][]byte {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, info := range infos {
+		content, err := ioutil.ReadFile(dir + "/" + info.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[info.Name()] = content
+	}
+	return files
+}
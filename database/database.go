@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"math"
 	"reflect"
 
@@ -8,8 +9,6 @@ import (
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/filter"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -21,7 +20,7 @@ const (
 )
 
 type LDataBase struct {
-	db        *leveldb.DB
+	db        KVStore
 	stack     *deque
 	path      string
 	reversion int64
@@ -31,6 +30,10 @@ type LDataBase struct {
 	batch     *leveldb.Batch
 	count     int64
 	isClosed	bool
+	sizes     map[string]int64
+	size      int64
+	maxSize   uint64
+	readOnly  bool
 }
 
 /*
@@ -40,30 +43,54 @@ type LDataBase struct {
  */
 
 func NewDataBase(path string, flag ...bool) (DataBase, error) {
+	return OpenDataBase(LevelDBBackend, path, flag...)
+}
+
+/*
+*	Create a database on the key value engine of backend (LevelDBBackend or MemoryBackend)
+ */
+
+func OpenDataBase(backend, path string, flag ...bool) (DataBase, error) {
+	return openDataBase(backend, path, false, flag...)
+}
 
-	db, err := leveldb.OpenFile(path, &opt.Options{
-		OpenFilesCacheCapacity: 16,
-		BlockCacheCapacity:     16 / 2 * opt.MiB,
-		WriteBuffer:            16 / 4 * opt.MiB, // Two of these are used internally
-		Filter:                 filter.NewBloomFilter(10),
-	})
+/*
+*	Open a database to inspect it, nothing is written to path on open or on Close and every change fails
+ */
+
+func OpenReadOnlyDataBase(backend, path string) (DataBase, error) {
+	return openDataBase(backend, path, true)
+}
 
-	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
-		db, err = leveldb.RecoverFile(path, nil)
+func openDataBase(backend, path string, readOnly bool, flag ...bool) (DataBase, error) {
+	db, err := openKVStore(backend, path, readOnly)
+	if err != nil {
+		return nil, err
 	}
+	/* read stack, before anything is removed from a database it refuses */
+	stack, err := readUndoStackFromDb(db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 	/*	read every type increment	*/
-	nextId, err := readIncrementFromDb(db)
+	nextId, err := readIncrementFromDb(db, readOnly)
 	if err != nil {
 		log.Error("database init failed : %s", err.Error())
 		panic("open database file failed : " + err.Error())
 	}
 	/* read reversion */
 	reversion := readReversionFromDb(db)
-	/* read stack */
-	stack:=readUndoStackFromDb(db)
+	/* read the size of every type */
+	sizes, err := readSizeFromDb(db, readOnly)
+	if err != nil {
+		log.Error("database init failed : %s", err.Error())
+		panic("open database file failed : " + err.Error())
+	}
+	size := int64(0)
+	for _, typeSize := range sizes {
+		size += typeSize
+	}
 	logFlag := false
 	if len(flag) > 0 {
 		logFlag = flag[0]
@@ -75,45 +102,47 @@ func NewDataBase(path string, flag ...bool) (DataBase, error) {
 		dbLog.SetHandler(h)
 	} else {
 		dbLog.SetHandler(log.DiscardHandler())
+		dbLog.SetEnable(false) /* do not format the messages nobody reads */
 	}
-	return &LDataBase{db: db, stack:stack , path: path, nextId: nextId, logFlag: logFlag, log: dbLog, batch: new(leveldb.Batch),reversion:reversion}, nil
+	return &LDataBase{db: db, stack:stack , path: path, nextId: nextId, logFlag: logFlag, log: dbLog, batch: new(leveldb.Batch),reversion:reversion, sizes: sizes, size: size, readOnly: readOnly}, nil
 }
-func readUndoStackFromDb(db*leveldb.DB)(*deque){
-	key := []byte(undoKey)
-	val, err := db.Get(key, nil)
-	if err != nil && err != leveldb.ErrNotFound {
-		//throw
-
-	}
+func readUndoStackFromDb(db KVStore) (*deque, error) {
 	dq := newDeque()
-	if err == leveldb.ErrNotFound{
-		return dq
+	val, err := db.Get([]byte(undoKey))
+	if err == leveldb.ErrNotFound {
+		return dq, nil
 	}
-	values := [][]byte{}
-	err = DecodeBytes(val, &values)
-	if err != nil{
-		// throw
+	if err != nil {
+		return nil, err
 	}
-	cons := []undoContainer{}
-
-	for index,_ := range values{
-		con := undoContainer{}
-		err = DecodeBytes(values[index],&con)
-		if err != nil{
-			//throw
+	version := uint32(0) /* the stacks written before the version key have no keys in their rows */
+	if v, err := db.Get([]byte(undoVersionKey)); err == nil {
+		if err = DecodeBytes(v, &version); err != nil {
+			return nil, err
 		}
-		cons = append(cons, con)
+	} else if err != leveldb.ErrNotFound {
+		return nil, err
 	}
-
-	for index,_:= range cons{
-		dq.Append(&cons[index])
+	if version != undoStackVersion {
+		return nil, fmt.Errorf("%w: found version %d, expected version %d", ErrUndoStackVersion, version, undoStackVersion)
 	}
 
-	return  dq
+	values := [][]byte{}
+	if err = DecodeBytes(val, &values); err != nil {
+		return nil, err
+	}
+	for index := range values { /* from the oldest session to the newest */
+		con := &undoContainer{}
+		if err = DecodeBytes(values[index], con); err != nil {
+			return nil, err
+		}
+		dq.Append(con)
+	}
+	return dq, nil
 }
-func readReversionFromDb(db *leveldb.DB) (int64 ) {
+func readReversionFromDb(db KVStore) (int64 ) {
 	key := []byte(dbReversion)
-	val, err := db.Get(key, nil)
+	val, err := db.Get(key)
 	if err != nil && err != leveldb.ErrNotFound {
 		//throw
 
@@ -128,11 +157,11 @@ func readReversionFromDb(db *leveldb.DB) (int64 ) {
 	}
 	return reversion
 }
-func readIncrementFromDb(db *leveldb.DB) (map[string]int64, error) {
+func readIncrementFromDb(db KVStore, readOnly bool) (map[string]int64, error) {
 	nextId := make(map[string]int64)
 
 	key := []byte(dbIncrement)
-	val, err := db.Get(key, nil)
+	val, err := db.Get(key)
 	if err != nil && err != leveldb.ErrNotFound {
 		return nil, err
 	}
@@ -142,7 +171,10 @@ func readIncrementFromDb(db *leveldb.DB) (map[string]int64, error) {
 			panic("database init failed : " + err.Error())
 		}
 	}
-	err = db.Delete(key, nil)
+	if readOnly {
+		return nextId, nil
+	}
+	err = db.Delete(key)
 	if err != nil {
 		panic("database init failed : " + err.Error())
 	}
@@ -153,11 +185,17 @@ func (ldb *LDataBase) Close() {
 	if ldb.isClosed{
 		return
 	}
-	err := ldb.writeIncrementToDb()
-	if err != nil {
-		ldb.log.Error("database close failed : %s", err.Error())
+	if !ldb.readOnly {
+		err := ldb.writeIncrementToDb()
+		if err != nil {
+			ldb.log.Error("database close failed : %s", err.Error())
+		}
+		err = ldb.writeSizeToDb()
+		if err != nil {
+			ldb.log.Error("database close failed : %s", err.Error())
+		}
 	}
-	err = ldb.db.Close()
+	err := ldb.db.Close()
 	if err != nil {
 		/* throw ? */
 		ldb.log.Error("database close failed : %s", err.Error())
@@ -178,7 +216,7 @@ func (ldb *LDataBase) writeIncrementToDb() error {
 		ldb.log.Error("WriteIncrement rlp EncodeToBytes failed is : %s", err.Error())
 		return err
 	}
-	err = ldb.db.Put([]byte(dbIncrement), val, nil)
+	err = ldb.db.Put([]byte(dbIncrement), val)
 	if err != nil {
 		ldb.log.Error("WriteIncrement saveKey failed is : %s", err.Error())
 		return err
@@ -188,43 +226,48 @@ func (ldb *LDataBase) writeIncrementToDb() error {
 	if err != nil{
 		return err
 	}
-	err = ldb.db.Put([]byte(dbReversion),val,nil)
+	err = ldb.db.Put([]byte(dbReversion),val)
 	if err != nil{
 		return err
 	}
 	// write undo stack
-	ldb.writeUndoStack()
-	return nil
+	return ldb.writeUndoStack()
 }
 
 
-func (ldb *LDataBase) undoStackToByte() [] byte{
-		u := ldb.getStack()
-		uv := undoContainer{}
-		uv = *u
-		val, err := EncodeToBytes(uv)
-		if err != nil{
-			return nil
+func (ldb *LDataBase) writeUndoStack() error {
+	if ldb.stack.Size() == 0 { /* do not leave a committed stack behind to be read again */
+		if err := ldb.db.Delete([]byte(undoKey)); err != nil {
+			return err
 		}
-		return val
-}
-func (ldb*LDataBase)writeUndoStack(){
-	values := [][]byte{}
-	for ldb.stack.Size() > 0{
-		val := ldb.undoStackToByte()
-		values = append(values,val)
-		ldb.stack.Pop()
+		return ldb.db.Delete([]byte(undoVersionKey))
 	}
-	if len(values)	!= 0{
-		val ,err:= EncodeToBytes(values)
-		if err != nil{
-			// throw
-		}
-		err = ldb.db.Put([]byte(undoKey),val,nil)
-		if err != nil{
-			//throw
+	values := [][]byte{}
+	for _, item := range ldb.stack.Items() {
+		val, err := EncodeToBytes(item.(*undoContainer))
+		if err != nil {
+			return err
 		}
+		values = append(values, val)
 	}
+	val, err := EncodeToBytes(values)
+	if err != nil {
+		return err
+	}
+	version, err := EncodeToBytes(undoStackVersion)
+	if err != nil {
+		return err
+	}
+	if err = ldb.db.Put([]byte(undoVersionKey), version); err != nil {
+		return err
+	}
+	if err = ldb.db.Put([]byte(undoKey), val); err != nil {
+		return err
+	}
+	for ldb.stack.Size() > 0 {
+		ldb.stack.Pop()
+	}
+	return nil
 }
 func (ldb *LDataBase) Revision() int64 {
 	ldb.log.Info("ldb reversion is : %d", ldb.reversion)
@@ -355,10 +398,10 @@ func (ldb *LDataBase) Insert(in interface{}) error {
 	return nil
 }
 
-func (ldb *LDataBase) insert(in interface{}, flag ...bool) error { /* struct cfg --> KV struct --> kv to db --> undo db */
-	cfg, err := parseObjectToCfg(in) /* (struct cfg) parse object tag */
+func (ldb *LDataBase) insert(in interface{}, flag ...bool) error { /* object accessor --> KV struct --> kv to db --> undo db */
+	obj, err := mutableObjectAccessor(in) /* (object accessor) generated or parsed from the object tag */
 	if err != nil {
-		ldb.log.Error("error database insert  parseObjectToCfg failed : %s", err.Error())
+		ldb.log.Error("error database insert  mutableObjectAccessor failed : %s", err.Error())
 		return err
 	}
 	undoFlag := false
@@ -367,15 +410,15 @@ func (ldb *LDataBase) insert(in interface{}, flag ...bool) error { /* struct cfg
 	}
 
 	if !undoFlag {
-		err = ldb.setIncrement(cfg) /* (kv.id) set increment id */
-		if err != nil {
-			ldb.log.Error("error database insert cfg setIncrement failed : %s,  cfg is : %v", err.Error(), cfg)
-			return err
-		}
+		ldb.setIncrement(obj) /* (kv.id) set increment id */
 	}
 
 	dbKV := &dbKeyValue{}
-	structKV(in, dbKV, cfg)
+	err = objectKV(obj, dbKV)
+	if err != nil {
+		ldb.log.Error("error database insert objectKV failed : %s", err.Error())
+		return err
+	}
 
 	err = ldb.insertKvToDb(dbKV) /* (kv to db) kv insert database (atomic) */
 	if err != nil {
@@ -383,12 +426,50 @@ func (ldb *LDataBase) insert(in interface{}, flag ...bool) error { /* struct cfg
 		return err
 	}
 
-	dbKV.id = cfg.id_
 	m := new(modifyValue)
 	m.NewKv = dbKV
-	m.Id = dbKV.id
+	m.Id = dbKV.Id
 	m.OldKv = dbKV
-	ldb.insertUndoState(cfg.Name,m, INSERT)
+	ldb.insertUndoState(obj.DbTypeName(), m, INSERT)
+	return nil
+}
+
+/*
+*	Insert a piece of data into the database keeping the id it already carries,
+*	used to rebuild the state from a snapshot
+*	the increment id of its type is moved past the restored id
+ */
+
+func (ldb *LDataBase) Restore(in interface{}) error {
+	obj, err := mutableObjectAccessor(in)
+	if err != nil {
+		ldb.log.Error("error database restore mutableObjectAccessor failed : %s", err.Error())
+		return err
+	}
+
+	dbKV := &dbKeyValue{}
+	err = objectKV(obj, dbKV)
+	if err != nil {
+		ldb.log.Error("error database restore objectKV failed : %s", err.Error())
+		return err
+	}
+
+	err = ldb.insertKvToDb(dbKV)
+	if err != nil {
+		ldb.log.Error("error database restore insertKvToDb failed : %s", err)
+		return err
+	}
+
+	typeName := obj.DbTypeName()
+	if dbKV.Id >= ldb.nextId[typeName] {
+		ldb.nextId[typeName] = dbKV.Id + 1
+	}
+
+	m := new(modifyValue)
+	m.NewKv = dbKV
+	m.Id = dbKV.Id
+	m.OldKv = dbKV
+	ldb.insertUndoState(typeName, m, INSERT)
 	return nil
 }
 
@@ -400,19 +481,16 @@ func (ldb *LDataBase) insertKvToDb(dbKV *dbKeyValue) error {
 	if err != nil {
 		return err
 	}
-
+	ldb.accountSize(dbKV, 1)
 	return nil
 }
 
-func (ldb *LDataBase) setIncrement(cfg *structInfo) error {
+func (ldb *LDataBase) setIncrement(obj MutableObjectAccessor) {
+	typeName := obj.DbTypeName()
+	id := ldb.nextId[typeName] // First insertion is 0
 
-	if _, ok := ldb.nextId[cfg.Name]; ok { // First insertion
-		cfg.id_ = ldb.nextId[cfg.Name]
-	}
-
-	cfg.rId.Set(reflect.ValueOf(cfg.id_).Convert(cfg.rId.Type()))
-	ldb.nextId[cfg.Name] = cfg.id_ + 1
-	return nil
+	obj.DbSetId(id)
+	ldb.nextId[typeName] = id + 1
 }
 
 /*
@@ -440,16 +518,19 @@ func (ldb *LDataBase) remove(in interface{}) error {
 		ldb.log.Error("remove DeepEqual error")
 	}
 
-	cfg, err := parseObjectToCfg(out)
+	obj, err := mutableObjectAccessor(out)
 	if err != nil {
 		ldb.log.Error("failed : %s", err.Error())
 		return err
 	}
 
 	dbKV := &dbKeyValue{}
-	structKV(in, dbKV, cfg) /* (kv.index) all key and value*/
+	err = objectKV(obj, dbKV) /* (kv.index) all key and value*/
+	if err != nil {
+		ldb.log.Error("failed : %s", err.Error())
+		return err
+	}
 
-	dbKV.id = cfg.id_
 	err = ldb.removeKvToDb(dbKV)
 	if err != nil {
 		ldb.log.Error("failed  : %s, dbKV is : %v", err, dbKV)
@@ -458,9 +539,9 @@ func (ldb *LDataBase) remove(in interface{}) error {
 
 	m := new(modifyValue)
 	m.NewKv = dbKV
-	m.Id = dbKV.id
+	m.Id = dbKV.Id
 	m.OldKv = dbKV
-	ldb.insertUndoState(cfg.Name,m, REMOVE)
+	ldb.insertUndoState(obj.DbTypeName(), m, REMOVE)
 	return nil
 }
 
@@ -472,6 +553,7 @@ func (ldb *LDataBase) removeKvToDb(dbKV *dbKeyValue) error {
 	if err != nil {
 		return err
 	}
+	ldb.accountSize(dbKV, -1)
 	return nil
 }
 
@@ -516,45 +598,47 @@ func (ldb *LDataBase) modifyCallFn(data interface{}, fn interface{}) error {
 
 	fnRef.Call([]reflect.Value{dataRef}) /*	call fn */
 	// modify
-	oldRef := reflect.ValueOf(oldInter)
-	return ldb.modifyRefToKv(&oldRef, &dataRef)
+	return ldb.modifyObjectToKv(oldInter, data)
 }
 
-func (ldb *LDataBase) modifyRefToKv(oldRef, newRef *reflect.Value) error {
+func (ldb *LDataBase) modifyObjectToKv(oldData, newData interface{}) error {
 
-	oldCfg, err := extractObjectTagInfo(oldRef)
+	oldObj, err := objectAccessor(oldData)
 	if err != nil {
-		ldb.log.Error("extractObjectTagInfo oldRef failed : " + err.Error())
+		ldb.log.Error("objectAccessor oldData failed : " + err.Error())
 		return err
 	}
-	newCfg, err := extractObjectTagInfo(newRef)
+	newObj, err := objectAccessor(newData)
 	if err != nil {
-		ldb.log.Error("extractObjectTagInfo newRef failed : " + err.Error())
+		ldb.log.Error("objectAccessor newData failed : " + err.Error())
 		return err
 	}
-	if oldCfg.id_ != newCfg.id_ {
-		ldb.log.Error("newCfg and oldCfg id failed,  newCfg id is :  %v,  oldCfg id is : %v", newCfg.id_, oldCfg.id_)
-		return errors.New("newCfg and oldCfg id failed")
-	}
-	if oldCfg.Name != newCfg.Name{
+	if oldObj.DbTypeName() != newObj.DbTypeName() {
 		return errors.New("newCfg and oldCfg typeName failed")
 	}
 	newKV := &dbKeyValue{}
 	oldKV := &dbKeyValue{}
 
-	structKV(oldRef.Interface(), oldKV, oldCfg)
-	structKV(newRef.Interface(), newKV, newCfg)
+	if err = objectKV(oldObj, oldKV); err != nil {
+		return err
+	}
+	if err = objectKV(newObj, newKV); err != nil {
+		return err
+	}
+	if oldKV.Id != newKV.Id {
+		ldb.log.Error("newCfg and oldCfg id failed,  newCfg id is :  %v,  oldCfg id is : %v", newKV.Id, oldKV.Id)
+		return errors.New("newCfg and oldCfg id failed")
+	}
 
 	err = ldb.modifyKvToDb(oldKV, newKV)
 	if err != nil {
 		return err
 	}
 	m := new(modifyValue)
-	m.Id = oldCfg.id_
-	//oldCfg.rId.Set(reflect.ValueOf(m.id).Convert(oldCfg.rId.Type()))
+	m.Id = oldKV.Id
 	m.NewKv = newKV
 	m.OldKv = oldKV
-	ldb.insertUndoState(oldCfg.Name,m, MODIFY)
+	ldb.insertUndoState(oldObj.DbTypeName(), m, MODIFY)
 	return nil
 }
 
@@ -568,7 +652,8 @@ func (ldb *LDataBase) modifyKvToDb(oldKV, newKV *dbKeyValue) error {
 		ldb.log.Error("newKV is : %v", newKV)
 		return err
 	}
-
+	ldb.accountSize(oldKV, -1)
+	ldb.accountSize(newKV, 1)
 	return nil
 }
 
@@ -591,15 +676,7 @@ func (ldb *LDataBase) Find(tagName string, in interface{}, out interface{}, skip
 func (ldb *LDataBase) find(tagName string, value interface{}, to interface{}, skip ...SkipSuffix) error {
 	ldb.log.Info("tagName is: %v", tagName)
 	fieldName := []byte(tagName)
-	fields, err := getFieldInfo(tagName, value)
-	if err != nil {
-		ldb.log.Error("failed : %s", err.Error())
-		return err
-	}
-
-	typeName := []byte(fields.typeName)
-
-	suffix, err := fieldValueToByte(fields, skip...)
+	typeName, suffix, err := indexKey(tagName, value, skip...)
 	if err != nil {
 		ldb.log.Error("failed : %s", err.Error())
 		return err
@@ -615,7 +692,7 @@ func (ldb *LDataBase) find(tagName string, value interface{}, to interface{}, sk
 	key = append(key, suffix...)
 
 	//ldb.log.Info("key is : %v", key)
-	it := ldb.db.NewIterator(util.BytesPrefix(key), nil)
+	it := ldb.db.NewIterator(util.BytesPrefix(key))
 
 	//fmt.Println("ErrorNotFound key is : %v", key)
 	//fmt.Println(it.Key(),"  ",it.Value())
@@ -627,7 +704,7 @@ func (ldb *LDataBase) find(tagName string, value interface{}, to interface{}, sk
 
 func (ldb *LDataBase) getAllKv(key []byte) {
 
-	it := ldb.db.NewIterator(nil, nil)
+	it := ldb.db.NewIterator(nil)
 	for it.Next() {
 		ldb.log.Info("key %v", it.Key(), "  value %v", it.Value())
 	}
@@ -670,13 +747,16 @@ func (ldb *LDataBase) getIndex(tagName string, value interface{}) (*MultiIndex,
 
 	// fieldName == tagName --> Just different nextId
 	fieldName := []byte(tagName)
-	fields, err := getFieldInfo(tagName, value)
+	obj, err := objectAccessor(value)
+	if err == nil && !hasIndex(obj, tagName) {
+		err = ErrNotFound
+	}
 	if err != nil {
 		ldb.log.Error("failed : %s, val is %v", err.Error(), fieldName)
 		return nil, err
 	}
 
-	typeName := []byte(fields.typeName)
+	typeName := []byte(obj.DbTypeName())
 	begin := splicingString(typeName, fieldName)
 
 	end := keyEnd(begin)
@@ -693,7 +773,7 @@ func (ldb *LDataBase) GetMutableIndex(fieldName string, in interface{}) (*MultiI
 func (ldb *LDataBase) LowerBound(begin, end, fieldName []byte, data interface{}, skip ...SkipSuffix) (*DbIterator, error) {
 	key, typeName := ldb.dbPrefix(begin, fieldName, data, skip...)
 	//return ldb.dbIterator(key,begin,end,typeName,false)
-	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end}, nil)
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
 	if !it.Next() {
 		return nil, ErrNotFound
 	}
@@ -721,7 +801,7 @@ func (ldb *LDataBase) UpperBound(begin, end, fieldName []byte, data interface{},
 }
 
 func (ldb *LDataBase) dbIterator(key, begin, end, typeName []byte, upper bool) (*DbIterator, error) {
-	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end}, nil)
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
 	if !it.Next() {
 		return nil, ErrNotFound
 	}
@@ -745,7 +825,7 @@ func (ldb *LDataBase) EndIterator(begin, end, typeName []byte) (*DbIterator, err
 
 	ldb.log.Info("begin : %v, end : %v, typeName: %v", begin, end, typeName)
 
-	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end}, nil)
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
 	if !it.Next(){
 		// not found  --> iterator is nil  == end
 		itr := &DbIterator{it: nil, db: ldb.db, first: false, typeName: typeName, currentStatus: itEND}
@@ -764,13 +844,7 @@ func (ldb *LDataBase) EndIterator(begin, end, typeName []byte) (*DbIterator, err
 func (ldb *LDataBase) dbPrefix(begin_, fieldName []byte, data interface{}, skip ...SkipSuffix) ([]byte, []byte) {
 	begin := cloneByte(begin_)
 	ldb.log.Info("begin : %v, end : %v, fieldName: %v", begin, fieldName)
-	fields, err := getFieldInfo(string(fieldName), data)
-	if err != nil {
-		ldb.log.Error("failed %s", err.Error())
-		return nil, nil
-	}
-
-	prefix, err := fieldValueToByte(fields, skip...)
+	typeName, prefix, err := indexKey(string(fieldName), data, skip...)
 	if err != nil {
 		ldb.log.Error("failed %s", err.Error())
 		return nil, nil
@@ -784,13 +858,13 @@ func (ldb *LDataBase) dbPrefix(begin_, fieldName []byte, data interface{}, skip
 	prefix = append(begin, prefix...)
 
 	ldb.log.Info("prefix is : %v", prefix)
-	return prefix, []byte(fields.typeName)
+	return prefix, typeName
 }
 
 func (ldb *LDataBase) Empty(begin, end, fieldName []byte) bool {
 
 	ldb.log.Info("begin : %v, end : %v, fieldName: %v ", begin, end, fieldName)
-	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end}, nil)
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
 	defer it.Release()
 	if it.Next() {
 		return false
@@ -801,12 +875,7 @@ func (ldb *LDataBase) Empty(begin, end, fieldName []byte) bool {
 func (ldb *LDataBase) IteratorTo(begin, end, fieldName []byte, data interface{}, skip ...SkipSuffix) (*DbIterator, error) {
 
 	ldb.log.Info("begin : %v, end : %v, fieldName: %v , greater: %t", begin, end, fieldName)
-	fields, err := getFieldInfo(string(fieldName), data)
-	if err != nil {
-		ldb.log.Error("failed %s", err.Error())
-		return nil, err
-	}
-	prefix, err := fieldValueToByte(fields, skip...)
+	typeName, prefix, err := indexKey(string(fieldName), data, skip...)
 	if err != nil {
 		ldb.log.Error("failed %s", err.Error())
 		return nil, err
@@ -818,20 +887,20 @@ func (ldb *LDataBase) IteratorTo(begin, end, fieldName []byte, data interface{},
 	key := []byte{}
 	key = append(begin, prefix...)
 
-	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end}, nil)
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
 	if !it.Seek(key) {
 		ldb.log.Error("seek failed key is %v", key)
 		return nil, ErrNotFound
 	}
 
-	idk := splicingString([]byte(fields.typeName), it.Value())
+	idk := splicingString(typeName, it.Value())
 	idv, err := getDbKey(idk, ldb.db)
 	if err != nil {
 		ldb.log.Error("failed %s", err.Error())
 		return nil, err
 	}
 
-	itr := &DbIterator{it: it, db: ldb.db, first: false, value: idv, typeName: []byte(fields.typeName)}
+	itr := &DbIterator{it: it, db: ldb.db, first: false, value: idv, typeName: typeName}
 	return itr, nil
 }
 
@@ -839,7 +908,7 @@ func (ldb *LDataBase) BeginIterator(begin, end, typeName []byte) (*DbIterator, e
 
 	ldb.log.Info("begin : %v, end : %v, typeName: %v ", begin, end, typeName)
 
-	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end}, nil)
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
 	if !it.Next() {
 		ldb.log.Error("Next Failed")
 		return nil, ErrNotFound
@@ -857,6 +926,29 @@ func (ldb *LDataBase) BeginIterator(begin, end, typeName []byte) (*DbIterator, e
 	return itr, nil
 }
 
+/*
+*	LastIterator returns an iterator at the last entry in [begin, end), moving it back with Prev stops at begin
+ */
+
+func (ldb *LDataBase) LastIterator(begin, end, typeName []byte) (*DbIterator, error) {
+
+	it := ldb.db.NewIterator(&util.Range{Start: begin, Limit: end})
+	if !it.Last() {
+		it.Release()
+		return nil, ErrNotFound
+	}
+
+	k := splicingString([]byte(typeName), it.Value())
+	val, err := getDbKey(k, ldb.db)
+	if err != nil {
+		it.Release()
+		ldb.log.Error("failed %s %v", err.Error(), k)
+		return nil, ErrNotFound
+	}
+
+	return &DbIterator{it: it, db: ldb.db, typeName: typeName, key: k, value: val, currentStatus: itCURRENT}, nil
+}
+
 func (ldb *LDataBase) enable() bool { /* Whether the database enables the undo function*/
 	return ldb.stack.Size() != 0
 }
@@ -864,28 +956,28 @@ func (ldb *LDataBase) enable() bool { /* Whether the database enables the undo f
 /*Batch operation*/
 
 func (ldb *LDataBase) putBatch(dbKV *dbKeyValue) {
-	for _, v := range dbKV.index {
+	for _, v := range dbKV.Index {
 		ldb.count++
-		ldb.log.Debug("save key %v | %v", v.key, v.value)
-		ldb.batch.Put(v.key, v.value)
+		ldb.log.Debug("save key %v | %v", v.Key, v.Value)
+		ldb.batch.Put(v.Key, v.Value)
 	}
 	ldb.count++
-	ldb.log.Debug("save key %v | %v", dbKV.idk.key, dbKV.idk.value)
-	ldb.batch.Put(dbKV.idk.key, dbKV.idk.value)
+	ldb.log.Debug("save key %v | %v", dbKV.Idk.Key, dbKV.Idk.Value)
+	ldb.batch.Put(dbKV.Idk.Key, dbKV.Idk.Value)
 }
 
 func (ldb *LDataBase) deleteBatch(dbKV *dbKeyValue) {
-	for idx, _ := range dbKV.index {
-		//ldb.log.Debug("delete key %v",dbKV.index[idx].key)
-		ldb.batch.Delete(dbKV.index[idx].key)
+	for idx, _ := range dbKV.Index {
+		//ldb.log.Debug("delete key %v",dbKV.Index[idx].Key)
+		ldb.batch.Delete(dbKV.Index[idx].Key)
 	}
-	//ldb.log.Debug("delete key %v",dbKV.idk.key)
-	ldb.batch.Delete(dbKV.idk.key)
+	//ldb.log.Debug("delete key %v",dbKV.Idk.Key)
+	ldb.batch.Delete(dbKV.Idk.Key)
 }
 
 func (ldb *LDataBase) writeBatch() error {
 	if ldb.batch.Len() > 0 {
-		err := ldb.db.Write(ldb.batch, nil)
+		err := ldb.db.Write(ldb.batch)
 		if err != nil {
 			return err
 		}
@@ -971,8 +1063,8 @@ func (ldb *LDataBase) getStack() *undoContainer {
 	return nil
 }
 
-func getDbKey(key []byte, db *leveldb.DB) ([]byte, error) {
-	val, err := db.Get(key, nil)
+func getDbKey(key []byte, db KVStore) ([]byte, error) {
+	val, err := db.Get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -981,10 +1073,12 @@ func getDbKey(key []byte, db *leveldb.DB) ([]byte, error) {
 
 func undoStateSquash(stack *undoState, preStack *undoState) {
 	for key, value := range stack.OldValue {
-		if _, ok := preStack.NewValue[key]; ok {
+		if inserted, ok := preStack.NewValue[key]; ok {
+			inserted.NewKv = value.NewKv
 			continue
 		}
-		if _, ok := preStack.OldValue[key]; ok {
+		if modified, ok := preStack.OldValue[key]; ok {
+			modified.NewKv = value.NewKv
 			continue
 		}
 		if _, ok := preStack.RemoveValue[key]; ok {
@@ -1000,17 +1094,12 @@ func undoStateSquash(stack *undoState, preStack *undoState) {
 	for key, value := range stack.RemoveValue {
 
 		if _, ok := preStack.NewValue[key]; ok {
-			if _, ok := preStack.NewValue[key]; ok {
-				delete(preStack.NewValue, key)
-			}
+			delete(preStack.NewValue, key)
 			continue
 		}
-		if _, ok := preStack.OldValue[key]; ok {
-
-			preStack.RemoveValue[key] = value
-			if _, ok := preStack.OldValue[key]; ok {
-				delete(preStack.OldValue, key)
-			}
+		if modified, ok := preStack.OldValue[key]; ok {
+			preStack.RemoveValue[key] = &modifyValue{Id: key, OldKv: modified.OldKv, NewKv: modified.OldKv}
+			delete(preStack.OldValue, key)
 			continue
 		}
 		preStack.RemoveValue[key] = value
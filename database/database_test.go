@@ -1912,3 +1912,59 @@ func Test_reversion(t *testing.T) {
 	db.Close()
 }
 
+
+func Test_lastSessionDeltas(t *testing.T) {
+	db, clo := openDb()
+	if db == nil {
+		log.Fatalln("db open failed")
+	}
+	defer clo()
+
+	if deltas := db.LastSessionDeltas(); len(deltas) != 0 {
+		log.Fatalln("no session, no deltas")
+	}
+
+	objs, _ := Objects()
+	for i := 0; i < 3; i++ {
+		if err := db.Insert(&objs[i]); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	session := db.StartSession()
+	defer session.Undo()
+
+	house := DbHouse{Area: 100, Name: "house", Carnivore: Carnivore{1, 1}}
+	if err := db.Insert(&house); err != nil {
+		log.Fatalln(err)
+	}
+	if err := db.Modify(&objs[0], func(obj *DbTableIdObject) {
+		obj.Count = 99
+	}); err != nil {
+		log.Fatalln(err)
+	}
+	if err := db.Remove(&objs[1]); err != nil {
+		log.Fatalln(err)
+	}
+
+	deltas := db.LastSessionDeltas()
+	if len(deltas) != 2 || deltas[0].Name != "DbHouse" || deltas[1].Name != "DbTableIdObject" {
+		log.Fatalln("unexpected tables", deltas)
+	}
+	if len(deltas[0].Rows) != 1 || !deltas[0].Rows[0].Present {
+		log.Fatalln("inserted row expected", deltas[0].Rows)
+	}
+
+	rows := deltas[1].Rows
+	if len(rows) != 2 || rows[0].Id != int64(objs[0].ID) || !rows[0].Present || rows[1].Id != int64(objs[1].ID) || rows[1].Present {
+		log.Fatalln("modified and removed rows expected", rows)
+	}
+	modified := DbTableIdObject{}
+	if err := DecodeBytes(rows[0].Value, &modified); err != nil || modified.Count != 99 {
+		log.Fatalln("modified row carries the current value", modified, err)
+	}
+	removed := DbTableIdObject{}
+	if err := DecodeBytes(rows[1].Value, &removed); err != nil || removed.Code != objs[1].Code {
+		log.Fatalln("removed row carries its value", removed, err)
+	}
+}
@@ -0,0 +1,402 @@
+// Command dbgen generates the database.ObjectAccessor of the structs of a package from their multiIndex tags.
+//
+// It writes one gen_<file>.go next to every file declaring such structs, run it from the package directory:
+//
+//	//go:generate go run github.com/eosspark/eos-go/database/dbgen
+//
+// The generated code builds the same keys and values as the reflect path of the database, values the generator
+// has no code for are still encoded with database.AppendValue. Structs whose tags the database would reject,
+// that use inline tags or whose id is not an int64 are left to the reflect path.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	header       = "// Code generated by dbgen. DO NOT EDIT.\n"
+	databasePath = "github.com/eosspark/eos-go/database"
+
+	maxNumArrayElement = 1024 * 1024
+)
+
+/*
+ * the types the database encoder special cases before looking at their kind
+ */
+var encodedByValue = map[string]bool{
+	"github.com/eosspark/eos-go/crypto/ecc.PublicKey":     true,
+	"github.com/eosspark/eos-go/crypto/ecc.Signature":     true,
+	"github.com/eosspark/eos-go/common/eos_math.Float64":  true,
+	"github.com/eosspark/eos-go/common/eos_math.Float128": true,
+}
+
+/*
+ * Uint128 is special cased as well but simple enough to be written here
+ */
+const uint128Type = "github.com/eosspark/eos-go/common/eos_math.Uint128"
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, *dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		log.Fatalf("expected one package in %s, found %d", *dir, len(pkgs))
+	}
+
+	var files []*ast.File
+	var names []string
+	for _, pkg := range pkgs {
+		for name, file := range pkg.Files {
+			if isGenerated(file) {
+				os.Remove(name)
+				continue
+			}
+			files = append(files, file)
+			names = append(names, name)
+		}
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(files[0].Name.Name, fset, files, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i, file := range files {
+		g := &generator{pkg: pkg, imports: map[string]string{}}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				obj := pkg.Scope().Lookup(spec.(*ast.TypeSpec).Name.Name)
+				if obj == nil {
+					continue
+				}
+				if named, ok := obj.Type().(*types.Named); ok {
+					g.object(named)
+				}
+			}
+		}
+		if g.body.Len() == 0 {
+			continue
+		}
+
+		out := filepath.Join(filepath.Dir(names[i]), "gen_"+filepath.Base(names[i]))
+		if err := ioutil.WriteFile(out, g.source(), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func isGenerated(file *ast.File) bool {
+	for _, comment := range file.Comments {
+		if comment.Pos() < file.Package && strings.HasPrefix(comment.Text(), strings.TrimPrefix(header, "// ")) {
+			return true
+		}
+	}
+	return false
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string
+	body    bytes.Buffer
+	depth   int
+}
+
+func (g *generator) source() []byte {
+	buf := bytes.Buffer{}
+	buf.WriteString(header + "\n")
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkg.Name())
+
+	paths := []string{databasePath}
+	for path := range g.imports {
+		if path != databasePath {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	buf.WriteString("import (\n")
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+	buf.WriteString(")\n")
+	buf.Write(g.body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("%s\n%s", err, buf.Bytes())
+	}
+	return src
+}
+
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+	g.imports[pkg.Path()] = pkg.Name()
+	return pkg.Name()
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+/*
+ * index is a multiIndex tag and the fields it is built from, in the order the reflect path finds them
+ */
+type index struct {
+	tag    string
+	fields []*types.Var
+}
+
+func (g *generator) object(named *types.Named) {
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	indexes, id, ok := parseTags(st)
+	if !ok || id == nil {
+		return
+	}
+	if basic, ok := id.Type().Underlying().(*types.Basic); !ok || basic.Kind() != types.Int64 {
+		return
+	}
+
+	name := named.Obj().Name()
+	recv := strings.ToLower(name[:1])
+	if recv == "b" {
+		recv = "o" // b is the buffer of the generated methods
+	}
+	indexesVar := strings.ToLower(name[:1]) + name[1:] + "DbIndexes"
+
+	tags := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		tags = append(tags, fmt.Sprintf("%q", idx.tag))
+	}
+	g.printf("\nvar %s = []string{%s}\n", indexesVar, strings.Join(tags, ", "))
+
+	g.printf("\nfunc (%s %s) DbTypeName() string {\n\treturn %q\n}\n", recv, name, name)
+	g.printf("\nfunc (%s %s) DbId() int64 {\n\treturn int64(%s.%s)\n}\n", recv, name, recv, id.Name())
+	g.printf("\nfunc (%s *%s) DbSetId(id int64) {\n\t%s.%s = %s(id)\n}\n",
+		recv, name, recv, id.Name(), types.TypeString(id.Type(), g.qualifier))
+	g.printf("\nfunc (%s %s) DbIndexes() []string {\n\treturn %s\n}\n", recv, name, indexesVar)
+
+	g.printf("\nfunc (%s %s) DbEncode() (b []byte, err error) {\n", recv, name)
+	g.value(recv, named, false)
+	g.printf("\treturn b, nil\n}\n")
+
+	g.printf("\nfunc (%s %s) DbIndexKey(tag string, skip int) (b []byte, err error) {\n\tswitch tag {\n", recv, name)
+	for _, idx := range indexes {
+		g.printf("\tcase %q:\n", idx.tag)
+		for i, field := range idx.fields {
+			g.printf("\tif skip < %d {\n\tb = append(b, '_', '_')\n", len(idx.fields)-i)
+			g.value(recv+"."+field.Name(), field.Type(), false)
+			g.printf("\t}\n")
+		}
+	}
+	g.printf("\tdefault:\n\treturn nil, database.ErrNotFound\n\t}\n\treturn b, nil\n}\n")
+}
+
+/*
+ * parseTags reads the multiIndex tags the way database.extractObjectTagInfo does
+ */
+func parseTags(st *types.Struct) ([]*index, *types.Var, bool) {
+	var indexes []*index
+	var id *types.Var
+	add := func(tag string, field *types.Var) {
+		for _, idx := range indexes {
+			if idx.tag == tag {
+				idx.fields = append(idx.fields, field)
+				return
+			}
+		}
+		indexes = append(indexes, &index{tag: tag, fields: []*types.Var{field}})
+	}
+	sorted := func(tag string) bool {
+		return tag == "less" || tag == "greater"
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("multiIndex")
+		if !field.Exported() || tag == "" {
+			continue
+		}
+		for _, sub := range strings.Split(tag, ":") {
+			tags := strings.Split(sub, ",")
+			switch tags[0] {
+			case "id":
+				for _, t := range tags {
+					if sorted(t) {
+						return nil, nil, false
+					}
+					if t != "increment" {
+						add(t, field)
+					}
+				}
+				id = field
+			case "orderedUnique":
+				if len(tags) > 1 && !sorted(tags[1]) {
+					return nil, nil, false
+				}
+				add(field.Name(), field)
+			case "inline":
+				return nil, nil, false
+			default:
+				if len(tags) < 2 || tags[1] != "orderedUnique" || len(tags) > 2 && !sorted(tags[2]) {
+					return nil, nil, false
+				}
+				add(tags[0], field)
+			}
+		}
+	}
+	return indexes, id, true
+}
+
+/*
+ * value writes the code appending the encoding of expr to b
+ */
+func (g *generator) value(expr string, typ types.Type, eosArray bool) {
+	switch typePath(typ) {
+	case uint128Type:
+		g.printf("\tb = database.AppendUint64(database.AppendUint64(b, %s.High), %s.Low)\n", expr, expr)
+		return
+	default:
+		if encodedByValue[typePath(typ)] {
+			g.fallback(expr)
+			return
+		}
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		if !g.basic(expr, t) {
+			g.fallback(expr)
+		}
+
+	case *types.Slice:
+		if elem, ok := t.Elem().Underlying().(*types.Basic); ok && elem.Kind() == types.Uint8 && !isSpecial(t.Elem()) {
+			g.printf("\tb = database.AppendBytes(b, %s)\n", expr)
+			return
+		}
+		g.printf("\tb = database.AppendSliceLen(b, len(%s))\n", expr)
+		g.elements(expr, t.Elem())
+
+	case *types.Array:
+		if t.Len() > maxNumArrayElement {
+			g.fallback(expr)
+			return
+		}
+		if !eosArray {
+			g.printf("\tb = database.AppendUvarint(b, %d)\n", t.Len())
+		}
+		g.elements(expr, t.Elem())
+
+	case *types.Struct:
+		if !encodable(t) {
+			g.fallback(expr)
+			return
+		}
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			tag := reflect.StructTag(t.Tag(i)).Get("eos")
+			if field.Name() == "_" || !field.Exported() || tag == "-" {
+				continue
+			}
+			g.value(expr+"."+field.Name(), field.Type(), tag == "array")
+		}
+
+	default:
+		g.fallback(expr)
+	}
+}
+
+func (g *generator) elements(expr string, elem types.Type) {
+	g.depth++
+	v := fmt.Sprintf("v%d", g.depth)
+	g.printf("\tfor _, %s := range %s {\n", v, expr)
+	g.value(v, elem, false)
+	g.printf("\t}\n")
+	g.depth--
+}
+
+func (g *generator) basic(expr string, t *types.Basic) bool {
+	switch t.Kind() {
+	case types.Bool:
+		g.printf("\tb = database.AppendBool(b, bool(%s))\n", expr)
+	case types.Int8, types.Uint8:
+		g.printf("\tb = database.AppendUint8(b, uint8(%s))\n", expr)
+	case types.Int16, types.Uint16:
+		g.printf("\tb = database.AppendUint16(b, uint16(%s))\n", expr)
+	case types.Int32, types.Int, types.Uint32, types.Uint:
+		g.printf("\tb = database.AppendUint32(b, uint32(%s))\n", expr)
+	case types.Int64, types.Uint64:
+		g.printf("\tb = database.AppendUint64(b, uint64(%s))\n", expr)
+	case types.String:
+		g.printf("\tb = database.AppendString(b, string(%s))\n", expr)
+	default:
+		return false
+	}
+	return true
+}
+
+func (g *generator) fallback(expr string) {
+	g.printf("\tif b, err = database.AppendValue(b, %s); err != nil {\n\treturn nil, err\n\t}\n", expr)
+}
+
+func typePath(typ types.Type) string {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
+
+func isSpecial(typ types.Type) bool {
+	path := typePath(typ)
+	return path == uint128Type || encodedByValue[path]
+}
+
+/*
+ * encodable reports whether the fields of a struct are written without the encoder states
+ * its eos tags set (optional, vuint32, static variants), "array" is only followed on arrays
+ */
+func encodable(t *types.Struct) bool {
+	for i := 0; i < t.NumFields(); i++ {
+		switch reflect.StructTag(t.Tag(i)).Get("eos") {
+		case "", "-":
+		case "array":
+			if _, ok := t.Field(i).Type().Underlying().(*types.Array); !ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
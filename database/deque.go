@@ -109,3 +109,15 @@ func (s *deque) Empty() bool {
 
 	return s.container.Len() == 0
 }
+
+/* Items returns the items from the first to the last */
+func (s *deque) Items() []interface{} {
+	s.RLock()
+	defer s.RUnlock()
+
+	items := make([]interface{}, 0, s.container.Len())
+	for item := s.container.Front(); item != nil; item = item.Next() {
+		items = append(items, item.Value)
+	}
+	return items
+}
@@ -21,4 +21,6 @@ var (
 	ErrPtrNeeded = errors.New("database : provided target must be a pointer to a valid variable")
 
 	ErrNotFound = errors.New("database not found")
+
+	ErrUndoStackVersion = errors.New("database : the undo stack was written in another format, replay or restore from a snapshot")
 )
@@ -15,6 +15,7 @@ const (
 	tagInline    = "inline"
 	dbIncrement  = "db_increment"
  undoKey = "undo_stack"
+	undoVersionKey = "undo_version"
 	dbReversion = "db_reversion"
 )
 
@@ -48,7 +49,6 @@ type structInfo struct {
 	Name   string
 	rId     *reflect.Value //TODO delete
 	Fields map[string]*fieldInfo
-	id_		int64
 }
 
 func isZero(v *reflect.Value) bool {
@@ -170,7 +170,6 @@ func extractObjectTagInfo(s *reflect.Value, mi ...*structInfo) (*structInfo, err
 		}
 	}
 
-	m.id_ = 0
 	for tag, _ := range m.Fields {
 		if len(m.Fields[tag].fieldValue) == 1 {
 			m.Fields[tag].unique = true
@@ -313,70 +312,48 @@ non unique fields 	--> idFieldValue
 
 */
 
+/* the fields are exported so that the undo stack written on Close keeps them */
 type kv struct {
-	key   []byte
-	value []byte
+	Key   []byte
+	Value []byte
 }
 
 type dbKeyValue struct {
-	idk       kv
-	index    []kv
-	typeName []byte
-	id 		 int64
+	Idk      kv
+	Index    []kv
+	TypeName []byte
+	Id       int64
 }
 
-func structKV(in interface{}, dbKV *dbKeyValue, cfg *structInfo) error {
+func objectKV(obj ObjectAccessor, dbKV *dbKeyValue) error {
 
-	objValue, err := EncodeToBytes(in)
+	objValue, err := obj.DbEncode()
 	if err != nil {
 		return err
 	}
-	objId, err := EncodeToBytes(cfg.rId.Interface())
-	if err != nil {
-		return err
-	}
-	err = DecodeBytes(objId,&cfg.id_)
-	if err != nil {
-		return err
-	}
-	idk := splicingString([]byte(cfg.Name),objId)
-	kv_ := kv{}
-	kv_.key = idk
-	kv_.value = objValue
-	dbKV.idk = kv_
-	dbKV.typeName = []byte(cfg.Name)
-
-	err = cfgToKV(objId, cfg, dbKV)
-	if err != nil{
-		return err
-	}
+	dbKV.Id = obj.DbId()
+	objId := AppendUint64(nil, uint64(dbKV.Id))
+	typeName := []byte(obj.DbTypeName())
 
-	return nil
-}
-//xxx aaaxxxx
-//xxx dddxxxx
-func cfgToKV(objId []byte, cfg *structInfo, dbKV *dbKeyValue) error{
-
-	for tag, fieldCfg := range cfg.Fields {
-		typeName := []byte(cfg.Name)
-		prefix := append(typeName, '_') 					/* 			typeName__ 				*/
-		prefix = append(prefix, '_')
-		prefix = append(prefix, tag...) 						/* 			typeName__tagName__ 	*/
-		key,err := fieldValueToByte(fieldCfg)
-		if err != nil{
-			return err
-		}
-		key = append(prefix,key...)
+	dbKV.Idk = kv{Key: splicingString(typeName, objId), Value: objValue}
+	dbKV.TypeName = typeName
 
-		if !fieldCfg.unique && len(fieldCfg.fieldValue) == 1 { 	/* 			non unique 				*/
-			key = append(key, objId...)
+	for _, tag := range obj.DbIndexes() {
+		suffix, err := obj.DbIndexKey(tag, 0)
+		if err != nil {
+			return err
 		}
-		key = append(key,'_')
-
-		kv_ := kv{}
-		kv_.key = key
-		kv_.value = objId
-		dbKV.index = append(dbKV.index, kv_)
+		dbKV.Index = append(dbKV.Index, kv{Key: indexEntryKey(typeName, tag, suffix), Value: objId})
 	}
 	return nil
 }
+
+func indexEntryKey(typeName []byte, tag string, suffix []byte) []byte {
+	key := make([]byte, 0, len(typeName)+len(tag)+len(suffix)+3)
+	key = append(key, typeName...) /* 			typeName__tagName__fieldValue_ 	*/
+	key = append(key, '_', '_')
+	key = append(key, tag...)
+	key = append(key, suffix...)
+	key = append(key, '_')
+	return key
+}
@@ -5,6 +5,8 @@ type DataBase interface {
 
 	Insert(in interface{}) error
 
+	Restore(in interface{}) error
+
 	Find(tagName string, in interface{}, out interface{}, skip ...SkipSuffix) error
 
 	Empty(begin, end, fieldName []byte) bool
@@ -40,4 +42,22 @@ type DataBase interface {
 	Squash()
 
 	EndIterator(begin, end, typeName []byte) (*DbIterator, error)
+
+	LastIterator(begin, end, typeName []byte) (*DbIterator, error)
+
+	LastSessionDeltas() []TableDelta
+
+	Size() uint64
+
+	TypeSizes() map[string]uint64
+
+	SetMaxSize(size uint64)
+
+	MaxSize() uint64
+
+	FreeSize() uint64
+
+	CheckIndexes(in interface{}) []error
+
+	CheckUndo() []error
 }
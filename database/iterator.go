@@ -1,7 +1,6 @@
 package database
 
 import (
-	"github.com/syndtr/goleveldb/leveldb"
 	"reflect"
 	"fmt"
 )
@@ -47,13 +46,13 @@ type DbIterator struct {
 	begin         []byte
 	currentStatus string
 	typeName      []byte
-	db            *leveldb.DB // TODO interface
+	db            KVStore
 	it            iterator
 	first         bool
 }
 
 //Do not use the functions in this file
-func newDbIterator(typeName []byte, it iterator, db *leveldb.DB) (*DbIterator, error) {
+func newDbIterator(typeName []byte, it iterator, db KVStore) (*DbIterator, error) {
 
 	idx := &DbIterator{typeName: typeName, it: it, db: db}
 
@@ -98,6 +97,9 @@ func (index *DbIterator) Prev() bool {
 }
 
 func (index *DbIterator) next() bool {
+	if index.it == nil {
+		return false
+	}
 	for index.it.Next() {
 		index.currentStatus = itCURRENT
 		return index.keyValue(index.it.Value()) == nil
@@ -108,6 +110,9 @@ func (index *DbIterator) next() bool {
 }
 
 func (index *DbIterator) prev() bool {
+	if index.it == nil {
+		return false
+	}
 	for index.it.Prev() {
 		index.currentStatus = itCURRENT
 		return index.keyValue(index.it.Value()) == nil
@@ -173,18 +178,49 @@ func (index *DbIterator) Key() []byte {
 }
 
 func (index *DbIterator) Value() []byte {
+	if index.it == nil {
+		return nil
+	}
 	index.keyValue(index.it.Value())
 	return index.value
 }
 
-func (index *DbIterator) Last() bool { // TODO
-	return index.it.Last()
+/*
+*	Last, First and Seek stay in the range of the index the iterator was made for, the keys of the other indexes
+*	and types are outside of it. When there is no row to move to the iterator is at the end of the index
+ */
+
+func (index *DbIterator) Last() bool {
+	if index.it == nil || !index.it.Last() {
+		return index.toEnd()
+	}
+	index.currentStatus = itCURRENT
+	return index.keyValue(index.it.Value()) == nil
 }
 
-func (index *DbIterator) First() bool { // TODO
-	return index.it.First()
+func (index *DbIterator) First() bool {
+	if index.it == nil || !index.it.First() {
+		return index.toEnd()
+	}
+	index.currentStatus = itBEGIN
+	return index.keyValue(index.it.Value()) == nil
+}
+
+/* Seek moves to the first entry of the index whose key is not below key, key is the whole key of the entry */
+func (index *DbIterator) Seek(key []byte) bool {
+	if index.it == nil || !index.it.Seek(key) {
+		return index.toEnd()
+	}
+	index.currentStatus = itCURRENT
+	return index.keyValue(index.it.Value()) == nil
 }
 
-func (index *DbIterator) Seek(key []byte) bool { // TODO
-	return index.it.Seek(key)
+func (index *DbIterator) toEnd() bool {
+	if index.it != nil {
+		index.it.Last()
+		index.it.Next()
+	}
+	index.clearKV()
+	index.currentStatus = itEND
+	return false
 }
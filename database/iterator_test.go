@@ -0,0 +1,190 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func openIteratorDataBase(t *testing.T, backend string) (*LDataBase, string) {
+	dir, err := ioutil.TempDir("", "iterator_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenDataBase(backend, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db.(*LDataBase), dir
+}
+
+func insertHouses(t *testing.T, db DataBase, areas ...uint64) map[uint64]*DbHouse {
+	houses := make(map[uint64]*DbHouse)
+	for _, area := range areas {
+		house := &DbHouse{Area: area, Name: string('a' + rune(area)), Carnivore: Carnivore{int(area), int(area)}}
+		if err := db.Insert(house); err != nil {
+			t.Fatal(err)
+		}
+		houses[area] = house
+	}
+	return houses
+}
+
+func houseArea(t *testing.T, it Iterator) uint64 {
+	house := DbHouse{}
+	if err := it.Data(&house); err != nil {
+		t.Fatal(err)
+	}
+	return house.Area
+}
+
+func reverseAreas(t *testing.T, idx *MultiIndex, lower, upper Iterator, limit int) []uint64 {
+	areas := []uint64{}
+	idx.ReverseRange(lower, upper, func(it Iterator) bool {
+		areas = append(areas, houseArea(t, it))
+		return len(areas) != limit
+	})
+	return areas
+}
+
+/* checkAreas walks the Area index forward and backward, both must see the rows in order */
+func checkAreas(t *testing.T, step string, idx *MultiIndex, want ...uint64) {
+	forward := []uint64{}
+	for it := idx.Begin(); !idx.CompareEnd(it); it.Next() {
+		forward = append(forward, houseArea(t, it))
+	}
+	reverse := reverseAreas(t, idx, nil, nil, -1)
+	backward := []uint64{}
+	for it := idx.End(); it.Prev(); {
+		backward = append(backward, houseArea(t, it))
+	}
+	for i, j := 0, len(reverse)-1; i < j; i, j = i+1, j-1 {
+		reverse[i], reverse[j] = reverse[j], reverse[i]
+		backward[i], backward[j] = backward[j], backward[i]
+	}
+	if !reflect.DeepEqual(forward, want) || !reflect.DeepEqual(reverse, want) || !reflect.DeepEqual(backward, want) {
+		t.Fatalf("%s: forward %v, reverse range %v, prev from end %v, want %v", step, forward, reverse, backward, want)
+	}
+}
+
+func TestIteratorFirstLastSeek(t *testing.T) {
+	for _, backend := range []string{LevelDBBackend, MemoryBackend} {
+		db, dir := openIteratorDataBase(t, backend)
+		insertHouses(t, db, 10, 20, 30, 40, 50)
+
+		idx, err := db.GetIndex("Area", &DbHouse{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		it := idx.Begin()
+		if !it.Last() || houseArea(t, it) != 50 { /* the Name and Tiger entries sort after the Area entries */
+			t.Fatalf("%s: last is not 50", backend)
+		}
+		if !it.First() || houseArea(t, it) != 10 || !idx.CompareBegin(it) {
+			t.Fatalf("%s: first is not 10", backend)
+		}
+
+		key, _ := db.dbPrefix(idx.begin, idx.fieldName, DbHouse{Area: 30})
+		if !it.Seek(key) || houseArea(t, it) != 30 {
+			t.Fatalf("%s: seek to 30", backend)
+		}
+		if !it.Prev() || houseArea(t, it) != 20 || !it.Next() || !it.Next() || houseArea(t, it) != 40 {
+			t.Fatalf("%s: next and prev after seek", backend)
+		}
+		if !it.Seek([]byte("DbHouse__Ar")) || houseArea(t, it) != 10 {
+			t.Fatalf("%s: seek below the index does not stop at its first entry", backend)
+		}
+		if it.Seek(idx.end) || !idx.CompareEnd(it) {
+			t.Fatalf("%s: seek past the index is not the end", backend)
+		}
+		if !it.Prev() || houseArea(t, it) != 50 || it.Next() || !it.End() {
+			t.Fatalf("%s: prev from the end after seek", backend)
+		}
+
+		empty, err := db.GetIndex("byTable", &DbTableIdObject{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		it = empty.Begin()
+		if it.First() || it.Last() || it.Seek(empty.begin) || it.Prev() || !empty.CompareEnd(it) {
+			t.Fatalf("%s: empty index", backend)
+		}
+		if areas := reverseAreas(t, empty, nil, nil, -1); len(areas) != 0 {
+			t.Fatalf("%s: reverse range of an empty index %v", backend, areas)
+		}
+
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReverseRangeWithUndo(t *testing.T) {
+	for _, backend := range []string{LevelDBBackend, MemoryBackend} {
+		db, dir := openIteratorDataBase(t, backend)
+		houses := insertHouses(t, db, 10, 20, 30, 40, 50)
+
+		idx, err := db.GetIndex("Area", &DbHouse{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkAreas(t, backend+" insert", idx, 10, 20, 30, 40, 50)
+
+		bound := func(upper bool, area uint64) Iterator {
+			it, err := idx.LowerBound(DbHouse{Area: area})
+			if upper {
+				it, err = idx.UpperBound(DbHouse{Area: area})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			return it
+		}
+		for _, test := range []struct {
+			lower, upper Iterator
+			limit        int
+			want         []uint64
+		}{
+			{bound(false, 20), bound(true, 40), -1, []uint64{40, 30, 20}},
+			{bound(false, 15), bound(false, 45), -1, []uint64{40, 30, 20}},
+			{nil, bound(true, 40), 2, []uint64{40, 30}},
+			{bound(false, 20), bound(true, 60), -1, []uint64{50, 40, 30, 20}},
+			{bound(false, 60), nil, -1, []uint64{}},
+			{bound(false, 40), bound(false, 20), -1, []uint64{}},
+		} {
+			if areas := reverseAreas(t, idx, test.lower, test.upper, test.limit); !reflect.DeepEqual(areas, test.want) {
+				t.Fatalf("%s: reverse range %v, want %v", backend, areas, test.want)
+			}
+		}
+
+		at40 := bound(false, 40)
+		session := db.StartSession()
+		insertHouses(t, db, 35)
+		db.Modify(houses[20], func(house *DbHouse) { house.Area = 25 })
+		db.Remove(houses[50])
+		checkAreas(t, backend+" session", idx, 10, 25, 30, 35, 40)
+		if areas := reverseAreas(t, idx, nil, at40, -1); !reflect.DeepEqual(areas, []uint64{35, 30, 25, 10}) {
+			t.Fatalf("%s: reverse range below an iterator taken before the session %v", backend, areas)
+		}
+
+		nested := db.StartSession()
+		db.Remove(houses[10])
+		insertHouses(t, db, 5)
+		checkAreas(t, backend+" nested session", idx, 5, 25, 30, 35, 40)
+		if areas := reverseAreas(t, idx, nil, bound(true, 30), -1); !reflect.DeepEqual(areas, []uint64{30, 25, 5}) {
+			t.Fatalf("%s: reverse range in the nested session %v", backend, areas)
+		}
+		nested.Undo()
+		checkAreas(t, backend+" nested undo", idx, 10, 25, 30, 35, 40)
+
+		session.Undo()
+		checkAreas(t, backend+" undo", idx, 10, 20, 30, 40, 50)
+		if areas := reverseAreas(t, idx, bound(false, 20), at40, -1); !reflect.DeepEqual(areas, []uint64{30, 20}) {
+			t.Fatalf("%s: reverse range after undo %v", backend, areas)
+		}
+
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
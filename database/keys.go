@@ -1,30 +1,25 @@
 package database
 
-import (
-	"reflect"
-)
+/*
+*	indexKey returns the type name of an object and the field values it has in the index tagName
+ */
 
-func getFieldInfo(fieldName string, value interface{}) (*fieldInfo, error) {
-	ref := reflect.ValueOf(value)
-	if !ref.IsValid() || reflect.Indirect(ref).Kind() != reflect.Struct {
-		return nil, ErrBadType
+func indexKey(tagName string, value interface{}, skip ...SkipSuffix) ([]byte, []byte, error) {
+	obj, err := objectAccessor(value)
+	if err != nil {
+		return nil, nil, err
 	}
-	if ref.Kind() == reflect.Ptr {
-		ref = ref.Elem()
+	skipNum := 0
+	if len(skip) > 0 {
+		skipNum = int(skip[0])
 	}
-	cfg, err := extractObjectTagInfo(&ref)
+	key, err := obj.DbIndexKey(tagName, skipNum)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	fields, ok := cfg.Fields[fieldName]
-	if !ok {
-		return nil, ErrNotFound
-	}
-	return fields, nil
+	return []byte(obj.DbTypeName()), key, nil
 }
 
-
 func splicingString(k,v[]byte) []byte {
 	key := cloneByte(k)
 	key = append(key, '_')
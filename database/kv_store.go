@@ -0,0 +1,110 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+/*
+*	The key value engines a database can be stored in
+ */
+
+const (
+	LevelDBBackend = "leveldb"
+	MemoryBackend  = "memory"
+)
+
+/*
+*	KVStore is the ordered key value engine under LDataBase.
+*	Get returns leveldb.ErrNotFound for missing keys, a batch is written atomically,
+*	iterators walk the keys in bytewise order and must be released.
+ */
+
+type KVStore interface {
+	Get(key []byte) ([]byte, error)
+
+	Put(key, value []byte) error
+
+	Delete(key []byte) error
+
+	Write(batch *leveldb.Batch) error
+
+	NewIterator(slice *util.Range) iterator
+
+	Close() error
+}
+
+/*
+*	Open the key value engine of a backend, path is not used by the memory backend
+ */
+
+func OpenKVStore(backend, path string) (KVStore, error) {
+	return openKVStore(backend, path, false)
+}
+
+/*
+*	A read only store fails every write, the leveldb files in path are left as they are
+ */
+
+func openKVStore(backend, path string, readOnly bool) (KVStore, error) {
+	switch backend {
+	case LevelDBBackend, "":
+		return openLevelDBStore(path, readOnly)
+	case MemoryBackend:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database backend: %s", backend)
+	}
+}
+
+type levelDBStore struct {
+	db *leveldb.DB
+}
+
+func openLevelDBStore(path string, readOnly bool) (KVStore, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		OpenFilesCacheCapacity: 16,
+		BlockCacheCapacity:     16 / 2 * opt.MiB,
+		WriteBuffer:            16 / 4 * opt.MiB, // Two of these are used internally
+		Filter:                 filter.NewBloomFilter(10),
+		ReadOnly:               readOnly,
+		ErrorIfMissing:         readOnly,
+	})
+
+	if _, corrupted := err.(*errors.ErrCorrupted); corrupted && !readOnly { /* recovering rewrites the manifest */
+		db, err = leveldb.RecoverFile(path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBStore{db: db}, nil
+}
+
+func (s *levelDBStore) Get(key []byte) ([]byte, error) {
+	return s.db.Get(key, nil)
+}
+
+func (s *levelDBStore) Put(key, value []byte) error {
+	return s.db.Put(key, value, nil)
+}
+
+func (s *levelDBStore) Delete(key []byte) error {
+	return s.db.Delete(key, nil)
+}
+
+func (s *levelDBStore) Write(batch *leveldb.Batch) error {
+	return s.db.Write(batch, nil)
+}
+
+func (s *levelDBStore) NewIterator(slice *util.Range) iterator {
+	return s.db.NewIterator(slice, nil)
+}
+
+func (s *levelDBStore) Close() error {
+	return s.db.Close()
+}
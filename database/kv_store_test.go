@@ -0,0 +1,143 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+func openBackend(t *testing.T, backend string) (DataBase, func()) {
+	dir, err := ioutil.TempDir("", "kv_store_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenDataBase(backend, dir, logFlag)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func readCodeIndex(t *testing.T, db DataBase) []DbTableIdObject {
+	idx, err := db.GetIndex("Code", DbTableIdObject{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := []DbTableIdObject{}
+	for it := idx.Begin(); !idx.CompareEnd(it); it.Next() {
+		obj := DbTableIdObject{}
+		if err := it.Data(&obj); err != nil {
+			t.Fatal(err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+func checkObjects(t *testing.T, backend, step string, got, want []DbTableIdObject) {
+	if len(got) != len(want) {
+		t.Fatalf("%s %s: got %d objects, want %d", backend, step, len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s %s: object %d is %v, want %v", backend, step, i, got[i], want[i])
+		}
+	}
+}
+
+func TestBackendsUndoSemantics(t *testing.T) {
+	for _, backend := range []string{LevelDBBackend, MemoryBackend} {
+		db, clo := openBackend(t, backend)
+
+		objs, _ := Objects()
+		for i := 0; i < 3; i++ {
+			if err := db.Insert(&objs[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		db.SetRevision(10)
+		base := append([]DbTableIdObject(nil), objs[:3]...)
+
+		session := db.StartSession()
+		for i := 3; i < 6; i++ {
+			if err := db.Insert(&objs[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.Modify(&objs[0], func(obj *DbTableIdObject) { obj.Count = 99 }); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Remove(&objs[1]); err != nil {
+			t.Fatal(err)
+		}
+		session.Push()
+		pushed := []DbTableIdObject{objs[0], objs[2], objs[3], objs[4], objs[5]}
+		checkObjects(t, backend, "push", readCodeIndex(t, db), pushed)
+
+		session = db.StartSession()
+		if err := db.Modify(&objs[3], func(obj *DbTableIdObject) { obj.Count = 77 }); err != nil {
+			t.Fatal(err)
+		}
+		session.Undo()
+		checkObjects(t, backend, "session undo", readCodeIndex(t, db), pushed)
+		if db.Revision() != 11 {
+			t.Fatalf("%s: revision %d, want 11", backend, db.Revision())
+		}
+
+		db.Undo()
+		checkObjects(t, backend, "undo", readCodeIndex(t, db), base)
+		if db.Revision() != 10 {
+			t.Fatalf("%s: revision %d, want 10", backend, db.Revision())
+		}
+
+		found := DbTableIdObject{}
+		if err := db.Find("Code", DbTableIdObject{Code: base[1].Code}, &found); err != nil || found != base[1] {
+			t.Fatalf("%s: removed object not restored: %v %v", backend, found, err)
+		}
+		clo()
+	}
+}
+
+func TestMemoryStoreCompaction(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	value := make([]byte, 1024*1024)
+	for i := 0; i < 64; i++ {
+		value[0] = byte(i)
+		if err := store.Put([]byte("key"), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.garbage >= memoryStoreMinGarbage {
+		t.Fatalf("garbage %d was not compacted", store.garbage)
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put([]byte("a"), []byte("1"))
+	batch.Put([]byte("b"), []byte("2"))
+	batch.Delete([]byte("key"))
+	if err := store.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get([]byte("key")); err != leveldb.ErrNotFound {
+		t.Fatalf("deleted key found: %v", err)
+	}
+
+	it := store.NewIterator(util.BytesPrefix(nil))
+	defer it.Release()
+	keys := ""
+	for it.Next() {
+		keys += string(it.Key())
+	}
+	if keys != "ab" {
+		t.Fatalf("keys %q, want \"ab\"", keys)
+	}
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	memoryStoreCapacity   = 4 * 1024 * 1024
+	memoryStoreMinGarbage = 16 * 1024 * 1024
+)
+
+/*
+*	MemoryStore keeps the keys in an in-memory skiplist, nothing is written to disk and nothing survives Close.
+*	Iterators see the writes made after they were created, which leveldb iterators do not, the database code
+*	does not rely on either behaviour.
+*	The skiplist never frees the space of overwritten and deleted values, so once that garbage outgrows the
+*	live data the live keys are copied to a new skiplist, iterators created before keep walking the old one.
+ */
+
+type MemoryStore struct {
+	lock    sync.Mutex
+	db      *memdb.DB
+	garbage int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{db: memdb.New(comparer.DefaultComparer, memoryStoreCapacity)}
+}
+
+func (s *MemoryStore) Get(key []byte) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	value, err := s.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *MemoryStore) Put(key, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.put(key, value)
+	s.compact()
+	return nil
+}
+
+func (s *MemoryStore) Delete(key []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.delete(key)
+	s.compact()
+	return nil
+}
+
+func (s *MemoryStore) Write(batch *leveldb.Batch) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := batch.Replay(memoryBatchReplay{s}); err != nil {
+		return err
+	}
+	s.compact()
+	return nil
+}
+
+func (s *MemoryStore) NewIterator(slice *util.Range) iterator {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.db.NewIterator(slice)
+}
+
+func (s *MemoryStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.db.Reset()
+	s.garbage = 0
+	return nil
+}
+
+func (s *MemoryStore) put(key, value []byte) {
+	if old, err := s.db.Get(key); err == nil {
+		s.garbage += len(key) + len(old)
+	}
+	s.db.Put(key, value)
+}
+
+func (s *MemoryStore) delete(key []byte) {
+	if old, err := s.db.Get(key); err == nil {
+		s.garbage += len(key) + len(old)
+		s.db.Delete(key)
+	}
+}
+
+func (s *MemoryStore) compact() {
+	if s.garbage < memoryStoreMinGarbage || s.garbage < s.db.Size() {
+		return
+	}
+	db := memdb.New(comparer.DefaultComparer, s.db.Size())
+	it := s.db.NewIterator(nil)
+	for it.Next() {
+		db.Put(it.Key(), it.Value())
+	}
+	it.Release()
+	s.db = db
+	s.garbage = 0
+}
+
+type memoryBatchReplay struct {
+	store *MemoryStore
+}
+
+func (r memoryBatchReplay) Put(key, value []byte) {
+	r.store.put(key, value)
+}
+
+func (r memoryBatchReplay) Delete(key []byte) {
+	r.store.delete(key)
+}
@@ -218,3 +218,47 @@ func (index *MultiIndex) IteratorTo(in interface{}) Iterator {
 func (index *MultiIndex) Empty() bool {
 	return index.db.Empty(index.begin, index.end, index.fieldName)
 }
+
+/*
+
+--> for it := upper - 1; it >= lower; it-- <--
+
+@param lower 		--> 	Iterator, nil for idx.begin()
+@param upper 		--> 	Iterator, nil for idx.end()
+@param fn 			--> 	called with the iterator at each row from the last row below upper down to lower,
+							the walk stops when it returns false
+
+*/
+
+func (index *MultiIndex) ReverseRange(lower, upper Iterator, fn func(it Iterator) bool) {
+	begin, end := index.begin, index.end
+	if lower != nil {
+		begin = index.boundKey(lower)
+	}
+	if upper != nil {
+		end = index.boundKey(upper)
+	}
+	if bytes.Compare(begin, end) >= 0 {
+		return
+	}
+
+	it, err := index.db.LastIterator(begin, end, index.typeName)
+	if err != nil {
+		return
+	}
+	defer it.Release()
+	for ok := true; ok && fn(it); ok = it.Prev() {
+	}
+}
+
+/* boundKey is the key of the entry an iterator of the index is at, the end of the index for idx.end() */
+func (index *MultiIndex) boundKey(in Iterator) []byte {
+	if in.End() {
+		return index.end
+	}
+	it, ok := in.(*DbIterator)
+	if !ok || it.it == nil || it.it.Key() == nil { /* moved before the first row */
+		return index.begin
+	}
+	return cloneByte(it.it.Key())
+}
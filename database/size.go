@@ -0,0 +1,108 @@
+package database
+
+import (
+	"bytes"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const dbSize = "db_size"
+
+/*
+*	The database keeps the logical size of the live state of every type: the bytes of the keys and values of
+*	its objects and of their index keys. It is what the state would take in a store without any overhead, so it
+*	does not move with the compactions of the engine and is the same on every backend.
+*	The sizes are written on Close and removed when the database is opened, a database that was not closed
+*	cleanly has them counted again from its keys. A read only database leaves them where they are.
+ */
+
+func readSizeFromDb(db KVStore, readOnly bool) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+
+	key := []byte(dbSize)
+	val, err := db.Get(key)
+	if err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	if err == nil {
+		if err = DecodeBytes(val, &sizes); err != nil {
+			return nil, err
+		}
+		if readOnly {
+			return sizes, nil
+		}
+		return sizes, db.Delete(key)
+	}
+
+	it := db.NewIterator(nil) /* typeName__id and typeName__tagName__fieldValue_, the other keys have no "__" */
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if i := bytes.Index(key, []byte("__")); i > 0 {
+			sizes[string(key[:i])] += int64(len(key) + len(it.Value()))
+		}
+	}
+	return sizes, nil
+}
+
+func (ldb *LDataBase) writeSizeToDb() error {
+	val, err := EncodeToBytes(ldb.sizes)
+	if err != nil {
+		return err
+	}
+	return ldb.db.Put([]byte(dbSize), val)
+}
+
+func (ldb *LDataBase) accountSize(dbKV *dbKeyValue, sign int64) {
+	size := int64(len(dbKV.Idk.Key) + len(dbKV.Idk.Value))
+	for _, v := range dbKV.Index {
+		size += int64(len(v.Key) + len(v.Value))
+	}
+	ldb.sizes[string(dbKV.TypeName)] += sign * size
+	ldb.size += sign * size
+}
+
+/*
+*	Size returns the logical size of the live state
+ */
+
+func (ldb *LDataBase) Size() uint64 {
+	return uint64(ldb.size)
+}
+
+/*
+*	TypeSizes returns the logical size of the live state of every type
+ */
+
+func (ldb *LDataBase) TypeSizes() map[string]uint64 {
+	sizes := make(map[string]uint64, len(ldb.sizes))
+	for typeName, size := range ldb.sizes {
+		if size != 0 {
+			sizes[typeName] = uint64(size)
+		}
+	}
+	return sizes
+}
+
+/*
+*	SetMaxSize sets the size the state is allowed to grow to, FreeSize is measured against it
+ */
+
+func (ldb *LDataBase) SetMaxSize(size uint64) {
+	ldb.maxSize = size
+}
+
+func (ldb *LDataBase) MaxSize() uint64 {
+	return ldb.maxSize
+}
+
+/*
+*	FreeSize returns what is left of the max size, 0 once the state has outgrown it
+ */
+
+func (ldb *LDataBase) FreeSize() uint64 {
+	if ldb.Size() >= ldb.maxSize {
+		return 0
+	}
+	return ldb.maxSize - ldb.Size()
+}
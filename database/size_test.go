@@ -0,0 +1,92 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func checkSize(t *testing.T, step string, db DataBase) {
+	ldb := db.(*LDataBase)
+	counted, err := readSizeFromDb(ldb.db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := uint64(0)
+	for typeName, size := range counted {
+		if db.TypeSizes()[typeName] != uint64(size) {
+			t.Fatalf("%s: %s size %d, counted %d", step, typeName, db.TypeSizes()[typeName], size)
+		}
+		total += uint64(size)
+	}
+	if db.Size() != total || len(db.TypeSizes()) != len(counted) {
+		t.Fatalf("%s: size %d of %d types, counted %d of %d types", step, db.Size(), len(db.TypeSizes()), total, len(counted))
+	}
+}
+
+func TestSizeTracking(t *testing.T) {
+	dir, err := ioutil.TempDir("", "size_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := OpenDataBase(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Size() != 0 {
+		t.Fatalf("new database has size %d", db.Size())
+	}
+
+	objs, houses := Objects()
+	for i := range objs {
+		houses[i].Name = string(rune('a' + i)) /* Name is unique */
+		if err := db.Insert(&objs[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Insert(&houses[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkSize(t, "insert", db)
+	inserted := db.Size()
+
+	session := db.StartSession()
+	if err := db.Modify(&houses[0], func(house *DbHouse) { house.Name = "a longer name than before" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Remove(&objs[1]); err != nil {
+		t.Fatal(err)
+	}
+	checkSize(t, "modify and remove", db)
+	if db.Size() == inserted {
+		t.Fatal("size did not change")
+	}
+	session.Undo()
+	checkSize(t, "undo", db)
+	if db.Size() != inserted {
+		t.Fatalf("size %d after undo, want %d", db.Size(), inserted)
+	}
+
+	db.SetMaxSize(inserted + 100)
+	if db.FreeSize() != 100 {
+		t.Fatalf("free size %d, want 100", db.FreeSize())
+	}
+	db.SetMaxSize(inserted - 1)
+	if db.FreeSize() != 0 {
+		t.Fatalf("free size %d of a full database", db.FreeSize())
+	}
+
+	sizes := db.TypeSizes()
+	db.Close()
+	db, err = OpenDataBase(LevelDBBackend, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if db.Size() != inserted || len(db.TypeSizes()) != len(sizes) {
+		t.Fatalf("size %d after reopen, want %d", db.Size(), inserted)
+	}
+	checkSize(t, "reopen", db)
+}
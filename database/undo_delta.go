@@ -0,0 +1,67 @@
+package database
+
+import "sort"
+
+/////////////////////////////////////////////////////// UndoDelta  //////////////////////////////////////////////////////////
+
+/*
+*	A row changed by an undo session
+*	Present is false for a removed row, Value is the row packed as it is stored in the database
+ */
+type RowDelta struct {
+	Id      int64
+	Present bool
+	Value   []byte
+}
+
+type TableDelta struct {
+	Name string
+	Rows []RowDelta
+}
+
+/*
+*	The rows inserted, modified and removed by the newest undo session
+*	inserted and modified rows carry their current value, removed rows the value they had
+*	tables are sorted by name and rows by id
+ */
+func (ldb *LDataBase) LastSessionDeltas() []TableDelta {
+	stack := ldb.getStack()
+	if stack == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(stack.Undo))
+	for name := range stack.Undo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deltas := make([]TableDelta, 0, len(names))
+	for _, name := range names {
+		state := stack.Undo[name]
+		delta := TableDelta{Name: name}
+
+		for _, values := range []map[int64]*modifyValue{state.NewValue, state.OldValue} {
+			for id, value := range values {
+				current, err := getDbKey(value.OldKv.Idk.Key, ldb.db)
+				if err != nil {
+					ldb.log.Error("changed row %d of %s not found : %s", id, name, err.Error())
+					continue
+				}
+				delta.Rows = append(delta.Rows, RowDelta{Id: id, Present: true, Value: current})
+			}
+		}
+		for id, value := range state.RemoveValue {
+			delta.Rows = append(delta.Rows, RowDelta{Id: id, Present: false, Value: value.OldKv.Idk.Value})
+		}
+
+		if len(delta.Rows) == 0 {
+			continue
+		}
+		sort.Slice(delta.Rows, func(i, j int) bool {
+			return delta.Rows[i].Id < delta.Rows[j].Id
+		})
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
@@ -13,6 +13,9 @@ type undoState struct {
 	OldValue    map[int64]*modifyValue
 }
 
+/* the format of the undo stack written on Close, version 1 keeps the keys of its rows */
+const undoStackVersion = uint32(1)
+
 type undoContainer struct {
 	Undo      map[string]*undoState
 	OldIds    map[string]int64
@@ -25,7 +28,7 @@ func newUndoContainer(reversion int64, oldIds map[string]int64) *undoContainer {
 	for k, v := range oldIds {
 		oldIds_[k] = v
 	}
-	return &undoContainer{Undo: make(map[string]*undoState), OldIds: oldIds, Reversion: reversion}
+	return &undoContainer{Undo: make(map[string]*undoState), OldIds: oldIds_, Reversion: reversion}
 }
 
 func newUndoState() *undoState {
@@ -90,9 +93,9 @@ func (stack *undoState) undoStackRemove(value *modifyValue) {
 		delete(stack.NewValue, id)
 		return
 	}
-	_, ok = stack.OldValue[id]
-	if ok {
-		stack.RemoveValue[id] = value
+	old, ok := stack.OldValue[id]
+	if ok { /* undo restores the row as it was before the session, not as it was modified */
+		value = &modifyValue{Id: id, OldKv: old.OldKv, NewKv: old.OldKv}
 		delete(stack.OldValue, id)
 	}
 
@@ -104,12 +107,14 @@ func (stack *undoState) undoStackRemove(value *modifyValue) {
 	stack.RemoveValue[id] = value
 }
 
-func (stack *undoState) undoStackModify(value *modifyValue) {
+func (stack *undoState) undoStackModify(value *modifyValue) { /* keep the latest value, undo removes its keys */
 	id := value.Id
-	if _, ok := stack.NewValue[id]; ok {
+	if inserted, ok := stack.NewValue[id]; ok {
+		inserted.NewKv = value.NewKv
 		return
 	}
-	if _, ok := stack.OldValue[id]; ok {
+	if modified, ok := stack.OldValue[id]; ok {
+		modified.NewKv = value.NewKv
 		return
 	}
 	stack.OldValue[id] = value
@@ -0,0 +1,184 @@
+package entity
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/eosspark/eos-go/crypto/ecc"
+	"github.com/eosspark/eos-go/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func accessorObjects() []database.MutableObjectAccessor {
+	return []database.MutableObjectAccessor{
+		&AccountControlHistoryObject{}, &AccountHistoryObject{}, &ActionHistoryObject{}, &AccountObject{},
+		&AccountSequenceObject{}, &BlockSummaryObject{}, &TableIdObject{}, &KeyValueObject{}, &Idx64Object{},
+		&Idx128Object{}, &Idx256Object{}, &IdxDoubleObject{}, &IdxLongDoubleObject{},
+		&GeneratedTransactionObject{}, &GlobalPropertyObject{}, &DynamicGlobalPropertyObject{},
+		&PermissionLinkObject{}, &PermissionObject{}, &PermissionUsageObject{}, &ProducerObject{},
+		&PublicKeyHistoryObject{}, &ResourceLimitsConfigObject{}, &ResourceLimitsObject{},
+		&ResourceLimitsStateObject{}, &ResourceUsageObject{}, &ReversibleBlockObject{}, &TransactionObject{},
+	}
+}
+
+/*
+ * fill sets every exported field of v to a random value, keys and signatures get the size the encoder expects
+ */
+func fill(r *rand.Rand, v reflect.Value) {
+	switch v.Interface().(type) {
+	case ecc.Signature:
+		content := make([]byte, 65)
+		r.Read(content)
+		v.Set(reflect.ValueOf(ecc.Signature{Curve: ecc.CurveK1, Content: content}))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63() - r.Int63())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(r.Uint64())
+	case reflect.String:
+		v.SetString(string(rune('a' + r.Intn(26))))
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1+r.Intn(3), 3))
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(r, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				fill(r, v.Field(i))
+			}
+		}
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(r, v.Elem())
+	}
+}
+
+func TestGeneratedAccessors(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, obj := range accessorObjects() {
+		for round := 0; round < 10; round++ {
+			fill(r, reflect.ValueOf(obj).Elem())
+			name := reflect.TypeOf(obj).Elem().Name()
+
+			reflected, err := database.ReflectAccessor(obj)
+			assert.NoError(t, err, name)
+			assert.Equal(t, reflected.DbTypeName(), obj.DbTypeName(), name)
+			assert.Equal(t, reflected.DbId(), obj.DbId(), name)
+
+			value, err := obj.DbEncode()
+			assert.NoError(t, err, name)
+			reflectedValue, err := database.EncodeToBytes(obj)
+			assert.NoError(t, err, name)
+			assert.Equal(t, reflectedValue, value, name)
+
+			tags, reflectedTags := obj.DbIndexes(), reflected.DbIndexes()
+			sort.Strings(reflectedTags)
+			assert.Equal(t, reflectedTags, sortedCopy(tags), name)
+
+			for _, tag := range tags {
+				for skip := 0; skip < 3; skip++ {
+					key, err := obj.DbIndexKey(tag, skip)
+					assert.NoError(t, err, name)
+					reflectedKey, err := reflected.DbIndexKey(tag, skip)
+					assert.NoError(t, err, name)
+					assert.Equal(t, string(reflectedKey), string(key), "%s %s skip %d", name, tag, skip)
+				}
+			}
+			_, err = obj.DbIndexKey("noSuchIndex", 0)
+			assert.Equal(t, database.ErrNotFound, err, name)
+
+			obj.DbSetId(42)
+			assert.Equal(t, int64(42), obj.DbId(), name)
+		}
+	}
+}
+
+func sortedCopy(tags []string) []string {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+/*
+ * reflectKeyValueObject has the tags of KeyValueObject but no generated accessor, so the database goes through reflect
+ */
+type reflectKeyValueObject KeyValueObject
+
+/*
+ * benchmarkApply runs the database calls a contract writing a row makes: find the row, insert it, modify it,
+ * and remove one row out of two, in a session like the transactions of a block
+ */
+func benchmarkApply(b *testing.B, newObject func(key uint64) interface{}, modify func(db database.DataBase, obj interface{}) error) {
+	db, err := database.OpenDataBase(database.MemoryBackend, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	session := db.StartSession()
+	defer session.Undo()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		obj := newObject(uint64(i))
+		if err := db.Find("byScopePrimary", obj, newObject(0)); err != database.ErrNotFound {
+			b.Fatal("row already exists", err)
+		}
+		if err := db.Insert(obj); err != nil {
+			b.Fatal(err)
+		}
+		if err := modify(db, obj); err != nil {
+			b.Fatal(err)
+		}
+		if i%2 == 1 {
+			if err := db.Remove(obj); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	value := make([]byte, 64)
+	b.Run("generated", func(b *testing.B) {
+		benchmarkApply(b, func(key uint64) interface{} {
+			return &KeyValueObject{TId: 1, PrimaryKey: key, Value: value}
+		}, func(db database.DataBase, obj interface{}) error {
+			return db.Modify(obj, func(obj *KeyValueObject) { obj.Payer++ })
+		})
+	})
+	b.Run("reflect", func(b *testing.B) {
+		benchmarkApply(b, func(key uint64) interface{} {
+			return &reflectKeyValueObject{TId: 1, PrimaryKey: key, Value: value}
+		}, func(db database.DataBase, obj interface{}) error {
+			return db.Modify(obj, func(obj *reflectKeyValueObject) { obj.Payer++ })
+		})
+	})
+}
+
+func BenchmarkEncode(b *testing.B) {
+	obj := &PermissionObject{}
+	fill(rand.New(rand.NewSource(1)), reflect.ValueOf(obj).Elem())
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			obj.DbEncode()
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			database.EncodeToBytes(obj)
+		}
+	})
+}
@@ -0,0 +1,12 @@
+package entity
+
+import (
+	"github.com/eosspark/eos-go/common"
+)
+
+type AccountControlHistoryObject struct {
+	ControlledAccount    common.AccountName    `multiIndex:"byControlledAuthority,orderedUnique"`
+	ControlledPermission common.PermissionName `multiIndex:"byControlledAuthority,orderedUnique"`
+	ControllingAccount   common.AccountName    `multiIndex:"byControlling,orderedUnique:byControlledAuthority,orderedUnique"`
+	ID                   common.IdType         `multiIndex:"id,increment,byControlling"`
+}
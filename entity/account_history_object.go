@@ -12,13 +12,14 @@ type AccountHistoryObject struct {
 	AccountSequenceNum int32 `multiIndex:"byAccountActionSeq,orderedUnique"`
 }
 
+//TrxId is declared before ActionSequenceNum so that byTrxId is ordered by (TrxId, ActionSequenceNum)
 type ActionHistoryObject struct {
-	ID                common.IdType `multiIndex:"id,increment"`
-	ActionSequenceNum uint64        `multiIndex:"byActionSequenceNum,orderedUnique:byTrxId,orderedUnique"`
+	ID                common.IdType            `multiIndex:"id,increment"`
+	TrxId             common.TransactionIdType `multiIndex:"byTrxId,orderedUnique"`
+	ActionSequenceNum uint64                   `multiIndex:"byActionSequenceNum,orderedUnique:byTrxId,orderedUnique"`
 	PackedActionTrace common.HexBytes
 	BlockNum          uint32
 	BlockTime         types.BlockTimeStamp
-	TrxId             common.TransactionIdType `multiIndex:"byTrxId,orderedUnique"`
 }
 
 //type FilterEntry struct {
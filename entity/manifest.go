@@ -0,0 +1,3 @@
+package entity
+
+//go:generate go run github.com/eosspark/eos-go/database/dbgen
@@ -5,9 +5,10 @@ import (
 	"github.com/eosspark/eos-go/crypto/ecc"
 )
 
+//ID is the last field so that it is appended as the tie-breaker of byPubKey and byAccountPermission
 type PublicKeyHistoryObject struct {
-	ID         common.IdType         `multiIndex:"id,increment,byPubKey,byAccountPermission"`
 	PublicKey  ecc.PublicKey         `multiIndex:"byPubKey,orderedUnique"`            //c++ publicKey+id unique
 	Name       common.AccountName    `multiIndex:"byAccountPermission,orderedUnique"` //c++ ByAccountPermission+id unique
 	Permission common.PermissionName `multiIndex:"byAccountPermission,orderedUnique"` //c++ ByAccountPermission+id unique
+	ID         common.IdType         `multiIndex:"id,increment,byPubKey,byAccountPermission"`
 }
@@ -355,3 +355,15 @@ type ContractApiExceptions interface {
 type _ContractApiException struct{ _ChainException }
 
 func (_ContractApiException) ContractApiExceptions() {}
+
+/**
+ * snapshot_exception
+ */
+type SnapshotExceptions interface {
+	ChainExceptions
+	SnapshotExceptions()
+}
+
+type _SnapshotException struct{ _ChainException }
+
+func (_SnapshotException) SnapshotExceptions() {}
@@ -27,6 +27,7 @@ package exception
  *   |- resource_limit_exception		 >3210000
  *   |- mongo_db_exception 				 >3220000
  *   |- contract_api_exception  		 >3230000
+ *   |- snapshot_exception  			 >3240000
 */
 
 //TODO: go get gotemplate
@@ -142,6 +143,7 @@ package exception
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "WasmSerializationError (_WasmException,3070003,\"Serialization Error Processing WASM\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "OverlappingMemoryError (_WasmException,3070004,\"memcpy with overlapping memory\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "BinaryenException (_WasmException,3070005,\"binaryen exception\")"
+//go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "WasmGasExceeded (_WasmException,3070006,\"WASM instruction weight limit exceeded\")"
 
 //_ResourceExhaustedException
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ResourceExhaustedException (_ResourceExhaustedException,3080000,\"Resource exhausted exception\")"
@@ -255,7 +257,9 @@ package exception
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "MissingPendingBlockState (_ProducerException,3170002,\"Pending block state is missing\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ProducerDoubleConfirm (_ProducerException,3170003,\"Producer is double confirming known range\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ProducerScheduleException (_ProducerException,3170004,\"Producer schedule exception\")"
+//go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "SignatureProviderException (_ProducerException,3170005,\"Signature provider failed to sign\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ProducerNotInSchedule (_ProducerException,3170006,\"The producer is not part of current schedule\")"
+//go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ProducerDoubleSign (_ProducerException,3170100,\"Producer would sign below its watermark\")"
 
 //_ReversibleBlocksException
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ReversibleBlocksException (_ReversibleBlocksException,3180000,\"Reversible Blocks exception\")"
@@ -290,6 +294,10 @@ package exception
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "DbApiException (_ContractApiException,3230002,\"Database API exception\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ArithmeticException (_ContractApiException,3230003,\"Arithmetic exception\")"
 
+//_SnapshotException
+//go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "SnapshotException (_SnapshotException,3240000,\"Snapshot exception\")"
+//go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "SnapshotValidationException (_SnapshotException,3240001,\"Snapshot Validation Exception\")"
+
 // Exception in plugin
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "ExplainedException(Exception,9000000,\"explained exception,see error log\")"
 //go:generate gotemplate -outfmt "gen_%v" "github.com/eosspark/eos-go/exception/template" "LocalizedException(Exception,10000000,\"an error occured\")"
@@ -0,0 +1,105 @@
+// Code generated by gotemplate. DO NOT EDIT.
+
+package exception
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/eosspark/eos-go/log"
+)
+
+// template type Exception(PARENT,CODE,WHAT)
+
+var ProducerDoubleSignName = reflect.TypeOf(ProducerDoubleSign{}).Name()
+
+type ProducerDoubleSign struct {
+	_ProducerException
+	Elog log.Messages
+}
+
+func NewProducerDoubleSign(parent _ProducerException, message log.Message) *ProducerDoubleSign {
+	return &ProducerDoubleSign{parent, log.Messages{message}}
+}
+
+func (e ProducerDoubleSign) Code() int64 {
+	return 3170100
+}
+
+func (e ProducerDoubleSign) Name() string {
+	return ProducerDoubleSignName
+}
+
+func (e ProducerDoubleSign) What() string {
+	return "Producer would sign below its watermark"
+}
+
+func (e *ProducerDoubleSign) AppendLog(l log.Message) {
+	e.Elog = append(e.Elog, l)
+}
+
+func (e ProducerDoubleSign) GetLog() log.Messages {
+	return e.Elog
+}
+
+func (e ProducerDoubleSign) TopMessage() string {
+	for _, l := range e.Elog {
+		if msg := l.GetMessage(); len(msg) > 0 {
+			return msg
+		}
+	}
+	return e.String()
+}
+
+func (e ProducerDoubleSign) DetailMessage() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(strconv.Itoa(int(e.Code())))
+	buffer.WriteByte(' ')
+	buffer.WriteString(e.Name())
+	buffer.Write([]byte{':', ' '})
+	buffer.WriteString(e.What())
+	buffer.WriteByte('\n')
+	for _, l := range e.Elog {
+		buffer.WriteByte('[')
+		buffer.WriteString(l.GetMessage())
+		buffer.Write([]byte{']', ' '})
+		buffer.WriteString(l.GetContext().String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+func (e ProducerDoubleSign) String() string {
+	return e.DetailMessage()
+}
+
+func (e ProducerDoubleSign) MarshalJSON() ([]byte, error) {
+	type Exception struct {
+		Code int64  `json:"code"`
+		Name string `json:"name"`
+		What string `json:"what"`
+	}
+
+	except := Exception{
+		Code: 3170100,
+		Name: ProducerDoubleSignName,
+		What: "Producer would sign below its watermark",
+	}
+
+	return json.Marshal(except)
+}
+
+func (e ProducerDoubleSign) Callback(f interface{}) bool {
+	switch callback := f.(type) {
+	case func(*ProducerDoubleSign):
+		callback(&e)
+		return true
+	case func(ProducerDoubleSign):
+		callback(e)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+// Code generated by gotemplate. DO NOT EDIT.
+
+package exception
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/eosspark/eos-go/log"
+)
+
+// template type Exception(PARENT,CODE,WHAT)
+
+var SignatureProviderExceptionName = reflect.TypeOf(SignatureProviderException{}).Name()
+
+type SignatureProviderException struct {
+	_ProducerException
+	Elog log.Messages
+}
+
+func NewSignatureProviderException(parent _ProducerException, message log.Message) *SignatureProviderException {
+	return &SignatureProviderException{parent, log.Messages{message}}
+}
+
+func (e SignatureProviderException) Code() int64 {
+	return 3170005
+}
+
+func (e SignatureProviderException) Name() string {
+	return SignatureProviderExceptionName
+}
+
+func (e SignatureProviderException) What() string {
+	return "Signature provider failed to sign"
+}
+
+func (e *SignatureProviderException) AppendLog(l log.Message) {
+	e.Elog = append(e.Elog, l)
+}
+
+func (e SignatureProviderException) GetLog() log.Messages {
+	return e.Elog
+}
+
+func (e SignatureProviderException) TopMessage() string {
+	for _, l := range e.Elog {
+		if msg := l.GetMessage(); len(msg) > 0 {
+			return msg
+		}
+	}
+	return e.String()
+}
+
+func (e SignatureProviderException) DetailMessage() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(strconv.Itoa(int(e.Code())))
+	buffer.WriteByte(' ')
+	buffer.WriteString(e.Name())
+	buffer.Write([]byte{':', ' '})
+	buffer.WriteString(e.What())
+	buffer.WriteByte('\n')
+	for _, l := range e.Elog {
+		buffer.WriteByte('[')
+		buffer.WriteString(l.GetMessage())
+		buffer.Write([]byte{']', ' '})
+		buffer.WriteString(l.GetContext().String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+func (e SignatureProviderException) String() string {
+	return e.DetailMessage()
+}
+
+func (e SignatureProviderException) MarshalJSON() ([]byte, error) {
+	type Exception struct {
+		Code int64  `json:"code"`
+		Name string `json:"name"`
+		What string `json:"what"`
+	}
+
+	except := Exception{
+		Code: 3170005,
+		Name: SignatureProviderExceptionName,
+		What: "Signature provider failed to sign",
+	}
+
+	return json.Marshal(except)
+}
+
+func (e SignatureProviderException) Callback(f interface{}) bool {
+	switch callback := f.(type) {
+	case func(*SignatureProviderException):
+		callback(&e)
+		return true
+	case func(SignatureProviderException):
+		callback(e)
+		return true
+	default:
+		return false
+	}
+}
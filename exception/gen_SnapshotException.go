@@ -0,0 +1,105 @@
+// Code generated by gotemplate. DO NOT EDIT.
+
+package exception
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/eosspark/eos-go/log"
+)
+
+// template type Exception(PARENT,CODE,WHAT)
+
+var SnapshotExceptionName = reflect.TypeOf(SnapshotException{}).Name()
+
+type SnapshotException struct {
+	_SnapshotException
+	Elog log.Messages
+}
+
+func NewSnapshotException(parent _SnapshotException, message log.Message) *SnapshotException {
+	return &SnapshotException{parent, log.Messages{message}}
+}
+
+func (e SnapshotException) Code() int64 {
+	return 3240000
+}
+
+func (e SnapshotException) Name() string {
+	return SnapshotExceptionName
+}
+
+func (e SnapshotException) What() string {
+	return "Snapshot exception"
+}
+
+func (e *SnapshotException) AppendLog(l log.Message) {
+	e.Elog = append(e.Elog, l)
+}
+
+func (e SnapshotException) GetLog() log.Messages {
+	return e.Elog
+}
+
+func (e SnapshotException) TopMessage() string {
+	for _, l := range e.Elog {
+		if msg := l.GetMessage(); len(msg) > 0 {
+			return msg
+		}
+	}
+	return e.String()
+}
+
+func (e SnapshotException) DetailMessage() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(strconv.Itoa(int(e.Code())))
+	buffer.WriteByte(' ')
+	buffer.WriteString(e.Name())
+	buffer.Write([]byte{':', ' '})
+	buffer.WriteString(e.What())
+	buffer.WriteByte('\n')
+	for _, l := range e.Elog {
+		buffer.WriteByte('[')
+		buffer.WriteString(l.GetMessage())
+		buffer.Write([]byte{']', ' '})
+		buffer.WriteString(l.GetContext().String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+func (e SnapshotException) String() string {
+	return e.DetailMessage()
+}
+
+func (e SnapshotException) MarshalJSON() ([]byte, error) {
+	type Exception struct {
+		Code int64  `json:"code"`
+		Name string `json:"name"`
+		What string `json:"what"`
+	}
+
+	except := Exception{
+		Code: 3240000,
+		Name: SnapshotExceptionName,
+		What: "Snapshot exception",
+	}
+
+	return json.Marshal(except)
+}
+
+func (e SnapshotException) Callback(f interface{}) bool {
+	switch callback := f.(type) {
+	case func(*SnapshotException):
+		callback(&e)
+		return true
+	case func(SnapshotException):
+		callback(e)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+// Code generated by gotemplate. DO NOT EDIT.
+
+package exception
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/eosspark/eos-go/log"
+)
+
+// template type Exception(PARENT,CODE,WHAT)
+
+var SnapshotValidationExceptionName = reflect.TypeOf(SnapshotValidationException{}).Name()
+
+type SnapshotValidationException struct {
+	_SnapshotException
+	Elog log.Messages
+}
+
+func NewSnapshotValidationException(parent _SnapshotException, message log.Message) *SnapshotValidationException {
+	return &SnapshotValidationException{parent, log.Messages{message}}
+}
+
+func (e SnapshotValidationException) Code() int64 {
+	return 3240001
+}
+
+func (e SnapshotValidationException) Name() string {
+	return SnapshotValidationExceptionName
+}
+
+func (e SnapshotValidationException) What() string {
+	return "Snapshot Validation Exception"
+}
+
+func (e *SnapshotValidationException) AppendLog(l log.Message) {
+	e.Elog = append(e.Elog, l)
+}
+
+func (e SnapshotValidationException) GetLog() log.Messages {
+	return e.Elog
+}
+
+func (e SnapshotValidationException) TopMessage() string {
+	for _, l := range e.Elog {
+		if msg := l.GetMessage(); len(msg) > 0 {
+			return msg
+		}
+	}
+	return e.String()
+}
+
+func (e SnapshotValidationException) DetailMessage() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(strconv.Itoa(int(e.Code())))
+	buffer.WriteByte(' ')
+	buffer.WriteString(e.Name())
+	buffer.Write([]byte{':', ' '})
+	buffer.WriteString(e.What())
+	buffer.WriteByte('\n')
+	for _, l := range e.Elog {
+		buffer.WriteByte('[')
+		buffer.WriteString(l.GetMessage())
+		buffer.Write([]byte{']', ' '})
+		buffer.WriteString(l.GetContext().String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+func (e SnapshotValidationException) String() string {
+	return e.DetailMessage()
+}
+
+func (e SnapshotValidationException) MarshalJSON() ([]byte, error) {
+	type Exception struct {
+		Code int64  `json:"code"`
+		Name string `json:"name"`
+		What string `json:"what"`
+	}
+
+	except := Exception{
+		Code: 3240001,
+		Name: SnapshotValidationExceptionName,
+		What: "Snapshot Validation Exception",
+	}
+
+	return json.Marshal(except)
+}
+
+func (e SnapshotValidationException) Callback(f interface{}) bool {
+	switch callback := f.(type) {
+	case func(*SnapshotValidationException):
+		callback(&e)
+		return true
+	case func(SnapshotValidationException):
+		callback(e)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+// Code generated by gotemplate. DO NOT EDIT.
+
+package exception
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/eosspark/eos-go/log"
+)
+
+// template type Exception(PARENT,CODE,WHAT)
+
+var WasmGasExceededName = reflect.TypeOf(WasmGasExceeded{}).Name()
+
+type WasmGasExceeded struct {
+	_WasmException
+	Elog log.Messages
+}
+
+func NewWasmGasExceeded(parent _WasmException, message log.Message) *WasmGasExceeded {
+	return &WasmGasExceeded{parent, log.Messages{message}}
+}
+
+func (e WasmGasExceeded) Code() int64 {
+	return 3070006
+}
+
+func (e WasmGasExceeded) Name() string {
+	return WasmGasExceededName
+}
+
+func (e WasmGasExceeded) What() string {
+	return "WASM instruction weight limit exceeded"
+}
+
+func (e *WasmGasExceeded) AppendLog(l log.Message) {
+	e.Elog = append(e.Elog, l)
+}
+
+func (e WasmGasExceeded) GetLog() log.Messages {
+	return e.Elog
+}
+
+func (e WasmGasExceeded) TopMessage() string {
+	for _, l := range e.Elog {
+		if msg := l.GetMessage(); len(msg) > 0 {
+			return msg
+		}
+	}
+	return e.String()
+}
+
+func (e WasmGasExceeded) DetailMessage() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(strconv.Itoa(int(e.Code())))
+	buffer.WriteByte(' ')
+	buffer.WriteString(e.Name())
+	buffer.Write([]byte{':', ' '})
+	buffer.WriteString(e.What())
+	buffer.WriteByte('\n')
+	for _, l := range e.Elog {
+		buffer.WriteByte('[')
+		buffer.WriteString(l.GetMessage())
+		buffer.Write([]byte{']', ' '})
+		buffer.WriteString(l.GetContext().String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+func (e WasmGasExceeded) String() string {
+	return e.DetailMessage()
+}
+
+func (e WasmGasExceeded) MarshalJSON() ([]byte, error) {
+	type Exception struct {
+		Code int64  `json:"code"`
+		Name string `json:"name"`
+		What string `json:"what"`
+	}
+
+	except := Exception{
+		Code: 3070006,
+		Name: WasmGasExceededName,
+		What: "WASM instruction weight limit exceeded",
+	}
+
+	return json.Marshal(except)
+}
+
+func (e WasmGasExceeded) Callback(f interface{}) bool {
+	switch callback := f.(type) {
+	case func(*WasmGasExceeded):
+		callback(&e)
+		return true
+	case func(WasmGasExceeded):
+		callback(e)
+		return true
+	default:
+		return false
+	}
+}
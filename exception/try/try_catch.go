@@ -215,6 +215,13 @@ func (c *CatchOrFinally) Catch(f interface{}) (r *CatchOrFinally) {
 		}
 		return c
 
+	case func(SnapshotExceptions):
+		if et, ok := c.e.(SnapshotExceptions); ok {
+			ft(et)
+			return nil
+		}
+		return c
+
 	case func(TransactionExceptions):
 		if et, ok := c.e.(TransactionExceptions); ok {
 			ft(et)
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+ * Counter is a value that only goes up, a Gauge one that is set, both can be updated from any goroutine.
+ */
+type Counter struct {
+	bits uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&c.bits)
+		if atomic.CompareAndSwapUint64(&c.bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+type Gauge struct {
+	Counter
+}
+
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+/**
+ * Histogram counts the observed values in cumulative buckets, Buckets are the upper bounds of the buckets.
+ */
+type Histogram struct {
+	lock    sync.Mutex
+	Buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{Buckets: sorted, counts: make([]uint64, len(sorted))}
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for i, bound := range h.Buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) snapshot() (counts []uint64, count uint64, sum float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]uint64(nil), h.counts...), h.count, h.sum
+}
+
+/**
+ * CounterVec is a family of counters told apart by the values of their labels.
+ */
+type CounterVec struct {
+	labels   []string
+	lock     sync.Mutex
+	children map[string]*Counter
+	values   map[string][]string
+}
+
+func newCounterVec(labels []string) *CounterVec {
+	return &CounterVec{labels: labels, children: make(map[string]*Counter), values: make(map[string][]string)}
+}
+
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	if len(values) != len(v.labels) {
+		panic("metrics: wrong number of label values")
+	}
+	key := strings.Join(values, "\xff")
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	c, ok := v.children[key]
+	if !ok {
+		c = &Counter{}
+		v.children[key] = c
+		v.values[key] = append([]string(nil), values...)
+	}
+	return c
+}
+
+/**
+ * ExponentialBuckets returns count bounds starting at start, each factor times the previous one.
+ */
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
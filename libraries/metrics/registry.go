@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/**
+ * Registry holds the metrics of a process and writes them in the prometheus text exposition format (version 0.0.4).
+ * The metrics of the node are registered to DefaultRegistry by the package level constructors, at package init.
+ */
+type Registry struct {
+	lock    sync.Mutex
+	metrics map[string]*metric
+}
+
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	write func(buf *bytes.Buffer, name string)
+}
+
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+func (r *Registry) register(m *metric) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.metrics[m.name]; ok {
+		panic("metrics: duplicate metric " + m.name)
+	}
+	r.metrics[m.name] = m
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	r.register(&metric{name, help, "counter", func(buf *bytes.Buffer, name string) {
+		writeSample(buf, name, "", c.Value())
+	}})
+	return c
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	r.register(&metric{name, help, "gauge", func(buf *bytes.Buffer, name string) {
+		writeSample(buf, name, "", g.Value())
+	}})
+	return g
+}
+
+/**
+ * the value of a gauge func is read when the metrics are written
+ */
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) {
+	r.register(&metric{name, help, "gauge", func(buf *bytes.Buffer, name string) {
+		writeSample(buf, name, "", f())
+	}})
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := newHistogram(buckets)
+	r.register(&metric{name, help, "histogram", func(buf *bytes.Buffer, name string) {
+		counts, count, sum := h.snapshot()
+		for i, bound := range h.Buckets {
+			writeSample(buf, name+"_bucket", `le="`+formatValue(bound)+`"`, float64(counts[i]))
+		}
+		writeSample(buf, name+"_bucket", `le="+Inf"`, float64(count))
+		writeSample(buf, name+"_sum", "", sum)
+		writeSample(buf, name+"_count", "", float64(count))
+	}})
+	return h
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := newCounterVec(labels)
+	r.register(&metric{name, help, "counter", func(buf *bytes.Buffer, name string) {
+		v.lock.Lock()
+		keys := make([]string, 0, len(v.children))
+		for key := range v.children {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			pairs := make([]string, len(v.labels))
+			for i, label := range v.labels {
+				pairs[i] = label + `="` + escapeLabel(v.values[key][i]) + `"`
+			}
+			writeSample(buf, name, strings.Join(pairs, ","), v.children[key].Value())
+		}
+		v.lock.Unlock()
+	}})
+	return v
+}
+
+/**
+ * WriteText returns all the metrics sorted by name
+ */
+func (r *Registry) WriteText() []byte {
+	r.lock.Lock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.lock.Unlock()
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name < metrics[j].name
+	})
+
+	buf := bytes.NewBuffer(nil)
+	for _, m := range metrics {
+		fmt.Fprintf(buf, "# HELP %s %s\n", m.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", m.name, m.kind)
+		m.write(buf, m.name)
+	}
+	return buf.Bytes()
+}
+
+func writeSample(buf *bytes.Buffer, name string, labels string, value float64) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		buf.WriteString("{" + labels + "}")
+	}
+	buf.WriteString(" " + formatValue(value) + "\n")
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(v)
+}
+
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.NewCounter(name, help)
+}
+
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help)
+}
+
+func NewGaugeFunc(name, help string, f func() float64) {
+	DefaultRegistry.NewGaugeFunc(name, help, f)
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets)
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	blocks := r.NewCounter("test_blocks_total", "Blocks applied")
+	head := r.NewGauge("test_head_block_num", "Head block number")
+	apply := r.NewHistogram("test_apply_seconds", "Block apply time", []float64{0.5, 0.1})
+	requests := r.NewCounterVec("test_requests_total", "Requests", "url", "code")
+	r.NewGaugeFunc("test_queue_size", "Queue size", func() float64 { return 7 })
+
+	blocks.Inc()
+	blocks.Add(2)
+	head.Set(42)
+	head.Dec()
+	apply.Observe(0.05)
+	apply.Observe(0.3)
+	apply.Observe(2)
+	requests.WithLabelValues("/v1/chain/get_info", "200").Inc()
+	requests.WithLabelValues("/v1/chain/get_info", "200").Inc()
+	requests.WithLabelValues(`/a"b`, "404").Inc()
+
+	expected := `# HELP test_apply_seconds Block apply time
+# TYPE test_apply_seconds histogram
+test_apply_seconds_bucket{le="0.1"} 1
+test_apply_seconds_bucket{le="0.5"} 2
+test_apply_seconds_bucket{le="+Inf"} 3
+test_apply_seconds_sum 2.35
+test_apply_seconds_count 3
+# HELP test_blocks_total Blocks applied
+# TYPE test_blocks_total counter
+test_blocks_total 3
+# HELP test_head_block_num Head block number
+# TYPE test_head_block_num gauge
+test_head_block_num 41
+# HELP test_queue_size Queue size
+# TYPE test_queue_size gauge
+test_queue_size 7
+# HELP test_requests_total Requests
+# TYPE test_requests_total counter
+test_requests_total{url="/a\"b",code="404"} 1
+test_requests_total{url="/v1/chain/get_info",code="200"} 2
+`
+	assert.Equal(t, expected, string(r.WriteText()))
+	assert.Panics(t, func() { r.NewGauge("test_blocks_total", "again") })
+}
+
+func TestCounter_Concurrent(t *testing.T) {
+	c := &Counter{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			for j := 0; j < 1000; j++ {
+				c.Inc()
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, float64(8000), c.Value())
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	assert.Equal(t, []float64{0.5, 1, 2, 4}, ExponentialBuckets(0.5, 2, 4))
+}
@@ -2,6 +2,9 @@ package chain_api_plugin
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
 	"github.com/eosspark/eos-go/chain"
 	"github.com/eosspark/eos-go/common"
 	. "github.com/eosspark/eos-go/exception"
@@ -10,11 +13,16 @@ import (
 	. "github.com/eosspark/eos-go/plugins/appbase/app"
 	"github.com/eosspark/eos-go/plugins/chain_plugin"
 	"github.com/eosspark/eos-go/plugins/http_plugin"
+	"github.com/eosspark/eos-go/plugins/producer_plugin"
+	"github.com/eosspark/eos-go/wasmgo"
 	"github.com/urfave/cli"
 )
 
 const ChainApiPlug = PluginTypeName("ChainApiPlugin")
 
+// defaultMaxTransactionTimeMs is the max-transaction-age of a node without a running producer_plugin
+const defaultMaxTransactionTimeMs = 30
+
 var chainApiPlugin = App().RegisterPlugin(ChainApiPlug, NewChainApiPlugin())
 
 type ChainApiPlugin struct {
@@ -24,7 +32,9 @@ type ChainApiPlugin struct {
 }
 
 type ChainApiPluginImpl struct {
-	db *chain.Controller
+	db               *chain.Controller
+	wasmDebugAddress string
+	wasmDebugServer  *http.Server
 }
 
 func NewChainApiPlugin() *ChainApiPlugin {
@@ -36,9 +46,17 @@ func NewChainApiPlugin() *ChainApiPlugin {
 }
 
 func (c *ChainApiPlugin) SetProgramOptions(options *[]cli.Flag) {
+	*options = append(*options,
+		cli.StringFlag{
+			Name:  "wasm-debug-address",
+			Usage: "The local IP and port to serve the wasm debugger on when the chain runs with wasm-debug",
+			Value: common.WasmDebugListenString,
+		},
+	)
 }
 
 func (c *ChainApiPlugin) PluginInitialize(options *cli.Context) {
+	c.my.wasmDebugAddress = options.String("wasm-debug-address")
 }
 
 func (c *ChainApiPlugin) PluginStartup() {
@@ -283,6 +301,34 @@ func (c *ChainApiPlugin) PluginStartup() {
 		}).End()
 	})
 
+	httpPlugin.AddHandler(common.SimulateTxnFunc, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			if len(body) == 0 {
+				body = []byte("{}")
+			}
+
+			var param chain_plugin.SimulateTransactionParams
+			if err := json.Unmarshal(body, &param); err != nil {
+				EosThrow(&EofException{}, "marshal simulate_transaction params: %s", err.Error())
+			}
+
+			result := ROApi.SimulateTransaction(param, maxTransactionTime())
+
+			if byte, err := json.Marshal(result); err == nil {
+				cb(200, byte)
+			} else {
+				Throw(err)
+			}
+
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "chain", "simulate_transaction", string(body), cb)
+		}).End()
+	})
+
+	if debugger := c.my.db.WasmDebugger(); debugger != nil {
+		c.serveWasmDebug(debugger)
+	}
+
 	//TODO read_write api
 	RWApi := App().GetPlugin(chain_plugin.ChainPlug).(*chain_plugin.ChainPlugin).GetReadWriteApi()
 
@@ -314,4 +360,132 @@ func (c *ChainApiPlugin) PluginStartup() {
 }
 
 func (c *ChainApiPlugin) PluginShutdown() {
+	if c.my.wasmDebugServer != nil {
+		c.my.wasmDebugServer.Close()
+	}
+}
+
+/**
+ * serveWasmDebug serves the debugger of the contracts on a listener of its own: the http plugin handles its requests
+ * on the thread applying the transactions, which a paused contract holds
+ */
+func (c *ChainApiPlugin) serveWasmDebug(debugger *wasmgo.Debugger) {
+	handlers := make(map[string]http_plugin.UrlHandler)
+	addWasmDebugHandlers(handlers, debugger)
+
+	c.my.wasmDebugServer = &http.Server{
+		Addr: c.my.wasmDebugAddress,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler, ok := handlers[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			handler(r.URL.Path, body, func(code int, body []byte) {
+				w.WriteHeader(code)
+				w.Write(body)
+			})
+		}),
+	}
+	c.log.Info("serving the wasm debugger on %s", c.my.wasmDebugAddress)
+	go func(server *http.Server) {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			c.log.Error("wasm debugger failed to serve on %s: %s", server.Addr, err)
+		}
+	}(c.my.wasmDebugServer)
+}
+
+func addWasmDebugHandlers(handlers map[string]http_plugin.UrlHandler, debugger *wasmgo.Debugger) {
+	handle := func(url, callName string, call func(body []byte) interface{}) {
+		handlers[url] = func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+			Try(func() {
+				if len(body) == 0 {
+					body = []byte("{}")
+				}
+
+				result := call(body)
+
+				if byte, err := json.Marshal(result); err == nil {
+					cb(200, byte)
+				} else {
+					Throw(err)
+				}
+
+			}).Catch(func(e interface{}) {
+				http_plugin.HandleException(e, "wasm_debug", callName, string(body), cb)
+			}).End()
+		}
+	}
+	command := func(c func() error) func([]byte) interface{} {
+		return func([]byte) interface{} {
+			if err := c(); err != nil {
+				Throw(err)
+			}
+			return struct{}{}
+		}
+	}
+
+	handle(common.WasmDebugAddBreakpoint, "add_breakpoint", func(body []byte) interface{} {
+		var param wasmgo.Breakpoint
+		if err := json.Unmarshal(body, &param); err != nil {
+			EosThrow(&EofException{}, "marshal add_breakpoint params: %s", err.Error())
+		}
+		debugger.AddBreakpoint(param)
+		return debugger.Breakpoints()
+	})
+	handle(common.WasmDebugRemoveBreakpoint, "remove_breakpoint", func(body []byte) interface{} {
+		var param wasmgo.Breakpoint
+		if err := json.Unmarshal(body, &param); err != nil {
+			EosThrow(&EofException{}, "marshal remove_breakpoint params: %s", err.Error())
+		}
+		EosAssert(debugger.RemoveBreakpoint(param), &InvalidHttpRequest{}, "no breakpoint %s:%d", param.Function, param.Instruction)
+		return debugger.Breakpoints()
+	})
+	handle(common.WasmDebugGetBreakpoints, "get_breakpoints", func([]byte) interface{} {
+		return debugger.Breakpoints()
+	})
+	handle(common.WasmDebugGetState, "get_state", func([]byte) interface{} {
+		return debugger.State()
+	})
+	handle(common.WasmDebugContinue, "continue", command(debugger.Continue))
+	handle(common.WasmDebugStep, "step", command(debugger.Step))
+	handle(common.WasmDebugStepOver, "step_over", command(debugger.StepOver))
+	handle(common.WasmDebugStepOut, "step_out", command(debugger.StepOut))
+	handle(common.WasmDebugAbort, "abort", command(debugger.Abort))
+	handle(common.WasmDebugReadMemory, "read_memory", func(body []byte) interface{} {
+		var param chain_plugin.WasmReadMemoryParams
+		if err := json.Unmarshal(body, &param); err != nil {
+			EosThrow(&EofException{}, "marshal read_memory params: %s", err.Error())
+		}
+		data, err := debugger.ReadMemory(param.Address, param.Length)
+		if err != nil {
+			Throw(err)
+		}
+		return chain_plugin.WasmReadMemoryResult{Address: param.Address, Data: data}
+	})
+	handle(common.WasmDebugDisassemble, "disassemble", func(body []byte) interface{} {
+		var param chain_plugin.WasmDisassembleParams
+		if err := json.Unmarshal(body, &param); err != nil {
+			EosThrow(&EofException{}, "marshal disassemble params: %s", err.Error())
+		}
+		instructions, err := debugger.Disassemble(param.Function)
+		if err != nil {
+			Throw(err)
+		}
+		return instructions
+	})
+}
+
+// maxTransactionTime is the time a pushed transaction may execute, as the producer_plugin currently has it
+func maxTransactionTime() common.Microseconds {
+	producer, ok := App().FindPlugin(producer_plugin.ProducerPlug).(*producer_plugin.ProducerPlugin)
+	if !ok || producer.GetState() != Started {
+		return common.Milliseconds(defaultMaxTransactionTimeMs)
+	}
+	return common.Milliseconds(int64(*producer.GetRuntimeOptions().MaxTransactionTime))
 }
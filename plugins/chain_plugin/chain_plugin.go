@@ -7,12 +7,19 @@ import (
 	"github.com/eosspark/eos-go/chain/types"
 	. "github.com/eosspark/eos-go/common"
 	"github.com/eosspark/eos-go/crypto/ecc"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
+	"github.com/eosspark/eos-go/entity"
 	. "github.com/eosspark/eos-go/exception"
 	. "github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/log"
 	. "github.com/eosspark/eos-go/plugins/appbase/app"
 	"github.com/eosspark/eos-go/plugins/chain_interface"
+	"github.com/eosspark/eos-go/wasmgo"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
 	"github.com/urfave/cli"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -47,30 +54,66 @@ func (c *ChainPlugin) SetProgramOptions(options *[]cli.Flag) {
 		},
 
 		cli.StringFlag{
-			Name:  "wasm-runtime",
-			Usage: "Override default WASM runtime.",
+			Name: "wasm-runtime",
+			Usage: "Override default WASM runtime (\"wasmgo\", \"wagon\" or \"differential\").\n" +
+				"\"differential\" runs every action on wagon and on wasmgo and fails the action with a wasm execution error\n" +
+				"where they differ, it is meant for test setups: the node rejects blocks the rest of the chain accepts.",
 		},
 		cli.UintFlag{
 			Name:  "abi-serializer-max-time-ms",
 			Usage: "Override default maximum ABI serialization time allowed in ms",
 		},
-		//TODO UNUSED
-		//cli.Uint64Flag{
-		//	Name:  "chain-state-db-size-mb",
-		//	Usage: "Maximum size (in MiB) of the chain state database",
-		//},
-		//cli.Uint64Flag{
-		//	Name:  "chain-state-db-guard-size-mb",
-		//	Usage: "Safely shut down node when free space remaining in the chain state database drops below this size (in MiB).",
-		//},
-		//cli.Uint64Flag{
-		//	Name:  "reversible-blocks-db-size-mb",
-		//	Usage: "Maximum size (in MiB) of the reversible blocks database",
-		//},
-		//cli.Uint64Flag{
-		//	Name:  "reversible-blocks-db-guard-size-mb",
-		//	Usage: "Safely shut down node when free space remaining in the reverseible blocks database drops below this size (in MiB).",
-		//},
+		cli.StringFlag{
+			Name:  "database-backend",
+			Usage: "Key value engine of the chain state and reversible blocks databases (\"leveldb\" or \"memory\").\n" +
+				"In \"memory\" mode nothing is written to disk and the chain state is lost when the node stops.",
+			Value: database.LevelDBBackend,
+		},
+		cli.Uint64Flag{
+			Name:  "chain-state-db-size-mb",
+			Usage: "Maximum size (in MiB) of the chain state database",
+			Value: DefaultConfig.DefaultStateSize / (1024 * 1024),
+		},
+		cli.Uint64Flag{
+			Name:  "chain-state-db-guard-size-mb",
+			Usage: "Safely shut down node when free space remaining in the chain state database drops below this size (in MiB).",
+			Value: DefaultConfig.DefaultStateGuardSize / (1024 * 1024),
+		},
+		cli.Uint64Flag{
+			Name:  "reversible-blocks-db-size-mb",
+			Usage: "Maximum size (in MiB) of the reversible blocks database",
+			Value: DefaultConfig.DefaultReversibleCacheSize / (1024 * 1024),
+		},
+		cli.Uint64Flag{
+			Name:  "reversible-blocks-db-guard-size-mb",
+			Usage: "Safely shut down node when free space remaining in the reverseible blocks database drops below this size (in MiB).",
+			Value: DefaultConfig.DefaultReversibleGuardSize / (1024 * 1024),
+		},
+		cli.Uint64Flag{
+			Name:  "wasm-cache-size-mb",
+			Usage: "Maximum memory (in MiB) taken by the compiled contracts kept in memory, the compiled code of every contract is also kept in the code_cache directory of the data dir",
+			Value: DefaultConfig.DefaultWasmCacheSize / (1024 * 1024),
+		},
+		cli.StringFlag{
+			Name: "wasm-gas-table",
+			Usage: "JSON file of the weights of the wasm instructions by class (\"default\", \"memory\", \"call\", \"div_rem\", \"float\", \"grow_memory\") " +
+				"and by instruction (\"ops\"), the weight of the instructions of an action is in its trace. No file does not weigh them",
+		},
+		cli.Uint64Flag{
+			Name:  "wasm-gas-limit",
+			Usage: "Maximum weight of the wasm instructions an action may execute by the wasm-gas-table, 0 is unlimited. The limit is " +
+				"subjective, it applies to the speculative and produced transactions and not to the blocks this node validates",
+		},
+		cli.StringSliceFlag{
+			Name: "wasm-profile-account",
+			Usage: "Account whose contract is profiled by the wasmgo runtime (may specify multiple times). The calls, instructions and time " +
+				"of its functions are written to the wasm_profiles directory of the data dir when the node stops, as <account>.pb.gz for pprof and <account>.json",
+		},
+		cli.BoolFlag{
+			Name: "wasm-debug",
+			Usage: "Pause the contracts on the breakpoints of the wasm debugger the chain api serves on wasm-debug-address, " +
+				"for local test chains only: a paused contract holds up the node until it is resumed",
+		},
 		cli.BoolFlag{
 			Name:  "contracts-console",
 			Usage: "print contract's output to console",
@@ -176,10 +219,39 @@ func (c *ChainPlugin) SetProgramOptions(options *[]cli.Flag) {
 			Name:  "export-reversible-blocks",
 			Usage: "export reversible block database in portable format into specified file and then exit",
 		},
+		cli.StringFlag{
+			Name:  "export-blocks",
+			Usage: "export irreversible blocks from blocks.log into specified file as a portable compressed archive and then exit",
+		},
+		cli.UintFlag{
+			Name:  "export-first-block",
+			Usage: "first block number to export with --export-blocks (0 for the first block of blocks.log)",
+		},
+		cli.UintFlag{
+			Name:  "export-last-block",
+			Usage: "last block number to export with --export-blocks (0 for the last block of blocks.log)",
+		},
+		cli.StringFlag{
+			Name:  "import-blocks",
+			Usage: "validate and apply the blocks of the portable archive in specified file after startup and then exit",
+		},
 		cli.StringFlag{
 			Name:  "snapshot",
 			Usage: "File to read Snapshot State from",
 		},
+		cli.BoolFlag{
+			Name:  "check-database",
+			Usage: "verify the indexes, undo sessions and revision of the chain state and reversible blocks databases, print the state hash and exit",
+		},
+		cli.StringSliceFlag{
+			Name: "dump-table",
+			Usage: "dump a table of the chain state database as JSON and exit, e.g. AccountObject or PermissionObject. " +
+				"The contract tables take the table to dump: KeyValueObject:code:scope:table (may specify multiple times)",
+		},
+		cli.StringFlag{
+			Name:  "dump-file",
+			Usage: "file to write the tables of --dump-table to instead of the console",
+		},
 	)
 }
 
@@ -245,8 +317,6 @@ func (c *ChainPlugin) PluginInitialize(options *cli.Context) {
 		log.Debug(cp)
 	}
 
-	//TODO wasm-runtime: just use wasmgo?
-
 	if ms := options.Uint("abi-serializer-max-time-ms"); ms > 0 {
 		c.my.AbiSerializerMaxTimeMs = Microseconds(ms * 1000)
 	} else {
@@ -257,21 +327,41 @@ func (c *ChainPlugin) PluginInitialize(options *cli.Context) {
 	c.my.ChainConfig.StateDir = App().DataDir() + "/" + DefaultConfig.DefaultStateDirName
 	c.my.ChainConfig.ReadOnly = c.my.Readonly
 
-	//TODO UNUSED
-	//if mb := options.Uint64("chain-state-db-size-mb"); mb > 0 {
-	//	c.my.ChainConfig.StateSize = mb * 1024 * 1024
-	//}
-	//if mb := options.Uint64("chain-state-db-guard-size-mb"); mb > 0 {
-	//	c.my.ChainConfig.StateGuardSize = mb * 1024 * 1024
-	//}
-	//if mb := options.Uint64("reversible-blocks-db-size-mb"); mb > 0 {
-	//	c.my.ChainConfig.ReversibleCacheSize = mb * 1024 * 1024
-	//}
-	//if mb := options.Uint64("reversible-blocks-db-guard-size-mb"); mb > 0 {
-	//	c.my.ChainConfig.ReversibleGuardSize = mb * 1024 * 1024
-	//}
-
-	//TODO handle wasm-runtime
+	switch backend := options.String("database-backend"); backend {
+	case database.LevelDBBackend, database.MemoryBackend:
+		c.my.ChainConfig.DbBackend = backend
+	default:
+		EosThrow(&PluginConfigException{}, "Unknown database-backend: %s", backend)
+	}
+
+	c.my.ChainConfig.StateSize = options.Uint64("chain-state-db-size-mb") * 1024 * 1024
+	c.my.ChainConfig.StateGuardSize = options.Uint64("chain-state-db-guard-size-mb") * 1024 * 1024
+	c.my.ChainConfig.ReversibleCacheSize = options.Uint64("reversible-blocks-db-size-mb") * 1024 * 1024
+	c.my.ChainConfig.ReversibleGuardSize = options.Uint64("reversible-blocks-db-guard-size-mb") * 1024 * 1024
+	c.my.ChainConfig.WasmCacheSize = options.Uint64("wasm-cache-size-mb") * 1024 * 1024
+	c.my.ChainConfig.WasmCacheDir = App().DataDir() + "/" + DefaultConfig.DefaultCodeCacheDirName
+	if path := options.String("wasm-gas-table"); path != "" {
+		gasTable, err := compiler.LoadGasTable(path)
+		EosAssert(err == nil, &PluginConfigException{}, "Cannot load wasm-gas-table: %s", err)
+		c.my.ChainConfig.WasmGasPolicy = gasTable
+	}
+	c.my.ChainConfig.WasmGasLimit = options.Uint64("wasm-gas-limit")
+	EosAssert(c.my.ChainConfig.WasmGasLimit == 0 || c.my.ChainConfig.WasmGasPolicy != nil, &PluginConfigException{},
+		"wasm-gas-limit requires a wasm-gas-table")
+	for _, account := range options.StringSlice("wasm-profile-account") {
+		c.my.ChainConfig.WasmProfileAccounts = append(c.my.ChainConfig.WasmProfileAccounts, N(account))
+	}
+	c.my.ChainConfig.WasmDebug = options.Bool("wasm-debug")
+
+	if runtime := options.String("wasm-runtime"); runtime != "" {
+		vmType, ok := wasmgo.VmTypeFromString(runtime)
+		EosAssert(ok, &PluginConfigException{}, "Unknown wasm-runtime: %s", runtime)
+		c.my.ChainConfig.VmType = vmType
+		if vmType == wasmgo.DIFFERENTIAL {
+			log.Warn("wasm-runtime differential fails every action on which wagon and wasmgo differ, this node will " +
+				"reject the blocks and transactions the rest of the chain accepts there. Only use it on test setups.")
+		}
+	}
 	c.my.ChainConfig.ForceAllChecks = options.Bool("force-all-checks")
 	c.my.ChainConfig.DisableReplayOpts = options.Bool("disable-replay-opts")
 	c.my.ChainConfig.ContractsConsole = options.Bool("contracts-console")
@@ -307,6 +397,53 @@ func (c *ChainPlugin) PluginInitialize(options *cli.Context) {
 		EosThrow(&NodeManagementSuccess{}, "exported reversible blocks")
 	}
 
+	if blocksFile := options.String("export-blocks"); blocksFile != "" {
+		log.Info("Exporting blocks to '%s'", blocksFile)
+		out, err := os.Create(blocksFile)
+		EosAssert(err == nil, &PluginConfigException{}, "Cannot create block archive %s: %s", blocksFile, err)
+		defer out.Close()
+
+		header := chain.ExportBlocks(c.my.BlockDir, out,
+			uint32(options.Uint("export-first-block")), uint32(options.Uint("export-last-block")))
+		log.Info("Exported blocks %d to %d", header.FirstBlockNum, header.LastBlockNum)
+
+		EosThrow(&NodeManagementSuccess{}, "exported blocks")
+	}
+
+	if options.Bool("check-database") {
+		report := chain.CheckState(c.my.ChainConfig)
+		for _, problem := range report.Problems {
+			log.Error("%s", problem)
+		}
+		log.Info("Chain state at revision %d, head block #%d, state hash %s", report.Revision, report.HeadBlockNum, report.StateHash)
+		EosAssert(len(report.Problems) == 0, &DatabaseException{}, "found %d problems in the chain state", len(report.Problems))
+
+		EosThrow(&NodeManagementSuccess{}, "checked the chain state")
+	}
+
+	if tables := options.StringSlice("dump-table"); len(tables) > 0 {
+		out := io.Writer(os.Stdout)
+		if dumpFile := options.String("dump-file"); dumpFile != "" {
+			file, err := os.Create(dumpFile)
+			EosAssert(err == nil, &PluginConfigException{}, "Cannot create dump file %s: %s", dumpFile, err)
+			defer file.Close()
+			out = file
+		}
+		db, err := database.OpenReadOnlyDataBase(c.my.ChainConfig.DbBackend, c.my.ChainConfig.StateDir)
+		EosAssert(err == nil, &DatabaseException{}, "Cannot open the chain state database: %s", err)
+		defer db.Close()
+		for _, table := range tables {
+			chain.DumpTable(db, table, out)
+		}
+
+		EosThrow(&NodeManagementSuccess{}, "dumped tables")
+	}
+
+	if blocksFile := options.String("import-blocks"); blocksFile != "" {
+		EosAssert(FileExist(blocksFile), &PluginConfigException{}, "Cannot import blocks, %s does not exist", blocksFile)
+		c.my.ImportBlocksPath = blocksFile
+	}
+
 	if options.Bool("delete-all-blocks") {
 		log.Info("Deleting state database and blocks")
 		if options.Uint("truncate-at-block") > 0 {
@@ -318,7 +455,18 @@ func (c *ChainPlugin) PluginInitialize(options *cli.Context) {
 	} else if options.Bool("hard-replay-blockchain") {
 		log.Info("Hard replay requested: deleting state database")
 		ClearDirectoryContents(c.my.ChainConfig.StateDir)
-		//TODO: hard-replay-blockchain
+		truncateAtBlock := uint32(options.Uint("truncate-at-block"))
+		backupDir := chain.RepairLog(c.my.BlockDir, truncateAtBlock)
+
+		reversibleBackup := fmt.Sprintf("%s/%s", backupDir, DefaultConfig.DefaultReversibleBlocksDirName)
+		reversibleDir := fmt.Sprintf("%s/%s", c.my.BlockDir, DefaultConfig.DefaultReversibleBlocksDirName)
+		if FileExist(reversibleBackup) || options.Bool("fix-reversible-blocks") {
+			// Do not try to recover reversible blocks if the directory does not exist, unless the option was explicitly provided.
+			if !c.RecoverReversibleBlocks(reversibleBackup, uint32(c.my.ChainConfig.ReversibleCacheSize), reversibleDir, truncateAtBlock) {
+				log.Info("Reversible blocks database was not corrupted. Copying from backup to blocks directory.")
+				copyDirectory(reversibleBackup, reversibleDir)
+			}
+		}
 
 	} else if options.Bool("replay-blockchain") {
 		log.Info("Replay requested: deleting state database")
@@ -360,8 +508,30 @@ func (c *ChainPlugin) PluginInitialize(options *cli.Context) {
 		log.Warn("The --import-reversible-blocks option should be used by itself.")
 	}
 
-	if options.String("snapshot") != "" {
-		//TODO: snapshot
+	if snapshotPath := options.String("snapshot"); snapshotPath != "" {
+		c.my.SnapshotPath = snapshotPath
+
+		// recover genesis information from the snapshot
+		infile, err := os.Open(snapshotPath)
+		EosAssert(err == nil, &PluginConfigException{}, "Cannot load snapshot, %s does not exist", snapshotPath)
+		Try(func() {
+			reader := chain.NewSnapshotReader(infile)
+			reader.Validate()
+			c.my.ChainConfig.Genesis = reader.GenesisState()
+		}).Catch(func(e Exception) {
+			infile.Close()
+			Throw(e)
+		}).End()
+		infile.Close()
+
+		EosAssert(options.String("genesis-json") == "" && options.String("genesis-timestamp") == "", &PluginConfigException{},
+			"--snapshot is incompatible with --genesis-json and --genesis-timestamp as the snapshot contains genesis information")
+
+		if FileExist(c.my.BlockDir + "/blocks.log") {
+			logGenesis := chain.ExtractGenesisState(c.my.BlockDir)
+			EosAssert(logGenesis.ComputeChainID() == c.my.ChainConfig.Genesis.ComputeChainID(), &PluginConfigException{},
+				"Genesis information in blocks.log does not match genesis information in the snapshot")
+		}
 	} else {
 		if genesisFile := options.String("genesis-json"); genesisFile != "" {
 			//TODO: genesis-json
@@ -401,7 +571,27 @@ func (c *ChainPlugin) PluginInitialize(options *cli.Context) {
 	//TODO
 
 	// relay signals to channels
-	//TODO
+	c.my.Chain.PreAcceptedBlock.Connect(&chain_interface.PreAcceptedBlockCaller{Caller: func(s *types.SignedBlock) {
+		c.my.PreAcceptedBlockChannel.Publish(s)
+	}})
+	c.my.Chain.AcceptedBlockHeader.Connect(&chain_interface.AcceptedBlockHeaderCaller{Caller: func(b *types.BlockState) {
+		c.my.AcceptedBlockHeaderChannel.Publish(b)
+	}})
+	c.my.Chain.AcceptedBlock.Connect(&chain_interface.AcceptedBlockCaller{Caller: func(b *types.BlockState) {
+		c.my.AcceptedBlockChannel.Publish(b)
+	}})
+	c.my.Chain.IrreversibleBlock.Connect(&chain_interface.IrreversibleBlockCaller{Caller: func(b *types.BlockState) {
+		c.my.IrreversibleBlockChannel.Publish(b)
+	}})
+	c.my.Chain.AcceptedTransaction.Connect(&chain_interface.AcceptedTransactionCaller{Caller: func(t *types.TransactionMetadata) {
+		c.my.AcceptedTransactionChannel.Publish(t)
+	}})
+	c.my.Chain.AppliedTransaction.Connect(&chain_interface.AppliedTransactionCaller{Caller: func(t *types.TransactionTrace) {
+		c.my.AppliedTransactionChannel.Publish(t)
+	}})
+	c.my.Chain.AcceptedConfirmation.Connect(&chain_interface.AcceptedConfirmationCaller{Caller: func(h *types.HeaderConfirmation) {
+		c.my.AcceptedConfirmationChannel.Publish(h)
+	}})
 }
 
 func (c *ChainPlugin) PluginStartup() {
@@ -409,12 +599,25 @@ func (c *ChainPlugin) PluginStartup() {
 	//	c.my.Chain.HeadBlockNum(), c.my.ChainConfig.Genesis.InitialTimestamp)
 	//my->chain->head_block_num(), my->chain_config->genesis.initial_timestamp
 	Try(func() {
-		c.my.Chain.Startup()
+		if c.my.SnapshotPath != "" {
+			infile, err := os.Open(c.my.SnapshotPath)
+			EosAssert(err == nil, &PluginConfigException{}, "Cannot load snapshot, %s does not exist", c.my.SnapshotPath)
+			defer infile.Close()
+
+			c.my.Chain.Startup(chain.NewSnapshotReader(infile))
+		} else {
+			c.my.Chain.Startup()
+		}
 	}).Catch(func(e *DatabaseGuardException) {
 		c.logGuardException(e)
 		Throw(e)
 	})
 
+	if c.my.ImportBlocksPath != "" {
+		c.importBlocks(c.my.ImportBlocksPath)
+		EosThrow(&NodeManagementSuccess{}, "imported blocks")
+	}
+
 	if !c.my.Readonly {
 		log.Info("starting chain in read/write mode")
 	}
@@ -423,10 +626,41 @@ func (c *ChainPlugin) PluginStartup() {
 }
 
 func (c *ChainPlugin) PluginShutdown() {
+	c.writeWasmProfiles()
 	c.my.Chain.Close()
 	log.Info("chain plugin shutdown")
 }
 
+// writeWasmProfiles writes the profiles of the contracts of the wasm-profile-account accounts
+func (c *ChainPlugin) writeWasmProfiles() {
+	profiles := c.my.Chain.WasmProfiles()
+	if len(profiles) == 0 {
+		return
+	}
+	dir := App().DataDir() + "/" + DefaultConfig.DefaultWasmProfileDirName
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		log.Error("cannot create the wasm profile directory %s: %s", dir, err)
+		return
+	}
+	for _, profile := range profiles {
+		writeFile := func(path string, write func(io.Writer) error) {
+			file, err := os.Create(path)
+			if err == nil {
+				err = write(file)
+				if closeErr := file.Close(); err == nil {
+					err = closeErr
+				}
+			}
+			if err != nil {
+				log.Error("cannot write the wasm profile %s: %s", path, err)
+			}
+		}
+		writeFile(dir+"/"+profile.Account.String()+".pb.gz", profile.WritePprof)
+		writeFile(dir+"/"+profile.Account.String()+".json", profile.WriteJSON)
+		log.Info("wrote the wasm profile of %s to %s", profile.Account, dir)
+	}
+}
+
 func (c *ChainPlugin) GetReadOnlyApi() *ReadOnly {
 	return NewReadOnly(c.Chain(), c.GetAbiSerializerMaxTime())
 }
@@ -443,11 +677,176 @@ func (c *ChainPlugin) AcceptTransaction(trx *types.PackedTransaction, next chain
 	c.my.IncomingTransactionAsyncMethod.CallMethods(trx, false, next)
 }
 
+/**
+ * rebuilds the reversible blocks database at dbDir (or at newDbDir when it is given) from the blocks that can be
+ * recovered: the blocks must be contiguous and decodable, and the recovery stops after truncateAtBlock when it is
+ * not 0. Returns false if the database was not corrupted and had nothing to be recovered.
+ */
 func (c *ChainPlugin) RecoverReversibleBlocks(dbDir string, cacheSize uint32, newDbDir string, truncateAtBlock uint32) bool {
-	//TODO: recover_reversible_blocks
+	if !FileExist(dbDir) {
+		log.Warn("Reversible blocks database '%s' does not exist", dbDir)
+		return false
+	}
+
+	if newDbDir == "" && reversibleBlocksIntact(dbDir, truncateAtBlock) {
+		return false
+	}
+	// Reversible block database is dirty. So back it up (unless already moved) and then create a new one.
+
+	reversibleDir, err := filepath.Abs(dbDir)
+	Throw(err)
+	now := Now().String()
+	var backupDir string
+	if newDbDir != "" {
+		backupDir = reversibleDir
+		reversibleDir = newDbDir
+	} else {
+		backupDir = reversibleDir + "-" + now
+		Throw(os.Rename(reversibleDir, backupDir))
+		log.Info("Moved existing reversible directory to backup location: '%s'", backupDir)
+	}
+	Throw(os.MkdirAll(reversibleDir, os.ModePerm))
+	log.Info("Reconstructing '%s' from backed up reversible directory", reversibleDir)
+
+	oldReversible, err := database.NewDataBase(backupDir)
+	Throw(err)
+	defer oldReversible.Close()
+	newReversible, err := database.NewDataBase(reversibleDir)
+	Throw(err)
+	defer newReversible.Close()
+
+	portable, err := os.Create(filepath.Dir(reversibleDir) + "/portable-reversible-blocks-" + now)
+	Throw(err)
+	defer portable.Close()
+
+	var num, start, end uint32
+	Try(func() {
+		ubi, err := oldReversible.GetIndex("byNum", &entity.ReversibleBlockObject{})
+		Throw(err)
+		if ubi.Empty() {
+			return
+		}
+
+		itr := ubi.Begin()
+		first := entity.ReversibleBlockObject{}
+		Throw(itr.Data(&first))
+		start = first.BlockNum
+		end = start - 1
+		if truncateAtBlock > 0 && start > truncateAtBlock {
+			log.Info("Did not recover any reversible blocks since the specified block number to stop at (%d) is less than first block in the reversible database (%d).",
+				truncateAtBlock, start)
+			return
+		}
+
+		for ; !ubi.CompareEnd(itr); itr.Next() {
+			rbo := entity.ReversibleBlockObject{}
+			Throw(itr.Data(&rbo))
+			EosAssert(rbo.BlockNum == end+1, &GapInReversibleBlocksDb{},
+				"gap in reversible block database between %d and %d", end, rbo.BlockNum)
+
+			// unpacking and packing again rather than copying the packed data acts as additional validation
+			block := types.SignedBlock{}
+			Throw(rlp.DecodeBytes(rbo.PackedBlock, &block))
+			ubo := entity.ReversibleBlockObject{BlockNum: rbo.BlockNum}
+			ubo.SetBlock(&block)
+			Throw(newReversible.Insert(&ubo))
+			_, err = portable.Write(rbo.PackedBlock)
+			Throw(err)
+
+			end = rbo.BlockNum
+			num++
+			if end == truncateAtBlock {
+				break
+			}
+		}
+	}).Catch(func(e Exception) {
+		log.Warn("%s", e.DetailMessage())
+	}).End()
+
+	if end == truncateAtBlock {
+		log.Info("Stopped recovery of reversible blocks early at specified block number: %d", truncateAtBlock)
+	}
+
+	if num == 0 {
+		log.Info("There were no recoverable blocks in the reversible block database")
+	} else if num == 1 {
+		log.Info("Recovered 1 block from reversible block database: block %d", start)
+	} else {
+		log.Info("Recovered %d blocks from reversible block database: blocks %d to %d", num, start, end)
+	}
+
 	return true
 }
 
+// reversibleBlocksIntact checks that the reversible blocks are contiguous, decodable and end before truncateAtBlock
+func reversibleBlocksIntact(dbDir string, truncateAtBlock uint32) bool {
+	db, err := database.NewDataBase(dbDir)
+	if err != nil {
+		return false
+	}
+	defer db.Close()
+
+	intact := true
+	Try(func() {
+		ubi, err := db.GetIndex("byNum", &entity.ReversibleBlockObject{})
+		Throw(err)
+		if ubi.Empty() {
+			return
+		}
+
+		var end uint32
+		for itr := ubi.Begin(); !ubi.CompareEnd(itr); itr.Next() {
+			rbo := entity.ReversibleBlockObject{}
+			Throw(itr.Data(&rbo))
+			block := types.SignedBlock{}
+			Throw(rlp.DecodeBytes(rbo.PackedBlock, &block))
+			if (end != 0 && rbo.BlockNum != end+1) || (truncateAtBlock > 0 && rbo.BlockNum > truncateAtBlock) {
+				intact = false
+				return
+			}
+			end = rbo.BlockNum
+		}
+	}).Catch(func(e interface{}) {
+		intact = false
+	}).End()
+
+	return intact
+}
+
+func copyDirectory(from string, to string) {
+	Throw(filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, info.Mode())
+	}))
+}
+
+func (c *ChainPlugin) importBlocks(blocksFile string) {
+	log.Info("Importing blocks from '%s'", blocksFile)
+	infile, err := os.Open(blocksFile)
+	EosAssert(err == nil, &PluginConfigException{}, "Cannot import blocks, %s does not exist", blocksFile)
+	defer infile.Close()
+
+	// the blocks pushed are kept in the reversible blocks database and the fork database on close
+	defer c.my.Chain.Close()
+	applied := c.my.Chain.ImportBlocks(chain.NewBlockArchiveReader(infile))
+	log.Info("Imported %d blocks, head block is #%d", applied, c.my.Chain.HeadBlockNum())
+}
+
 func (c *ChainPlugin) ImportReversibleBlocks(reversibleDir string, cacheSize uint32, reversibleBlocksFile string) bool {
 	//TODO: import_reversible_blocks
 	return true
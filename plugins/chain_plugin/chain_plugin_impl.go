@@ -23,17 +23,18 @@ type ChainPluginImpl struct {
 
 	//fc::optional<vm_type>            wasm_runtime;
 	AbiSerializerMaxTimeMs common.Microseconds
-	//fc::optional<bfs::path>          snapshot_path;
+	SnapshotPath           string
+	ImportBlocksPath       string
 
 	// retained references to channels for easy publication
-	PreAcceptedBlockChannel     include.Channel
-	AcceptedBlockHeaderChannel  include.Channel
-	AcceptedBlockChannel        include.Channel
-	IrreversibleBlockChannel    include.Channel
-	AcceptedTransactionChannel  include.Channel
-	AppliedTransactionChannel   include.Channel
-	AcceptedConfirmationChannel include.Channel
-	IncomingBlockChannel        include.Channel
+	PreAcceptedBlockChannel     *include.Channel
+	AcceptedBlockHeaderChannel  *include.Channel
+	AcceptedBlockChannel        *include.Channel
+	IrreversibleBlockChannel    *include.Channel
+	AcceptedTransactionChannel  *include.Channel
+	AppliedTransactionChannel   *include.Channel
+	AcceptedConfirmationChannel *include.Channel
+	IncomingBlockChannel        *include.Channel
 
 	// retained references to methods for easy calling
 	IncomingBlockSyncMethod        *include.Method
@@ -50,6 +51,15 @@ type ChainPluginImpl struct {
 
 func NewChainPluginImpl() *ChainPluginImpl {
 	return &ChainPluginImpl{
+		PreAcceptedBlockChannel:     app.App().GetChannel(PreAcceptedBlock),
+		AcceptedBlockHeaderChannel:  app.App().GetChannel(AcceptedBlockHeader),
+		AcceptedBlockChannel:        app.App().GetChannel(AcceptedBlock),
+		IrreversibleBlockChannel:    app.App().GetChannel(IrreversibleBlock),
+		AcceptedTransactionChannel:  app.App().GetChannel(AcceptedTransaction),
+		AppliedTransactionChannel:   app.App().GetChannel(AppliedTransaction),
+		AcceptedConfirmationChannel: app.App().GetChannel(AcceptedConfirmation),
+		IncomingBlockChannel:        app.App().GetChannel(Block),
+
 		IncomingBlockSyncMethod:        app.App().GetMethod(BlockSync),
 		IncomingTransactionAsyncMethod: app.App().GetMethod(TransactionAsync),
 	}
@@ -1,6 +1,8 @@
 package chain_plugin
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 	"github.com/eosspark/eos-go/chain"
 	"github.com/eosspark/eos-go/chain/abi_serializer"
@@ -14,6 +16,7 @@ import (
 	. "github.com/eosspark/eos-go/exception"
 	. "github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/plugins/appbase/app"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -393,6 +396,73 @@ func (ro *ReadOnly) GetRequiredKeys(params GetRequiredKeysParams) GetRequiredKey
 	return GetRequiredKeysResult{RequiredKeys: ro.db.GetAuthorizationManager().GetRequiredKeys(trx, &params.AvailableKeys, 0)}
 }
 
+/**
+ * SimulateTransaction executes a transaction against the pending state without keeping any of its changes, within
+ * maxTransactionTime as a pushed transaction. cpu and net are those of the receipt billed to the accounts the chain
+ * billed, ram the deltas of the payers of the rows.
+ */
+func (ro *ReadOnly) SimulateTransaction(params SimulateTransactionParams, maxTransactionTime common.Microseconds) SimulateTransactionResult {
+	var trx *types.TransactionMetadata
+	if v, ok := params.Transaction.(map[string]interface{}); ok && v["packed_trx"] != nil {
+		packed := &types.PackedTransaction{}
+		common.FromVariant(&params.Transaction, packed)
+		trx = types.NewTransactionMetadata(packed)
+	} else {
+		signed := &types.SignedTransaction{}
+		common.FromVariant(&params.Transaction, signed)
+		trx = types.NewTransactionMetadataBySignedTrx(signed, types.CompressionNone)
+	}
+	EosAssert(params.SkipSignatures || len(trx.Trx.Signatures) > 0, &TxNoAuths{},
+		"transaction is not signed, set skip_signatures to simulate it")
+
+	// the transaction is not added to the block, only a negative max-transaction-age bounds it by the block time
+	deadline := common.Now().AddUs(maxTransactionTime)
+	if maxTransactionTime < 0 {
+		deadline = ro.db.PendingBlockTime()
+	}
+
+	trace, billed := ro.db.SimulateTransaction(trx, deadline, params.SkipSignatures)
+
+	result := SimulateTransactionResult{TransactionId: trace.ID}
+	common.ToVariant(trace, &result.Processed)
+	if trace.Except != nil {
+		result.Except = trace.Except.DetailMessage()
+	}
+
+	deltas := make(map[common.AccountName]*SimulateAccountDelta)
+	delta := func(account common.AccountName) *SimulateAccountDelta {
+		if _, ok := deltas[account]; !ok {
+			deltas[account] = &SimulateAccountDelta{Account: account}
+		}
+		return deltas[account]
+	}
+	for _, account := range billed {
+		d := delta(account)
+		d.CpuUsageUs = trace.Receipt.CpuUsageUs
+		d.NetUsage = uint64(trace.Receipt.NetUsageWords) * 8
+	}
+	var walk func(traces []types.ActionTrace)
+	walk = func(traces []types.ActionTrace) {
+		for i := range traces {
+			result.Console += traces[i].Console
+			for _, ram := range traces[i].AccountRamDeltas.Values() {
+				delta(ram.Account).RamDelta += ram.Delta
+			}
+			walk(traces[i].InlineTraces)
+		}
+	}
+	walk(trace.ActionTraces)
+
+	result.AccountDeltas = make([]SimulateAccountDelta, 0, len(deltas))
+	for _, d := range deltas {
+		result.AccountDeltas = append(result.AccountDeltas, *d)
+	}
+	sort.Slice(result.AccountDeltas, func(i, j int) bool {
+		return result.AccountDeltas[i].Account < result.AccountDeltas[j].Account
+	})
+	return result
+}
+
 func (ro *ReadOnly) GetTableIndexName(p GetTableRowsParams, primary *bool) uint64 {
 	// see multi_index packing of index name
 	table := p.Table
@@ -423,7 +493,7 @@ func (ro *ReadOnly) GetTableIndexName(p GetTableRowsParams, primary *bool) uint6
 		pos = 8
 	} else {
 		Try(func() {
-			math.MustParseUint64(p.IndexPosition)
+			pos = math.MustParseUint64(p.IndexPosition)
 		}).Catch(func(interface{}) {
 			EosAssert(false, &ContractTableQueryException{}, "Invalid index_position: %s", p.IndexPosition)
 		}).End()
@@ -438,79 +508,116 @@ func (ro *ReadOnly) GetTableIndexName(p GetTableRowsParams, primary *bool) uint6
 	return index
 }
 
-func (ro *ReadOnly) GetTableRowsEx(p GetTableRowsParams, abi *abi_serializer.AbiDef) GetTableRowsResult {
-	result := GetTableRowsResult{}
+/**
+ * GetTableRowsEx walks the index of the table named tableWithIndex, the primary index or a secondary index, and
+ * returns the rows of the table p.Table in the order of that index.
+ */
+func (ro *ReadOnly) GetTableRowsEx(p GetTableRowsParams, abi *abi_serializer.AbiDef, tableWithIndex uint64, index *tableIndex) GetTableRowsResult {
+	result := GetTableRowsResult{Rows: []common.Variant{}}
 	d := ro.db.DataBase()
 	scope := convertToUint64(p.Scope, "scope")
 
 	abis := abi_serializer.AbiSerializer{}
 	abis.SetAbi(abi, ro.abiSerializerMaxTime)
-	tid := TableIdObject{Code: p.Code, Scope: common.ScopeName(scope), Table: p.Table}
-	if d.Find("byCodeScopeTable", tid, &tid) == nil {
-		//TODO
-		idx, err := d.GetIndex("byScopePrimary", KeyValueObject{})
-		Throw(err)
-		nextTid := tid.ID + 1
-		lower, err := idx.LowerBound(KeyValueObject{TId: tid.ID})
-		Throw(err)
-		upper, err := idx.UpperBound(KeyValueObject{TId: nextTid})
-		Throw(err)
 
-		if len(p.LowerBound) > 0 {
-			if p.KeyType == "name" {
-				s := common.N(p.LowerBound)
-				lower, err = idx.LowerBound(KeyValueObject{TId: tid.ID, PrimaryKey: uint64(s)})
-				Throw(err)
-			} else {
-				lv := convertToUint64(p.LowerBound, "lower_bound")
-				lower, err = idx.LowerBound(KeyValueObject{TId: tid.ID, PrimaryKey: lv})
-				Throw(err)
-			}
+	primaryTid := TableIdObject{Code: p.Code, Scope: common.ScopeName(scope), Table: p.Table}
+	if d.Find("byCodeScopeTable", primaryTid, &primaryTid) != nil {
+		return result
+	}
+	tid := primaryTid
+	if tableWithIndex != uint64(p.Table) {
+		tid = TableIdObject{Code: p.Code, Scope: common.ScopeName(scope), Table: common.TableName(tableWithIndex)}
+		if d.Find("byCodeScopeTable", tid, &tid) != nil {
+			return result
 		}
+	}
 
-		if len(p.UpperBound) > 0 {
-			if p.KeyType == "name" {
-				s := common.N(p.UpperBound)
-				lower, err = idx.UpperBound(KeyValueObject{TId: tid.ID, PrimaryKey: uint64(s)})
-				Throw(err)
-			} else {
-				uv := convertToUint64(p.UpperBound, "upper_bound")
-				upper, err = idx.UpperBound(KeyValueObject{TId: tid.ID, PrimaryKey: uv})
-				Throw(err)
-			}
+	var lowerKey, upperKey interface{}
+	if len(p.LowerBound) > 0 {
+		lowerKey = index.parse(p.LowerBound)
+	}
+	if len(p.UpperBound) > 0 {
+		upperKey = index.parse(p.UpperBound)
+	}
+	if lowerKey != nil && upperKey != nil {
+		lower, err := database.EncodeToBytes(lowerKey)
+		Throw(err)
+		upper, err := database.EncodeToBytes(upperKey)
+		Throw(err)
+		if bytes.Compare(lower, upper) > 0 {
+			return result
 		}
+	}
+
+	idx, err := d.GetIndex(index.name, index.object(tid.ID, nil))
+	Throw(err)
+	lower, err := idx.LowerBound(index.object(tid.ID, nil), index.tableSkip)
+	Throw(err)
+	upper, err := idx.UpperBound(index.object(tid.ID, nil), index.tableSkip)
+	Throw(err)
+	if lowerKey != nil {
+		lower, err = idx.LowerBound(index.object(tid.ID, lowerKey), index.keySkip...)
+		Throw(err)
+	}
+	if upperKey != nil {
+		upper, err = idx.UpperBound(index.object(tid.ID, upperKey), index.keySkip...)
+		Throw(err)
+	}
+
+	end := common.Now().AddUs(common.Microseconds(1000 * 10)) /// 10ms max time
+	count := uint32(0)
+	addRow := func(primary uint64) {
+		obj := KeyValueObject{TId: primaryTid.ID, PrimaryKey: primary}
+		Throw(d.Find("byScopePrimary", obj, &obj))
 
 		var data []byte
-		end := common.Now().AddUs(common.Microseconds(1000 * 10)) /// 10ms max time
+		CopyInlineRow(&obj, &data)
 
-		count := uint32(0)
-		itr := lower
-		for ; !idx.CompareIterator(itr, upper); itr.Next() {
-			obj := KeyValueObject{}
-			err = itr.Data(&obj)
-			Throw(err)
-			CopyInlineRow(&obj, &data)
+		var row common.Variant
+		if p.JSON {
+			row = abis.BinaryToVariant(abis.GetTableType(p.Table), data, ro.abiSerializerMaxTime, false)
+		} else {
+			row = hex.EncodeToString(data)
+		}
+		if p.ShowPayer {
+			row = common.Variants{"data": row, "payer": obj.Payer}
+		}
+		result.Rows = append(result.Rows, row)
+	}
 
-			if p.JSON {
-				result.Rows = append(result.Rows, abis.BinaryToVariant(abis.GetTableType(p.Table), data, ro.abiSerializerMaxTime, false))
-			} else {
-				result.Rows = append(result.Rows, common.VariantsFromData(data))
-			}
+	// the key of the first row that is not returned is the bound to send to continue the walk
+	walk := func(itr database.Iterator) bool {
+		rowTid, primary, key := index.read(itr)
+		if rowTid != tid.ID {
+			return false
+		}
+		if count == p.Limit || common.Now() > end {
+			result.More = true
+			result.NextKey = key
+			return false
+		}
+		addRow(primary)
+		count++
+		return true
+	}
 
-			if count = count + 1; count == p.Limit || common.Now() > end {
-				itr.Next()
+	if p.Reverse {
+		idx.ReverseRange(lower, upper, walk)
+	} else {
+		for itr := lower; !idx.CompareIterator(itr, upper); itr.Next() {
+			if !walk(itr) {
 				break
 			}
 		}
-		if !idx.CompareIterator(itr, upper) {
-			result.More = true
-		}
 	}
 	return result
 }
 
 func (ro *ReadOnly) GetTableRows(p GetTableRowsParams) GetTableRowsResult {
 	abi := GetAbi(ro.db, p.Code)
+	if p.Limit == 0 {
+		p.Limit = 10
+	}
 
 	primary := false
 	tableWithIndex := ro.GetTableIndexName(p, &primary)
@@ -518,13 +625,13 @@ func (ro *ReadOnly) GetTableRows(p GetTableRowsParams) GetTableRowsResult {
 		EosAssert(uint64(p.Table) == tableWithIndex, &ContractTableQueryException{}, "Invalid table name %s", p.Table)
 		tableType := GetTableType(&abi, p.Table)
 		if tableType == KEYi64 || p.KeyType == KEYi64 || p.KeyType == "name" {
-			return ro.GetTableRowsEx(p, &abi)
+			return ro.GetTableRowsEx(p, &abi, tableWithIndex, primaryTableIndex(p.KeyType))
 		}
 		EosAssert(false, &ContractTableQueryException{}, "Invalid table type %s", tableType)
 	} else {
 		EosAssert(len(p.KeyType) != 0, &ContractTableQueryException{}, "key type required for non-primary index")
-
-		//todo: second key
+		GetTableType(&abi, p.Table)
+		return ro.GetTableRowsEx(p, &abi, tableWithIndex, secondaryTableIndex(p.KeyType, p.EncodeType))
 	}
 
 	return GetTableRowsResult{}
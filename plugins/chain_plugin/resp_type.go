@@ -160,6 +160,37 @@ type GetRequiredKeysResult struct {
 	RequiredKeys PublicKeySet `json:"required_keys"`
 }
 
+type SimulateTransactionParams struct {
+	Transaction    common.Variant `json:"transaction"` // a packed transaction as pushed, or a signed or unsigned transaction
+	SkipSignatures bool           `json:"skip_signatures"`
+}
+type SimulateTransactionResult struct {
+	TransactionId common.TransactionIdType `json:"transaction_id"`
+	Processed     common.Variants          `json:"processed"`
+	Console       string                   `json:"console"`
+	AccountDeltas []SimulateAccountDelta   `json:"account_deltas"`
+	Except        string                   `json:"except,omitempty"`
+}
+type SimulateAccountDelta struct {
+	Account    common.AccountName `json:"account"`
+	CpuUsageUs uint32             `json:"cpu_usage_us"`
+	NetUsage   uint64             `json:"net_usage"`
+	RamDelta   int64              `json:"ram_delta"`
+}
+
+type WasmReadMemoryParams struct {
+	Address uint32 `json:"address"`
+	Length  uint32 `json:"length"`
+}
+type WasmReadMemoryResult struct {
+	Address uint32          `json:"address"`
+	Data    common.HexBytes `json:"data"`
+}
+
+type WasmDisassembleParams struct {
+	Function string `json:"function"` // the name or the index of the function
+}
+
 type GetCurrencyBalanceParams struct {
 	Code    common.Name `json:"code"`
 	Account common.Name `json:"account_name"`
@@ -179,10 +210,13 @@ type GetTableRowsParams struct {
 	KeyType       string      `json:"key_type"`        // type of key specified by index_position
 	IndexPosition string      `json:"index_position"`  // 1 - primary (first), 2 - secondary index (in order defined by multi_index), 3 - third index, etc
 	EncodeType    string      `json:"encode_type"`     //dec, hex , default=dec
+	Reverse       bool        `json:"reverse"`         // walk the index from the upper bound down to the lower bound
+	ShowPayer     bool        `json:"show_payer"`      // return the rows as {"data": row, "payer": payer}
 }
 type GetTableRowsResult struct {
-	Rows []common.Variants `json:"rows"` // true if last element in data is not the end and sizeof data() < limit
-	More bool              `json:"more"` // one row per item, either encoded as hex String or JSON object
+	Rows    []common.Variant `json:"rows"`     // one row per item, either encoded as hex String or JSON object
+	More    bool             `json:"more"`     // true if last element in data is not the end and sizeof data() < limit
+	NextKey string           `json:"next_key"` // the bound to fetch more rows with, lower_bound or upper_bound when reverse
 }
 
 type GetTableByScopeParams struct {
@@ -0,0 +1,229 @@
+package chain_plugin
+
+import (
+	"encoding/hex"
+	"fmt"
+	gomath "math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/eosspark/eos-go/common"
+	math "github.com/eosspark/eos-go/common/eos_math"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
+	. "github.com/eosspark/eos-go/entity"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+)
+
+const ripemd160 = "ripemd160"
+
+/**
+ * tableIndex is how get_table_rows walks an index of a contract table: the primary index of the key value objects,
+ * or one of the secondary index tables of the contract_secondary_idx_* apis.
+ * parse converts a bound to the key of the index, object builds the search object of a table (key nil to search by
+ * table only), read returns the table, primary key and key of the object an iterator points to, the key formatted
+ * so that it can be sent back as a bound.
+ */
+type tableIndex struct {
+	name      string
+	tableSkip database.SkipSuffix
+	keySkip   []database.SkipSuffix
+	parse     func(bound string) interface{}
+	object    func(tid common.IdType, key interface{}) interface{}
+	read      func(itr database.Iterator) (common.IdType, uint64, string)
+}
+
+func primaryTableIndex(keyType string) *tableIndex {
+	parse, format := uint64Converter(keyType)
+	return &tableIndex{
+		name:      "byScopePrimary",
+		tableSkip: database.SKIP_ONE,
+		parse:     parse,
+		object: func(tid common.IdType, key interface{}) interface{} {
+			obj := &KeyValueObject{TId: tid}
+			if key != nil {
+				obj.PrimaryKey = key.(uint64)
+			}
+			return obj
+		},
+		read: func(itr database.Iterator) (common.IdType, uint64, string) {
+			obj := KeyValueObject{}
+			Throw(itr.Data(&obj))
+			return obj.TId, obj.PrimaryKey, format(obj.PrimaryKey)
+		},
+	}
+}
+
+func secondaryTableIndex(keyType, encodeType string) *tableIndex {
+	index := &tableIndex{
+		name:      "bySecondary",
+		tableSkip: database.SKIP_TWO,
+		keySkip:   []database.SkipSuffix{database.SKIP_ONE},
+	}
+
+	switch keyType {
+	case i64, "name":
+		parse, format := uint64Converter(keyType)
+		index.parse = parse
+		index.object = func(tid common.IdType, key interface{}) interface{} {
+			obj := &Idx64Object{TId: tid}
+			if key != nil {
+				obj.SecondaryKey = key.(uint64)
+			}
+			return obj
+		}
+		index.read = func(itr database.Iterator) (common.IdType, uint64, string) {
+			obj := Idx64Object{}
+			Throw(itr.Data(&obj))
+			return obj.TId, obj.PrimaryKey, format(obj.SecondaryKey)
+		}
+
+	case i128:
+		index.parse = func(bound string) interface{} { return parseUint128(bound) }
+		index.object = func(tid common.IdType, key interface{}) interface{} {
+			obj := &Idx128Object{TId: tid}
+			if key != nil {
+				obj.SecondaryKey = key.(math.Uint128)
+			}
+			return obj
+		}
+		index.read = func(itr database.Iterator) (common.IdType, uint64, string) {
+			obj := Idx128Object{}
+			Throw(itr.Data(&obj))
+			return obj.TId, obj.PrimaryKey, formatUint128(obj.SecondaryKey)
+		}
+
+	case i256, sha256, ripemd160:
+		EosAssert(keyType != i256 || encodeType == "hex", &ContractTableQueryException{},
+			"Unsupported encode_type for i256 key type: %s, use hex", encodeType)
+		size := 32
+		if keyType == ripemd160 {
+			size = 20
+		}
+		index.parse = func(bound string) interface{} { return parseKey256(bound, size) }
+		index.object = func(tid common.IdType, key interface{}) interface{} {
+			obj := &Idx256Object{TId: tid}
+			if key != nil {
+				obj.SecondaryKey = key.(math.Uint256)
+			}
+			return obj
+		}
+		index.read = func(itr database.Iterator) (common.IdType, uint64, string) {
+			obj := Idx256Object{}
+			Throw(itr.Data(&obj))
+			return obj.TId, obj.PrimaryKey, formatKey256(obj.SecondaryKey, size)
+		}
+
+	case f64:
+		index.parse = func(bound string) interface{} {
+			return math.Float64(gomath.Float64bits(parseFloat(bound)))
+		}
+		index.object = func(tid common.IdType, key interface{}) interface{} {
+			obj := &IdxDoubleObject{TId: tid}
+			if key != nil {
+				obj.SecondaryKey = key.(math.Float64)
+			}
+			return obj
+		}
+		index.read = func(itr database.Iterator) (common.IdType, uint64, string) {
+			obj := IdxDoubleObject{}
+			Throw(itr.Data(&obj))
+			return obj.TId, obj.PrimaryKey, strconv.FormatFloat(gomath.Float64frombits(uint64(obj.SecondaryKey)), 'g', -1, 64)
+		}
+
+	case f128:
+		index.parse = func(bound string) interface{} { return parseFloat128(bound) }
+		index.object = func(tid common.IdType, key interface{}) interface{} {
+			obj := &IdxLongDoubleObject{TId: tid}
+			if key != nil {
+				obj.SecondaryKey = key.(math.Float128)
+			}
+			return obj
+		}
+		index.read = func(itr database.Iterator) (common.IdType, uint64, string) {
+			obj := IdxLongDoubleObject{}
+			Throw(itr.Data(&obj))
+			return obj.TId, obj.PrimaryKey, formatUint128(math.Uint128{Low: obj.SecondaryKey.Low, High: obj.SecondaryKey.High})
+		}
+
+	default:
+		EosThrow(&ContractTableQueryException{}, "Unsupported secondary index type: %s", keyType)
+	}
+	return index
+}
+
+/**
+ * keys of type name are read and written as names, other 64 bits keys are written as numbers
+ */
+func uint64Converter(keyType string) (func(string) interface{}, func(uint64) string) {
+	if keyType == "name" {
+		return func(bound string) interface{} {
+				return uint64(common.N(strings.TrimSpace(bound)))
+			}, func(key uint64) string {
+				return common.Name(key).String()
+			}
+	}
+	return func(bound string) interface{} {
+			return convertToUint64(bound, "bound")
+		}, func(key uint64) string {
+			return strconv.FormatUint(key, 10)
+		}
+}
+
+func parseBigInt(bound string, bits uint) *big.Int {
+	v, ok := new(big.Int).SetString(strings.TrimSpace(bound), 0)
+	EosAssert(ok && v.Sign() >= 0 && v.BitLen() <= int(bits), &ChainTypeException{},
+		"Could not convert bound '%s' to an unsigned %d bits integer", bound, bits)
+	return v
+}
+
+/**
+ * 128 bits keys are read as decimal or 0x prefixed hex numbers, and written as hex
+ */
+func parseUint128(bound string) math.Uint128 {
+	v := parseBigInt(bound, 128)
+	low := new(big.Int).And(v, new(big.Int).SetUint64(gomath.MaxUint64))
+	return math.Uint128{Low: low.Uint64(), High: new(big.Int).Rsh(v, 64).Uint64()}
+}
+
+func formatUint128(v math.Uint128) string {
+	return fmt.Sprintf("0x%016x%016x", v.High, v.Low)
+}
+
+/**
+ * sha256 and ripemd160 keys are hex strings, laid out in the 256 bits key as the contracts store them
+ */
+func parseKey256(bound string, size int) math.Uint256 {
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(bound), "0x"))
+	EosAssert(err == nil && len(data) == size, &ChainTypeException{},
+		"Could not convert bound '%s' to a %d bytes hex string", bound, size)
+
+	key := math.Uint256{}
+	Throw(rlp.DecodeBytes(append(data, make([]byte, 32-size)...), &key))
+	return key
+}
+
+func formatKey256(key math.Uint256, size int) string {
+	data, err := rlp.EncodeToBytes(key)
+	Throw(err)
+	return hex.EncodeToString(data[:size])
+}
+
+func parseFloat(bound string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(bound), 64)
+	EosAssert(err == nil, &ChainTypeException{}, "Could not convert bound '%s' to a float", bound)
+	return v
+}
+
+/**
+ * float128 keys are read as floats, or as the 0x prefixed hex of their bits which is how they are written
+ */
+func parseFloat128(bound string) math.Float128 {
+	if strings.HasPrefix(strings.TrimSpace(bound), "0x") {
+		v := parseUint128(bound)
+		return math.Float128{Low: v.Low, High: v.High}
+	}
+	return math.F64ToF128(math.Float64(gomath.Float64bits(parseFloat(bound))))
+}
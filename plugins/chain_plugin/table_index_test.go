@@ -0,0 +1,28 @@
+package chain_plugin
+
+import (
+	"testing"
+
+	math "github.com/eosspark/eos-go/common/eos_math"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableIndex_KeyConversions(t *testing.T) {
+	u := parseUint128("0x0000000000000001ffffffffffffffff")
+	assert.Equal(t, math.Uint128{Low: 0xffffffffffffffff, High: 1}, u)
+	assert.Equal(t, u, parseUint128("36893488147419103231"))
+	assert.Equal(t, "0x0000000000000001ffffffffffffffff", formatUint128(u))
+	assert.Panics(t, func() { parseUint128("0x1" + "00000000000000000000000000000000") })
+
+	sha := "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20"
+	key := parseKey256(sha, 32)
+	assert.Equal(t, uint64(0x0807060504030201), key.Low.Low)
+	assert.Equal(t, sha, formatKey256(key, 32))
+
+	ripemd := "0102030405060708090a0b0c0d0e0f1011121314"
+	assert.Equal(t, ripemd, formatKey256(parseKey256(ripemd, 20), 20))
+	assert.Panics(t, func() { parseKey256(ripemd, 32) })
+
+	f := parseFloat128("1.5")
+	assert.Equal(t, f, parseFloat128(formatUint128(math.Uint128{Low: f.Low, High: f.High})))
+}
@@ -124,6 +124,9 @@ func (c *Console) init(preload []string) (err error) {
 	producer := newProduceAPI(c)
 	c.jsre.Bind("producer", producer)
 
+	wasmDebug := newWasmDebugAPI(c)
+	c.jsre.Bind("wasmdebug", wasmDebug)
+
 	multiSig := newMultiSig(c)
 	//c.jsre.Bind("multiSig", multiSig)
 	c.jsre.Set("multisig", struct{}{})
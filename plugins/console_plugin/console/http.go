@@ -16,6 +16,7 @@ import (
 )
 
 var BaseUrl string
+var WasmDebugUrl string
 
 type client struct {
 	HttpClient *http.Client
@@ -111,6 +112,8 @@ func (client *client) call(path string, body interface{}) ([]byte, error) {
 			return nil, fmt.Errorf("%s: %s", "Missing History API Plugin", targetURL)
 		} else if strings.Contains(path, common.NetFuncBase) {
 			return nil, fmt.Errorf("%s: %s", "Missing Net API Plugin", targetURL)
+		} else if strings.Contains(path, common.WasmDebugFuncBase) {
+			return nil, fmt.Errorf("%s: %s", "Missing wasm debugger, start the node with wasm-debug", targetURL)
 		}
 	} else {
 		var errorInfo http_plugin.ErrorResults
@@ -130,7 +133,11 @@ func (client *client) call(path string, body interface{}) ([]byte, error) {
 }
 
 func DoHttpCall(result interface{}, path string, body interface{}) error {
-	client := NewClient(BaseUrl)
+	return doHttpCall(BaseUrl, result, path, body)
+}
+
+func doHttpCall(baseUrl string, result interface{}, path string, body interface{}) error {
+	client := NewClient(baseUrl)
 	out, err := client.call(path, body)
 	if err != nil {
 		return err
@@ -2,6 +2,7 @@ package console
 
 import (
 	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/plugins/producer_plugin"
 	"github.com/robertkrimen/otto"
 )
 
@@ -72,3 +73,14 @@ func (p *ProduceAPI) GetWhitelistBlacklist(call otto.FunctionCall) (response ott
 	}
 	return getJsResult(call, result)
 }
+
+func (p *ProduceAPI) GetWatermarks(call otto.FunctionCall) (response otto.Value) {
+	var result []producer_plugin.ProducerWatermark
+
+	err := DoHttpCall(&result, common.ProducerGetWatermarks, nil)
+	if err != nil {
+		clog.Error("GetWatermarks is error: %s", err.Error())
+		return otto.FalseValue()
+	}
+	return getJsResult(call, result)
+}
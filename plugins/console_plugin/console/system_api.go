@@ -168,7 +168,7 @@ func (s *system) Approveroducer(call otto.FunctionCall) (response otto.Value) {
 	if len(res.Rows) == 0 {
 		throwJSException(fmt.Sprintf("Voter info not found for account %s", params.Voter))
 	} else {
-		rows0, ok := res.Rows[0]["owner"]
+		rows0, ok := res.Rows[0].(common.Variants)["owner"]
 		if !ok {
 			throwJSException(fmt.Sprintf("Voter info not found for account %s", params.Voter))
 		} else {
@@ -180,7 +180,7 @@ func (s *system) Approveroducer(call otto.FunctionCall) (response otto.Value) {
 	}
 	EosAssert(1 == len(res.Rows), &exception.MultipleVoterInfo{}, "More than one voter_info for account")
 
-	prodsInterface, ok := res.Rows[0]["producers"] //TODO
+	prodsInterface, ok := res.Rows[0].(common.Variants)["producers"] //TODO
 	if !ok {
 		throwJSException(fmt.Sprintf("Voter info not found producers"))
 	}
@@ -233,7 +233,7 @@ func (s *system) UnapproveProducer(call otto.FunctionCall) (response otto.Value)
 	if len(res.Rows) == 0 {
 		throwJSException(fmt.Sprintf("Voter info not found for account %s", params.Voter))
 	} else {
-		rows0, ok := res.Rows[0]["owner"]
+		rows0, ok := res.Rows[0].(common.Variants)["owner"]
 		if !ok {
 			throwJSException(fmt.Sprintf("Voter info not found for account %s", params.Voter))
 		} else {
@@ -245,7 +245,7 @@ func (s *system) UnapproveProducer(call otto.FunctionCall) (response otto.Value)
 	}
 	EosAssert(1 == len(res.Rows), &exception.MultipleVoterInfo{}, "More than one voter_info for account")
 
-	prodsInterface, ok := res.Rows[0]["producers"] //TODO
+	prodsInterface, ok := res.Rows[0].(common.Variants)["producers"] //TODO
 	if !ok {
 		throwJSException(fmt.Sprintf("Voter info not found producers"))
 	}
@@ -0,0 +1,125 @@
+package console
+
+import (
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/plugins/chain_plugin"
+	"github.com/eosspark/eos-go/wasmgo"
+	"github.com/robertkrimen/otto"
+)
+
+// WasmDebugAPI drives the debugger of the contracts of a node started with wasm-debug, the node serves it on its
+// wasm-debug-address as the node does not serve its other apis while a contract is paused
+type WasmDebugAPI struct {
+	c *Console
+}
+
+func newWasmDebugAPI(c *Console) *WasmDebugAPI {
+	return &WasmDebugAPI{c: c}
+}
+
+// AddBreakpoint pauses a contract before an instruction of a function: {account, function, instruction}
+func (w *WasmDebugAPI) AddBreakpoint(call otto.FunctionCall) (response otto.Value) {
+	var params wasmgo.Breakpoint
+	readParams(&params, call)
+
+	var result []wasmgo.Breakpoint
+	if err := doHttpCall(WasmDebugUrl, &result, common.WasmDebugAddBreakpoint, params); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return getJsResult(call, result)
+}
+
+// RemoveBreakpoint removes a breakpoint: {account, function, instruction}
+func (w *WasmDebugAPI) RemoveBreakpoint(call otto.FunctionCall) (response otto.Value) {
+	var params wasmgo.Breakpoint
+	readParams(&params, call)
+
+	var result []wasmgo.Breakpoint
+	if err := doHttpCall(WasmDebugUrl, &result, common.WasmDebugRemoveBreakpoint, params); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return getJsResult(call, result)
+}
+
+// Breakpoints lists the breakpoints
+func (w *WasmDebugAPI) Breakpoints(call otto.FunctionCall) (response otto.Value) {
+	var result []wasmgo.Breakpoint
+	if err := doHttpCall(WasmDebugUrl, &result, common.WasmDebugGetBreakpoints, nil); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return getJsResult(call, result)
+}
+
+// State shows where the contract paused, with its action, frames, locals and operand stack, null when none is paused
+func (w *WasmDebugAPI) State(call otto.FunctionCall) (response otto.Value) {
+	var result *wasmgo.DebugState
+	if err := doHttpCall(WasmDebugUrl, &result, common.WasmDebugGetState, nil); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return getJsResult(call, result)
+}
+
+// Continue runs the paused contract to its next breakpoint
+func (w *WasmDebugAPI) Continue(call otto.FunctionCall) (response otto.Value) {
+	return w.command(call, common.WasmDebugContinue)
+}
+
+// Step runs the paused contract to its next wasm instruction
+func (w *WasmDebugAPI) Step(call otto.FunctionCall) (response otto.Value) {
+	return w.command(call, common.WasmDebugStep)
+}
+
+// StepOver runs the paused contract to the next wasm instruction of the function, over the functions it calls
+func (w *WasmDebugAPI) StepOver(call otto.FunctionCall) (response otto.Value) {
+	return w.command(call, common.WasmDebugStepOver)
+}
+
+// StepOut runs the paused contract until the function returns
+func (w *WasmDebugAPI) StepOut(call otto.FunctionCall) (response otto.Value) {
+	return w.command(call, common.WasmDebugStepOut)
+}
+
+// Abort fails the action of the paused contract
+func (w *WasmDebugAPI) Abort(call otto.FunctionCall) (response otto.Value) {
+	return w.command(call, common.WasmDebugAbort)
+}
+
+func (w *WasmDebugAPI) command(call otto.FunctionCall, path string) otto.Value {
+	if err := doHttpCall(WasmDebugUrl, nil, path, nil); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return otto.TrueValue()
+}
+
+// ReadMemory reads the linear memory of the paused contract: address, length
+func (w *WasmDebugAPI) ReadMemory(call otto.FunctionCall) (response otto.Value) {
+	address, err := call.Argument(0).ToInteger()
+	if err != nil {
+		return otto.UndefinedValue()
+	}
+	length, err := call.Argument(1).ToInteger()
+	if err != nil {
+		return otto.UndefinedValue()
+	}
+
+	params := chain_plugin.WasmReadMemoryParams{Address: uint32(address), Length: uint32(length)}
+	var result chain_plugin.WasmReadMemoryResult
+	if err := doHttpCall(WasmDebugUrl, &result, common.WasmDebugReadMemory, params); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return getJsResult(call, result)
+}
+
+// Disassemble lists the wasm instructions of a function of the paused contract, by its name or its index
+func (w *WasmDebugAPI) Disassemble(call otto.FunctionCall) (response otto.Value) {
+	function, err := call.Argument(0).ToString()
+	if err != nil {
+		return otto.UndefinedValue()
+	}
+
+	var result []wasmgo.DebugInstruction
+	if err := doHttpCall(WasmDebugUrl, &result, common.WasmDebugDisassemble, chain_plugin.WasmDisassembleParams{Function: function}); err != nil {
+		return getJsResult(call, err.Error())
+	}
+	return getJsResult(call, result)
+}
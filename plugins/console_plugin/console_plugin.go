@@ -39,6 +39,11 @@ func (cp *ConsolePlugin) SetProgramOptions(options *[]cli.Flag) {
 			Usage: "Start an interactive JavaScript environment (connect to node)",
 			Value: common.HttpEndPoint,
 		},
+		cli.StringFlag{
+			Name:  "attach-wasm-debug",
+			Usage: "The wasm debugger of the node to attach to, see wasm-debug-address",
+			Value: common.WasmDebugEndPoint,
+		},
 		cli.StringFlag{ // ATM the url is left to the user and deployment to
 			Name:  "jspath",
 			Usage: "JavaScript root path for `loadScript`",
@@ -141,6 +146,7 @@ func (cp *ConsolePlugin) PluginInitialize(c *cli.Context) {
 		}
 		cp.my.baseUrl = c.String("attach")
 		console.BaseUrl = cp.my.baseUrl
+		console.WasmDebugUrl = c.String("attach-wasm-debug")
 
 		cp.my.enable = c.Bool("console")
 		if cp.my.enable {
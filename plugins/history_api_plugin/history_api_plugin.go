@@ -0,0 +1,143 @@
+package history_api_plugin
+
+import (
+	"encoding/json"
+
+	"github.com/eosspark/eos-go/common"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+	. "github.com/eosspark/eos-go/plugins/appbase/app"
+	"github.com/eosspark/eos-go/plugins/history_plugin"
+	"github.com/eosspark/eos-go/plugins/http_plugin"
+	"github.com/urfave/cli"
+)
+
+const HistoryApiPlug = PluginTypeName("HistoryApiPlugin")
+
+var historyApiPlugin = App().RegisterPlugin(HistoryApiPlug, NewHistoryApiPlugin())
+
+type HistoryApiPlugin struct {
+	AbstractPlugin
+	log log.Logger
+}
+
+func NewHistoryApiPlugin() *HistoryApiPlugin {
+	plugin := &HistoryApiPlugin{}
+	plugin.log = log.New("HistoryApiPlugin")
+	plugin.log.SetHandler(log.TerminalHandler)
+	return plugin
+}
+
+func (h *HistoryApiPlugin) SetProgramOptions(options *[]cli.Flag) {
+}
+
+func (h *HistoryApiPlugin) PluginInitialize(options *cli.Context) {
+	App().GetPlugin(history_plugin.HistoryPlug).Initialize(options)
+}
+
+func (h *HistoryApiPlugin) PluginStartup() {
+	h.log.Info("starting history_api_plugin")
+
+	httpPlugin := App().GetPlugin(http_plugin.HttpPlug).(*http_plugin.HttpPlugin)
+	ROApi := App().GetPlugin(history_plugin.HistoryPlug).(*history_plugin.HistoryPlugin).GetReadOnlyApi()
+
+	httpPlugin.AddHandler(common.GetActionsFunc, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			if len(body) == 0 {
+				body = []byte("{}")
+			}
+
+			var param history_plugin.GetActionsParams
+			if err := json.Unmarshal(body, &param); err != nil {
+				EosThrow(&EofException{}, "marshal get_actions params: %s", err.Error())
+			}
+
+			result := ROApi.GetActions(param)
+
+			if byte, err := json.Marshal(result); err == nil {
+				cb(200, byte)
+			} else {
+				Throw(err)
+			}
+
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "history", "get_actions", string(body), cb)
+		}).End()
+	})
+
+	httpPlugin.AddHandler(common.GetTransactionFunc, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			if len(body) == 0 {
+				body = []byte("{}")
+			}
+
+			var param history_plugin.GetTransactionParams
+			if err := json.Unmarshal(body, &param); err != nil {
+				EosThrow(&EofException{}, "marshal get_transaction params: %s", err.Error())
+			}
+
+			result := ROApi.GetTransaction(param)
+
+			if byte, err := json.Marshal(result); err == nil {
+				cb(200, byte)
+			} else {
+				Throw(err)
+			}
+
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "history", "get_transaction", string(body), cb)
+		}).End()
+	})
+
+	httpPlugin.AddHandler(common.GetKeyAccountsFunc, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			if len(body) == 0 {
+				body = []byte("{}")
+			}
+
+			var param history_plugin.GetKeyAccountsParams
+			if err := json.Unmarshal(body, &param); err != nil {
+				EosThrow(&EofException{}, "marshal get_key_accounts params: %s", err.Error())
+			}
+
+			result := ROApi.GetKeyAccounts(param)
+
+			if byte, err := json.Marshal(result); err == nil {
+				cb(200, byte)
+			} else {
+				Throw(err)
+			}
+
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "history", "get_key_accounts", string(body), cb)
+		}).End()
+	})
+
+	httpPlugin.AddHandler(common.GetControlledAccountsFunc, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			if len(body) == 0 {
+				body = []byte("{}")
+			}
+
+			var param history_plugin.GetControlledAccountsParams
+			if err := json.Unmarshal(body, &param); err != nil {
+				EosThrow(&EofException{}, "marshal get_controlled_accounts params: %s", err.Error())
+			}
+
+			result := ROApi.GetControlledAccounts(param)
+
+			if byte, err := json.Marshal(result); err == nil {
+				cb(200, byte)
+			} else {
+				Throw(err)
+			}
+
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "history", "get_controlled_accounts", string(body), cb)
+		}).End()
+	})
+}
+
+func (h *HistoryApiPlugin) PluginShutdown() {
+}
@@ -1,160 +1,406 @@
 package history_plugin
 
-//type AccountHistoryObject struct {
-//	ID                 types.IdType       `storm:"id,increment" json:"id"`
-//	Account            common.AccountName `storm:"index"`
-//	ActionSequenceNum  uint64
-//	AccountSequenceNum int32
-//	ByAccountActionSeq ByAccountActionSeq `storm:"index"`
-//	/*ByAccountActionSeq struct {
-//		Account            common.AccountName
-//		AccountSequenceNum int32
-//	}`storm:"index"`*/
-//}
-//
-///*search condition*/
-//type ByAccountActionSeq struct {
-//	Account            common.AccountName
-//	AccountSequenceNum int32
-//}
-//
-//type ActionHistoryObject struct {
-//	ID                types.IdType `storm:"id,increment"`
-//	ActionSequenceNum uint64       `storm:"by_action_sequence_num unique"`
-//	PackedActionTrace common.HexBytes
-//	BlockNum          uint32
-//	BlockTime         common.BlockTimeStamp
-//	TrxId             common.TransactionIdType
-//	//c++ no param
-//	ByTrxId struct {
-//		TrxId             common.TransactionIdType
-//		ActionSequenceNum uint64
-//	} `storm:"unique"`
-//}
-//
-//func AddAccountHistoryObject(db *eosiodb.DataBase, aho *AccountHistoryObject) {
-//	//fmt.Println("params:", aho)
-//	err := db.Insert(&aho)
-//	defer db.Close()
-//	if err != nil {
-//		fmt.Println(err.Error())
-//		return
-//	}
-//}
-//
-//func GetAccountHistoryObjectById(db *eosiodb.DataBase, id types.IdType) {
-//	result := AccountHistoryObject{}
-//	err := db.Find("ById", id, &result)
-//	defer db.Close()
-//	if err != nil {
-//		fmt.Println(err.Error())
-//		return
-//	}
-//	//fmt.Println("GetAccountHistoryObjectById result :", result)
-//}
-//
-//func GetAccountByAccountActionSeq(db *eosiodb.DataBase, asNum int32, account common.AccountName) {
-//
-//	aho := AccountHistoryObject{}
-//	aho.ByAccountActionSeq.AccountSequenceNum = asNum
-//	aho.ByAccountActionSeq.Account = account
-//	result := AccountHistoryObject{}
-//	err := db.Find("condition", aho.ByAccountActionSeq, &result)
-//	defer db.Close()
-//	if err != nil {
-//		fmt.Println(err.Error())
-//		return
-//	}
-//	//fmt.Println("GetAccountByAccActSeq result:", result)
-//}
-//
-//func GetActionHistoryObjectByActSeqNum(db *eosiodb.DataBase, asNum uint64) *ActionHistoryObject {
-//	result := ActionHistoryObject{}
-//	err := db.Find("ActionSequenceNum", asNum, &result)
-//	defer db.Close()
-//	if err != nil {
-//		fmt.Println("GetActionHistoryObjectByActSeqNum is error detail :", err.Error())
-//	}
-//	return &result
-//}
-//
-//func GetActionHistoryByAccActSeq(db *eosiodb.DataBase, asNum uint64, trxId common.TransactionIdType) {
-//	result := ActionHistoryObject{}
-//	result.ByTrxId.TrxId = trxId
-//	result.ByTrxId.ActionSequenceNum = asNum
-//	err := db.Find("ByAccountActionSeq", result.ByTrxId, &result)
-//	defer db.Close()
-//	if err != nil {
-//		fmt.Println(err.Error())
-//		return
-//	}
-//	//fmt.Println("result:", result)
-//}
-//
-//func GetAccountHistoryObjectByAccount(db *eosiodb.DataBase, account common.AccountName) *[]AccountHistoryObject {
-//	aho := AccountHistoryObject{}
-//	aho.Account = account
-//	//aho.ByAccountActionSeq.AccountSequenceNum = 4
-//	fmt.Println("+++++++++++++++++:", aho.ByAccountActionSeq.Account)
-//	var result []AccountHistoryObject
-//	err := db.Get("Account", aho.Account, &result)
-//	defer db.Close()
-//	if err != nil {
-//		fmt.Println(err.Error())
-//		return nil
-//	}
-//	//fmt.Print("result :", result)
-//	return &result
-//}
-//
-//type GetActionParam struct {
-//	AccountName common.AccountName
-//	Pos         int32
-//	Offset      int32
-//}
-//
-//type OrderedActionResult struct {
-//	GlobalActionSeq  uint64
-//	AccountActionSeq int32
-//	BlockNum         uint32
-//	BlockTime        common.BlockTimeStamp
-//	ActionTrace      types.ActionTrace
-//}
-//type GetActionResult struct {
-//	Actions                []OrderedActionResult
-//	LastIrreversibleBlock  uint32
-//	TimeLimitExceededError bool
-//}
-//
-//func GetActions(params *GetActionParam) *[]GetActionResult {
-//	//control := chain.GetControlInstance()
-//	//db := control.DB()
-//	//chain.get_abi_serializer_max_time
-//	abiSerializerMaxTime := chain_plugin.GetInstance().GetAbiSerializerMaxTime()
-//
-//	db, err := eosiodb.NewDataBase("./", "shared_memory.bin", true)
-//	if err != nil {
-//		fmt.Println("Test_GetAccountHistoryObject is error detail:", err.Error())
-//	}
-//	defer db.Close()
-//
-//	ahoList := *GetAccountHistoryObjectByAccount(db, params.AccountName)
-//	var pageNum, start, end int32
-//	start = params.Pos
-//	if start == 0 {
-//		end = params.Offset //data count per page
-//	}
-//	//end = params.Offset+5
-//	if len(ahoList) > 0 {
-//		pageNum = int32(len(ahoList)) / end
-//	}
-//	//db.Find("ByAccountActionSeq",AccountHistoryObject,[]AccountHistoryObject)
-//
-//	array := ahoList[0:3]
-//	fmt.Println(array)
-//
-//	fmt.Println(abiSerializerMaxTime, pageNum, start, end)
-//
-//	//return &array
-//	return nil
-//}
+import (
+	"strings"
+
+	"github.com/eosspark/eos-go/chain"
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/chain/types/generated_containers"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
+	"github.com/eosspark/eos-go/entity"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+	. "github.com/eosspark/eos-go/plugins/appbase/app"
+	"github.com/eosspark/eos-go/plugins/chain_interface"
+	"github.com/eosspark/eos-go/plugins/chain_plugin"
+	"github.com/urfave/cli"
+)
+
+const HistoryPlug = PluginTypeName("HistoryPlugin")
+
+var historyPlugin = App().RegisterPlugin(HistoryPlug, NewHistoryPlugin())
+
+type HistoryPlugin struct {
+	AbstractPlugin
+	my  *HistoryPluginImpl
+	log log.Logger
+}
+
+/**
+ * A filter entry matches receiver:action:actor, a zero action or actor matches any.
+ */
+type FilterEntry struct {
+	Receiver common.Name
+	Action   common.Name
+	Actor    common.Name
+}
+
+type HistoryPluginImpl struct {
+	BypassFilter bool
+	FilterOn     map[FilterEntry]struct{}
+	FilterOut    map[FilterEntry]struct{}
+
+	ChainPlug *chain_plugin.ChainPlugin
+	DB        database.DataBase
+
+	LastIrreversibleBlock uint32
+}
+
+func NewHistoryPlugin() *HistoryPlugin {
+	plugin := &HistoryPlugin{}
+	plugin.my = NewHistoryPluginImpl()
+	plugin.log = log.New("HistoryPlugin")
+	plugin.log.SetHandler(log.TerminalHandler)
+	return plugin
+}
+
+func NewHistoryPluginImpl() *HistoryPluginImpl {
+	return &HistoryPluginImpl{
+		FilterOn:  make(map[FilterEntry]struct{}),
+		FilterOut: make(map[FilterEntry]struct{}),
+	}
+}
+
+func (h *HistoryPlugin) SetProgramOptions(options *[]cli.Flag) {
+	*options = append(*options,
+		cli.StringSliceFlag{
+			Name: "filter-on,f",
+			Usage: "Track actions which match receiver:action:actor. Receiver may not be blank. " +
+				"Action and actor both blank allows all from Receiver. Action blank and actor not blank allows all from Receiver with actor. " +
+				"Actor blank allows all from receiver:action. Use * to track all actions",
+		},
+		cli.StringSliceFlag{
+			Name: "filter-out,F",
+			Usage: "Do not track actions which match receiver:action:actor. " +
+				"Action and actor both blank excludes all from Receiver. Actor blank excludes all from receiver:action. Receiver may not be blank.",
+		},
+	)
+}
+
+func (h *HistoryPlugin) PluginInitialize(options *cli.Context) {
+	Try(func() {
+		for _, s := range options.StringSlice("filter-on") {
+			if s == "*" || s == "\"*\"" {
+				h.my.BypassFilter = true
+				h.log.Warn("--filter-on * enabled. This can fill the state database, causing eosgo to stop.")
+				break
+			}
+			h.my.FilterOn[ParseFilterEntry(s, "filter-on")] = struct{}{}
+		}
+		for _, s := range options.StringSlice("filter-out") {
+			h.my.FilterOut[ParseFilterEntry(s, "filter-out")] = struct{}{}
+		}
+
+		h.my.ChainPlug = App().GetPlugin(chain_plugin.ChainPlug).(*chain_plugin.ChainPlugin)
+		chain := h.my.ChainPlug.Chain()
+		h.my.DB = chain.DataBase()
+
+		chain.AppliedTransaction.Connect(&chain_interface.AppliedTransactionCaller{Caller: h.my.OnAppliedTransaction})
+		chain.IrreversibleBlock.Connect(&chain_interface.IrreversibleBlockCaller{Caller: h.my.OnIrreversibleBlock})
+	}).FcLogAndRethrow().End()
+}
+
+func (h *HistoryPlugin) PluginStartup() {
+	h.log.Info("starting history_plugin")
+}
+
+func (h *HistoryPlugin) PluginShutdown() {
+}
+
+func (h *HistoryPlugin) GetReadOnlyApi() *ReadOnly {
+	return NewReadOnly(h.my)
+}
+
+func ParseFilterEntry(s string, option string) FilterEntry {
+	v := strings.Split(s, ":")
+	EosAssert(len(v) == 3, &InvalidArgException{}, "Invalid value %s for --%s", s, option)
+	fe := FilterEntry{Receiver: common.N(v[0]), Action: common.N(v[1]), Actor: common.N(v[2])}
+	EosAssert(fe.Receiver != 0, &InvalidArgException{}, "Invalid value %s for --%s", s, option)
+	return fe
+}
+
+func (h *HistoryPluginImpl) Chain() *chain.Controller {
+	return h.ChainPlug.Chain()
+}
+
+func (h *HistoryPluginImpl) matches(filters map[FilterEntry]struct{}, receiver, action, actor common.Name) bool {
+	_, ok := filters[FilterEntry{receiver, action, actor}]
+	return ok
+}
+
+func (h *HistoryPluginImpl) Filter(at *types.ActionTrace) bool {
+	receiver, action := at.Receipt.Receiver, at.Act.Name
+
+	passOn := h.BypassFilter ||
+		h.matches(h.FilterOn, receiver, 0, 0) ||
+		h.matches(h.FilterOn, receiver, action, 0)
+	for _, a := range at.Act.Authorization {
+		if h.matches(h.FilterOn, receiver, 0, a.Actor) || h.matches(h.FilterOn, receiver, action, a.Actor) {
+			passOn = true
+		}
+	}
+	if !passOn {
+		return false
+	}
+
+	if h.matches(h.FilterOut, receiver, 0, 0) || h.matches(h.FilterOut, receiver, action, 0) {
+		return false
+	}
+	for _, a := range at.Act.Authorization {
+		if h.matches(h.FilterOut, receiver, 0, a.Actor) || h.matches(h.FilterOut, receiver, action, a.Actor) {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * returns the accounts an action is recorded against: the receiver, plus every authorizer that passes the filters
+ */
+func (h *HistoryPluginImpl) AccountSet(at *types.ActionTrace) []common.AccountName {
+	receiver, action := at.Receipt.Receiver, at.Act.Name
+	result := []common.AccountName{receiver}
+	seen := map[common.AccountName]bool{receiver: true}
+
+	for _, a := range at.Act.Authorization {
+		if seen[a.Actor] {
+			continue
+		}
+		if h.BypassFilter ||
+			h.matches(h.FilterOn, receiver, 0, 0) ||
+			h.matches(h.FilterOn, receiver, 0, a.Actor) ||
+			h.matches(h.FilterOn, receiver, action, 0) ||
+			h.matches(h.FilterOn, receiver, action, a.Actor) {
+			if !h.matches(h.FilterOut, receiver, 0, 0) &&
+				!h.matches(h.FilterOut, receiver, 0, a.Actor) &&
+				!h.matches(h.FilterOut, receiver, action, 0) &&
+				!h.matches(h.FilterOut, receiver, action, a.Actor) {
+				seen[a.Actor] = true
+				result = append(result, a.Actor)
+			}
+		}
+	}
+	return result
+}
+
+/**
+ * returns the highest account sequence recorded for account n
+ */
+func (h *HistoryPluginImpl) LastAccountSequence(n common.AccountName) (int32, bool) {
+	idx, err := h.DB.GetIndex("byAccountActionSeq", entity.AccountHistoryObject{})
+	Throw(err)
+	if idx.Empty() {
+		return 0, false
+	}
+
+	itr, err := idx.LowerBound(entity.AccountHistoryObject{Account: n + 1})
+	Throw(err)
+	if idx.CompareBegin(itr) {
+		return 0, false
+	}
+	itr.Prev()
+
+	obj := entity.AccountHistoryObject{}
+	Throw(itr.Data(&obj))
+	if obj.Account != n {
+		return 0, false
+	}
+	return obj.AccountSequenceNum, true
+}
+
+func (h *HistoryPluginImpl) RecordAccountAction(n common.AccountName, at *types.ActionTrace) {
+	asn := int32(0)
+	if last, ok := h.LastAccountSequence(n); ok {
+		asn = last + 1
+	}
+
+	aho := entity.AccountHistoryObject{
+		Account:            n,
+		ActionSequenceNum:  at.Receipt.GlobalSequence,
+		AccountSequenceNum: asn,
+	}
+	Throw(h.DB.Insert(&aho))
+}
+
+func (h *HistoryPluginImpl) addKeys(keys []types.KeyWeight, name common.AccountName, permission common.PermissionName) {
+	for _, k := range keys {
+		obj := entity.PublicKeyHistoryObject{PublicKey: k.Key, Name: name, Permission: permission}
+		Throw(h.DB.Insert(&obj))
+	}
+}
+
+func (h *HistoryPluginImpl) addAccounts(accounts []types.PermissionLevelWeight, name common.AccountName, permission common.PermissionName) {
+	controlling := make(map[common.AccountName]bool)
+	for _, a := range accounts {
+		if controlling[a.Permission.Actor] {
+			continue
+		}
+		controlling[a.Permission.Actor] = true
+
+		obj := entity.AccountControlHistoryObject{ControlledAccount: name, ControlledPermission: permission, ControllingAccount: a.Permission.Actor}
+		Throw(h.DB.Insert(&obj))
+	}
+}
+
+func (h *HistoryPluginImpl) removeAuthority(name common.AccountName, permission common.PermissionName) {
+	keys, err := h.DB.GetIndex("byAccountPermission", entity.PublicKeyHistoryObject{})
+	Throw(err)
+	keyObjs := make([]entity.PublicKeyHistoryObject, 0)
+	itr, err := keys.LowerBound(entity.PublicKeyHistoryObject{Name: name, Permission: permission})
+	Throw(err)
+	for ; !keys.CompareEnd(itr); itr.Next() {
+		obj := entity.PublicKeyHistoryObject{}
+		Throw(itr.Data(&obj))
+		if obj.Name != name || obj.Permission != permission {
+			break
+		}
+		keyObjs = append(keyObjs, obj)
+	}
+	for i := range keyObjs {
+		Throw(h.DB.Remove(&keyObjs[i]))
+	}
+
+	controls, err := h.DB.GetIndex("byControlledAuthority", entity.AccountControlHistoryObject{})
+	Throw(err)
+	controlObjs := make([]entity.AccountControlHistoryObject, 0)
+	itr, err = controls.LowerBound(entity.AccountControlHistoryObject{ControlledAccount: name, ControlledPermission: permission})
+	Throw(err)
+	for ; !controls.CompareEnd(itr); itr.Next() {
+		obj := entity.AccountControlHistoryObject{}
+		Throw(itr.Data(&obj))
+		if obj.ControlledAccount != name || obj.ControlledPermission != permission {
+			break
+		}
+		controlObjs = append(controlObjs, obj)
+	}
+	for i := range controlObjs {
+		Throw(h.DB.Remove(&controlObjs[i]))
+	}
+}
+
+func (h *HistoryPluginImpl) OnSystemAction(at *types.ActionTrace) {
+	switch at.Act.Name {
+	case common.N("newaccount"):
+		create := chain.NewAccount{}
+		at.Act.DataAs(&create)
+		h.addKeys(create.Owner.Keys, create.Name, common.DefaultConfig.OwnerName)
+		h.addAccounts(create.Owner.Accounts, create.Name, common.DefaultConfig.OwnerName)
+		h.addKeys(create.Active.Keys, create.Name, common.DefaultConfig.ActiveName)
+		h.addAccounts(create.Active.Accounts, create.Name, common.DefaultConfig.ActiveName)
+
+	case common.N("updateauth"):
+		update := chain.UpdateAuth{}
+		at.Act.DataAs(&update)
+		h.removeAuthority(update.Account, update.Permission)
+		h.addKeys(update.Auth.Keys, update.Account, update.Permission)
+		h.addAccounts(update.Auth.Accounts, update.Account, update.Permission)
+
+	case common.N("deleteauth"):
+		del := chain.DeleteAuth{}
+		at.Act.DataAs(&del)
+		h.removeAuthority(del.Account, del.Permission)
+	}
+}
+
+// historyActionTrace is the form the history keeps an action trace in. The actions in the history did not fail, so the
+// exception of the trace is left out, it can not be unpacked.
+type historyActionTrace struct {
+	Receipt          types.ActionReceipt
+	Act              types.Action
+	ContextFree      bool
+	Elapsed          common.Microseconds
+	CpuUsage         uint64
+	Console          string
+	TotalCpuUsage    uint64
+	TrxId            common.TransactionIdType
+	BlockNum         uint32
+	BlockTime        types.BlockTimeStamp
+	ProducerBlockId  common.BlockIdType
+	AccountRamDeltas generated.AccountDeltaSet
+	InlineTraces     []historyActionTrace
+}
+
+func newHistoryActionTrace(at *types.ActionTrace) *historyActionTrace {
+	h := &historyActionTrace{
+		Receipt:          at.Receipt,
+		Act:              at.Act,
+		ContextFree:      at.ContextFree,
+		Elapsed:          at.Elapsed,
+		CpuUsage:         at.CpuUsage,
+		Console:          at.Console,
+		TotalCpuUsage:    at.TotalCpuUsage,
+		TrxId:            at.TrxId,
+		BlockNum:         at.BlockNum,
+		BlockTime:        at.BlockTime,
+		ProducerBlockId:  at.ProducerBlockId,
+		AccountRamDeltas: at.AccountRamDeltas,
+		InlineTraces:     make([]historyActionTrace, len(at.InlineTraces)),
+	}
+	for i := range at.InlineTraces {
+		h.InlineTraces[i] = *newHistoryActionTrace(&at.InlineTraces[i])
+	}
+	return h
+}
+
+func (h *historyActionTrace) actionTrace() types.ActionTrace {
+	at := types.ActionTrace{InlineTraces: make([]types.ActionTrace, len(h.InlineTraces))}
+	at.Receipt = h.Receipt
+	at.Act = h.Act
+	at.ContextFree = h.ContextFree
+	at.Elapsed = h.Elapsed
+	at.CpuUsage = h.CpuUsage
+	at.Console = h.Console
+	at.TotalCpuUsage = h.TotalCpuUsage
+	at.TrxId = h.TrxId
+	at.BlockNum = h.BlockNum
+	at.BlockTime = h.BlockTime
+	at.ProducerBlockId = h.ProducerBlockId
+	at.AccountRamDeltas = h.AccountRamDeltas
+	for i := range h.InlineTraces {
+		at.InlineTraces[i] = h.InlineTraces[i].actionTrace()
+	}
+	return at
+}
+
+func (h *HistoryPluginImpl) OnActionTrace(at *types.ActionTrace) {
+	if h.Filter(at) {
+		packed, err := rlp.EncodeToBytes(newHistoryActionTrace(at))
+		Throw(err)
+
+		aho := entity.ActionHistoryObject{
+			TrxId:             at.TrxId,
+			ActionSequenceNum: at.Receipt.GlobalSequence,
+			PackedActionTrace: packed,
+			BlockNum:          at.BlockNum,
+			BlockTime:         at.BlockTime,
+		}
+		Throw(h.DB.Insert(&aho))
+
+		for _, a := range h.AccountSet(at) {
+			h.RecordAccountAction(a, at)
+		}
+	}
+	if at.Receipt.Receiver == common.DefaultConfig.SystemAccountName {
+		h.OnSystemAction(at)
+	}
+	for i := range at.InlineTraces {
+		h.OnActionTrace(&at.InlineTraces[i])
+	}
+}
+
+func (h *HistoryPluginImpl) OnAppliedTransaction(trace *types.TransactionTrace) {
+	if trace.Except != nil || (trace.Receipt.Status != types.TransactionStatusExecuted &&
+		trace.Receipt.Status != types.TransactionStatusSoftFail) {
+		return
+	}
+	for i := range trace.ActionTraces {
+		h.OnActionTrace(&trace.ActionTraces[i])
+	}
+}
+
+func (h *HistoryPluginImpl) OnIrreversibleBlock(s *types.BlockState) {
+	h.LastIrreversibleBlock = s.BlockNum
+}
@@ -1,155 +1,201 @@
 package history_plugin
 
 import (
+	"os"
 	"testing"
+
+	"github.com/eosspark/eos-go/chain"
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/chain/types/generated_containers"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/ecc"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/stretchr/testify/assert"
 )
 
-func Test_AddAccountHistoryObject(t *testing.T) {
-	/*db, err := eosiodb.NewDataBase("./", "shared_memory.bin", true)
-	if err != nil {
-		fmt.Println("Test_AddAccountHistoryObject is error detail:", err.Error())
-	}
-	defer db.Close()
-
-	aho := AccountHistoryObject{}
-	acount := common.AccountName(common.N("tuanhuo"))
-	num := int32(66)
-	aho.Account = acount
-	aho.AccountSequenceNum = num
-	aho.ByAccountActionSeq.Account = acount
-	aho.ByAccountActionSeq.AccountSequenceNum = num
-	aho.ActionSequenceNum = uint64(aho.ByAccountActionSeq.AccountSequenceNum)
-	c := ByAccountActionSeq{}
-	c.Account = aho.ByAccountActionSeq.Account
-	c.AccountSequenceNum = aho.ByAccountActionSeq.AccountSequenceNum
-	err = db.Insert(&aho)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+const testDbPath = "/tmp/history_plugin_test"
+
+func newTestHistory(t *testing.T, filters ...string) (*HistoryPluginImpl, func()) {
+	os.RemoveAll(testDbPath)
+	db, err := database.NewDataBase(testDbPath)
+	assert.NoError(t, err)
+
+	h := NewHistoryPluginImpl()
+	h.DB = db
+	for _, f := range filters {
+		if f == "*" {
+			h.BypassFilter = true
+			continue
+		}
+		h.FilterOn[ParseFilterEntry(f, "filter-on")] = struct{}{}
 	}
-	fmt.Println("Test_AddAccountHistoryObject finished")
 
-	fmt.Println("*******************************************")
-	result := []AccountHistoryObject{}
-	db.All(&result)
-	fmt.Print("all context:", result)*/
+	return h, func() {
+		db.Close()
+		os.RemoveAll(testDbPath)
+	}
 }
 
-/*func Test_AddAccountHistorys(t *testing.T) {
+var globalSequence uint64
 
-	fmt.Println(time.Now())
-	db, err := eosiodb.NewDataBase("./", "shared_memory.bin", true)
-	if err != nil {
-		fmt.Println("Test_AddAccountHistoryIndex is error detail:",err.Error())
-	}
-	defer db.Close()
+func newActionTrace(trxId common.TransactionIdType, receiver, account, name common.AccountName, actors []common.AccountName, data interface{}) types.ActionTrace {
+	globalSequence++
 
-	results := []AccountHistoryObject{}
-	err = db.All(&results)
-	if err != nil{
-		fmt.Println("db.all() is error",err.Error())
+	at := types.ActionTrace{}
+	at.Receipt.Receiver = receiver
+	at.Receipt.GlobalSequence = globalSequence
+	at.Receipt.AuthSequence = *generated.NewAccountNameUint64Map()
+	at.AccountRamDeltas = *generated.NewAccountDeltaSet()
+	at.Act.Account = account
+	at.Act.Name = name
+	for _, a := range actors {
+		at.Act.Authorization = append(at.Act.Authorization, common.PermissionLevel{Actor: a, Permission: common.DefaultConfig.ActiveName})
 	}
-	fmt.Println("db.all() result:",results)
-	aho := AccountHistoryObject{}
-	aho.ID = 1
-	var h AccountHistoryObject
-	err = db.Find("ID",&aho.ID,&h)
-	if err != nil{
-		fmt.Println(err.Error())
-		return
+	if data != nil {
+		bytes, err := rlp.EncodeToBytes(data)
+		Throw(err)
+		at.Act.Data = bytes
 	}
-	fmt.Println(h)
-	AddAccountHistoryObject(db, &h)
-
-	for i :=0;i<1000;i++ {
-		aho := AccountHistoryObject{}
-		aho.Account = (h.Account+common.AccountName(i))
-		aho.AccountSequenceNum = int32(i+1)
-		aho.ActionSequenceNum = uint64(aho.AccountSequenceNum)
-		aho.condition.account = aho.Account
-		aho.condition.accountSequenceNum = aho.AccountSequenceNum
-		AddAccountHistoryObject(db, &aho)
+	at.TrxId = trxId
+	at.BlockNum = 10
+	return at
+}
+
+func newTransactionTrace(trxId common.TransactionIdType, traces ...types.ActionTrace) *types.TransactionTrace {
+	return &types.TransactionTrace{
+		ID:           trxId,
+		Receipt:      types.TransactionReceiptHeader{Status: types.TransactionStatusExecuted},
+		ActionTraces: traces,
 	}
+}
 
+func int32Ptr(i int32) *int32 { return &i }
 
-	fmt.Println(time.Now())
-}*/
+func TestParseFilterEntry(t *testing.T) {
+	fe := ParseFilterEntry("eosio.token:transfer:", "filter-on")
+	assert.Equal(t, FilterEntry{Receiver: common.N("eosio.token"), Action: common.N("transfer")}, fe)
 
-func Test_GetAccountHistoryObject(t *testing.T) {
-	/*db, err := eosiodb.NewDataBase("./", "shared_memory.bin", true)
-	if err != nil {
-		fmt.Println("Test_GetAccountHistoryObject is error detail:", err.Error())
-	}
-	defer db.Close()
-
-	result := AccountHistoryObject{}
-	result.ID = 2
-	err = db.Find("ID", result.ID, &result)
-	if err != nil {
-		fmt.Println("Test_GetAccountHistoryObject is error detail:", err.Error())
-		return
+	for _, s := range []string{"eosio.token", ":transfer:alice", "a:b:c:d"} {
+		returning := false
+		Try(func() {
+			ParseFilterEntry(s, "filter-on")
+		}).Catch(func(e *InvalidArgException) {
+			returning = true
+		}).End()
+		assert.True(t, returning, s)
 	}
-	fmt.Println("object:", result)
+}
 
-	//results := []AccountHistoryIndex{}
-	var results []AccountHistoryObject
+func TestFilter(t *testing.T) {
+	h, closeDb := newTestHistory(t, "eosio.token::", "dice:bet:alice")
+	defer closeDb()
+	h.FilterOut[ParseFilterEntry("eosio.token:issue:", "filter-out")] = struct{}{}
 
-	err = db.All(&results)
-	if err != nil {
-		fmt.Println(err.Error())
-	}
-	fmt.Println("query all data:", results)*/
+	trxId := *crypto.Hash256("filter")
+	token := common.N("eosio.token")
+	alice, bob := common.N("alice"), common.N("bob")
+
+	transfer := newActionTrace(trxId, token, token, common.N("transfer"), []common.AccountName{alice}, nil)
+	issue := newActionTrace(trxId, token, token, common.N("issue"), []common.AccountName{alice}, nil)
+	aliceBet := newActionTrace(trxId, common.N("dice"), common.N("dice"), common.N("bet"), []common.AccountName{alice}, nil)
+	bobBet := newActionTrace(trxId, common.N("dice"), common.N("dice"), common.N("bet"), []common.AccountName{bob}, nil)
+
+	assert.True(t, h.Filter(&transfer))
+	assert.False(t, h.Filter(&issue))
+	assert.True(t, h.Filter(&aliceBet))
+	assert.False(t, h.Filter(&bobBet))
+
+	assert.Equal(t, []common.AccountName{token, alice}, h.AccountSet(&transfer))
+	assert.Equal(t, []common.AccountName{common.N("dice"), alice}, h.AccountSet(&aliceBet))
 }
 
-func Test_UpdateAccountHistoryObject(t *testing.T) {
-	/*db, err := eosiodb.NewDataBase("./", "shared_memory.bin", true)
-	if err != nil {
-		fmt.Println("Test_UpdateAccountHistoryObject is error detail:", err.Error())
+func TestGetActions(t *testing.T) {
+	h, closeDb := newTestHistory(t, "*")
+	defer closeDb()
+	ro := NewReadOnly(h)
+
+	alice, bob := common.N("alice"), common.N("bob")
+	for i := 0; i < 30; i++ {
+		trxId := *crypto.Hash256(i)
+		at := newActionTrace(trxId, alice, alice, common.N("hi"), []common.AccountName{alice, bob}, nil)
+		h.OnAppliedTransaction(newTransactionTrace(trxId, at))
 	}
-	defer db.Close()
 
-	var results []AccountHistoryObject
-	err = db.All(&results)
-	if err != nil {
-		fmt.Println("update is err :", err.Error())
-		return
+	result := ro.GetActions(GetActionsParams{AccountName: alice})
+	assert.Equal(t, 20, len(result.Actions))
+	assert.Equal(t, int32(10), result.Actions[0].AccountActionSeq)
+	assert.Equal(t, int32(29), result.Actions[19].AccountActionSeq)
+
+	result = ro.GetActions(GetActionsParams{AccountName: bob, Pos: int32Ptr(0), Offset: int32Ptr(4)})
+	assert.Equal(t, 5, len(result.Actions))
+	for i, a := range result.Actions {
+		assert.Equal(t, int32(i), a.AccountActionSeq)
+		assert.Equal(t, alice, a.ActionTrace.Receipt.Receiver)
+		assert.Equal(t, a.GlobalActionSeq, a.ActionTrace.Receipt.GlobalSequence)
 	}
 
-	//updateObject All properties can not be empty.
-
-	//param :=AccountHistoryObject{}
-	param := results[0]
-	aho := AccountHistoryObject{}
-	aho.ID = param.ID
-	//aho.Condition.AccountSequenceNum = param.ByAccountActionSeq.AccountSequenceNum+5
-	//aho.Condition.Account = param.ByAccountActionSeq.Account
-	fmt.Println("modify object param:", param)
-	err = db.UpdateObject(&param, &aho)
-	if err != nil {
-		fmt.Println("modify object is error:", err.Error())
-		return
-	}*/
+	result = ro.GetActions(GetActionsParams{AccountName: common.N("carol")})
+	assert.Equal(t, 0, len(result.Actions))
+
+	trxId := *crypto.Hash256(3)
+	trx := ro.GetTransaction(GetTransactionParams{ID: trxId.String()})
+	assert.Equal(t, trxId, trx.ID)
+	assert.Equal(t, 1, len(trx.Traces))
+	assert.Equal(t, trxId, trx.Traces[0].TrxId)
+
+	returning := false
+	Try(func() {
+		ro.GetTransaction(GetTransactionParams{ID: crypto.Hash256("missing").String()})
+	}).Catch(func(e *TxNotFound) {
+		returning = true
+	}).End()
+	assert.True(t, returning)
 }
 
-func Test_GetAccountHistoryObjectsByAccount(t *testing.T) {
-	/*db, err := eosiodb.NewDataBase("./", "shared_memory.bin", true)
-	if err != nil {
-		fmt.Println("Test_GetAccountHistoryObjectByAccount is error detail:", err.Error())
-		return
+func TestGetKeyAndControlledAccounts(t *testing.T) {
+	h, closeDb := newTestHistory(t)
+	defer closeDb()
+	ro := NewReadOnly(h)
+
+	eosio := common.DefaultConfig.SystemAccountName
+	alice, bob := common.N("alice"), common.N("bob")
+	key := ecc.MustNewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+
+	auth := types.Authority{
+		Threshold: 1,
+		Keys:      []types.KeyWeight{{Key: key, Weight: 1}},
+		Accounts:  []types.PermissionLevelWeight{{Permission: common.PermissionLevel{Actor: bob, Permission: common.DefaultConfig.ActiveName}, Weight: 1}},
 	}
-	defer db.Close()
+	create := chain.NewAccount{Creator: eosio, Name: alice, Owner: auth, Active: auth}
 
-	result := GetAccountHistoryObjectByAccount(db, common.AccountName(common.N("tuanhuo2")))
-	fmt.Print("Query many data :")
-	fmt.Println(result)*/
-}
+	trxId := *crypto.Hash256("newaccount")
+	at := newActionTrace(trxId, eosio, eosio, common.N("newaccount"), []common.AccountName{eosio}, &create)
+	h.OnAppliedTransaction(newTransactionTrace(trxId, at))
+
+	assert.Equal(t, []common.AccountName{alice}, ro.GetKeyAccounts(GetKeyAccountsParams{PublicKey: key}).AccountNames)
+	assert.Equal(t, []common.AccountName{alice}, ro.GetControlledAccounts(GetControlledAccountsParams{ControllingAccount: bob}).ControlledAccounts)
+
+	// no action is recorded without a filter, only the authorities
+	assert.Equal(t, 0, len(ro.GetActions(GetActionsParams{AccountName: eosio}).Actions))
+
+	update := chain.UpdateAuth{Account: alice, Permission: common.DefaultConfig.ActiveName, Parent: common.DefaultConfig.OwnerName,
+		Auth: types.Authority{Threshold: 1, Keys: []types.KeyWeight{{Key: key, Weight: 1}}}}
+	trxId = *crypto.Hash256("updateauth")
+	at = newActionTrace(trxId, eosio, eosio, common.N("updateauth"), []common.AccountName{alice}, &update)
+	h.OnAppliedTransaction(newTransactionTrace(trxId, at))
+
+	// bob still controls owner
+	assert.Equal(t, []common.AccountName{alice}, ro.GetControlledAccounts(GetControlledAccountsParams{ControllingAccount: bob}).ControlledAccounts)
 
-func Test_GetActions(t *testing.T) {
-	/*param := GetActionParam{}
-	param.Pos = 0
-	param.Offset = 3
-	param.AccountName = common.AccountName(common.N("tuanhuo"))
+	del := chain.DeleteAuth{Account: alice, Permission: common.DefaultConfig.OwnerName}
+	trxId = *crypto.Hash256("deleteauth")
+	at = newActionTrace(trxId, eosio, eosio, common.N("deleteauth"), []common.AccountName{alice}, &del)
+	h.OnAppliedTransaction(newTransactionTrace(trxId, at))
 
-	result := GetActions(&param)
-	fmt.Println("Test_GetActions result :", result)*/
+	assert.Equal(t, 0, len(ro.GetControlledAccounts(GetControlledAccountsParams{ControllingAccount: bob}).ControlledAccounts))
+	assert.Equal(t, []common.AccountName{alice}, ro.GetKeyAccounts(GetKeyAccountsParams{PublicKey: key}).AccountNames)
 }
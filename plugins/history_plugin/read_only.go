@@ -0,0 +1,234 @@
+package history_plugin
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/entity"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+)
+
+type ReadOnly struct {
+	history *HistoryPluginImpl
+}
+
+func NewReadOnly(history *HistoryPluginImpl) *ReadOnly {
+	return &ReadOnly{history: history}
+}
+
+func (ro *ReadOnly) lastIrreversibleBlock() uint32 {
+	if ro.history.ChainPlug != nil {
+		return ro.history.Chain().LastIrreversibleBlockNum()
+	}
+	return ro.history.LastIrreversibleBlock
+}
+
+func (ro *ReadOnly) unpackActionTrace(aho *entity.ActionHistoryObject) types.ActionTrace {
+	at := historyActionTrace{}
+	Throw(rlp.DecodeBytes(aho.PackedActionTrace, &at))
+	return at.actionTrace()
+}
+
+func (ro *ReadOnly) GetActions(params GetActionsParams) GetActionsResult {
+	db := ro.history.DB
+	idx, err := db.GetIndex("byAccountActionSeq", entity.AccountHistoryObject{})
+	Throw(err)
+
+	n := params.AccountName
+	pos, offset := int64(-1), int64(-20)
+	if params.Pos != nil {
+		pos = int64(*params.Pos)
+	}
+	if params.Offset != nil {
+		offset = int64(*params.Offset)
+	}
+
+	if pos == -1 {
+		if last, ok := ro.history.LastAccountSequence(n); ok {
+			pos = int64(last) + 1
+		}
+	}
+	if pos == -1 {
+		pos = 0xfffffff
+	}
+
+	var start, end int64
+	if offset > 0 {
+		start = pos
+		end = start + offset
+	} else {
+		start = pos + offset
+		if start < 0 {
+			start = 0
+		}
+		end = pos
+	}
+	EosAssert(end >= start, &PluginException{}, "end position is earlier than start position")
+	if end > math.MaxInt32-1 {
+		end = math.MaxInt32 - 1
+	}
+
+	result := GetActionsResult{Actions: make([]OrderedActionResult, 0), LastIrreversibleBlock: ro.lastIrreversibleBlock()}
+	if idx.Empty() {
+		return result
+	}
+
+	startItr, err := idx.LowerBound(entity.AccountHistoryObject{Account: n, AccountSequenceNum: int32(start)})
+	Throw(err)
+	endItr, err := idx.LowerBound(entity.AccountHistoryObject{Account: n, AccountSequenceNum: int32(end + 1)})
+	Throw(err)
+
+	for itr := startItr; !idx.CompareIterator(itr, endItr); itr.Next() {
+		ah := entity.AccountHistoryObject{}
+		Throw(itr.Data(&ah))
+
+		aho := entity.ActionHistoryObject{ActionSequenceNum: ah.ActionSequenceNum}
+		Throw(db.Find("byActionSequenceNum", aho, &aho))
+
+		result.Actions = append(result.Actions, OrderedActionResult{
+			GlobalActionSeq:  ah.ActionSequenceNum,
+			AccountActionSeq: ah.AccountSequenceNum,
+			BlockNum:         aho.BlockNum,
+			BlockTime:        aho.BlockTime,
+			ActionTrace:      ro.unpackActionTrace(&aho),
+		})
+	}
+
+	return result
+}
+
+func transactionIdOf(receipt *types.TransactionReceipt) common.TransactionIdType {
+	if receipt.Trx.PackedTransaction != nil {
+		return receipt.Trx.PackedTransaction.ID()
+	}
+	return receipt.Trx.TransactionID
+}
+
+/**
+ * searches the receipts of block blockNum (and the pending block) for a transaction whose id starts with prefix
+ */
+func (ro *ReadOnly) findReceipt(blockNum uint32, prefix string) *types.TransactionReceipt {
+	if ro.history.ChainPlug == nil {
+		return nil
+	}
+	chain := ro.history.Chain()
+
+	blocks := make([]*types.SignedBlock, 0, 2)
+	if blk := chain.FetchBlockByNumber(blockNum); blk != nil {
+		blocks = append(blocks, blk)
+	}
+	if pending := chain.PendingBlockState(); pending != nil && pending.BlockNum == blockNum {
+		blocks = append(blocks, pending.SignedBlock)
+	}
+
+	for _, blk := range blocks {
+		for i := range blk.Transactions {
+			if strings.HasPrefix(transactionIdOf(&blk.Transactions[i]).String(), prefix) {
+				return &blk.Transactions[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (ro *ReadOnly) GetTransaction(params GetTransactionParams) GetTransactionResult {
+	id := strings.ToLower(params.ID)
+	EosAssert(strings.TrimLeft(id, "0123456789abcdef") == "" && len(id) >= 8 && len(id) <= 64, &TransactionIdTypeException{},
+		"Invalid transaction ID: %s", params.ID)
+
+	var receipt *types.TransactionReceipt
+	if len(id) < 64 {
+		// action history is keyed by the full id, so a partial id has to be resolved through its block
+		EosAssert(params.BlockNumHint != nil, &TransactionIdTypeException{},
+			"block_num_hint is required for a partial transaction ID: %s", params.ID)
+		receipt = ro.findReceipt(*params.BlockNumHint, id)
+		EosAssert(receipt != nil, &TxNotFound{}, "Transaction %s not found in history", params.ID)
+		id = transactionIdOf(receipt).String()
+	}
+
+	trxId := *crypto.NewSha256String(id)
+	idx, err := ro.history.DB.GetIndex("byTrxId", entity.ActionHistoryObject{})
+	Throw(err)
+
+	result := GetTransactionResult{ID: trxId, Traces: make([]types.ActionTrace, 0), LastIrreversibleBlock: ro.lastIrreversibleBlock()}
+	itr, err := idx.LowerBound(entity.ActionHistoryObject{TrxId: trxId})
+	Throw(err)
+	for ; !idx.CompareEnd(itr); itr.Next() {
+		aho := entity.ActionHistoryObject{}
+		Throw(itr.Data(&aho))
+		if aho.TrxId != trxId {
+			break
+		}
+		if len(result.Traces) == 0 {
+			result.BlockNum = aho.BlockNum
+			result.BlockTime = aho.BlockTime
+		}
+		result.Traces = append(result.Traces, ro.unpackActionTrace(&aho))
+	}
+	EosAssert(len(result.Traces) > 0, &TxNotFound{}, "Transaction %s not found in history", params.ID)
+
+	if receipt == nil {
+		receipt = ro.findReceipt(result.BlockNum, id)
+	}
+	if receipt != nil {
+		result.Receipt = &receipt.TransactionReceiptHeader
+		if receipt.Trx.PackedTransaction != nil {
+			result.Trx = receipt.Trx.PackedTransaction.GetSignedTransaction()
+		}
+	}
+
+	return result
+}
+
+func sortedAccountNames(set map[common.AccountName]struct{}) []common.AccountName {
+	names := make([]common.AccountName, 0, len(set))
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func (ro *ReadOnly) GetKeyAccounts(params GetKeyAccountsParams) GetKeyAccountsResult {
+	idx, err := ro.history.DB.GetIndex("byPubKey", entity.PublicKeyHistoryObject{})
+	Throw(err)
+
+	accounts := make(map[common.AccountName]struct{})
+	itr, err := idx.LowerBound(entity.PublicKeyHistoryObject{PublicKey: params.PublicKey})
+	Throw(err)
+	for ; !idx.CompareEnd(itr); itr.Next() {
+		obj := entity.PublicKeyHistoryObject{}
+		Throw(itr.Data(&obj))
+		if !obj.PublicKey.Compare(params.PublicKey) {
+			break
+		}
+		accounts[obj.Name] = struct{}{}
+	}
+
+	return GetKeyAccountsResult{AccountNames: sortedAccountNames(accounts)}
+}
+
+func (ro *ReadOnly) GetControlledAccounts(params GetControlledAccountsParams) GetControlledAccountsResult {
+	idx, err := ro.history.DB.GetIndex("byControlling", entity.AccountControlHistoryObject{})
+	Throw(err)
+
+	accounts := make(map[common.AccountName]struct{})
+	itr, err := idx.LowerBound(entity.AccountControlHistoryObject{ControllingAccount: params.ControllingAccount})
+	Throw(err)
+	for ; !idx.CompareEnd(itr); itr.Next() {
+		obj := entity.AccountControlHistoryObject{}
+		Throw(itr.Data(&obj))
+		if obj.ControllingAccount != params.ControllingAccount {
+			break
+		}
+		accounts[obj.ControlledAccount] = struct{}{}
+	}
+
+	return GetControlledAccountsResult{ControlledAccounts: sortedAccountNames(accounts)}
+}
@@ -0,0 +1,57 @@
+package history_plugin
+
+import (
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/ecc"
+)
+
+type GetActionsParams struct {
+	AccountName common.AccountName `json:"account_name"`
+	Pos         *int32             `json:"pos"`    ///< a absolute sequence positon -1 is the end/last action
+	Offset      *int32             `json:"offset"` ///< the number of actions relative to pos, negative numbers return [pos-offset,pos), positive numbers return [pos,pos+offset)
+}
+
+type OrderedActionResult struct {
+	GlobalActionSeq  uint64               `json:"global_action_seq"`
+	AccountActionSeq int32                `json:"account_action_seq"`
+	BlockNum         uint32               `json:"block_num"`
+	BlockTime        types.BlockTimeStamp `json:"block_time"`
+	ActionTrace      types.ActionTrace    `json:"action_trace"`
+}
+
+type GetActionsResult struct {
+	Actions               []OrderedActionResult `json:"actions"`
+	LastIrreversibleBlock uint32                `json:"last_irreversible_block"`
+}
+
+type GetTransactionParams struct {
+	ID           string  `json:"id"`
+	BlockNumHint *uint32 `json:"block_num_hint"`
+}
+
+type GetTransactionResult struct {
+	ID                    common.TransactionIdType        `json:"id"`
+	Trx                   *types.SignedTransaction        `json:"trx,omitempty"`
+	Receipt               *types.TransactionReceiptHeader `json:"receipt,omitempty"`
+	BlockTime             types.BlockTimeStamp            `json:"block_time"`
+	BlockNum              uint32                          `json:"block_num"`
+	LastIrreversibleBlock uint32                          `json:"last_irreversible_block"`
+	Traces                []types.ActionTrace             `json:"traces"`
+}
+
+type GetKeyAccountsParams struct {
+	PublicKey ecc.PublicKey `json:"public_key"`
+}
+
+type GetKeyAccountsResult struct {
+	AccountNames []common.AccountName `json:"account_names"`
+}
+
+type GetControlledAccountsParams struct {
+	ControllingAccount common.AccountName `json:"controlling_account"`
+}
+
+type GetControlledAccountsResult struct {
+	ControlledAccounts []common.AccountName `json:"controlled_accounts"`
+}
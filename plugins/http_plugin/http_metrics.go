@@ -0,0 +1,32 @@
+package http_plugin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/eosspark/eos-go/libraries/metrics"
+)
+
+const httpMetricsEndpoint string = "/v1/metrics"
+
+/**
+ * requests to unknown urls are counted under the "unknown" url to keep the number of series bounded
+ */
+var (
+	httpRequestsCounter = metrics.NewCounterVec("eosgo_http_requests_total",
+		"Number of http requests, by url and status code", "url", "code")
+	httpRequestSeconds = metrics.NewHistogram("eosgo_http_request_seconds",
+		"Time to answer an http request", metrics.ExponentialBuckets(0.0005, 2, 14))
+)
+
+func observeRequest(url string, code int, start time.Time) {
+	httpRequestsCounter.WithLabelValues(url, strconv.Itoa(code)).Inc()
+	httpRequestSeconds.Observe(time.Since(start).Seconds())
+}
+
+/**
+ * metricsHandler answers with every metric of the process in the prometheus text format
+ */
+func metricsHandler(source string, body []byte, cb UrlResponseCallback) {
+	cb(200, metrics.DefaultRegistry.WriteText())
+}
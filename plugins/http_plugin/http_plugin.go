@@ -10,6 +10,7 @@ import (
 	"github.com/eosspark/eos-go/libraries/asio"
 	"github.com/eosspark/eos-go/plugins/http_plugin/fasthttp"
 	"github.com/urfave/cli"
+	"time"
 )
 
 const (
@@ -90,6 +91,11 @@ func (h *HttpPlugin) SetProgramOptions(options *[]cli.Flag) {
 			Name:  "http-alias",
 			Usage: "Additionaly acceptable values for the \"Host\" header of incoming HTTP requests,can be specified multiple times. Include http/s_server_address by default.",
 		},
+		cli.StringFlag{
+			Name:  "metrics-endpoint",
+			Usage: "The url at which the node metrics are served in the prometheus text format; set blank to disable.",
+			Value: httpMetricsEndpoint,
+		},
 	)
 }
 
@@ -131,12 +137,21 @@ func (h *HttpPlugin) PluginInitialize(c *cli.Context) {
 		h.my.MaxBodySize = common.SizeT(c.Uint64("max-body-size"))
 		verboseHttpErrors = c.Bool("verbose-http-errors")
 
+		h.my.metricsEndpoint = c.String("metrics-endpoint")
+		if len(h.my.metricsEndpoint) > 0 {
+			hlog.Info("configured http to serve metrics on %s", h.my.metricsEndpoint)
+		}
+
 	}).FcLogAndRethrow().End()
 }
 
 func (h *HttpPlugin) PluginStartup() {
 	hlog.Info("http plugin startup")
 
+	if len(h.my.metricsEndpoint) > 0 {
+		h.AddHandler(h.my.metricsEndpoint, metricsHandler)
+	}
+
 	if len(h.my.listenStr) > 0 {
 		err := fasthttp.ListenAndAsyncServe(App().GetIoService(), h.my.listenStr, h.Handler)
 		if err != nil {
@@ -226,7 +241,25 @@ func (h *HttpPlugin) AddHandler(url string, handler UrlHandler) {
 	})
 }
 
+/**
+ * registers a websocket stream, clients connect to url with an upgrade request
+ */
+func (h *HttpPlugin) AddStreamHandler(url string, handler StreamHandler) {
+	hlog.Info("add stream url: %s", url)
+	App().GetIoService().Post(func(err error) {
+		h.my.StreamHandlers[url] = handler
+	})
+}
+
 func (h *HttpPlugin) Handler(ctx *fasthttp.RequestCtx) {
+	if isWebsocketUpgrade(ctx) {
+		resource := string(ctx.Path())
+		if handler, ok := h.my.StreamHandlers[resource]; ok {
+			h.my.upgrade(ctx, resource, handler)
+			return
+		}
+	}
+
 	//hlog.Error("source: %s", ctx.Path())
 	//hlog.Info("body: %s", ctx.Request.Body())
 
@@ -241,16 +274,19 @@ func (h *HttpPlugin) Handler(ctx *fasthttp.RequestCtx) {
 
 	resource := string(ctx.Path())
 	body := ctx.Request.Body()
+	start := time.Now()
 
 	handler, ok := h.my.UrlHandlers[resource]
 	if !ok {
 		hlog.Debug("404 - not found: %s", resource)
 		ctx.NotFound()
+		observeRequest("unknown", fasthttp.StatusNotFound, start)
 	} else {
 		handler(resource, body, func(code int, body []byte) {
 			//hlog.Debug("body: %s",string(body))
 			ctx.SetBody([]byte(body))
 			ctx.SetStatusCode(code)
+			observeRequest(resource, code, start)
 		})
 	}
 }
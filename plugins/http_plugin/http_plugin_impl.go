@@ -12,7 +12,8 @@ type UrlResponseCallback = func(int, []byte)
 type UrlHandler = func(source string, body []byte, cb UrlResponseCallback)
 
 type HttpPluginImpl struct {
-	UrlHandlers map[string]UrlHandler
+	UrlHandlers    map[string]UrlHandler
+	StreamHandlers map[string]StreamHandler
 
 	AccessControlAllowOrigin      string
 	AccessControlAllowHeaders     string
@@ -23,6 +24,7 @@ type HttpPluginImpl struct {
 	httpsKey                      string
 
 	listenStr           string
+	metricsEndpoint     string
 	ListenEndpoint      *http.ServeMux
 	HttpsListenEndpoint *http.ServeMux
 
@@ -35,6 +37,7 @@ type HttpPluginImpl struct {
 func NewHttpPluginImpl(io *asio.IoContext) *HttpPluginImpl {
 	impl := new(HttpPluginImpl)
 	impl.UrlHandlers = make(map[string]UrlHandler)
+	impl.StreamHandlers = make(map[string]StreamHandler)
 	impl.AccessControlAllowCredentials = false
 	return impl
 }
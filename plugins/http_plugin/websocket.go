@@ -0,0 +1,263 @@
+package http_plugin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/eosspark/eos-go/plugins/http_plugin/fasthttp"
+)
+
+/**
+ * Minimal server side of the WebSocket protocol (RFC 6455), enough to push streams of json messages to clients.
+ * Streams are registered with AddStreamHandler, the connection is hijacked from fasthttp after the upgrade response
+ * and the handler runs on its own goroutine until it returns or the client goes away.
+ */
+type StreamHandler = func(source string, conn *WsConnection)
+
+const (
+	wsGuid         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsWriteTimeout = 10 * time.Second
+
+	WsTextMessage   = byte(0x1)
+	WsBinaryMessage = byte(0x2)
+
+	wsContinuationFrame = byte(0x0)
+	wsCloseFrame        = byte(0x8)
+	wsPingFrame         = byte(0x9)
+	wsPongFrame         = byte(0xa)
+
+	wsCloseNormal        = uint16(1000)
+	wsCloseProtocolError = uint16(1002)
+	wsCloseTooBig        = uint16(1009)
+)
+
+var (
+	ErrWsClosed        = errors.New("websocket: connection closed")
+	errWsProtocol      = errors.New("websocket: protocol error")
+	errWsUnmasked      = errors.New("websocket: client frame is not masked")
+	errWsMessageTooBig = errors.New("websocket: message exceeds the maximum size")
+)
+
+type WsConnection struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxMsgSize uint64
+
+	writeLock sync.Mutex
+	closed    bool
+}
+
+func newWsConnection(conn net.Conn, maxMsgSize uint64) *WsConnection {
+	return &WsConnection{conn: conn, reader: bufio.NewReader(conn), maxMsgSize: maxMsgSize}
+}
+
+func (c *WsConnection) RemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
+/**
+ * ReadMessage blocks until a complete data message arrives, control frames are answered on the way.
+ * returns ErrWsClosed after the client closed the connection.
+ */
+func (c *WsConnection) ReadMessage() (opcode byte, data []byte, err error) {
+	var message []byte
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			switch err {
+			case errWsMessageTooBig:
+				c.closeWith(wsCloseTooBig)
+			case errWsProtocol, errWsUnmasked:
+				c.closeWith(wsCloseProtocolError)
+			}
+			return 0, nil, err
+		}
+
+		switch op {
+		case wsPingFrame:
+			if err := c.writeFrame(wsPongFrame, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPongFrame:
+			continue
+		case wsCloseFrame:
+			c.closeWith(wsCloseNormal)
+			return 0, nil, ErrWsClosed
+		case wsContinuationFrame:
+			if !started {
+				c.closeWith(wsCloseProtocolError)
+				return 0, nil, errWsProtocol
+			}
+		case WsTextMessage, WsBinaryMessage:
+			if started {
+				c.closeWith(wsCloseProtocolError)
+				return 0, nil, errWsProtocol
+			}
+			started = true
+			opcode = op
+		default:
+			c.closeWith(wsCloseProtocolError)
+			return 0, nil, errWsProtocol
+		}
+
+		if uint64(len(message)+len(payload)) > c.maxMsgSize {
+			c.closeWith(wsCloseTooBig)
+			return 0, nil, errWsMessageTooBig
+		}
+		message = append(message, payload...)
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+func (c *WsConnection) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	head := make([]byte, 2)
+	if _, err = io.ReadFull(c.reader, head); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0f
+	if head[0]&0x70 != 0 {
+		err = errWsProtocol
+		return
+	}
+	if head[1]&0x80 == 0 {
+		err = errWsUnmasked
+		return
+	}
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err = io.ReadFull(c.reader, ext); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err = io.ReadFull(c.reader, ext); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if opcode >= wsCloseFrame && (!fin || length > 125) {
+		err = errWsProtocol
+		return
+	}
+	if length > c.maxMsgSize {
+		err = errWsMessageTooBig
+		return
+	}
+
+	mask := make([]byte, 4)
+	if _, err = io.ReadFull(c.reader, mask); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+/**
+ * WriteMessage sends data as a single text frame, it is safe to call from several goroutines
+ */
+func (c *WsConnection) WriteMessage(data []byte) error {
+	return c.writeFrame(WsTextMessage, data)
+}
+
+func (c *WsConnection) writeFrame(opcode byte, payload []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closed {
+		return ErrWsClosed
+	}
+
+	frame := bytes.NewBuffer(make([]byte, 0, len(payload)+10))
+	frame.WriteByte(0x80 | opcode)
+	switch length := len(payload); {
+	case length < 126:
+		frame.WriteByte(byte(length))
+	case length <= 0xffff:
+		frame.WriteByte(126)
+		binary.Write(frame, binary.BigEndian, uint16(length))
+	default:
+		frame.WriteByte(127)
+		binary.Write(frame, binary.BigEndian, uint64(length))
+	}
+	frame.Write(payload)
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, err := c.conn.Write(frame.Bytes())
+	if opcode == wsCloseFrame || err != nil {
+		c.closed = true
+	}
+	return err
+}
+
+func (c *WsConnection) closeWith(status uint16) {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, status)
+	c.writeFrame(wsCloseFrame, payload)
+}
+
+/**
+ * Close sends a normal close frame and closes the connection, a pending ReadMessage returns with an error
+ */
+func (c *WsConnection) Close() {
+	c.closeWith(wsCloseNormal)
+	c.conn.Close()
+}
+
+func wsAcceptKey(key []byte) string {
+	h := sha1.New()
+	h.Write(key)
+	h.Write([]byte(wsGuid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func isWebsocketUpgrade(ctx *fasthttp.RequestCtx) bool {
+	return ctx.IsGet() &&
+		bytes.EqualFold(ctx.Request.Header.Peek("Upgrade"), []byte("websocket")) &&
+		bytes.Contains(bytes.ToLower(ctx.Request.Header.Peek("Connection")), []byte("upgrade"))
+}
+
+func (h *HttpPluginImpl) upgrade(ctx *fasthttp.RequestCtx, resource string, handler StreamHandler) {
+	if string(ctx.Request.Header.Peek("Sec-WebSocket-Version")) != "13" {
+		ctx.Response.Header.Set("Sec-WebSocket-Version", "13")
+		ctx.Error("unsupported websocket version", fasthttp.StatusBadRequest)
+		return
+	}
+	key := ctx.Request.Header.Peek("Sec-WebSocket-Key")
+	if len(key) == 0 {
+		ctx.Error("missing Sec-WebSocket-Key", fasthttp.StatusBadRequest)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusSwitchingProtocols)
+	ctx.Response.Header.Set("Upgrade", "websocket")
+	ctx.Response.Header.Set("Connection", "Upgrade")
+	ctx.Response.Header.Set("Sec-WebSocket-Accept", wsAcceptKey(key))
+
+	maxMsgSize := uint64(h.MaxBodySize)
+	ctx.Hijack(func(c net.Conn) {
+		hlog.Debug("websocket stream %s opened by %s", resource, c.RemoteAddr())
+		handler(resource, newWsConnection(c, maxMsgSize))
+	})
+}
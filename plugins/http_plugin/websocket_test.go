@@ -0,0 +1,141 @@
+package http_plugin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func maskedFrame(fin bool, opcode byte, payload []byte) []byte {
+	frame := bytes.NewBuffer(nil)
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame.WriteByte(first)
+	switch {
+	case len(payload) < 126:
+		frame.WriteByte(0x80 | byte(len(payload)))
+	default:
+		frame.WriteByte(0x80 | 126)
+		binary.Write(frame, binary.BigEndian, uint16(len(payload)))
+	}
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame.Write(mask)
+	for i, b := range payload {
+		frame.WriteByte(b ^ mask[i%4])
+	}
+	return frame.Bytes()
+}
+
+func readServerFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	head := make([]byte, 2)
+	_, err := io.ReadFull(r, head)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x80), head[0]&0x80, "server frames are never fragmented")
+	assert.Equal(t, byte(0), head[1]&0x80, "server frames are never masked")
+
+	length := int(head[1] & 0x7f)
+	if length == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(r, ext)
+		length = int(binary.BigEndian.Uint16(ext))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	assert.NoError(t, err)
+	return head[0] & 0x0f, payload
+}
+
+func TestWsAcceptKey(t *testing.T) {
+	// sample handshake of RFC 6455 section 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", wsAcceptKey([]byte("dGhlIHNhbXBsZSBub25jZQ==")))
+}
+
+func TestWsConnection_Messages(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := newWsConnection(server, 1024)
+
+	go func() {
+		client.Write(maskedFrame(true, WsTextMessage, []byte("hello")))
+		client.Write(maskedFrame(true, wsPingFrame, []byte("ping")))
+		client.Write(maskedFrame(false, WsTextMessage, []byte("frag")))
+		client.Write(maskedFrame(true, wsContinuationFrame, bytes.Repeat([]byte("m"), 300)))
+	}()
+
+	opcode, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, WsTextMessage, opcode)
+	assert.Equal(t, "hello", string(data))
+
+	done := make(chan struct{})
+	go func() {
+		opcode, data, err = conn.ReadMessage()
+		close(done)
+	}()
+	op, payload := readServerFrame(t, client)
+	assert.Equal(t, wsPongFrame, op)
+	assert.Equal(t, "ping", string(payload))
+	<-done
+	assert.NoError(t, err)
+	assert.Equal(t, "frag"+string(bytes.Repeat([]byte("m"), 300)), string(data))
+
+	go conn.WriteMessage(bytes.Repeat([]byte("x"), 200))
+	op, payload = readServerFrame(t, client)
+	assert.Equal(t, WsTextMessage, op)
+	assert.Equal(t, 200, len(payload))
+}
+
+func TestWsConnection_Close(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := newWsConnection(server, 1024)
+
+	go client.Write(maskedFrame(true, wsCloseFrame, []byte{0x03, 0xe8}))
+	done := make(chan error)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+	op, payload := readServerFrame(t, client)
+	assert.Equal(t, wsCloseFrame, op)
+	assert.Equal(t, wsCloseNormal, binary.BigEndian.Uint16(payload))
+	assert.Equal(t, ErrWsClosed, <-done)
+	assert.Equal(t, ErrWsClosed, conn.WriteMessage([]byte("late")))
+}
+
+func TestWsConnection_Limits(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := newWsConnection(server, 16)
+
+	go client.Write(maskedFrame(true, WsTextMessage, bytes.Repeat([]byte("x"), 17)))
+	done := make(chan error)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+	op, payload := readServerFrame(t, client)
+	assert.Equal(t, wsCloseFrame, op)
+	assert.Equal(t, wsCloseTooBig, binary.BigEndian.Uint16(payload))
+	assert.Equal(t, errWsMessageTooBig, <-done)
+
+	server, client = net.Pipe()
+	defer client.Close()
+	conn = newWsConnection(server, 16)
+	unmasked := []byte{0x81, 0x02, 'h', 'i'}
+	go client.Write(unmasked)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		done <- err
+	}()
+	op, payload = readServerFrame(t, client)
+	assert.Equal(t, wsCloseFrame, op)
+	assert.Equal(t, wsCloseProtocolError, binary.BigEndian.Uint16(payload))
+	assert.Equal(t, errWsUnmasked, <-done)
+}
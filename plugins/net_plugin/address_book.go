@@ -0,0 +1,318 @@
+package net_plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/ecc"
+)
+
+const (
+	maxExchangedPeers = 32   // peers sent in a peer exchange
+	maxAddressBook    = 1024 // peers kept in the address book
+	maxLearnedPerHost = 64   // peers learned from the peers of a host that were not seen yet
+	maxPeerFailures   = 8    // failed connections in a row before a discovered peer is dropped
+	maxRetryBackoff   = 6    // times the connection-cleanup-period doubles between retries of a failing peer
+)
+
+type addressBookEntry struct {
+	PeerAddress
+	Static      bool             `json:"static"`
+	LastSeen    common.TimePoint `json:"last_seen"`
+	LastAttempt common.TimePoint `json:"last_attempt"`
+	Failures    uint32           `json:"failures"`
+	LearnedFrom string           `json:"learned_from,omitempty"` // host of the peer that shared it, until it is seen
+}
+
+// retryAt is the time the peer may be dialed again, the period doubles with each failure
+func (e *addressBookEntry) retryAt(period time.Duration) common.TimePoint {
+	failures := e.Failures
+	if failures > maxRetryBackoff {
+		failures = maxRetryBackoff
+	}
+	return e.LastAttempt.AddUs(common.Microseconds(period.Nanoseconds()/1e3) << failures)
+}
+
+// addressBook keeps the peers this node knows, when they were last seen and how many connections to them failed in a
+// row, in a file of the data dir so that the discovered peers are dialed again after a restart. The static peers of
+// p2p-peer-address are never dropped, the discovered ones are dropped after maxPeerFailures or to make room for
+// others when the book is full. A file of "" keeps the peers in memory only.
+type addressBook struct {
+	file         string
+	entries      map[string]*addressBookEntry
+	dirty        bool
+	allowPrivate bool // learn the private and loopback addresses the peers share
+}
+
+func newAddressBook(file string) *addressBook {
+	b := &addressBook{file: file, entries: make(map[string]*addressBookEntry)}
+	if len(file) == 0 {
+		return b
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return b
+	}
+	// the peers are discovered again, an unreadable book is not fatal
+	var entries []addressBookEntry
+	if err == nil {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		netLog.Warn("ignoring the unreadable address book %s: %s", file, err.Error())
+		return b
+	}
+	for i := range entries {
+		// the static peers are the ones of the current p2p-peer-address
+		entries[i].Static = false
+		if validPeerAddress(entries[i].Address) {
+			b.entries[entries[i].Address] = &entries[i]
+		}
+	}
+	return b
+}
+
+// validPeerAddress tells if address is a host:port that can be dialed
+func validPeerAddress(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || len(host) == 0 {
+		return false
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsUnspecified()
+}
+
+// privateAddress tells if the host of address is a loopback, link local or private network address
+func privateAddress(address string) bool {
+	host, _, _ := net.SplitHostPort(address)
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+func (b *addressBook) addStatic(address string) {
+	entry, ok := b.entries[address]
+	if !ok {
+		entry = &addressBookEntry{PeerAddress: PeerAddress{Address: address}}
+		b.entries[address] = entry
+	}
+	entry.Static = true
+	b.dirty = true
+}
+
+// learn adds the unknown peers of a peer exchange from the peers of host and returns how many were added. The peers
+// of a host that were not seen yet are at most maxLearnedPerHost, the private addresses are skipped unless allowed.
+func (b *addressBook) learn(host string, peers []PeerAddress) int {
+	learned := 0
+	for _, entry := range b.entries {
+		if entry.LearnedFrom == host {
+			learned++
+		}
+	}
+
+	added := 0
+	for _, peer := range peers {
+		if learned+added >= maxLearnedPerHost {
+			break
+		}
+		if _, ok := b.entries[peer.Address]; ok || !validPeerAddress(peer.Address) {
+			continue
+		}
+		if !b.allowPrivate && privateAddress(peer.Address) {
+			continue
+		}
+		if !b.makeRoom(false) {
+			break
+		}
+		b.entries[peer.Address] = &addressBookEntry{PeerAddress: peer, LearnedFrom: host}
+		added++
+	}
+	if added > 0 {
+		b.dirty = true
+	}
+	return added
+}
+
+// seen records a peer that completed a handshake
+func (b *addressBook) seen(address string, key ecc.PublicKey) {
+	if !validPeerAddress(address) {
+		return
+	}
+	entry, ok := b.entries[address]
+	if !ok {
+		if !b.makeRoom(true) {
+			return
+		}
+		entry = &addressBookEntry{PeerAddress: PeerAddress{Address: address}}
+		b.entries[address] = entry
+	}
+	entry.Key = key
+	entry.LastSeen = common.Now()
+	entry.Failures = 0
+	entry.LearnedFrom = ""
+	b.dirty = true
+}
+
+// makeRoom drops a discovered peer when the book is full, the one with the most failures and then the one seen the
+// longest ago. A peer that was not seen yet only takes the place of one that failed or was not seen either.
+func (b *addressBook) makeRoom(seen bool) bool {
+	if len(b.entries) < maxAddressBook {
+		return true
+	}
+	var worst *addressBookEntry
+	for _, entry := range b.entries {
+		if entry.Static || !seen && entry.Failures == 0 && entry.LastSeen > 0 {
+			continue
+		}
+		if worst == nil || entry.Failures > worst.Failures ||
+			entry.Failures == worst.Failures && (entry.LastSeen < worst.LastSeen ||
+				entry.LastSeen == worst.LastSeen && entry.Address < worst.Address) {
+			worst = entry
+		}
+	}
+	if worst == nil {
+		return false
+	}
+	delete(b.entries, worst.Address)
+	b.dirty = true
+	return true
+}
+
+func (b *addressBook) attempt(address string) {
+	if entry, ok := b.entries[address]; ok {
+		entry.LastAttempt = common.Now()
+		b.dirty = true
+	}
+}
+
+// failed records a connection to a peer that failed before its handshake
+func (b *addressBook) failed(address string) {
+	entry, ok := b.entries[address]
+	if !ok {
+		return
+	}
+	entry.Failures++
+	if !entry.Static && entry.Failures >= maxPeerFailures {
+		netLog.Info("dropping %s from the address book after %d failed connections", address, entry.Failures)
+		delete(b.entries, address)
+	}
+	b.dirty = true
+}
+
+// remove drops a discovered peer, such as an address of this node
+func (b *addressBook) remove(address string) {
+	if entry, ok := b.entries[address]; ok && !entry.Static {
+		delete(b.entries, address)
+		b.dirty = true
+	}
+}
+
+// shareable lists the peers seen since their last failure, the most recently seen first
+func (b *addressBook) shareable(exclude string) []PeerAddress {
+	entries := make([]*addressBookEntry, 0, len(b.entries))
+	for _, entry := range b.entries {
+		if entry.Failures == 0 && entry.LastSeen > 0 && entry.Address != exclude {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastSeen > entries[j].LastSeen })
+
+	peers := make([]PeerAddress, 0, maxExchangedPeers)
+	for _, entry := range entries {
+		if len(peers) == maxExchangedPeers {
+			break
+		}
+		peers = append(peers, entry.PeerAddress)
+	}
+	return peers
+}
+
+// candidates lists the peers that may be dialed at now, the static ones first then the fewest failures and the most
+// recently seen
+func (b *addressBook) candidates(now common.TimePoint, period time.Duration) []*addressBookEntry {
+	entries := make([]*addressBookEntry, 0, len(b.entries))
+	for _, entry := range b.entries {
+		if entry.retryAt(period) <= now {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Static != entries[j].Static {
+			return entries[i].Static
+		}
+		if entries[i].Failures != entries[j].Failures {
+			return entries[i].Failures < entries[j].Failures
+		}
+		if entries[i].LastSeen != entries[j].LastSeen {
+			return entries[i].LastSeen > entries[j].LastSeen
+		}
+		return entries[i].Address < entries[j].Address
+	})
+	return entries
+}
+
+// save writes the book if it changed since it was last written
+func (b *addressBook) save() {
+	if len(b.file) == 0 || !b.dirty {
+		return
+	}
+
+	entries := make([]*addressBookEntry, 0, len(b.entries))
+	for _, entry := range b.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Address < entries[j].Address })
+
+	err := func() error {
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(b.file), os.ModePerm); err != nil {
+			return err
+		}
+		tmp := b.file + ".tmp"
+		if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, b.file)
+	}()
+	if err != nil {
+		netLog.Error("unable to write the address book %s: %s", b.file, err.Error())
+		return
+	}
+	b.dirty = false
+}
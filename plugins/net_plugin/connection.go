@@ -70,6 +70,8 @@ type Connection struct {
 	responseExpected     *asio.DeadlineTimer
 	pendingFetch         *RequestMessage
 	noRetry              GoAwayReason
+	plaintextFallback    bool //< the peer does not encrypt, connect to it in plaintext
+	incoming             bool //< accepted by the listener, peerAddr is the address the peer connected from
 	forkHead             common.BlockIdType
 	forkHeadNum          uint32
 	lastReq              *RequestMessage
@@ -134,6 +136,7 @@ func NewConnectionByConn(socket *asio.ReactiveSocket, c net.Conn, impl *netPlugi
 		syncing:            false,
 		protocolVersion:    0,
 		peerAddr:           c.RemoteAddr().String(), //,
+		incoming:           true,
 		//responseExpected:,
 		pendingFetch: &RequestMessage{},
 		noRetry:      noReason,
@@ -222,7 +225,7 @@ func (c *Connection) handshakePopulate(impl *netPluginIMpl, hello *HandshakeMess
 		hello.Token = crypto.NewSha256Nil()
 	}
 
-	hello.P2PAddress = impl.p2PAddress + " - " + hello.NodeID.String()[:7]
+	hello.P2PAddress = impl.p2PServerAddress + " - " + hello.NodeID.String()[:7] + " " + capabilityPeerExchange
 
 	switch runtime.GOOS {
 	case "darwin":
@@ -698,6 +701,8 @@ func (c *Connection) processNextMessage(payloadBytes []byte) bool {
 			c.impl.handleSignedBlock(c, msg)
 		case *PackedTransactionMessage:
 			c.impl.handlePackTransaction(c, msg)
+		case *PeerExchangeMessage:
+			c.impl.handlePeerExchange(c, msg)
 		default:
 			Throw(fmt.Errorf("unsuppoted p2p message type %d", messageType))
 		}
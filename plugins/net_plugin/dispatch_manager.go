@@ -90,6 +90,7 @@ func (d *dispatchManager) rejectedBlock(id *common.BlockIdType) {
 }
 
 func (d *dispatchManager) recvBlock(c *Connection, id common.BlockIdType, bnum uint32) {
+	blocksReceivedCounter.Inc()
 	if _, ok := d.receivedBlocks[id]; ok {
 		d.receivedBlocks[id] = append(d.receivedBlocks[id], c)
 	} else {
@@ -228,6 +229,7 @@ func (d *dispatchManager) rejectedTransaction(id common.TransactionIdType) {
 }
 
 func (d *dispatchManager) recvTransaction(c *Connection, id common.TransactionIdType) {
+	transactionsReceivedCounter.Inc()
 	d.receivedTransactions[id] = append(d.receivedTransactions[id], c)
 	idsCount := len(c.lastReq.ReqTrx.IDs)
 	if c != nil && c.lastReq != nil && c.lastReq.ReqTrx.Mode != none && idsCount > 0 && c.lastReq.ReqTrx.IDs[idsCount-1] == id {
@@ -65,6 +65,10 @@ const (
 
 	netVersion uint16 = protoExplicitSync
 
+	//The protocol versions are shared with the other implementations, the messages only this implementation knows
+	//are sent to the peers that advertise a capability after the node id of their handshake p2p address
+	capabilityPeerExchange = "+peer-exchange"
+
 	nonePossible      possibleConnections = 0
 	producersPossible possibleConnections = 1 << 0
 	specifiedPossible possibleConnections = 1 << 1
@@ -79,6 +83,7 @@ type netPluginIMpl struct {
 
 	Listener           net.Listener
 	p2PAddress         string
+	p2PServerAddress   string
 	resolver           *ReactiveSocket
 	maxClientCount     uint32
 	maxNodesPerHost    uint32
@@ -87,6 +92,9 @@ type netPluginIMpl struct {
 	AllowedPeers       []ecc.PublicKey                  //< peer keys allowed to connect
 	privateKeys        map[ecc.PublicKey]ecc.PrivateKey //< overlapping with producer keys, also authenticating non-producing nodes
 	allowedConnections possibleConnections
+	encryption         p2pEncryption //< key exchange and encryption of the connections
+	peerDiscovery      bool          //< exchange peer addresses and dial the discovered peers
+	addressBook        *addressBook
 
 	connectorCheck   *DeadlineTimer
 	transactionCheck *DeadlineTimer
@@ -129,6 +137,7 @@ func NewNetPluginIMpl(io *IoContext) *netPluginIMpl {
 		resolver:                   NewReactiveSocket(io),
 		context:                    context.Background(),
 		suppliedPeers:              make([]string, 0),
+		addressBook:                newAddressBook(""),
 	}
 
 	impl.syncMaster = NewSyncManager(impl, 100)
@@ -173,9 +182,7 @@ func (impl *netPluginIMpl) startListenLoop() {
 			}
 			if fromAddr < impl.maxNodesPerHost && (impl.maxClientCount == 0 || impl.numClients < impl.maxClientCount) {
 				impl.numClients++
-				c := NewConnectionByConn(socket, conn, impl)
-				impl.connections = append(impl.connections, c)
-				impl.startReadMessage(socket, c)
+				impl.acceptConnection(socket, conn)
 
 			} else {
 				if fromAddr >= impl.maxNodesPerHost {
@@ -193,6 +200,35 @@ func (impl *netPluginIMpl) startListenLoop() {
 	})
 }
 
+//acceptConnection starts reading a connection accepted by the listener, once the peer negotiated the encryption
+//of the connection if it is configured.
+func (impl *netPluginIMpl) acceptConnection(socket *ReactiveSocket, conn net.Conn) {
+	if impl.encryption == encryptionNone {
+		impl.startConnection(socket, conn)
+		return
+	}
+
+	go func() {
+		secure, err := impl.secureServer(conn)
+		App().GetIoService().Post(func(error) {
+			if err != nil {
+				netLog.Error("key exchange with %s failed: %s", conn.RemoteAddr(), err.Error())
+				conn.Close()
+				impl.numClients--
+				return
+			}
+			impl.startConnection(socket, secure)
+		})
+	}()
+}
+
+func (impl *netPluginIMpl) startConnection(socket *ReactiveSocket, conn net.Conn) {
+	c := NewConnectionByConn(socket, conn, impl)
+	impl.connections = append(impl.connections, c)
+	impl.updateConnectionsGauge()
+	impl.startReadMessage(socket, c)
+}
+
 func (impl *netPluginIMpl) startReadMessage(socket *ReactiveSocket, conn *Connection) {
 	returning := false
 	pendingMessageBuffer := make([]byte, 0)
@@ -313,6 +349,7 @@ func (impl *netPluginIMpl) connect(c *Connection) {
 			impl.connect2(c, address)
 		} else {
 			netLog.Error("Unable to resolve %s:%s,%s", host, port, err)
+			impl.close(c)
 		}
 	})
 }
@@ -330,9 +367,13 @@ func (impl *netPluginIMpl) connect2(c *Connection, endPoint string) {
 			return
 		}
 		if err == nil {
-			c.conn = conn
-			impl.startReadMessage(c.socket, c)
-			c.sendHandshake()
+			if impl.encryption == encryptionNone || c.plaintextFallback {
+				c.conn = conn
+				impl.startReadMessage(c.socket, c)
+				c.sendHandshake()
+			} else {
+				impl.secureConnect(c, conn)
+			}
 
 		} else {
 			netLog.Error("connection failed to %s:%s", c.PeerName(), err.Error())
@@ -343,7 +384,42 @@ func (impl *netPluginIMpl) connect2(c *Connection, endPoint string) {
 
 }
 
+//secureConnect negotiates the encryption of a connection to a peer before the handshake, a peer that does not encrypt
+//is connected again in plaintext when the encryption is only preferred.
+func (impl *netPluginIMpl) secureConnect(c *Connection, conn net.Conn) {
+	go func() {
+		secure, err := impl.secureClient(conn)
+		App().GetIoService().Post(func(error) {
+			if c.socket == nil {
+				conn.Close()
+				return
+			}
+			if err == errPlaintextPeer && impl.encryption == encryptionPreferred {
+				netLog.Warn("%s does not encrypt, connecting again in plaintext", c.PeerName())
+				conn.Close()
+				c.connecting = false
+				c.plaintextFallback = true
+				impl.connect(c)
+				return
+			}
+			if err != nil {
+				netLog.Error("key exchange with %s failed: %s", c.PeerName(), err.Error())
+				conn.Close()
+				c.connecting = false
+				impl.close(c)
+				return
+			}
+			c.conn = secure
+			impl.startReadMessage(c.socket, c)
+			c.sendHandshake()
+		})
+	}()
+}
+
 func (impl *netPluginIMpl) close(c *Connection) {
+	if !c.incoming && c.socket != nil && c.lastHandshakeRecv.Generation == 0 {
+		impl.addressBook.failed(c.peerAddr)
+	}
 	if len(c.peerAddr) == 0 {
 		if impl.numClients == 0 {
 			FcLog.Warn("num_clients already at 0")
@@ -438,6 +514,7 @@ func (impl *netPluginIMpl) connectionMonitor(fromConnection *Connection) {
 		if len(impl.connections) > 0 {
 			i, it = 0, impl.connections[0]
 		} else {
+			impl.dialPeers()
 			impl.startConnTimer(impl.connectorPeriod, nil)
 			return
 		}
@@ -457,9 +534,90 @@ func (impl *netPluginIMpl) connectionMonitor(fromConnection *Connection) {
 			}
 		}
 	}
+	impl.dialPeers()
 	impl.startConnTimer(impl.connectorPeriod, nil)
 }
 
+//dialPeers connects to the peers of the address book that are not connected. The discovered peers are dialed up to
+//max-clients outgoing connections and p2p-max-nodes-per-host connections to a host, and only with a key
+//allowed-connection allows.
+func (impl *netPluginIMpl) dialPeers() {
+	defer impl.addressBook.save()
+	if impl.allowedConnections == nonePossible {
+		return
+	}
+	limit := impl.maxClientCount
+	if limit == 0 {
+		limit = defMaxClients
+	}
+
+	outgoing := uint32(0)
+	connected := make(map[string]bool)
+	hosts := make(map[string]uint32)
+	for _, c := range impl.connections {
+		if !c.incoming {
+			outgoing++
+		}
+		connected[impl.peerListenAddress(c, c.lastHandshakeRecv)] = true
+		hosts[peerHost(c.peerAddr)]++
+	}
+
+	for _, entry := range impl.addressBook.candidates(common.Now(), impl.connectorPeriod) {
+		if connected[entry.Address] || entry.Address == impl.p2PAddress || entry.Address == impl.p2PServerAddress {
+			continue
+		}
+		host := peerHost(entry.Address)
+		if !entry.Static {
+			if outgoing >= limit || hosts[host] >= impl.maxNodesPerHost {
+				continue
+			}
+			if impl.allowedConnections&(producersPossible|specifiedPossible) != 0 && !impl.isAllowedPeerKey(entry.Key) {
+				continue
+			}
+		}
+		FcLog.Debug("dialing %s from the address book", entry.Address)
+		impl.Self.Connect(entry.Address)
+		outgoing++
+		hosts[host]++
+	}
+}
+
+func peerHost(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+//peerListenAddress is the address a peer can be dialed at. That is the address this node dialed, or the address the
+//peer reports in its handshake with the host of the connection when the peer listens on all interfaces.
+func (impl *netPluginIMpl) peerListenAddress(c *Connection, msg *HandshakeMessage) string {
+	if !c.incoming {
+		return c.peerAddr
+	}
+	host, port, err := net.SplitHostPort(strings.Split(msg.P2PAddress, " ")[0])
+	if err != nil {
+		return ""
+	}
+	if ip := net.ParseIP(host); len(host) == 0 || ip != nil && ip.IsUnspecified() {
+		host = peerHost(c.peerAddr)
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// hasCapability tells whether a peer advertised a capability in the p2p address of its handshake,
+// "host:port - node id" followed by the capabilities
+func hasCapability(msg *HandshakeMessage, capability string) bool {
+	fields := strings.Fields(msg.P2PAddress)
+	for i := 1; i < len(fields); i++ {
+		if fields[i] == capability {
+			return true
+		}
+	}
+	return false
+}
+
 func (impl *netPluginIMpl) startTxnTimer() {
 	impl.transactionCheck.ExpiresFromNow(impl.txnExpPeriod)
 	impl.transactionCheck.AsyncWait(func(err error) {
@@ -515,30 +673,15 @@ func (impl *netPluginIMpl) ticker() {
 //Checks current connection mode and key authentication.
 //return False if the peer should not connect, True otherwise.
 func (impl *netPluginIMpl) authenticatePeer(msg *HandshakeMessage) bool {
-	var allowedIt, privateIt, foundProducerKey bool
-
 	if impl.allowedConnections == nonePossible {
 		return false
 	}
 	if impl.allowedConnections == anyPossible {
 		return true
 	}
-	if impl.allowedConnections&(producersPossible|specifiedPossible) != 0 {
-		for _, pubKey := range impl.AllowedPeers {
-			if pubKey == msg.Key {
-				allowedIt = true
-			}
-		}
-		_, privateIt = impl.privateKeys[msg.Key]
-
-		pp := App().FindPlugin(producer_plugin.ProducerPlug).(*producer_plugin.ProducerPlugin)
-		if pp != nil {
-			foundProducerKey = pp.IsProducerKey(msg.Key)
-		}
-		if !allowedIt && !privateIt && !foundProducerKey {
-			netLog.Error("Peer %s sent a handshake with an unauthorized key: %s", msg.P2PAddress, msg.Key)
-			return false
-		}
+	if impl.allowedConnections&(producersPossible|specifiedPossible) != 0 && !impl.isAllowedPeerKey(msg.Key) {
+		netLog.Error("Peer %s sent a handshake with an unauthorized key: %s", msg.P2PAddress, msg.Key)
+		return false
 	}
 
 	msgTime := msg.Time
@@ -572,6 +715,28 @@ func (impl *netPluginIMpl) authenticatePeer(msg *HandshakeMessage) bool {
 	return true
 }
 
+//isAllowedPeerKey determine if a peer authenticated with key is allowed to connect.
+//With 'producers' or 'specified' the key must be a peer-key, a key of this node or a producer key.
+func (impl *netPluginIMpl) isAllowedPeerKey(key ecc.PublicKey) bool {
+	if impl.allowedConnections == nonePossible {
+		return false
+	}
+	if impl.allowedConnections&(producersPossible|specifiedPossible) == 0 {
+		return true
+	}
+
+	for _, pubKey := range impl.AllowedPeers {
+		if pubKey == key {
+			return true
+		}
+	}
+	if _, ok := impl.privateKeys[key]; ok {
+		return true
+	}
+	pp := App().FindPlugin(producer_plugin.ProducerPlug).(*producer_plugin.ProducerPlugin)
+	return pp != nil && pp.IsProducerKey(key)
+}
+
 //getAuthenticationKey retrieve public key used to authenticate with peers.
 //Finds a key to use for authentication.  If this node is a producer, use
 //the front of the producer key map.  If the node is not a producer but has
@@ -631,13 +796,16 @@ func (impl *netPluginIMpl) handleHandshake(c *Connection, msg *HandshakeMessage)
 	}
 
 	if msg.Generation == 1 {
-		if msg.NodeID.Equals(c.nodeID) {
+		if msg.NodeID.Equals(impl.nodeID) {
 			netLog.Error("Self connection detected. Closing connection")
+			impl.addressBook.remove(c.peerAddr)
 			goAwayMsg := &GoAwayMessage{
-				Reason: fatalOther,
+				Reason: selfConnect,
 				NodeID: crypto.NewSha256Nil(),
 			}
 			c.enqueue(goAwayMsg, true)
+			c.noRetry = selfConnect
+			return
 		}
 
 		if len(c.peerAddr) == 0 || c.lastHandshakeRecv.NodeID.Equals(crypto.NewSha256Nil()) {
@@ -735,12 +903,36 @@ func (impl *netPluginIMpl) handleHandshake(c *Connection, msg *HandshakeMessage)
 		if c.sentHandshakeCount == 0 {
 			c.sendHandshake()
 		}
+
+		address := impl.peerListenAddress(c, msg)
+		impl.addressBook.seen(address, msg.Key)
+		if impl.peerDiscovery && hasCapability(msg, capabilityPeerExchange) {
+			c.enqueue(&PeerExchangeMessage{Peers: impl.addressBook.shareable(address)}, true)
+		}
 	}
 
 	c.lastHandshakeRecv = msg
 	impl.syncMaster.recvHandshake(c, msg)
 }
 
+func (impl *netPluginIMpl) handlePeerExchange(c *Connection, msg *PeerExchangeMessage) {
+	if !impl.peerDiscovery {
+		return
+	}
+
+	peers := make([]PeerAddress, 0, len(msg.Peers))
+	for i, peer := range msg.Peers {
+		if i == maxExchangedPeers {
+			break
+		}
+		if peer.Address != impl.p2PAddress && peer.Address != impl.p2PServerAddress {
+			peers = append(peers, peer)
+		}
+	}
+	added := impl.addressBook.learn(peerHost(c.peerAddr), peers)
+	FcLog.Debug("%s shared %d peers, %d new", c.PeerName(), len(msg.Peers), added)
+}
+
 func (impl *netPluginIMpl) handleGoaway(c *Connection, msg *GoAwayMessage) {
 	rsn := ReasonStr[msg.Reason]
 	FcLog.Info("%s : receive go_away_message reason = %s", c.peerAddr, rsn)
@@ -1032,4 +1224,5 @@ func (impl *netPluginIMpl) eraseConnection(it *Connection) {
 			impl.connections = append(impl.connections[:i], impl.connections[i+1:]...)
 		}
 	}
+	impl.updateConnectionsGauge()
 }
@@ -0,0 +1,30 @@
+package net_plugin
+
+import (
+	"github.com/eosspark/eos-go/libraries/metrics"
+)
+
+/**
+ * metrics of the net plugin, the sync stage is 0 for lib catchup, 1 for head catchup and 2 in sync
+ */
+var (
+	connectionsGauge = metrics.NewGauge("eosgo_net_connections",
+		"Number of peer connections, inbound and outbound")
+	clientsGauge = metrics.NewGauge("eosgo_net_inbound_connections",
+		"Number of connections accepted from clients")
+	syncStageGauge = metrics.NewGauge("eosgo_net_sync_stage",
+		"Sync stage of the node: 0 lib catchup, 1 head catchup, 2 in sync")
+	blocksReceivedCounter = metrics.NewCounter("eosgo_net_blocks_received_total",
+		"Number of new blocks received from peers")
+	transactionsReceivedCounter = metrics.NewCounter("eosgo_net_transactions_received_total",
+		"Number of new transactions received from peers")
+)
+
+func init() {
+	syncStageGauge.Set(float64(inSync))
+}
+
+func (impl *netPluginIMpl) updateConnectionsGauge() {
+	connectionsGauge.Set(float64(len(impl.connections)))
+	clientsGauge.Set(float64(impl.numClients))
+}
@@ -53,6 +53,19 @@ func (n *NetPlugin) SetProgramOptions(options *[]cli.Flag) {
 			Name:  "p2p-peer-address",
 			Usage: "The public endpoint of a peer node to connect to. Use multiple p2p-peer-address options as needed to compose a network.",
 		},
+		cli.BoolTFlag{
+			Name:  "p2p-peer-discovery",
+			Usage: "True to exchange peer addresses with the peers and dial the discovered peers.",
+		},
+		cli.BoolFlag{
+			Name:  "p2p-discover-private-peers",
+			Usage: "True to also dial the loopback and private network addresses the peers share.",
+		},
+		cli.StringFlag{
+			Name:  "p2p-address-book",
+			Usage: "The file the known peers are kept in, relative to the data dir. Empty keeps them in memory only.",
+			Value: "p2p_address_book.json",
+		},
 		cli.IntFlag{
 			Name:  "p2p-max-nodes-per-host",
 			Usage: "Maximum number of client nodes from any single IP address",
@@ -77,6 +90,12 @@ func (n *NetPlugin) SetProgramOptions(options *[]cli.Flag) {
 			Name:  "peer-private-key",
 			Usage: "Tuple of [PublicKey, WIF private key] (may specify multiple times)",
 		},
+		cli.StringFlag{
+			Name: "p2p-encryption",
+			Usage: "Can be 'none' or 'preferred' or 'required'. If not 'none', connections exchange an ephemeral key signed with the peer-private-key " +
+				"and are encrypted. 'preferred' connects in plaintext to the peers that do not encrypt, 'required' closes such connections.",
+			Value: "none",
+		},
 		cli.IntFlag{
 			Name:  "max-clients",
 			Usage: "Maximum number of clients from which connections are accepted, use 0 for no limit",
@@ -140,7 +159,19 @@ func (n *NetPlugin) PluginInitialize(c *cli.Context) {
 		n.my.numClients = 0
 
 		n.my.p2PAddress = c.String("p2p-listen-endpoint")
+		n.my.p2PServerAddress = c.String("p2p-server-address")
+		if len(n.my.p2PServerAddress) == 0 {
+			n.my.p2PServerAddress = n.my.p2PAddress
+		}
 		n.my.suppliedPeers = c.StringSlice("p2p-peer-address")
+		n.my.peerDiscovery = c.BoolT("p2p-peer-discovery")
+		if book := c.String("p2p-address-book"); len(book) > 0 {
+			n.my.addressBook = newAddressBook(common.AbsolutePath(App().DataDir(), book))
+		}
+		n.my.addressBook.allowPrivate = c.Bool("p2p-discover-private-peers")
+		for _, peer := range n.my.suppliedPeers {
+			n.my.addressBook.addStatic(peer)
+		}
 		n.my.userAgentName = c.String("agent-name")
 
 		allowedRemotes := c.StringSlice("allowed-connection")
@@ -191,6 +222,20 @@ func (n *NetPlugin) PluginInitialize(c *cli.Context) {
 			}
 		}
 
+		switch c.String("p2p-encryption") {
+		case "none":
+			n.my.encryption = encryptionNone
+		case "preferred":
+			n.my.encryption = encryptionPreferred
+		case "required":
+			n.my.encryption = encryptionRequired
+		default:
+			EosThrow(&exception.PluginConfigException{}, "unknown p2p-encryption %s", c.String("p2p-encryption"))
+		}
+		if n.my.encryption != encryptionNone {
+			EosAssert(len(n.my.privateKeys) > 0, &exception.PluginConfigException{}, "p2p-encryption requires a peer-private-key to sign the key exchange")
+		}
+
 		n.my.ChainPlugin = App().FindPlugin(chain_plugin.ChainPlug).(*chain_plugin.ChainPlugin)
 		EosAssert(n.my.ChainPlugin != nil, &exception.MissingChainPluginException{}, "")
 		n.my.chainID = n.my.ChainPlugin.GetChainId()
@@ -252,6 +297,7 @@ func (n *NetPlugin) PluginShutdown() {
 			netLog.Info("close acceptor")
 			n.my.Listener.Close()
 		}
+		n.my.addressBook.save()
 		netLog.Info("close %d connections", len(n.my.connections))
 		peers := n.my.connections
 		for _, p := range peers {
@@ -269,8 +315,10 @@ func (n *NetPlugin) Connect(host string) string {
 	}
 
 	c := NewConnectionByEndPoint(host, n.my)
+	n.my.addressBook.attempt(host)
 	FcLog.Info("adding new peer to the list")
 	n.my.connections = append(n.my.connections, c)
+	n.my.updateConnectionsGauge()
 	FcLog.Info("calling active connector")
 	n.my.connect(c)
 	return "added connection"
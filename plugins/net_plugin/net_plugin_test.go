@@ -0,0 +1,334 @@
+package net_plugin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/ecc"
+	"github.com/eosspark/eos-go/plugins/appbase/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func secureTestPeer(t *testing.T, encryption p2pEncryption) (*netPluginIMpl, ecc.PublicKey) {
+	impl := NewNetPluginIMpl(app.App().GetIoService())
+	impl.encryption = encryption
+	impl.allowedConnections = anyPossible
+	impl.respExpectedPeriod = defRespExpectedWait
+	impl.chainID = *crypto.Hash256("secure channel test")
+
+	priKey, err := ecc.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	impl.privateKeys[priKey.PublicKey()] = *priKey
+	return impl, priKey.PublicKey()
+}
+
+type secureResult struct {
+	conn net.Conn
+	err  error
+}
+
+func secureHandshake(client, server *netPluginIMpl) (secureResult, secureResult) {
+	clientSide, serverSide := net.Pipe()
+	done := make(chan secureResult)
+	go func() {
+		conn, err := server.secureServer(serverSide)
+		if err != nil {
+			serverSide.Close()
+		}
+		done <- secureResult{conn, err}
+	}()
+	conn, err := client.secureClient(clientSide)
+	if err != nil {
+		clientSide.Close()
+	}
+	return secureResult{conn, err}, <-done
+}
+
+func Test_secureChannel(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		client, _ := secureTestPeer(t, encryptionRequired)
+		server, _ := secureTestPeer(t, encryptionRequired)
+		c, s := secureHandshake(client, server)
+		assert.NoError(t, c.err)
+		assert.NoError(t, s.err)
+
+		// a message longer than a record is split and joined again
+		message := make([]byte, maxSecureRecord*2+100)
+		for i := range message {
+			message[i] = byte(i)
+		}
+		go c.conn.Write(message)
+		received := make([]byte, len(message))
+		_, err := io.ReadFull(s.conn, received)
+		assert.NoError(t, err)
+		assert.Equal(t, message, received)
+
+		go s.conn.Write([]byte("pong"))
+		received = make([]byte, 4)
+		_, err = io.ReadFull(c.conn, received)
+		assert.NoError(t, err)
+		assert.Equal(t, "pong", string(received))
+	})
+
+	t.Run("tampered record", func(t *testing.T) {
+		client, _ := secureTestPeer(t, encryptionRequired)
+		server, _ := secureTestPeer(t, encryptionRequired)
+		c, s := secureHandshake(client, server)
+		assert.NoError(t, c.err)
+		assert.NoError(t, s.err)
+
+		sc := c.conn.(*secureConn)
+		sealed := sc.sealer.Seal(nil, nextNonce(&sc.sealNonce, sc.sealer.NonceSize()), []byte("block"), nil)
+		sealed[0] ^= 1
+		record := make([]byte, 4)
+		binary.LittleEndian.PutUint32(record, uint32(len(sealed)))
+		go sc.Conn.Write(append(record, sealed...))
+
+		_, err := s.conn.Read(make([]byte, 16))
+		assert.Error(t, err)
+	})
+
+	t.Run("unauthorized key", func(t *testing.T) {
+		client, _ := secureTestPeer(t, encryptionRequired)
+		server, _ := secureTestPeer(t, encryptionRequired)
+		server.allowedConnections = specifiedPossible
+		c, s := secureHandshake(client, server)
+		assert.Error(t, s.err)
+		assert.Error(t, c.err)
+
+		client, clientKey := secureTestPeer(t, encryptionRequired)
+		server.AllowedPeers = append(server.AllowedPeers, clientKey)
+		c, s = secureHandshake(client, server)
+		assert.NoError(t, s.err)
+		assert.NoError(t, c.err)
+	})
+
+	t.Run("other chain", func(t *testing.T) {
+		client, _ := secureTestPeer(t, encryptionRequired)
+		server, _ := secureTestPeer(t, encryptionRequired)
+		server.chainID = *crypto.Hash256("other chain")
+		c, s := secureHandshake(client, server)
+		assert.Error(t, s.err)
+		assert.Error(t, c.err)
+	})
+
+	t.Run("plaintext peer", func(t *testing.T) {
+		plaintext := []byte{5, 0, 0, 0, 1, 2, 3, 4, 5}
+		for _, encryption := range []p2pEncryption{encryptionPreferred, encryptionRequired} {
+			server, _ := secureTestPeer(t, encryption)
+			clientSide, serverSide := net.Pipe()
+			go clientSide.Write(plaintext)
+
+			conn, err := server.secureServer(serverSide)
+			if encryption == encryptionRequired {
+				assert.Equal(t, errPlaintextPeer, err)
+				continue
+			}
+			assert.NoError(t, err)
+			received := make([]byte, len(plaintext))
+			_, err = io.ReadFull(conn, received)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, received)
+		}
+
+		// a peer that does not encrypt drops the key exchange as a message too long
+		client, _ := secureTestPeer(t, encryptionPreferred)
+		clientSide, serverSide := net.Pipe()
+		go func() {
+			serverSide.Read(make([]byte, 4096))
+			serverSide.Close()
+		}()
+		_, err := client.secureClient(clientSide)
+		assert.Equal(t, errPlaintextPeer, err)
+	})
+}
+
+func Test_addressBook(t *testing.T) {
+	NewNetPluginIMpl(app.App().GetIoService())
+	dir, err := ioutil.TempDir("", "address_book")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "p2p_address_book.json")
+
+	priKey, _ := ecc.NewRandomPrivateKey()
+	book := newAddressBook(file)
+	book.addStatic("seed.example.com:9876")
+	assert.Equal(t, 2, book.learn("198.51.100.1", []PeerAddress{
+		{Address: "192.0.2.1:9876", Key: priKey.PublicKey()},
+		{Address: "192.0.2.2:9876"},
+		{Address: "0.0.0.0:9876"},
+		{Address: "192.0.2.3"},
+		{Address: "seed.example.com:9876"},
+	}))
+	assert.Equal(t, 3, len(book.entries))
+
+	// only the peers seen since their last failure are shared
+	assert.Equal(t, 0, len(book.shareable("")))
+	book.seen("192.0.2.1:9876", priKey.PublicKey())
+	assert.Equal(t, []PeerAddress{{Address: "192.0.2.1:9876", Key: priKey.PublicKey()}}, book.shareable(""))
+	assert.Equal(t, 0, len(book.shareable("192.0.2.1:9876")))
+
+	// a failing peer is retried later and later, a discovered one is dropped after maxPeerFailures
+	period := 30 * time.Second
+	book.attempt("192.0.2.2:9876")
+	book.failed("192.0.2.2:9876")
+	entry := book.entries["192.0.2.2:9876"]
+	assert.Equal(t, entry.LastAttempt.AddUs(common.Seconds(60)), entry.retryAt(period))
+	candidates := book.candidates(common.Now(), period)
+	assert.Equal(t, 2, len(candidates))
+	assert.Equal(t, "seed.example.com:9876", candidates[0].Address)
+	assert.Equal(t, "192.0.2.1:9876", candidates[1].Address)
+
+	for i := 0; i < maxPeerFailures; i++ {
+		book.failed("192.0.2.2:9876")
+		book.failed("seed.example.com:9876")
+	}
+	assert.Nil(t, book.entries["192.0.2.2:9876"])
+	assert.Equal(t, uint32(maxPeerFailures), book.entries["seed.example.com:9876"].Failures)
+
+	// the book survives a restart, the static peers are the configured ones
+	book.save()
+	book = newAddressBook(file)
+	assert.Equal(t, 2, len(book.entries))
+	assert.Equal(t, priKey.PublicKey(), book.entries["192.0.2.1:9876"].Key)
+	assert.False(t, book.entries["seed.example.com:9876"].Static)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("{"), 0644))
+	assert.Equal(t, 0, len(newAddressBook(file).entries))
+}
+
+func Test_addressBookLearn(t *testing.T) {
+	book := newAddressBook("")
+	book.addStatic("10.0.0.1:9876")
+
+	// the private and loopback addresses are learned when allowed only
+	private := []PeerAddress{{Address: "10.0.0.2:9876"}, {Address: "172.16.0.1:9876"}, {Address: "192.168.1.1:9876"},
+		{Address: "127.0.0.1:9876"}, {Address: "localhost:9876"}, {Address: "[::1]:9876"}, {Address: "[fd00::1]:9876"}}
+	assert.Equal(t, 0, book.learn("198.51.100.1", private))
+	book.allowPrivate = true
+	assert.Equal(t, len(private), book.learn("198.51.100.1", private))
+
+	// a host shares at most maxLearnedPerHost peers that were not seen yet
+	book = newAddressBook("")
+	peers := func(first, n int) []PeerAddress {
+		peers := make([]PeerAddress, n)
+		for i := range peers {
+			peers[i].Address = fmt.Sprintf("192.0.2.%d:%d", (first+i)%250+1, 9000+first+i)
+		}
+		return peers
+	}
+	assert.Equal(t, maxLearnedPerHost, book.learn("198.51.100.1", peers(0, maxLearnedPerHost+1)))
+	assert.Equal(t, 0, book.learn("198.51.100.1", peers(maxLearnedPerHost+1, 1)))
+	book.seen(peers(0, 1)[0].Address, ecc.PublicKey{})
+	assert.Equal(t, 1, book.learn("198.51.100.1", peers(maxLearnedPerHost+1, 1)))
+	assert.Equal(t, 1, book.learn("198.51.100.2", peers(maxLearnedPerHost+2, 1)))
+
+	// a full book drops the discovered peer with the most failures, then the one seen the longest ago
+	book = newAddressBook("")
+	book.addStatic("seed.example.com:9876")
+	for i := 0; len(book.entries) < maxAddressBook; i++ {
+		book.seen(peers(i, 1)[0].Address, ecc.PublicKey{})
+	}
+	failing, oldest := peers(1, 1)[0].Address, peers(2, 1)[0].Address
+	book.failed(failing)
+	book.entries[oldest].LastSeen = 1
+	learned := peers(maxAddressBook, 3)
+	assert.Equal(t, 1, book.learn("198.51.100.1", learned[:1]))
+	assert.Nil(t, book.entries[failing])
+	assert.Equal(t, maxAddressBook, len(book.entries))
+
+	// a peer not seen yet only takes the place of one that failed or was not seen either, a seen peer of any
+	assert.Equal(t, 1, book.learn("198.51.100.1", learned[1:2]))
+	assert.Nil(t, book.entries[learned[0].Address])
+	book.seen(learned[1].Address, ecc.PublicKey{})
+	assert.Equal(t, 0, book.learn("198.51.100.2", learned[:1]))
+	book.seen(learned[2].Address, ecc.PublicKey{})
+	assert.Nil(t, book.entries[oldest])
+	assert.Equal(t, maxAddressBook, len(book.entries))
+	assert.True(t, book.entries["seed.example.com:9876"].Static)
+}
+
+func Test_dialPeers(t *testing.T) {
+	impl := NewNetPlugin(app.App().GetIoService()).my
+	impl.allowedConnections = anyPossible
+	impl.maxClientCount = 3
+	impl.maxNodesPerHost = 1
+	impl.connectorPeriod = 30 * time.Second
+	impl.p2PAddress = "0.0.0.0:9876"
+	impl.p2PServerAddress = "192.0.2.9:9876"
+
+	allowed, _ := ecc.NewRandomPrivateKey()
+	other, _ := ecc.NewRandomPrivateKey()
+	impl.addressBook.addStatic("192.0.2.1:9876")
+	impl.addressBook.learn("198.51.100.1", []PeerAddress{
+		{Address: "192.0.2.1:9877", Key: allowed.PublicKey()},
+		{Address: "192.0.2.2:9876", Key: other.PublicKey()},
+		{Address: "192.0.2.3:9876", Key: allowed.PublicKey()},
+		{Address: "192.0.2.4:9876", Key: allowed.PublicKey()},
+		{Address: "192.0.2.9:9876", Key: allowed.PublicKey()},
+	})
+
+	dialed := func() map[string]bool {
+		addresses := make(map[string]bool)
+		for _, c := range impl.connections {
+			addresses[c.peerAddr] = true
+		}
+		return addresses
+	}
+
+	// up to max-clients, one connection per host and never to itself
+	impl.dialPeers()
+	assert.Equal(t, 3, len(impl.connections))
+	assert.True(t, dialed()["192.0.2.1:9876"])
+	assert.False(t, dialed()["192.0.2.1:9877"])
+	assert.False(t, dialed()["192.0.2.9:9876"])
+
+	// only the keys allowed-connection allows
+	impl.connections = nil
+	impl.addressBook = newAddressBook("")
+	impl.addressBook.learn("198.51.100.1", []PeerAddress{
+		{Address: "192.0.2.2:9876", Key: other.PublicKey()},
+		{Address: "192.0.2.3:9876", Key: allowed.PublicKey()},
+	})
+	impl.allowedConnections = specifiedPossible
+	impl.AllowedPeers = []ecc.PublicKey{allowed.PublicKey()}
+	impl.dialPeers()
+	assert.Equal(t, map[string]bool{"192.0.2.3:9876": true}, dialed())
+
+	// a dialed peer waits for its retry
+	impl.connections = nil
+	impl.dialPeers()
+	assert.Equal(t, 0, len(impl.connections))
+
+	impl.allowedConnections = nonePossible
+	impl.addressBook = newAddressBook("")
+	impl.addressBook.addStatic("192.0.2.1:9876")
+	impl.dialPeers()
+	assert.Equal(t, 0, len(impl.connections))
+}
+
+func Test_hasCapability(t *testing.T) {
+	// upstream nodes share the protocol versions, only the advertised capability enables the exchange
+	upstream := &HandshakeMessage{NetworkVersion: netVersionBase + 2, P2PAddress: "192.0.2.1:9876 - 1a2b3c4"}
+	assert.False(t, hasCapability(upstream, capabilityPeerExchange))
+
+	peer := &HandshakeMessage{NetworkVersion: netVersionBase + netVersion,
+		P2PAddress: "192.0.2.1:9876 - 1a2b3c4 " + capabilityPeerExchange}
+	assert.True(t, hasCapability(peer, capabilityPeerExchange))
+	impl := NewNetPlugin(app.App().GetIoService()).my
+	incoming := &Connection{incoming: true, peerAddr: "192.0.2.1:40000"}
+	assert.Equal(t, "192.0.2.1:9876", impl.peerListenAddress(incoming, peer))
+
+	assert.True(t, hasCapability(&HandshakeMessage{P2PAddress: " - 1a2b3c4 " + capabilityPeerExchange}, capabilityPeerExchange))
+	assert.False(t, hasCapability(&HandshakeMessage{P2PAddress: capabilityPeerExchange}, capabilityPeerExchange))
+	assert.False(t, hasCapability(&HandshakeMessage{}, capabilityPeerExchange))
+}
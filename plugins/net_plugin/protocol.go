@@ -22,6 +22,7 @@ const (
 	SyncRequestMessageType
 	SignedBlockType
 	PackedTransactionMessageType //8
+	PeerExchangeMessageType
 )
 
 type MessageReflectTypes struct {
@@ -39,6 +40,7 @@ var messageAttributes = []MessageReflectTypes{
 	{Name: "SyncRequest", ReflectType: reflect.TypeOf(SyncRequestMessage{})},
 	{Name: "SignedBlock", ReflectType: reflect.TypeOf(SignedBlockMessage{})},
 	{Name: "PackedTransaction", ReflectType: reflect.TypeOf(PackedTransactionMessage{})},
+	{Name: "PeerExchange", ReflectType: reflect.TypeOf(PeerExchangeMessage{})},
 }
 
 func (t NetMessageType) isValid() bool {
@@ -274,6 +276,25 @@ func (p *PackedTransactionMessage) String() string {
 	return string(bytes)
 }
 
+type PeerAddress struct {
+	Address string        `json:"address"` // host:port the peer listens on
+	Key     ecc.PublicKey `json:"key"`     // key the peer authenticated with, or empty
+}
+
+// PeerExchangeMessage shares the peers a node recently connected to, it is only sent to peers that advertise
+// capabilityPeerExchange
+type PeerExchangeMessage struct {
+	Peers []PeerAddress `json:"peers"`
+}
+
+func (p *PeerExchangeMessage) GetType() NetMessageType {
+	return PeerExchangeMessageType
+}
+func (p *PeerExchangeMessage) String() string {
+	bytes, _ := json.Marshal(p)
+	return string(bytes)
+}
+
 /**
 Goals of Network Code
 1. low latency to minimize missed blocks and potentially reduce block interval
@@ -0,0 +1,292 @@
+package net_plugin
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/ecc"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+type p2pEncryption byte
+
+const (
+	encryptionNone      p2pEncryption = iota // plaintext, as peers without encryption
+	encryptionPreferred                      // encrypted, plaintext with the peers that do not encrypt
+	encryptionRequired                       // encrypted only
+)
+
+const (
+	maxSecureRecord = 64 * 1024
+	maxSecureHello  = 1024
+)
+
+var (
+	// secureMagic opens the key exchange, a peer that does not encrypt reads it as a message length that is too long
+	secureMagic = []byte{0xff, 0xff, 0xff, 0x01}
+
+	errPlaintextPeer = errors.New("peer does not encrypt")
+)
+
+// secureHello is the key exchange of a connection, the ephemeral curve25519 key is signed with the peer-private-key
+type secureHello struct {
+	Ephemeral []byte
+	Key       ecc.PublicKey
+	Signature ecc.Signature
+}
+
+// secureTranscript is signed by the two peers, the responder signs the ephemeral keys of both
+type secureTranscript struct {
+	ChainID   common.ChainIdType
+	Initiator []byte
+	Responder []byte
+}
+
+// secureConn encrypts and authenticates with AES-GCM the bytes of a connection in records of at most maxSecureRecord,
+// each direction has its own key and counts its nonces
+type secureConn struct {
+	net.Conn
+	sealer    cipher.AEAD
+	opener    cipher.AEAD
+	sealNonce uint64
+	openNonce uint64
+	pending   []byte
+}
+
+func nextNonce(counter *uint64, size int) []byte {
+	nonce := make([]byte, size)
+	binary.BigEndian.PutUint64(nonce[size-8:], *counter)
+	*counter++
+	return nonce
+}
+
+func (s *secureConn) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxSecureRecord {
+			chunk = chunk[:maxSecureRecord]
+		}
+		sealed := s.sealer.Seal(nil, nextNonce(&s.sealNonce, s.sealer.NonceSize()), chunk, nil)
+		record := make([]byte, 4, 4+len(sealed))
+		binary.LittleEndian.PutUint32(record, uint32(len(sealed)))
+		if _, err := s.Conn.Write(append(record, sealed...)); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+func (s *secureConn) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		var size [4]byte
+		if _, err := io.ReadFull(s.Conn, size[:]); err != nil {
+			return 0, err
+		}
+		length := binary.LittleEndian.Uint32(size[:])
+		if length > uint32(maxSecureRecord+s.opener.Overhead()) {
+			return 0, fmt.Errorf("encrypted record of %d bytes is too long", length)
+		}
+		record := make([]byte, length)
+		if _, err := io.ReadFull(s.Conn, record); err != nil {
+			return 0, err
+		}
+		plain, err := s.opener.Open(record[:0], nextNonce(&s.openNonce, s.opener.NonceSize()), record, nil)
+		if err != nil {
+			return 0, fmt.Errorf("encrypted record does not authenticate: %s", err.Error())
+		}
+		s.pending = plain
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// prefixedConn gives back the bytes read from a peer that turned out not to encrypt
+type prefixedConn struct {
+	net.Conn
+	prefix []byte
+}
+
+func (c *prefixedConn) Read(p []byte) (int, error) {
+	if len(c.prefix) > 0 {
+		n := copy(p, c.prefix)
+		c.prefix = c.prefix[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+func newSecureAEAD(key []byte) cipher.AEAD {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}
+
+func newEphemeralKey() (private, public []byte, err error) {
+	private = make([]byte, curve25519.ScalarSize)
+	if _, err = rand.Read(private); err != nil {
+		return nil, nil, err
+	}
+	public, err = curve25519.X25519(private, curve25519.Basepoint)
+	return
+}
+
+// secureClient runs the key exchange of a connection this node opened, it returns errPlaintextPeer when the peer
+// closes the connection or answers without the key exchange
+func (impl *netPluginIMpl) secureClient(conn net.Conn) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(impl.respExpectedPeriod))
+	defer conn.SetDeadline(time.Time{})
+
+	private, public, err := newEphemeralKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := impl.writeSecureHello(conn, secureTranscript{ChainID: impl.chainID, Initiator: public}, public); err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, len(secureMagic))
+	if _, err := io.ReadFull(conn, magic); err != nil || !bytes.Equal(magic, secureMagic) {
+		return nil, errPlaintextPeer
+	}
+	hello, err := readSecureHello(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := impl.verifySecureHello(hello, secureTranscript{ChainID: impl.chainID, Initiator: public, Responder: hello.Ephemeral}); err != nil {
+		return nil, err
+	}
+
+	toResponder, toInitiator, err := impl.deriveSecureKeys(private, hello.Ephemeral, public, hello.Ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	return &secureConn{Conn: conn, sealer: toResponder, opener: toInitiator}, nil
+}
+
+// secureServer runs the key exchange of a connection this node accepted, a peer that does not encrypt is served in
+// plaintext when the encryption is only preferred
+func (impl *netPluginIMpl) secureServer(conn net.Conn) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(impl.respExpectedPeriod))
+	defer conn.SetDeadline(time.Time{})
+
+	magic := make([]byte, len(secureMagic))
+	if _, err := io.ReadFull(conn, magic); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, secureMagic) {
+		if impl.encryption == encryptionPreferred {
+			return &prefixedConn{Conn: conn, prefix: magic}, nil
+		}
+		return nil, errPlaintextPeer
+	}
+
+	hello, err := readSecureHello(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := impl.verifySecureHello(hello, secureTranscript{ChainID: impl.chainID, Initiator: hello.Ephemeral}); err != nil {
+		return nil, err
+	}
+
+	private, public, err := newEphemeralKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := impl.writeSecureHello(conn, secureTranscript{ChainID: impl.chainID, Initiator: hello.Ephemeral, Responder: public}, public); err != nil {
+		return nil, err
+	}
+
+	toResponder, toInitiator, err := impl.deriveSecureKeys(private, hello.Ephemeral, hello.Ephemeral, public)
+	if err != nil {
+		return nil, err
+	}
+	return &secureConn{Conn: conn, sealer: toInitiator, opener: toResponder}, nil
+}
+
+func (impl *netPluginIMpl) writeSecureHello(conn net.Conn, transcript secureTranscript, ephemeral []byte) error {
+	key := impl.getAuthenticationKey()
+	hello := secureHello{Ephemeral: ephemeral, Key: *key, Signature: *impl.signCompact(key, crypto.Hash256(transcript))}
+	payload, err := rlp.EncodeToBytes(&hello)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, len(secureMagic)+4, len(secureMagic)+4+len(payload))
+	copy(buf, secureMagic)
+	binary.LittleEndian.PutUint32(buf[len(secureMagic):], uint32(len(payload)))
+	_, err = conn.Write(append(buf, payload...))
+	return err
+}
+
+func readSecureHello(conn net.Conn) (*secureHello, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(size[:])
+	if length > maxSecureHello {
+		return nil, fmt.Errorf("key exchange of %d bytes is too long", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, err
+	}
+
+	hello := &secureHello{}
+	if err := rlp.DecodeBytes(payload, hello); err != nil {
+		return nil, err
+	}
+	if len(hello.Ephemeral) != curve25519.PointSize {
+		return nil, fmt.Errorf("key exchange with an ephemeral key of %d bytes", len(hello.Ephemeral))
+	}
+	return hello, nil
+}
+
+func (impl *netPluginIMpl) verifySecureHello(hello *secureHello, transcript secureTranscript) error {
+	key, err := hello.Signature.PublicKey(crypto.Hash256(transcript).Bytes())
+	if err != nil || key != hello.Key {
+		return fmt.Errorf("key exchange is not signed by %s", hello.Key)
+	}
+	if !impl.isAllowedPeerKey(hello.Key) {
+		return fmt.Errorf("key exchange with an unauthorized key %s", hello.Key)
+	}
+	return nil
+}
+
+// deriveSecureKeys derives the keys of the two directions from the curve25519 secret and the ephemeral keys
+func (impl *netPluginIMpl) deriveSecureKeys(private, peer, initiator, responder []byte) (toResponder, toInitiator cipher.AEAD, err error) {
+	shared, err := curve25519.X25519(private, peer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := append(append([]byte("eosgo p2p"), initiator...), responder...)
+	keys := make([]byte, 64)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, impl.chainID.Bytes(), info), keys); err != nil {
+		return nil, nil, err
+	}
+	return newSecureAEAD(keys[:32]), newSecureAEAD(keys[32:]), nil
+}
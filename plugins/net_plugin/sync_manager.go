@@ -62,6 +62,7 @@ func (s *syncManager) setStage(newState stages) {
 	}
 	FcLog.Info("old state %s becoming %s", stageStr(s.state), stageStr(newState))
 	s.state = newState
+	syncStageGauge.Set(float64(newState))
 }
 
 func (s *syncManager) isActive(c *Connection) bool {
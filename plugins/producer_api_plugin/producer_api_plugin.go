@@ -123,6 +123,32 @@ func (c *ProducerApiPlugin) PluginStartup() {
 			http_plugin.HandleException(e, "producer", "get_whitelist_blacklist", string(body), cb)
 		}).End()
 	})
+
+	httpPlugin.AddHandler(common.ProducerCreateSnapshot, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			data := proApi.CreateSnapshot()
+			result, err := json.Marshal(data)
+			if err != nil {
+				log.Error("producer_plugin ProducerCreateSnapshot is error: %s", err.Error())
+			}
+			cb(201, result)
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "producer", "create_snapshot", string(body), cb)
+		}).End()
+	})
+
+	httpPlugin.AddHandler(common.ProducerGetWatermarks, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+		Try(func() {
+			data := proApi.GetWatermarks()
+			result, err := json.Marshal(data)
+			if err != nil {
+				log.Error("producer_plugin ProducerGetWatermarks is error: %s", err.Error())
+			}
+			cb(200, result)
+		}).Catch(func(e interface{}) {
+			http_plugin.HandleException(e, "producer", "get_watermarks", string(body), cb)
+		}).End()
+	})
 }
 
 func (c *ProducerApiPlugin) PluginShutdown() {
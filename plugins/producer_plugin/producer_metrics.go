@@ -0,0 +1,21 @@
+package producer_plugin
+
+import (
+	"github.com/eosspark/eos-go/libraries/metrics"
+)
+
+/**
+ * metrics of the producer plugin
+ */
+var (
+	blocksProducedCounter = metrics.NewCounter("eosgo_producer_blocks_produced_total",
+		"Number of blocks produced by this node")
+	pendingIncomingTransactionsGauge = metrics.NewGauge("eosgo_producer_pending_incoming_transactions",
+		"Number of incoming transactions waiting for a pending block")
+	incomingTransactionsCounter = metrics.NewCounterVec("eosgo_producer_incoming_transactions_total",
+		"Number of incoming transactions, by result", "result")
+)
+
+func (impl *ProducerPluginImpl) updatePendingIncomingTransactionsGauge() {
+	pendingIncomingTransactionsGauge.Set(float64(len(impl.PendingIncomingTransactions)))
+}
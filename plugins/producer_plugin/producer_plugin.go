@@ -1,11 +1,14 @@
 package producer_plugin
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/eosspark/eos-go/chain/types"
 	. "github.com/eosspark/eos-go/chain/types/generated_containers"
 	. "github.com/eosspark/eos-go/plugins/chain_interface"
 	"github.com/eosspark/eos-go/plugins/chain_plugin"
+	"github.com/eosspark/eos-go/plugins/http_plugin"
+	"github.com/eosspark/eos-go/plugins/wallet_plugin"
 
 	//Chain "github.com/eosspark/eos-go/plugins/producer_plugin/testing" /*test model*/
 	"encoding/json"
@@ -19,6 +22,11 @@ import (
 	. "github.com/eosspark/eos-go/plugins/appbase/app"
 	"github.com/eosspark/eos-go/libraries/asio"
 	"github.com/urfave/cli"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"time"
 )
@@ -68,6 +76,11 @@ type GreylistParams struct {
 	Accounts []common.AccountName
 }
 
+type SnapshotInformation struct {
+	HeadBlockId  common.BlockIdType `json:"head_block_id"`
+	SnapshotName string             `json:"snapshot_name"`
+}
+
 func NewProducerPlugin(io *asio.IoContext) *ProducerPlugin {
 	plugin := &ProducerPlugin{}
 
@@ -117,7 +130,9 @@ func (p *ProducerPlugin) SetProgramOptions(options *[]cli.Flag) {
 				"   <public-key>    \tis a string form of a vaild EOSIO public key\n\n" +
 				"   <provider-spec> \tis a string in the form <provider-type>:<data>\n\n" +
 				"   <provider-type> \tis KEY, or KEOSD\n\n" +
-				"   KEY:<data>      \tis a string form of a valid EOSIO private key which maps to the provided public key\n\n",
+				"   KEY:<data>      \tis a string form of a valid EOSIO private key which maps to the provided public key\n\n" +
+				"   KEOSD:<data>    \tis the URL where keosd is available and the appropriate wallet(s) are unlocked. It may not be the\n" +
+				"                   \thttp-server-address of this node, the node signs its blocks on the thread that serves its http requests\n\n",
 		},
 		cli.IntFlag{
 			Name:  "keosd-provider-timeout",
@@ -143,6 +158,20 @@ func (p *ProducerPlugin) SetProgramOptions(options *[]cli.Flag) {
 			Usage: "ratio between incoming transations and deferred transactions when both are exhausted",
 			Value: 1.0,
 		},
+		cli.StringFlag{
+			Name:  "snapshots-dir",
+			Usage: "the location of the snapshots directory (absolute path or relative to application data dir)",
+			Value: "snapshots",
+		},
+		cli.StringFlag{
+			Name:  "producer-watermarks-file",
+			Usage: "the file recording the last block signed with each producer key, no block is signed at or below it (absolute path or relative to application data dir)",
+			Value: "producer_watermarks.json",
+		},
+		cli.StringFlag{
+			Name:  "transaction-journal-file",
+			Usage: "the file journaling the received transactions that are not yet irreversible, they are pushed again on startup unless expired (absolute path or relative to application data dir, no journal when empty)",
+		},
 	)
 }
 
@@ -173,7 +202,9 @@ func (p *ProducerPlugin) PluginInitialize(c *cli.Context) {
 			}).End()
 		}
 
+		httpServerAddress := c.String("http-server-address")
 		for _, keySpecPair := range c.StringSlice("signature-provider") {
+			servedByThisNode := false
 			Try(func() {
 				delim := strings.Index(keySpecPair, "=")
 				EosAssert(delim >= 0, &PluginConfigException{}, "Missing \"=\" in the key spec pair")
@@ -197,12 +228,18 @@ func (p *ProducerPlugin) PluginInitialize(c *cli.Context) {
 					}
 					p.my.SignatureProviders[pubKey] = makeKeySignatureProvider(priKey)
 				} else if specTypeStr == "KEOSD" {
-					p.my.SignatureProviders[pubKey] = makeKeosdSignatureProvider(p.my, specData, pubKey)
+					if servedByThisNode = keosdServedBy(specData, httpServerAddress); !servedByThisNode {
+						p.my.SignatureProviders[pubKey] = makeKeosdSignatureProvider(p.my, specData, pubKey)
+					}
 				}
 
 			}).Catch(func(interface{}) {
 				log.Error("Malformed signature provider: \"%s\", ignoring!", keySpecPair)
 			}).End()
+			// the request to sign a block would wait for the thread that is producing the block to serve it
+			EosAssert(!servedByThisNode, &PluginConfigException{},
+				"signature provider \"%s\" is the http-server-address %s of this node, keosd must run in another process",
+				keySpecPair, httpServerAddress)
 		}
 
 		p.my.ProductionEnabled = c.Bool("enable-stale-production")
@@ -219,6 +256,19 @@ func (p *ProducerPlugin) PluginInitialize(c *cli.Context) {
 
 		p.my.IncomingDeferRadio = c.Float64("incoming-defer-ratio")
 
+		p.my.SnapshotsDir = common.AbsolutePath(App().DataDir(), c.String("snapshots-dir"))
+
+		p.my.SignedWatermarks = newProducerWatermarks(common.AbsolutePath(App().DataDir(), c.String("producer-watermarks-file")))
+		for _, mark := range p.my.SignedWatermarks.list() {
+			if mark.BlockNum > p.my.ProducerWatermarks[mark.Producer] {
+				p.my.ProducerWatermarks[mark.Producer] = mark.BlockNum
+			}
+		}
+
+		if journal := c.String("transaction-journal-file"); len(journal) > 0 {
+			p.my.TransactionJournal = openTransactionJournal(common.AbsolutePath(App().DataDir(), journal))
+		}
+
 		if greylist := c.StringSlice("greylist-account"); len(greylist) > 0 {
 			param := GreylistParams{}
 			for _, a := range greylist {
@@ -267,6 +317,10 @@ func (p *ProducerPlugin) PluginStartup() {
 			}
 		}
 
+		if p.my.TransactionJournal != nil {
+			p.my.ReplayTransactionJournal()
+		}
+
 		p.my.ScheduleProductionLoop()
 
 		log.Info("producer plugin:  plugin_startup() end")
@@ -276,6 +330,14 @@ func (p *ProducerPlugin) PluginStartup() {
 
 func (p *ProducerPlugin) PluginShutdown() {
 	p.my.Timer.Cancel()
+	if p.my.TransactionJournal != nil {
+		if p.my.Chain != nil {
+			for _, trx := range p.my.Chain.GetUnappliedTransactions() {
+				p.my.TransactionJournal.append(trx.PackedTrx, false)
+			}
+		}
+		p.my.TransactionJournal.close()
+	}
 	log.Info("producer plugin shutdown")
 }
 
@@ -379,6 +441,10 @@ func (p *ProducerPlugin) RemoveGreylistAccounts(params GreylistParams) {
 	}
 }
 
+func (p *ProducerPlugin) GetWatermarks() []ProducerWatermark {
+	return p.my.SignedWatermarks.list()
+}
+
 func (p *ProducerPlugin) GetGreylist() GreylistParams {
 	result := GreylistParams{}
 	list := p.my.Chain.GetResourceGreyList()
@@ -424,6 +490,53 @@ func (p *ProducerPlugin) SetWhitelistBlacklist(params WhitelistAndBlacklist) {
 	}
 }
 
+/**
+ * writes the state of the head block to <snapshots-dir>/snapshot-<head block id>.bin.pending, the snapshot is
+ * renamed to <snapshots-dir>/snapshot-<head block id>.bin once its block becomes irreversible and is discarded
+ * if that block is forked out
+ */
+func (p *ProducerPlugin) CreateSnapshot() SnapshotInformation {
+	chain := p.my.Chain
+	headId := chain.HeadBlockId()
+	snapshotPath := fmt.Sprintf("%s/snapshot-%s.bin", p.my.SnapshotsDir, headId)
+
+	for _, pending := range p.my.PendingSnapshots {
+		if pending.BlockId == headId {
+			return SnapshotInformation{HeadBlockId: headId, SnapshotName: snapshotPath}
+		}
+	}
+	_, err := os.Stat(snapshotPath)
+	EosAssert(os.IsNotExist(err), &SnapshotException{}, "snapshot named %s already exists", snapshotPath)
+
+	EosAssert(os.MkdirAll(p.my.SnapshotsDir, os.ModePerm) == nil, &SnapshotException{},
+		"unable to create snapshots directory %s", p.my.SnapshotsDir)
+
+	pendingPath := snapshotPath + ".pending"
+	out, err := os.Create(pendingPath)
+	EosAssert(err == nil, &SnapshotException{}, "unable to create snapshot %s: %s", pendingPath, err)
+
+	// the pending block has to be aborted to take a consistent snapshot
+	chain.AbortBlock()
+	Try(func() {
+		chain.WriteSnapshot(Chain.NewSnapshotWriter(out))
+		Throw(out.Close())
+	}).Catch(func(e interface{}) {
+		out.Close()
+		os.Remove(pendingPath)
+		Throw(e)
+	}).End()
+	p.my.ScheduleProductionLoop()
+
+	p.my.PendingSnapshots = append(p.my.PendingSnapshots, pendingSnapshot{
+		BlockId:     headId,
+		BlockNum:    chain.HeadBlockNum(),
+		PendingPath: pendingPath,
+		FinalPath:   snapshotPath,
+	})
+
+	return SnapshotInformation{HeadBlockId: headId, SnapshotName: snapshotPath}
+}
+
 func failureIsSubjective(e Exception, deadlineIsSubjective bool) bool {
 	code := e.Code()
 	return (code == BlockCpuUsageExceeded{}.Code()) ||
@@ -449,18 +562,124 @@ func makeKeySignatureProvider(key *ecc.PrivateKey) signatureProviderType {
 	return signFunc
 }
 
-func makeKeosdSignatureProvider(produce *ProducerPluginImpl, url string, publicKey ecc.PublicKey) signatureProviderType {
+// makeKeosdSignatureProvider signs with a key of a wallet served by a keosd or a node running the wallet_api_plugin,
+// a spec naming only the host signs on its /v1/wallet/sign_digest
+func makeKeosdSignatureProvider(produce *ProducerPluginImpl, keosdUrl string, publicKey ecc.PublicKey) signatureProviderType {
+	if u, err := url.Parse(keosdUrl); err == nil && (u.Path == "" || u.Path == "/") {
+		u.Path = common.WalletSignDigest
+		keosdUrl = u.String()
+	}
+
 	signFunc := func(digest crypto.Sha256) *ecc.Signature {
-		if produce != nil {
-			//TODO
-			return ecc.NewSigNil()
-		} else {
-			return ecc.NewSigNil()
+		client := http.Client{}
+		// keosd-provider-timeout is parsed after the providers are made
+		if produce != nil && produce.KeosdProviderTimeoutUs > 0 {
+			client.Timeout = time.Duration(produce.KeosdProviderTimeoutUs) * time.Microsecond
+		}
+
+		body, _ := json.Marshal(wallet_plugin.SignDigestParams{Digest: digest, Key: publicKey})
+		resp, err := client.Post(keosdUrl, "application/json", bytes.NewReader(body))
+		if err != nil {
+			EosThrow(&SignatureProviderException{}, "keosd %s failed to sign with %s: %s", keosdUrl, publicKey, err.Error())
+		}
+		defer resp.Body.Close()
+		result, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			EosThrow(&SignatureProviderException{}, "keosd %s failed to sign with %s: %s", keosdUrl, publicKey, err.Error())
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			var errorResults http_plugin.ErrorResults
+			if err := json.Unmarshal(result, &errorResults); err != nil {
+				EosThrow(&SignatureProviderException{}, "keosd %s failed to sign with %s: %s", keosdUrl, publicKey, resp.Status)
+			}
+			switch errorResults.Error.Code {
+			case WalletLockedException{}.Code():
+				EosThrow(&WalletLockedException{}, "keosd %s can not sign with %s: the wallet is locked", keosdUrl, publicKey)
+			case WalletMissingPubKeyException{}.Code():
+				EosThrow(&WalletMissingPubKeyException{}, "keosd %s can not sign with %s: no unlocked wallet holds the key", keosdUrl, publicKey)
+			default:
+				EosThrow(&SignatureProviderException{}, "keosd %s failed to sign with %s: %s", keosdUrl, publicKey, errorResults.Error.What)
+			}
+		}
+
+		sig := ecc.NewSigNil()
+		if err := json.Unmarshal(result, sig); err != nil {
+			EosThrow(&SignatureProviderException{}, "keosd %s returned an invalid signature: %s", keosdUrl, err.Error())
 		}
+		recovered, err := sig.PublicKey(digest.Bytes())
+		EosAssert(err == nil && recovered == publicKey, &SignatureProviderException{},
+			"keosd %s returned a signature that does not match %s", keosdUrl, publicKey)
+		return sig
 	}
 	return signFunc
 }
 
+// keosdServedBy reports whether the keosd at keosdUrl is the http server listening on listenAddress, a listen
+// address without a host serves the loopback and the interface addresses of the machine
+func keosdServedBy(keosdUrl string, listenAddress string) bool {
+	u, err := url.Parse(keosdUrl)
+	if err != nil || len(listenAddress) == 0 {
+		return false
+	}
+	listenHost, listenPort, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return false
+	}
+	port := u.Port()
+	if len(port) == 0 {
+		if port = "80"; u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if port != listenPort {
+		return false
+	}
+
+	listenIps := lookupIps(listenHost)
+	wildcard := len(listenHost) == 0 || len(listenIps) == 1 && listenIps[0].IsUnspecified()
+	for _, ip := range lookupIps(u.Hostname()) {
+		if wildcard && (ip.IsLoopback() || isInterfaceAddress(ip)) {
+			return true
+		}
+		for _, listenIp := range listenIps {
+			if ip.Equal(listenIp) || ip.IsLoopback() && listenIp.IsLoopback() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupIps returns the addresses of host, which is a name or an address
+func lookupIps(host string) []net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}
+	}
+	var ips []net.IP
+	if addrs, err := net.LookupHost(host); err == nil {
+		for _, addr := range addrs {
+			if ip := net.ParseIP(addr); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+func isInterfaceAddress(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if network, ok := addr.(*net.IPNet); ok && network.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func newChainBanner(db *Chain.Controller) {
 	fmt.Print("\n" +
 		"*******************************\n" +
@@ -15,6 +15,7 @@ import (
 	"github.com/eosspark/eos-go/libraries/asio"
 	"github.com/eosspark/eos-go/plugins/chain_interface"
 	. "github.com/eosspark/eos-go/plugins/producer_plugin/multi_index"
+	"os"
 )
 
 type ProducerPluginImpl struct {
@@ -29,6 +30,7 @@ type ProducerPluginImpl struct {
 	Timer              *common.Timer
 	ProducerWatermarks map[common.AccountName]uint32
 	PendingBlockMode   PendingBlockMode
+	SignedWatermarks   *producerWatermarks
 
 	PersistentTransactions  *TransactionIdWithExpiryIndex
 	BlacklistedTransactions *TransactionIdWithExpiryIndex
@@ -65,6 +67,18 @@ type ProducerPluginImpl struct {
 	IncomingDeferRadio float64
 
 	TransactionAckChannel *include.Channel
+
+	SnapshotsDir     string
+	PendingSnapshots []pendingSnapshot
+
+	TransactionJournal *transactionJournal
+}
+
+type pendingSnapshot struct {
+	BlockId     common.BlockIdType
+	BlockNum    uint32
+	PendingPath string
+	FinalPath   string
 }
 
 type StartBlockResult int
@@ -99,6 +113,7 @@ func NewProducerPluginImpl(io *asio.IoContext) *ProducerPluginImpl {
 		SignatureProviders:      make(map[ecc.PublicKey]signatureProviderType),
 		Producers:               *NewAccountNameSet(),
 		ProducerWatermarks:      make(map[common.AccountName]uint32),
+		SignedWatermarks:        newProducerWatermarks(""),
 		PersistentTransactions:  NewTransactionIdWithExpiryIndex(),
 		BlacklistedTransactions: NewTransactionIdWithExpiryIndex(),
 		IncomingTrxWeight:       0.0,
@@ -179,6 +194,45 @@ func (impl *ProducerPluginImpl) OnBlock(bsp *types.BlockState) {
 
 func (impl *ProducerPluginImpl) OnIrreversibleBlock(lib *types.SignedBlock) {
 	impl.IrreversibleBlockTime = lib.Timestamp.ToTimePoint()
+
+	libNum := lib.BlockNumber()
+	remaining := impl.PendingSnapshots[:0]
+	for _, pending := range impl.PendingSnapshots {
+		if pending.BlockNum > libNum {
+			remaining = append(remaining, pending)
+			continue
+		}
+
+		if impl.Chain.GetBlockIdForNum(pending.BlockNum) == pending.BlockId {
+			if err := os.Rename(pending.PendingPath, pending.FinalPath); err != nil {
+				log.Error("unable to finalize snapshot %s: %s", pending.FinalPath, err.Error())
+			}
+		} else {
+			// the block of the snapshot was forked out
+			os.Remove(pending.PendingPath)
+		}
+	}
+	impl.PendingSnapshots = remaining
+
+	if impl.TransactionJournal != nil {
+		impl.TransactionJournal.compact(lib)
+	}
+}
+
+// ReplayTransactionJournal pushes the transactions journaled before the node stopped, the expired ones are dropped
+func (impl *ProducerPluginImpl) ReplayTransactionJournal() {
+	now := common.Now()
+	replayed, expired := 0, 0
+	for _, entry := range impl.TransactionJournal.pending() {
+		if entry.expiry < now {
+			impl.TransactionJournal.remove(entry.id)
+			expired++
+			continue
+		}
+		impl.OnIncomingTransactionAsync(&entry.trx.Trx, entry.trx.PersistUntilExpired, func(interface{}) {})
+		replayed++
+	}
+	log.Info("Replayed %d journaled transactions, dropped %d expired ones", replayed, expired)
 }
 
 func (impl *ProducerPluginImpl) OnIncomingBlock(block *types.SignedBlock) {
@@ -247,8 +301,13 @@ type pendingIncomingTransaction struct {
 
 func (impl *ProducerPluginImpl) OnIncomingTransactionAsync(trx *types.PackedTransaction, persistUntilExpired bool, next func(interface{})) {
 	chain := impl.Chain
+	if impl.TransactionJournal != nil {
+		impl.TransactionJournal.append(trx, persistUntilExpired)
+	}
+
 	if chain.PendingBlockState() == nil {
 		impl.PendingIncomingTransactions = append(impl.PendingIncomingTransactions, pendingIncomingTransaction{trx, persistUntilExpired, next})
+		impl.updatePendingIncomingTransactionsGauge()
 		return
 	}
 
@@ -257,6 +316,11 @@ func (impl *ProducerPluginImpl) OnIncomingTransactionAsync(trx *types.PackedTran
 	sendResponse := func(response interface{}) {
 		next(response)
 		if re, ok := response.(Exception); ok {
+			incomingTransactionsCounter.WithLabelValues("rejected").Inc()
+			// a duplicate is journaled until it is irreversible or expires
+			if _, duplicate := re.(*TxDuplicate); !duplicate && impl.TransactionJournal != nil {
+				impl.TransactionJournal.remove(trx.ID())
+			}
 			impl.TransactionAckChannel.Publish(common.Pair{re, trx})
 			if impl.PendingBlockMode == PendingBlockMode(producing) {
 				trxTraceLog.Debug("[TRX_TRACE] Block %d for producer %s is REJECTING tx: %s : %s ",
@@ -267,6 +331,7 @@ func (impl *ProducerPluginImpl) OnIncomingTransactionAsync(trx *types.PackedTran
 			}
 
 		} else {
+			incomingTransactionsCounter.WithLabelValues("accepted").Inc()
 			impl.TransactionAckChannel.Publish(common.Pair{nil, trx})
 			if impl.PendingBlockMode == PendingBlockMode(producing) {
 				trxTraceLog.Debug("[TRX_TRACE] Block %d for producer %s is ACCEPTING tx: %s",
@@ -302,6 +367,7 @@ func (impl *ProducerPluginImpl) OnIncomingTransactionAsync(trx *types.PackedTran
 		if trace.Except != nil {
 			if failureIsSubjective(trace.Except, deadlineIsSubjective) {
 				impl.PendingIncomingTransactions = append(impl.PendingIncomingTransactions, pendingIncomingTransaction{trx, persistUntilExpired, next})
+				impl.updatePendingIncomingTransactionsGauge()
 				if impl.PendingBlockMode == PendingBlockMode(producing) {
 					trxTraceLog.Debug("[TRX_TRACE] Block %d for producer %s COULD NOT FIT, tx: %s RETRYING ",
 						chain.HeadBlockNum()+1, chain.PendingBlockState().Header.Producer, trx.ID())
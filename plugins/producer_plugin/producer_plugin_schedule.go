@@ -156,6 +156,14 @@ func (impl *ProducerPluginImpl) StartBlock() (StartBlockResult, bool) {
 		}
 	}
 
+	if impl.PendingBlockMode == PendingBlockMode(producing) {
+		if signed, ok := impl.SignedWatermarks.get(scheduleProducer.BlockSigningKey); ok && !signed.Allows(hbs.BlockNum+1, types.NewBlockTimeStamp(blockTime)) {
+			log.Error("Not producing block because %s already signed block %d @ %s with %s",
+				signed.Producer, signed.BlockNum, signed.Timestamp, signed.Key)
+			impl.PendingBlockMode = PendingBlockMode(speculating)
+		}
+	}
+
 	if impl.PendingBlockMode == PendingBlockMode(speculating) {
 		headBlockAge := now.Sub(chain.HeadBlockTime())
 		if headBlockAge > common.Seconds(5) {
@@ -351,6 +359,7 @@ func (impl *ProducerPluginImpl) StartBlock() (StartBlockResult, bool) {
 					for impl.IncomingTrxWeight >= 1.0 && origPendingTxnSize > 0 && len(impl.PendingIncomingTransactions) > 0 {
 						e := impl.PendingIncomingTransactions[0]
 						impl.PendingIncomingTransactions = impl.PendingIncomingTransactions[1:]
+						impl.updatePendingIncomingTransactionsGauge()
 						origPendingTxnSize--
 						impl.IncomingTrxWeight -= 1.0
 						impl.OnIncomingTransactionAsync(e.packedTransaction, e.persistUntilExpired, e.next)
@@ -420,6 +429,7 @@ func (impl *ProducerPluginImpl) StartBlock() (StartBlockResult, bool) {
 				for origPendingTxnSize > 0 && len(impl.PendingIncomingTransactions) > 0 {
 					e := impl.PendingIncomingTransactions[0]
 					impl.PendingIncomingTransactions = impl.PendingIncomingTransactions[1:]
+					impl.updatePendingIncomingTransactionsGauge()
 					origPendingTxnSize--
 					impl.OnIncomingTransactionAsync(e.packedTransaction, e.persistUntilExpired, e.next)
 					if blockTime <= common.Now() {
@@ -582,13 +592,26 @@ func (impl *ProducerPluginImpl) ProduceBlock() {
 	signatureProvider := impl.SignatureProviders[pbs.BlockSigningKey]
 	EosAssert(signatureProvider != nil, &ProducerPrivKeyNotFound{}, "Attempting to produce a block for which we don't have the private key")
 
+	signed, ok := impl.SignedWatermarks.get(pbs.BlockSigningKey)
+	EosAssert(!ok || signed.Allows(pbs.BlockNum, pbs.Header.Timestamp), &ProducerDoubleSign{},
+		"%s already signed block %d @ %s with %s", signed.Producer, signed.BlockNum, signed.Timestamp, signed.Key)
+
 	chain.FinalizeBlock()
 	chain.SignBlock(func(d crypto.Sha256) ecc.Signature {
 		defer makeDebugTimeLogger()
-		return *signatureProvider(d)
+		sig := *signatureProvider(d)
+		// the watermark is durable before the signature leaves the signer
+		impl.SignedWatermarks.set(ProducerWatermark{
+			Key:       pbs.BlockSigningKey,
+			Producer:  pbs.Header.Producer,
+			BlockNum:  pbs.BlockNum,
+			Timestamp: pbs.Header.Timestamp,
+		})
+		return sig
 	})
 
 	chain.CommitBlock(true)
+	blocksProducedCounter.Inc()
 
 	newBs := chain.HeadBlockState()
 	impl.ProducerWatermarks[newBs.Header.Producer] = chain.HeadBlockNum()
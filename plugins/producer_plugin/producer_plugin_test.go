@@ -1,9 +1,17 @@
 package producer_plugin
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"crypto/sha256"
 	"github.com/eosspark/eos-go/chain/types"
@@ -11,9 +19,13 @@ import (
 	"github.com/eosspark/eos-go/crypto"
 	"github.com/eosspark/eos-go/crypto/ecc"
 	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/exception"
 	"github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/log"
 	"github.com/eosspark/eos-go/plugins/appbase/app"
+	"github.com/eosspark/eos-go/plugins/http_plugin"
+	"github.com/eosspark/eos-go/plugins/wallet_api_plugin"
+	"github.com/eosspark/eos-go/plugins/wallet_plugin"
 	"github.com/eosspark/eos-go/libraries/asio"
 )
 
@@ -52,6 +64,8 @@ func TestProducerPlugin_PluginInitialize(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, plugin.my.SignatureProviders, pub)
 
+	// the snapshots dir is relative to the data dir
+	assert.Equal(t, filepath.Join(app.App().DataDir(), "snapshots"), plugin.my.SnapshotsDir)
 }
 
 func TestProducerPlugin_PluginStartup(t *testing.T) {
@@ -270,7 +284,7 @@ func BenchmarkProducerPluginImpl_OnIncomingBlock(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		block := &types.SignedBlock{}
-		block.Timestamp = types.NewBlockTimeStamp(*plugin.my.CalculateNextBlockTime(&eosio, chain.HeadBlockState().SignedBlock.Timestamp))
+		block.Timestamp = types.NewBlockTimeStamp(*plugin.my.CalculateNextBlockTime(eosio, chain.HeadBlockState().SignedBlock.Timestamp))
 		block.Producer = common.N("eosio")
 		block.Previous = chain.HeadBlockState().BlockId
 
@@ -334,3 +348,259 @@ func BenchmarkProducerPluginImpl_MaybeProduceBlock(b *testing.B) {
 		plugin.my.MaybeProduceBlock()
 	}
 }
+
+func keosdPluginsStartup(arguments ...string) []app.Plugin {
+	plugins := []app.Plugin{
+		app.App().GetPlugin(http_plugin.HttpPlug),
+		app.App().GetPlugin(wallet_plugin.WalletPlug),
+		app.App().GetPlugin(wallet_api_plugin.WalletApiPlug),
+	}
+
+	cliApp := cli.NewApp()
+	for _, plugin := range plugins {
+		plugin.SetProgramOptions(&cliApp.Flags)
+	}
+	cliApp.Action = func(option *cli.Context) {
+		for _, plugin := range plugins {
+			plugin.PluginInitialize(option)
+		}
+	}
+	cliApp.Run(append(make([]string, 1, len(arguments)+1), arguments...))
+
+	for _, plugin := range plugins {
+		plugin.PluginStartup()
+	}
+	return plugins
+}
+
+func Test_makeKeosdSignatureProvider(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	walletDir, err := ioutil.TempDir("", "keosd_provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(walletDir)
+
+	keosdPluginsStartup("--http-server-address", address, "--wallet-dir", walletDir)
+	// the wallet api is served by the io service, the providers are called from this goroutine as from the chain
+	go app.App().GetIoService().Run()
+	defer app.App().GetIoService().Stop()
+
+	walletMgr := app.App().GetPlugin(wallet_plugin.WalletPlug).(*wallet_plugin.WalletPlugin).GetWalletManager()
+	walletMgr.Create("producer")
+	walletMgr.ImportKey("producer", "5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3")
+
+	initPriKey, _ := ecc.NewPrivateKey("5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3")
+	initPubKey := initPriKey.PublicKey()
+	otherPriKey, _ := ecc.NewPrivateKey("5Ja3h2wJNUnNcoj39jDMHGigsazvbGHAeLYEHM5uTwtfUoRDoYP")
+	otherPubKey := otherPriKey.PublicKey()
+
+	impl := &ProducerPluginImpl{KeosdProviderTimeoutUs: common.Seconds(5)}
+	hash := crypto.Hash256("makeKeosdSignatureProvider")
+
+	// the handlers are added by the io service
+	sp := makeKeosdSignatureProvider(impl, "http://"+address, initPubKey)
+	var sig *ecc.Signature
+	for i := 0; i < 50 && sig == nil; i++ {
+		try.Try(func() {
+			sig = sp(*hash)
+		}).Catch(func(e exception.SignatureProviderException) {
+			time.Sleep(100 * time.Millisecond)
+		}).End()
+	}
+	assert.NotNil(t, sig)
+	pk, err := sig.PublicKey(hash.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, initPubKey, pk)
+
+	returned := false
+	try.Try(func() {
+		makeKeosdSignatureProvider(impl, "http://"+address, otherPubKey)(*hash)
+		returned = true
+	}).Catch(func(e exception.WalletMissingPubKeyException) {
+	}).End()
+	assert.False(t, returned)
+
+	walletMgr.Lock("producer")
+	try.Try(func() {
+		sp(*hash)
+		returned = true
+	}).Catch(func(e exception.WalletLockedException) {
+	}).End()
+	assert.False(t, returned)
+
+	// a provider returning a signature of another key
+	mismatched := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherSig, _ := otherPriKey.Sign(hash.Bytes())
+		result, _ := json.Marshal(otherSig)
+		w.WriteHeader(201)
+		w.Write(result)
+	}))
+	defer mismatched.Close()
+	try.Try(func() {
+		makeKeosdSignatureProvider(impl, mismatched.URL, initPubKey)(*hash)
+		returned = true
+	}).Catch(func(e exception.SignatureProviderException) {
+	}).End()
+	assert.False(t, returned)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer slow.Close()
+	try.Try(func() {
+		makeKeosdSignatureProvider(&ProducerPluginImpl{KeosdProviderTimeoutUs: common.Milliseconds(5)}, slow.URL, initPubKey)(*hash)
+		returned = true
+	}).Catch(func(e exception.SignatureProviderException) {
+	}).End()
+	assert.False(t, returned)
+}
+
+func Test_producerWatermarks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "producer_watermarks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := dir + "/producer_watermarks.json"
+
+	initPriKey, _ := ecc.NewPrivateKey("5KQwrPbwdL6PhXujxW37FSSQZ1JiwsST4cqQzDeyXtP79zkvFD3")
+	mark := ProducerWatermark{Key: initPriKey.PublicKey(), Producer: eosio, BlockNum: 10, Timestamp: types.BlockTimeStamp(100)}
+
+	watermarks := newProducerWatermarks(file)
+	assert.Equal(t, 0, len(watermarks.list()))
+	watermarks.set(mark)
+	_, err = os.Stat(file + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	// a restarted producer reads the watermarks back
+	watermarks = newProducerWatermarks(file)
+	assert.Equal(t, []ProducerWatermark{mark}, watermarks.list())
+	signed, ok := watermarks.get(mark.Key)
+	assert.True(t, ok)
+	assert.False(t, signed.Allows(10, types.BlockTimeStamp(101)))
+	assert.False(t, signed.Allows(11, types.BlockTimeStamp(100)))
+	assert.True(t, signed.Allows(11, types.BlockTimeStamp(101)))
+
+	plugin := producerPluginInitialize("-e", "-p", "eosio", "--producer-watermarks-file", file)
+	assert.Equal(t, uint32(10), plugin.my.ProducerWatermarks[eosio])
+	assert.Equal(t, []ProducerWatermark{mark}, plugin.GetWatermarks())
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("{"), 0644))
+	returned := false
+	try.Try(func() {
+		newProducerWatermarks(file)
+		returned = true
+	}).Catch(func(e exception.PluginConfigException) {
+	}).End()
+	assert.False(t, returned)
+}
+
+func journalTestTrx(expiration common.TimePoint, refBlockNum uint16) *types.PackedTransaction {
+	trx := types.Transaction{}
+	trx.Expiration = common.NewTimePointSecTp(expiration)
+	trx.RefBlockNum = refBlockNum
+	return types.NewPackedTransactionByTrx(&trx, types.CompressionNone)
+}
+
+func Test_transactionJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transaction_journal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := dir + "/transactions"
+
+	now := common.Now()
+	first := journalTestTrx(now.AddUs(common.Seconds(60)), 1)
+	second := journalTestTrx(now.AddUs(common.Seconds(60)), 2)
+	expiring := journalTestTrx(now.AddUs(common.Seconds(10)), 3)
+
+	journal := openTransactionJournal(file)
+	journal.append(first, true)
+	// a record that cannot be decoded is skipped, the records after it are kept
+	journal.out.Write([]byte{3, 0, 0, 0, 0xff, 0xff, 0xff})
+	journal.append(second, false)
+	journal.append(expiring, false)
+	journal.append(first, true)
+	journal.close()
+
+	// a record torn by a crash is dropped
+	out, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	out.Write([]byte{100, 0, 0, 0, 1, 2})
+	out.Close()
+
+	journal = openTransactionJournal(file)
+	pending := journal.pending()
+	assert.Equal(t, 3, len(pending))
+	assert.Equal(t, first.ID(), pending[0].id)
+	assert.True(t, pending[0].trx.PersistUntilExpired)
+	assert.Equal(t, second.ID(), pending[1].id)
+	assert.Equal(t, expiring.ID(), pending[2].id)
+	journal.close()
+
+	// a length above the maximum record size is not allocated
+	out, err = os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	out.Write([]byte{0xff, 0xff, 0xff, 0xff, 1, 2})
+	out.Close()
+
+	journal = openTransactionJournal(file)
+	assert.Equal(t, 3, len(journal.pending()))
+
+	// the transactions of an irreversible block and the ones expired at its time are compacted away
+	lib := &types.SignedBlock{}
+	lib.Timestamp = types.NewBlockTimeStamp(now.AddUs(common.Seconds(30)))
+	lib.Transactions = []types.TransactionReceipt{{Trx: types.TransactionWithID{PackedTransaction: second}}}
+	journal.compact(lib)
+	journal.close()
+
+	journal = openTransactionJournal(file)
+	pending = journal.pending()
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, first.ID(), pending[0].id)
+	journal.remove(first.ID())
+	assert.Equal(t, 0, len(journal.pending()))
+	journal.close()
+}
+
+func Test_keosdServedBy(t *testing.T) {
+	assert.True(t, keosdServedBy("http://127.0.0.1:8888", "127.0.0.1:8888"))
+	assert.True(t, keosdServedBy("http://localhost:8888/", "127.0.0.1:8888"))
+	assert.True(t, keosdServedBy("http://127.0.0.1:8888", "0.0.0.0:8888"))
+	assert.True(t, keosdServedBy("http://127.0.0.1:8888", ":8888"))
+	assert.True(t, keosdServedBy("http://127.0.0.1", "127.0.0.1:80"))
+
+	assert.False(t, keosdServedBy("http://127.0.0.1:8900", "127.0.0.1:8888"))
+	assert.False(t, keosdServedBy("http://192.0.2.1:8888", "127.0.0.1:8888"))
+	assert.False(t, keosdServedBy("http://192.0.2.1:8888", "0.0.0.0:8888"))
+	assert.False(t, keosdServedBy("http://127.0.0.1:8888", ""))
+}
+
+func TestProducerPlugin_KeosdOfThisNode(t *testing.T) {
+	initialize := func(arguments ...string) (plugin *ProducerPlugin, rejected bool) {
+		cliApp := cli.NewApp()
+		plugin = NewProducerPlugin(asio.NewIoContext())
+		plugin.SetProgramOptions(&cliApp.Flags)
+		app.App().GetPlugin(http_plugin.HttpPlug).SetProgramOptions(&cliApp.Flags)
+		cliApp.Action = func(option *cli.Context) {
+			plugin.PluginInitialize(option)
+		}
+		try.Try(func() {
+			cliApp.Run(append(make([]string, 1, len(arguments)+1), arguments...))
+		}).Catch(func(e exception.Exception) {
+			rejected = e.Code() == exception.PluginConfigException{}.Code()
+		}).End()
+		return
+	}
+	pub, err := ecc.NewPublicKey("EOS6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	assert.NoError(t, err)
+
+	plugin, rejected := initialize("-p", "eosio", "--http-server-address", "127.0.0.1:8888",
+		"--signature-provider", pub.String()+"=KEOSD:http://127.0.0.1:8900")
+	assert.False(t, rejected)
+	assert.Contains(t, plugin.my.SignatureProviders, pub)
+
+	_, rejected = initialize("-p", "eosio", "--http-server-address", "127.0.0.1:8888",
+		"--signature-provider", pub.String()+"=KEOSD:http://localhost:8888")
+	assert.True(t, rejected)
+}
@@ -0,0 +1,134 @@
+package producer_plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/ecc"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+)
+
+// ProducerWatermark is the last block signed with a producer key, the key never signs a block at or below it again
+type ProducerWatermark struct {
+	Key       ecc.PublicKey        `json:"key"`
+	Producer  common.AccountName   `json:"producer"`
+	BlockNum  uint32               `json:"block_num"`
+	Timestamp types.BlockTimeStamp `json:"timestamp"`
+}
+
+// Allows tells if a block of blockNum at timestamp is above the watermark
+func (w *ProducerWatermark) Allows(blockNum uint32, timestamp types.BlockTimeStamp) bool {
+	return blockNum > w.BlockNum && timestamp > w.Timestamp
+}
+
+// producerWatermarks keeps the watermarks of the producer keys in a file of the data dir so that they survive a restart
+// or a failover, a file of "" keeps them in memory only
+type producerWatermarks struct {
+	file  string
+	marks map[ecc.PublicKey]ProducerWatermark
+}
+
+func newProducerWatermarks(file string) *producerWatermarks {
+	w := &producerWatermarks{file: file, marks: make(map[ecc.PublicKey]ProducerWatermark)}
+	if len(file) == 0 {
+		return w
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return w
+	}
+	EosAssert(err == nil, &PluginConfigException{}, "cannot read the producer watermarks %s: %v", file, err)
+
+	// a producer must not start without the watermarks it wrote
+	var marks []ProducerWatermark
+	err = json.Unmarshal(data, &marks)
+	EosAssert(err == nil, &PluginConfigException{}, "corrupted producer watermarks %s: %v", file, err)
+	for _, mark := range marks {
+		w.marks[mark.Key] = mark
+	}
+	return w
+}
+
+func (w *producerWatermarks) get(key ecc.PublicKey) (ProducerWatermark, bool) {
+	mark, ok := w.marks[key]
+	return mark, ok
+}
+
+func (w *producerWatermarks) list() []ProducerWatermark {
+	marks := make([]ProducerWatermark, 0, len(w.marks))
+	for _, mark := range w.marks {
+		marks = append(marks, mark)
+	}
+	sort.Slice(marks, func(i, j int) bool {
+		if marks[i].Producer != marks[j].Producer {
+			return marks[i].Producer < marks[j].Producer
+		}
+		return marks[i].Key.String() < marks[j].Key.String()
+	})
+	return marks
+}
+
+// set replaces the file with the new watermark and syncs it before returning, the watermark is not kept if it can not
+// be written
+func (w *producerWatermarks) set(mark ProducerWatermark) {
+	previous, had := w.marks[mark.Key]
+	w.marks[mark.Key] = mark
+	if len(w.file) == 0 {
+		return
+	}
+
+	err := w.write()
+	if err != nil {
+		if had {
+			w.marks[mark.Key] = previous
+		} else {
+			delete(w.marks, mark.Key)
+		}
+		EosThrow(&ProducerException{}, "cannot write the producer watermarks %s: %v", w.file, err)
+	}
+}
+
+func (w *producerWatermarks) write() error {
+	data, err := json.Marshal(w.list())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.file), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp := w.file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.file); err != nil {
+		return err
+	}
+
+	// the rename is durable once the directory is synced
+	dir, err := os.Open(filepath.Dir(w.file))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
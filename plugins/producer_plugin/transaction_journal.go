@@ -0,0 +1,215 @@
+package producer_plugin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/log"
+)
+
+// the largest record read back from the journal, far above the size of any transaction the chain accepts. A larger
+// length is a damaged length and the records after it cannot be found.
+const maxJournalRecordSize = 8 * 1024 * 1024
+
+type journaledTransaction struct {
+	PersistUntilExpired bool
+	Trx                 types.PackedTransaction
+}
+
+type journalEntry struct {
+	seq    uint64
+	id     common.TransactionIdType
+	expiry common.TimePoint
+	trx    *journaledTransaction
+}
+
+// transactionJournal keeps the unexpired transactions a node received but did not see in an irreversible block, so that
+// they are pushed again after a restart. The transactions are appended to the file as they come, the file is rewritten
+// without the transactions in irreversible blocks, the expired and the rejected ones when it is compacted.
+type transactionJournal struct {
+	file    string
+	out     *os.File
+	seq     uint64
+	entries map[common.TransactionIdType]*journalEntry
+	removed int
+}
+
+func openTransactionJournal(file string) *transactionJournal {
+	j := &transactionJournal{file: file, entries: make(map[common.TransactionIdType]*journalEntry)}
+	EosAssert(os.MkdirAll(filepath.Dir(file), os.ModePerm) == nil, &PluginConfigException{},
+		"unable to create the directory of the transaction journal %s", file)
+
+	in, err := os.Open(file)
+	if err == nil {
+		j.read(in)
+		in.Close()
+	} else {
+		EosAssert(os.IsNotExist(err), &PluginConfigException{}, "unable to read the transaction journal %s: %v", file, err)
+	}
+
+	// drops a record torn by a crash
+	j.rewrite()
+	return j
+}
+
+func (j *transactionJournal) read(in io.Reader) {
+	reader := bufio.NewReader(in)
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(reader, size[:]); err != nil {
+			return
+		}
+		length := binary.LittleEndian.Uint32(size[:])
+		if length > maxJournalRecordSize {
+			log.Warn("dropping the rest of the transaction journal %s from a record of %d bytes", j.file, length)
+			return
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			log.Warn("dropping a torn record at the end of the transaction journal %s", j.file)
+			return
+		}
+
+		// the length of the record is intact, the records after it are still read
+		trx := &journaledTransaction{}
+		Try(func() {
+			if err := rlp.DecodeBytes(data, trx); err != nil {
+				Throw(err)
+			}
+			j.insert(trx)
+		}).Catch(func(e interface{}) {
+			log.Warn("dropping an unreadable record of the transaction journal %s", j.file)
+		}).End()
+	}
+}
+
+func (j *transactionJournal) insert(trx *journaledTransaction) *journalEntry {
+	id := trx.Trx.ID()
+	if _, ok := j.entries[id]; ok {
+		return nil
+	}
+	j.seq++
+	entry := &journalEntry{seq: j.seq, id: id, expiry: trx.Trx.Expiration().ToTimePoint(), trx: trx}
+	j.entries[id] = entry
+	return entry
+}
+
+// append journals a transaction unless it is already journaled
+func (j *transactionJournal) append(trx *types.PackedTransaction, persistUntilExpired bool) {
+	entry := j.insert(&journaledTransaction{PersistUntilExpired: persistUntilExpired, Trx: *trx})
+	if entry == nil {
+		return
+	}
+	if err := j.write(j.out, entry.trx); err != nil {
+		log.Error("unable to journal transaction %s: %s", entry.id, err.Error())
+	}
+}
+
+func (j *transactionJournal) write(out io.Writer, trx *journaledTransaction) error {
+	data, err := rlp.EncodeToBytes(trx)
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := out.Write(size[:]); err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// remove drops a transaction from the journal, the file keeps it until the journal is compacted
+func (j *transactionJournal) remove(id common.TransactionIdType) {
+	if _, ok := j.entries[id]; ok {
+		delete(j.entries, id)
+		j.removed++
+	}
+}
+
+// pending lists the journaled transactions in the order they came
+func (j *transactionJournal) pending() []*journalEntry {
+	entries := make([]*journalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].seq < entries[b].seq })
+	return entries
+}
+
+// compact drops the transactions of an irreversible block and the ones expired at its time, then rewrites the file
+// if anything was dropped since the last rewrite
+func (j *transactionJournal) compact(lib *types.SignedBlock) {
+	for _, receipt := range lib.Transactions {
+		if receipt.Trx.PackedTransaction != nil {
+			j.remove(receipt.Trx.PackedTransaction.ID())
+		}
+	}
+	for id, entry := range j.entries {
+		if entry.expiry < lib.Timestamp.ToTimePoint() {
+			j.remove(id)
+		}
+	}
+
+	if j.removed > 0 {
+		j.rewrite()
+	}
+}
+
+func (j *transactionJournal) rewrite() {
+	if j.out != nil {
+		j.out.Close()
+	}
+
+	tmp := j.file + ".tmp"
+	err := func() error {
+		out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		writer := bufio.NewWriter(out)
+		for _, entry := range j.pending() {
+			if err := j.write(writer, entry.trx); err != nil {
+				out.Close()
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Sync(); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp, j.file)
+	}()
+	if err != nil {
+		log.Error("unable to compact the transaction journal %s: %s", j.file, err.Error())
+	} else {
+		j.removed = 0
+	}
+
+	out, err := os.OpenFile(j.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	EosAssert(err == nil, &PluginConfigException{}, "unable to open the transaction journal %s: %v", j.file, err)
+	j.out = out
+}
+
+func (j *transactionJournal) close() {
+	if j.out != nil {
+		j.out.Close()
+		j.out = nil
+	}
+}
@@ -0,0 +1,311 @@
+package state_history_plugin
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/eosspark/eos-go/chain"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+)
+
+/**
+ * Every message is its size (uint32, little endian) followed by its type (one byte) and its rlp packed body.
+ *
+ * requests:
+ *   GetStatusRequest      answered with a GetStatusResult
+ *   GetBlocksRequest      answered with a GetBlocksResult for every block of [StartBlockNum, EndBlockNum), the blocks
+ *                         past the head are sent as they are accepted and a block is sent again when it is forked out
+ *   GetBlocksAckRequest   allows NumMessages more results, at most MaxMessagesInFlight are sent without an ack
+ *
+ * Block is the rlp packed types.SignedBlock, Traces the list of chain.StateHistoryTransactionTrace and Deltas the list
+ * of chain.StateHistoryTableDelta of the block. A field is empty when it was not requested or the log does not have
+ * the block.
+ */
+const (
+	GetStatusRequestType = byte(iota)
+	GetBlocksRequestType
+	GetBlocksAckRequestType
+)
+
+const (
+	GetStatusResultType = byte(iota)
+	GetBlocksResultType
+)
+
+const (
+	maxRequestSize      = 1024 * 1024
+	maxMessagesInFlight = 1024
+)
+
+var errRequestTooBig = errors.New("request too big")
+
+type GetStatusRequest struct{}
+
+type GetBlocksRequest struct {
+	StartBlockNum       uint32
+	EndBlockNum         uint32
+	MaxMessagesInFlight uint32
+	FetchBlock          bool
+	FetchTraces         bool
+	FetchDeltas         bool
+}
+
+type GetBlocksAckRequest struct {
+	NumMessages uint32
+}
+
+type BlockPosition struct {
+	BlockNum uint32
+	BlockId  common.BlockIdType
+}
+
+type GetStatusResult struct {
+	Head                 BlockPosition
+	LastIrreversible     BlockPosition
+	TraceBeginBlock      uint32
+	TraceEndBlock        uint32
+	ChainStateBeginBlock uint32
+	ChainStateEndBlock   uint32
+}
+
+type GetBlocksResult struct {
+	Head             BlockPosition
+	LastIrreversible BlockPosition
+	ThisBlock        BlockPosition
+	PrevBlock        BlockPosition
+	Block            []byte
+	Traces           []byte
+	Deltas           []byte
+}
+
+func packMessage(typ byte, body interface{}) []byte {
+	data, err := rlp.EncodeToBytes(body)
+	Throw(err)
+	return frameMessage(typ, data)
+}
+
+func frameMessage(typ byte, data []byte) []byte {
+	msg := make([]byte, 5, 5+len(data))
+	binary.LittleEndian.PutUint32(msg, uint32(1+len(data)))
+	msg[4] = typ
+	return append(msg, data...)
+}
+
+/**
+ * the entries are copied as they are, rlp caps the length of a slice below the size of a full chain state
+ */
+func (r *GetBlocksResult) pack() []byte {
+	data := make([]byte, 0)
+	for _, position := range []BlockPosition{r.Head, r.LastIrreversible, r.ThisBlock, r.PrevBlock} {
+		packed, err := rlp.EncodeToBytes(&position)
+		Throw(err)
+		data = append(data, packed...)
+	}
+
+	size := make([]byte, binary.MaxVarintLen64)
+	for _, field := range [][]byte{r.Block, r.Traces, r.Deltas} {
+		data = append(data, size[:binary.PutUvarint(size, uint64(len(field)))]...)
+		data = append(data, field...)
+	}
+	return frameMessage(GetBlocksResultType, data)
+}
+
+func readMessage(r io.Reader) (byte, []byte, error) {
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return 0, nil, err
+	}
+	size := binary.LittleEndian.Uint32(head)
+	if size == 0 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	if size > maxRequestSize {
+		return 0, nil, errRequestTooBig
+	}
+	body := make([]byte, size-1)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return head[4], body, nil
+}
+
+/**
+ * session is the state of a connection, it is only touched on the io goroutine except for conn and out, which the
+ * writer goroutine drains.
+ */
+type session struct {
+	impl   *StateHistoryPluginImpl
+	conn   net.Conn
+	remote string
+	out    chan []byte
+	closed bool
+
+	request   *GetBlocksRequest
+	nextBlock uint32
+	inFlight  uint32
+}
+
+func newSession(impl *StateHistoryPluginImpl, conn net.Conn) *session {
+	return &session{impl: impl, conn: conn, remote: conn.RemoteAddr().String(), out: make(chan []byte, maxMessagesInFlight+16)}
+}
+
+func (s *session) writeLoop() {
+	failed := false
+	for data := range s.out {
+		if failed {
+			continue
+		}
+		if _, err := s.conn.Write(data); err != nil {
+			s.impl.log.Debug("state history client %s write failed: %s", s.remote, err.Error())
+			s.conn.Close()
+			failed = true
+		}
+	}
+	s.conn.Close()
+}
+
+func (s *session) close() {
+	s.closed = true
+	close(s.out)
+}
+
+func (s *session) send(msg []byte) {
+	if s.closed {
+		return
+	}
+	select {
+	case s.out <- msg:
+	default:
+		s.impl.log.Warn("state history client %s is too slow, disconnecting", s.remote)
+		s.impl.closeSession(s)
+	}
+}
+
+func (s *session) handleRequest(typ byte, body []byte) {
+	if s.closed {
+		return
+	}
+	Try(func() {
+		switch typ {
+		case GetStatusRequestType:
+			s.send(packMessage(GetStatusResultType, s.status()))
+
+		case GetBlocksRequestType:
+			request := &GetBlocksRequest{}
+			Throw(rlp.DecodeBytes(body, request))
+			s.request = request
+			s.nextBlock = request.StartBlockNum
+			if s.nextBlock == 0 {
+				s.nextBlock = 1
+			}
+			s.inFlight = request.MaxMessagesInFlight
+			if s.inFlight > maxMessagesInFlight {
+				s.inFlight = maxMessagesInFlight
+			}
+			s.sendUpdate()
+
+		case GetBlocksAckRequestType:
+			ack := GetBlocksAckRequest{}
+			Throw(rlp.DecodeBytes(body, &ack))
+			s.inFlight += ack.NumMessages
+			if s.inFlight > maxMessagesInFlight {
+				s.inFlight = maxMessagesInFlight
+			}
+			s.sendUpdate()
+
+		default:
+			EosThrow(&PluginException{}, "unknown state history request type %d", typ)
+		}
+	}).Catch(func(e Exception) {
+		s.impl.log.Warn("state history client %s sent a bad request: %s", s.remote, e.DetailMessage())
+		s.impl.closeSession(s)
+	}).End()
+}
+
+/**
+ * a block that replaces one already sent is sent again
+ */
+func (s *session) onAcceptedBlock(blockNum uint32) {
+	if s.request == nil {
+		return
+	}
+	if blockNum < s.nextBlock && blockNum >= s.request.StartBlockNum {
+		s.nextBlock = blockNum
+	}
+	s.sendUpdate()
+}
+
+func (s *session) sendUpdate() {
+	if s.request == nil {
+		return
+	}
+	end := s.impl.Chain().HeadBlockNum() + 1
+	if s.request.EndBlockNum < end {
+		end = s.request.EndBlockNum
+	}
+	for ; s.nextBlock < end && s.inFlight > 0 && !s.closed; s.nextBlock++ {
+		s.send(s.blocksResult(s.nextBlock).pack())
+		s.inFlight--
+	}
+}
+
+func (s *session) status() *GetStatusResult {
+	control := s.impl.Chain()
+	result := &GetStatusResult{
+		Head:             BlockPosition{control.HeadBlockNum(), control.HeadBlockId()},
+		LastIrreversible: BlockPosition{control.LastIrreversibleBlockNum(), control.LastIrreversibleBlockId()},
+	}
+	if log := s.impl.History.TraceLog; log != nil {
+		result.TraceBeginBlock, result.TraceEndBlock = log.BeginBlock(), log.EndBlock()
+	}
+	if log := s.impl.History.ChainStateLog; log != nil {
+		result.ChainStateBeginBlock, result.ChainStateEndBlock = log.BeginBlock(), log.EndBlock()
+	}
+	return result
+}
+
+func (s *session) blocksResult(blockNum uint32) *GetBlocksResult {
+	control := s.impl.Chain()
+	result := &GetBlocksResult{
+		Head:             BlockPosition{control.HeadBlockNum(), control.HeadBlockId()},
+		LastIrreversible: BlockPosition{control.LastIrreversibleBlockNum(), control.LastIrreversibleBlockId()},
+	}
+
+	block := control.FetchBlockByNumber(blockNum)
+	if block == nil {
+		return result
+	}
+	result.ThisBlock = BlockPosition{blockNum, block.BlockID()}
+	if blockNum > 1 {
+		result.PrevBlock = BlockPosition{blockNum - 1, block.Previous}
+	}
+
+	if s.request.FetchBlock {
+		data, err := rlp.EncodeToBytes(block)
+		Throw(err)
+		result.Block = data
+	}
+	if s.request.FetchTraces {
+		result.Traces = readEntry(s.impl.History.TraceLog, result.ThisBlock)
+	}
+	if s.request.FetchDeltas {
+		result.Deltas = readEntry(s.impl.History.ChainStateLog, result.ThisBlock)
+	}
+	return result
+}
+
+func readEntry(log *chain.StateHistoryLog, block BlockPosition) []byte {
+	if log == nil {
+		return nil
+	}
+	id, payload, ok := log.Read(block.BlockNum)
+	if !ok || id != block.BlockId {
+		return nil
+	}
+	return payload
+}
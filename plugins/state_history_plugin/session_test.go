@@ -0,0 +1,58 @@
+package state_history_plugin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessages(t *testing.T) {
+	request := GetBlocksRequest{StartBlockNum: 2, EndBlockNum: 0xffffffff, MaxMessagesInFlight: 10, FetchTraces: true}
+	buf := bytes.NewBuffer(packMessage(GetBlocksRequestType, &request))
+	buf.Write(packMessage(GetStatusRequestType, &GetStatusRequest{}))
+
+	typ, body, err := readMessage(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, GetBlocksRequestType, typ)
+	decoded := GetBlocksRequest{}
+	assert.NoError(t, rlp.DecodeBytes(body, &decoded))
+	assert.Equal(t, request, decoded)
+
+	typ, body, err = readMessage(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, GetStatusRequestType, typ)
+	assert.Equal(t, 0, len(body))
+
+	_, _, err = readMessage(buf)
+	assert.Error(t, err)
+
+	huge := make([]byte, 5)
+	binary.LittleEndian.PutUint32(huge, maxRequestSize+1)
+	_, _, err = readMessage(bytes.NewBuffer(huge))
+	assert.Equal(t, errRequestTooBig, err)
+}
+
+func TestGetBlocksResult_Pack(t *testing.T) {
+	result := GetBlocksResult{
+		Head:      BlockPosition{9, *crypto.Hash256("head")},
+		ThisBlock: BlockPosition{8, *crypto.Hash256("this")},
+		Traces:    []byte{1, 2, 3},
+	}
+	typ, body, err := readMessage(bytes.NewBuffer(result.pack()))
+	assert.NoError(t, err)
+	assert.Equal(t, GetBlocksResultType, typ)
+	decoded := GetBlocksResult{}
+	assert.NoError(t, rlp.DecodeBytes(body, &decoded))
+	assert.Equal(t, result.Head, decoded.Head)
+	assert.Equal(t, result.ThisBlock, decoded.ThisBlock)
+	assert.Equal(t, 0, len(decoded.Block))
+	assert.Equal(t, result.Traces, decoded.Traces)
+
+	// entries are not bound by the slice limit of rlp
+	result.Deltas = make([]byte, rlp.MAX_NUM_ARRAY_ELEMENT+1)
+	assert.Equal(t, 5+4*36+1+4+3+len(result.Deltas), len(result.pack()))
+}
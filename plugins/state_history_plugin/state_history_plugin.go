@@ -0,0 +1,181 @@
+package state_history_plugin
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/eosspark/eos-go/chain"
+	"github.com/eosspark/eos-go/chain/types"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/libraries/asio"
+	"github.com/eosspark/eos-go/log"
+	. "github.com/eosspark/eos-go/plugins/appbase/app"
+	"github.com/eosspark/eos-go/plugins/chain_interface"
+	"github.com/eosspark/eos-go/plugins/chain_plugin"
+	"github.com/urfave/cli"
+)
+
+const StateHistoryPlug = PluginTypeName("StateHistoryPlugin")
+
+var stateHistoryPlugin = App().RegisterPlugin(StateHistoryPlug, NewStateHistoryPlugin(App().GetIoService()))
+
+/**
+ * state_history_plugin records the traces and the chain state deltas of every block in the logs of chain.StateHistory
+ * and serves them to local indexers over a plain tcp socket, see session.go for the protocol.
+ */
+type StateHistoryPlugin struct {
+	AbstractPlugin
+	my *StateHistoryPluginImpl
+}
+
+type StateHistoryPluginImpl struct {
+	io        *asio.IoContext
+	log       log.Logger
+	ChainPlug *chain_plugin.ChainPlugin
+
+	History  *chain.StateHistory
+	Endpoint string
+	listener net.Listener
+
+	// sessions are only touched on the io goroutine
+	sessions map[*session]struct{}
+}
+
+func NewStateHistoryPlugin(io *asio.IoContext) *StateHistoryPlugin {
+	plugin := &StateHistoryPlugin{}
+	plugin.my = &StateHistoryPluginImpl{io: io, sessions: make(map[*session]struct{})}
+	plugin.my.log = log.New("state_history")
+	plugin.my.log.SetHandler(log.TerminalHandler)
+	return plugin
+}
+
+func (s *StateHistoryPlugin) SetProgramOptions(options *[]cli.Flag) {
+	*options = append(*options,
+		cli.StringFlag{
+			Name:  "state-history-dir",
+			Usage: "the location of the state-history directory (absolute path or relative to application data dir)",
+			Value: "state-history",
+		},
+		cli.BoolFlag{
+			Name:  "delete-state-history",
+			Usage: "clear state history files",
+		},
+		cli.BoolFlag{
+			Name:  "trace-history",
+			Usage: "enable trace history",
+		},
+		cli.BoolFlag{
+			Name:  "chain-state-history",
+			Usage: "enable chain state history",
+		},
+		cli.StringFlag{
+			Name:  "state-history-endpoint",
+			Usage: "the endpoint upon which to listen for incoming connections",
+			Value: "127.0.0.1:8080",
+		},
+	)
+}
+
+func (s *StateHistoryPlugin) PluginInitialize(options *cli.Context) {
+	Try(func() {
+		s.my.ChainPlug = App().GetPlugin(chain_plugin.ChainPlug).(*chain_plugin.ChainPlugin)
+		s.my.Endpoint = options.String("state-history-endpoint")
+
+		dir := options.String("state-history-dir")
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(App().DataDir(), dir)
+		}
+		if options.Bool("delete-state-history") {
+			s.my.log.Info("Deleting state history")
+			EosAssert(os.RemoveAll(dir) == nil, &PluginConfigException{}, "cannot delete state history dir %s", dir)
+		}
+
+		traceHistory, chainStateHistory := options.Bool("trace-history"), options.Bool("chain-state-history")
+		if !traceHistory && !chainStateHistory {
+			s.my.log.Warn("neither --trace-history nor --chain-state-history is enabled, only blocks will be served")
+		}
+		s.my.History = chain.NewStateHistory(s.my.Chain(), dir, traceHistory, chainStateHistory)
+
+		// the logs are written by the controller signal, the channel is published after it
+		App().GetChannel(chain_interface.AcceptedBlock).Subscribe(&chain_interface.AcceptedBlockCaller{Caller: s.my.OnAcceptedBlock})
+	}).FcLogAndRethrow().End()
+}
+
+func (s *StateHistoryPlugin) PluginStartup() {
+	s.my.log.Info("starting state_history_plugin, listening on %s", s.my.Endpoint)
+
+	listener, err := net.Listen("tcp", s.my.Endpoint)
+	EosAssert(err == nil, &PluginConfigException{}, "state_history_plugin failed to listen on %s: %s", s.my.Endpoint, err)
+	s.my.listener = listener
+	go s.my.accept()
+}
+
+func (s *StateHistoryPlugin) PluginShutdown() {
+	if s.my.listener != nil {
+		s.my.listener.Close()
+	}
+	for ss := range s.my.sessions {
+		s.my.closeSession(ss)
+	}
+	if s.my.History != nil {
+		s.my.History.Close()
+	}
+}
+
+func (impl *StateHistoryPluginImpl) Chain() *chain.Controller {
+	return impl.ChainPlug.Chain()
+}
+
+func (impl *StateHistoryPluginImpl) accept() {
+	for {
+		conn, err := impl.listener.Accept()
+		if err != nil {
+			impl.log.Debug("state history listener closed: %s", err.Error())
+			return
+		}
+		go impl.serve(conn)
+	}
+}
+
+/**
+ * serve runs on the goroutine of the connection and hands every request over to the io goroutine
+ */
+func (impl *StateHistoryPluginImpl) serve(conn net.Conn) {
+	s := newSession(impl, conn)
+	go s.writeLoop()
+
+	impl.io.Post(func(err error) {
+		impl.sessions[s] = struct{}{}
+	})
+	for {
+		typ, body, err := readMessage(conn)
+		if err != nil {
+			impl.log.Debug("state history client %s disconnected: %s", s.remote, err.Error())
+			break
+		}
+		impl.io.Post(func(err error) {
+			s.handleRequest(typ, body)
+		})
+	}
+	impl.io.Post(func(err error) {
+		impl.closeSession(s)
+	})
+}
+
+func (impl *StateHistoryPluginImpl) closeSession(s *session) {
+	if s.closed {
+		return
+	}
+	delete(impl.sessions, s)
+	s.close()
+}
+
+func (impl *StateHistoryPluginImpl) OnAcceptedBlock(bs *types.BlockState) {
+	Try(func() {
+		for s := range impl.sessions {
+			s.onAcceptedBlock(bs.BlockNum)
+		}
+	}).FcLogAndDrop().End()
+}
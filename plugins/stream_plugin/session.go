@@ -0,0 +1,439 @@
+package stream_plugin
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/libraries/asio"
+	"github.com/eosspark/eos-go/plugins/http_plugin"
+)
+
+/**
+ * Stream protocol, every message is a json text frame.
+ *
+ * client requests:
+ *   {"type":"subscribe","id":"1","channels":["accepted_block"],"filters":[{"contract":"eosio.token","action":"transfer","account":"alice"}],"start_block_num":100}
+ *   {"type":"unsubscribe","id":"1"}
+ *
+ * channels defaults to all of accepted_block, irreversible_block and applied_transaction. An empty field of a filter
+ * matches anything, an event is sent when one of its actions matches one of the filters or when there is no filter.
+ * contract is the account the action is defined on, account is an authorizer or, for traces, the receiver.
+ *
+ * When start_block_num is set the blocks from start_block_num to the head block are sent first with "backfill":true,
+ * read from the fork database and the block log. Traces are not kept in the block log so applied_transaction only has
+ * live events. A "live" message marks the switch to live events.
+ *
+ * server messages:
+ *   {"type":"subscribed|unsubscribed|live|error","id":"1","block_num":..,"message":".."}
+ *   {"type":"accepted_block|irreversible_block","id":"1","block_num":..,"block_id":"..","backfill":true,"data":{signed block}}
+ *   {"type":"applied_transaction","id":"1","block_num":..,"data":{transaction trace}}
+ */
+const (
+	AcceptedBlockChannel      = "accepted_block"
+	IrreversibleBlockChannel  = "irreversible_block"
+	AppliedTransactionChannel = "applied_transaction"
+
+	backfillBatchSize   = 100
+	backfillRetryPeriod = 50 * time.Millisecond
+)
+
+type FilterEntry struct {
+	Contract common.AccountName `json:"contract"`
+	Action   common.ActionName  `json:"action"`
+	Account  common.AccountName `json:"account"`
+}
+
+func (f *FilterEntry) matches(act *types.Action, receiver common.AccountName) bool {
+	if f.Contract != 0 && f.Contract != act.Account {
+		return false
+	}
+	if f.Action != 0 && f.Action != act.Name {
+		return false
+	}
+	if f.Account == 0 || f.Account == receiver {
+		return true
+	}
+	for _, auth := range act.Authorization {
+		if auth.Actor == f.Account {
+			return true
+		}
+	}
+	return false
+}
+
+type StreamRequest struct {
+	Type          string        `json:"type"`
+	Id            string        `json:"id"`
+	Channels      []string      `json:"channels"`
+	Filters       []FilterEntry `json:"filters"`
+	StartBlockNum uint32        `json:"start_block_num"`
+}
+
+type StreamMessage struct {
+	Type     string              `json:"type"`
+	Id       string              `json:"id,omitempty"`
+	BlockNum uint32              `json:"block_num,omitempty"`
+	BlockId  *common.BlockIdType `json:"block_id,omitempty"`
+	Backfill bool                `json:"backfill,omitempty"`
+	Message  string              `json:"message,omitempty"`
+	Data     json.RawMessage     `json:"data,omitempty"`
+}
+
+type blockEvent struct {
+	block *types.SignedBlock
+	num   uint32
+	id    common.BlockIdType
+	data  json.RawMessage
+}
+
+func newBlockEvent(block *types.SignedBlock, num uint32, id common.BlockIdType) *blockEvent {
+	return &blockEvent{block: block, num: num, id: id}
+}
+
+/**
+ * the block is serialized once, for the first subscription that wants it
+ */
+func (e *blockEvent) json() json.RawMessage {
+	if e.data == nil {
+		data, err := json.Marshal(e.block)
+		Throw(err)
+		e.data = data
+	}
+	return e.data
+}
+
+type traceEvent struct {
+	trace *types.TransactionTrace
+	data  json.RawMessage
+}
+
+func (e *traceEvent) json() json.RawMessage {
+	if e.data == nil {
+		data, err := json.Marshal(e.trace)
+		Throw(err)
+		e.data = data
+	}
+	return e.data
+}
+
+type subscription struct {
+	Id                  string
+	AcceptedBlocks      bool
+	IrreversibleBlocks  bool
+	AppliedTransactions bool
+	Filters             []FilterEntry
+
+	live             bool
+	cursor           uint32
+	irreversibleSent uint32
+	// reversible blocks sent by the backfill, the live events of these blocks are already delivered
+	backfilled map[uint32]backfilledBlock
+}
+
+type backfilledBlock struct {
+	id      common.BlockIdType
+	matched bool
+}
+
+func newSubscription(req *StreamRequest) *subscription {
+	sub := &subscription{Id: req.Id, Filters: req.Filters, backfilled: make(map[uint32]backfilledBlock)}
+	if len(req.Channels) == 0 {
+		req.Channels = []string{AcceptedBlockChannel, IrreversibleBlockChannel, AppliedTransactionChannel}
+	}
+	for _, ch := range req.Channels {
+		switch ch {
+		case AcceptedBlockChannel:
+			sub.AcceptedBlocks = true
+		case IrreversibleBlockChannel:
+			sub.IrreversibleBlocks = true
+		case AppliedTransactionChannel:
+			sub.AppliedTransactions = true
+		default:
+			EosThrow(&InvalidArgException{}, "unknown channel %s", ch)
+		}
+	}
+	return sub
+}
+
+func (sub *subscription) matchesAction(act *types.Action, receiver common.AccountName) bool {
+	for i := range sub.Filters {
+		if sub.Filters[i].matches(act, receiver) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sub *subscription) matchesBlock(block *types.SignedBlock) bool {
+	if len(sub.Filters) == 0 {
+		return true
+	}
+	if block == nil {
+		return false
+	}
+	for _, receipt := range block.Transactions {
+		if receipt.Trx.PackedTransaction == nil {
+			continue
+		}
+		trx := receipt.Trx.PackedTransaction.GetTransaction()
+		for _, act := range trx.ContextFreeActions {
+			if sub.matchesAction(act, 0) {
+				return true
+			}
+		}
+		for _, act := range trx.Actions {
+			if sub.matchesAction(act, 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (sub *subscription) matchesActionTrace(at *types.ActionTrace) bool {
+	if sub.matchesAction(&at.Act, at.Receipt.Receiver) {
+		return true
+	}
+	for i := range at.InlineTraces {
+		if sub.matchesActionTrace(&at.InlineTraces[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sub *subscription) matchesTrace(trace *types.TransactionTrace) bool {
+	if len(sub.Filters) == 0 {
+		return true
+	}
+	for i := range trace.ActionTraces {
+		if sub.matchesActionTrace(&trace.ActionTraces[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * session is a stream client. Everything but writeLoop runs on the io goroutine, messages are handed to the writer
+ * through a bounded queue and a client that does not keep up is disconnected.
+ */
+type session struct {
+	impl       *StreamPluginImpl
+	conn       *http_plugin.WsConnection
+	remoteAddr string
+	out        chan []byte
+
+	subscriptions map[string]*subscription
+	closed        bool
+}
+
+func newSession(impl *StreamPluginImpl, conn *http_plugin.WsConnection, queueSize uint32) *session {
+	return &session{
+		impl:          impl,
+		conn:          conn,
+		remoteAddr:    conn.RemoteAddr(),
+		out:           make(chan []byte, queueSize),
+		subscriptions: make(map[string]*subscription),
+	}
+}
+
+/**
+ * writeLoop runs on the goroutine of the connection, it is the only one to close the connection
+ */
+func (s *session) writeLoop() {
+	for data := range s.out {
+		if err := s.conn.WriteMessage(data); err != nil {
+			break
+		}
+	}
+	s.conn.Close()
+}
+
+func (s *session) close() {
+	s.closed = true
+	close(s.out)
+}
+
+func (s *session) send(msg *StreamMessage) {
+	if s.closed {
+		return
+	}
+	data, err := json.Marshal(msg)
+	Throw(err)
+
+	select {
+	case s.out <- data:
+	default:
+		s.impl.log.Warn("stream client %s does not keep up, %d messages queued, disconnecting", s.remoteAddr, len(s.out))
+		s.impl.closeSession(s)
+	}
+}
+
+func (s *session) sendError(id string, message string) {
+	s.send(&StreamMessage{Type: "error", Id: id, Message: message})
+}
+
+func (s *session) handleRequest(data []byte) {
+	if s.closed {
+		return
+	}
+
+	req := StreamRequest{}
+	Try(func() {
+		err := json.Unmarshal(data, &req)
+		EosAssert(err == nil, &EofException{}, "invalid stream request: %s", err)
+
+		switch req.Type {
+		case "subscribe":
+			s.subscribe(&req)
+		case "unsubscribe":
+			_, ok := s.subscriptions[req.Id]
+			EosAssert(ok, &InvalidArgException{}, "unknown subscription %s", req.Id)
+			delete(s.subscriptions, req.Id)
+			s.send(&StreamMessage{Type: "unsubscribed", Id: req.Id})
+		default:
+			EosThrow(&InvalidArgException{}, "unknown request type %s", req.Type)
+		}
+	}).Catch(func(e Exception) {
+		s.sendError(req.Id, e.What())
+	}).End()
+}
+
+func (s *session) subscribe(req *StreamRequest) {
+	_, exists := s.subscriptions[req.Id]
+	EosAssert(!exists, &InvalidArgException{}, "subscription %s already exists", req.Id)
+
+	sub := newSubscription(req)
+	chain := s.impl.Chain()
+	s.subscriptions[sub.Id] = sub
+	s.send(&StreamMessage{Type: "subscribed", Id: sub.Id, BlockNum: chain.HeadBlockNum()})
+
+	if req.StartBlockNum == 0 {
+		sub.live = true
+		sub.irreversibleSent = chain.LastIrreversibleBlockNum()
+		return
+	}
+	sub.cursor = req.StartBlockNum
+	s.backfill(sub)
+}
+
+/**
+ * sends the blocks from the cursor to the head block in batches, yielding to the io goroutine between batches and
+ * waiting while the queue of the client is more than half full
+ */
+func (s *session) backfill(sub *subscription) {
+	if s.closed || s.subscriptions[sub.Id] != sub {
+		return
+	}
+
+	Try(func() {
+		chain := s.impl.Chain()
+		for i := 0; i < backfillBatchSize; i++ {
+			lib := chain.LastIrreversibleBlockNum()
+			if sub.cursor > chain.HeadBlockNum() {
+				s.goLive(sub, lib)
+				return
+			}
+			if len(s.out) > cap(s.out)/2 {
+				timer := asio.NewDeadlineTimer(s.impl.io)
+				timer.ExpiresFromNow(backfillRetryPeriod)
+				timer.AsyncWait(func(err error) {
+					s.backfill(sub)
+				})
+				return
+			}
+
+			num := sub.cursor
+			block := chain.FetchBlockByNumber(num)
+			EosAssert(block != nil, &UnknownBlockException{}, "block %d is not available for backfill", num)
+			id := block.BlockID()
+			matched := sub.matchesBlock(block)
+			event := newBlockEvent(block, num, id)
+			if matched && sub.AcceptedBlocks {
+				s.send(&StreamMessage{Type: AcceptedBlockChannel, Id: sub.Id, BlockNum: num, BlockId: &id, Backfill: true, Data: event.json()})
+			}
+			if num <= lib {
+				if matched && sub.IrreversibleBlocks {
+					s.send(&StreamMessage{Type: IrreversibleBlockChannel, Id: sub.Id, BlockNum: num, BlockId: &id, Backfill: true, Data: event.json()})
+				}
+				sub.irreversibleSent = num
+			} else {
+				sub.backfilled[num] = backfilledBlock{id: id, matched: matched}
+			}
+			sub.cursor++
+		}
+
+		s.impl.io.Post(func(err error) {
+			s.backfill(sub)
+		})
+	}).Catch(func(e Exception) {
+		delete(s.subscriptions, sub.Id)
+		s.sendError(sub.Id, e.What())
+	}).End()
+}
+
+/**
+ * blocks sent as reversible during the backfill may have become irreversible in the meantime, their irreversible
+ * events were not forwarded because the subscription was not live yet
+ */
+func (s *session) goLive(sub *subscription, lib uint32) {
+	for num := sub.irreversibleSent + 1; num <= lib; num++ {
+		b, ok := sub.backfilled[num]
+		if ok && b.matched && sub.IrreversibleBlocks {
+			event := newBlockEvent(s.impl.Chain().FetchBlockByNumber(num), num, b.id)
+			s.send(&StreamMessage{Type: IrreversibleBlockChannel, Id: sub.Id, BlockNum: num, BlockId: &event.id, Backfill: true, Data: event.json()})
+		}
+	}
+	if lib > sub.irreversibleSent {
+		sub.irreversibleSent = lib
+	}
+	sub.live = true
+	s.send(&StreamMessage{Type: "live", Id: sub.Id, BlockNum: sub.cursor - 1})
+}
+
+func (s *session) onAcceptedBlock(event *blockEvent) {
+	for _, sub := range s.subscriptions {
+		if !sub.live || !sub.AcceptedBlocks {
+			continue
+		}
+		if b, ok := sub.backfilled[event.num]; ok && b.id == event.id {
+			continue
+		}
+		if sub.matchesBlock(event.block) {
+			s.send(&StreamMessage{Type: AcceptedBlockChannel, Id: sub.Id, BlockNum: event.num, BlockId: &event.id, Data: event.json()})
+		}
+	}
+}
+
+func (s *session) onIrreversibleBlock(event *blockEvent) {
+	for _, sub := range s.subscriptions {
+		if !sub.live {
+			continue
+		}
+		for num := range sub.backfilled {
+			if num <= event.num {
+				delete(sub.backfilled, num)
+			}
+		}
+		if event.num <= sub.irreversibleSent {
+			continue
+		}
+		sub.irreversibleSent = event.num
+		if sub.IrreversibleBlocks && sub.matchesBlock(event.block) {
+			s.send(&StreamMessage{Type: IrreversibleBlockChannel, Id: sub.Id, BlockNum: event.num, BlockId: &event.id, Data: event.json()})
+		}
+	}
+}
+
+func (s *session) onAppliedTransaction(event *traceEvent) {
+	for _, sub := range s.subscriptions {
+		if sub.live && sub.AppliedTransactions && sub.matchesTrace(event.trace) {
+			s.send(&StreamMessage{Type: AppliedTransactionChannel, Id: sub.Id, BlockNum: event.trace.BlockNum, Data: event.json()})
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package stream_plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/chain/types/generated_containers"
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSession(queueSize int) *session {
+	impl := &StreamPluginImpl{log: log.New("stream"), sessions: make(map[*session]struct{})}
+	s := &session{impl: impl, out: make(chan []byte, queueSize), subscriptions: make(map[string]*subscription)}
+	impl.sessions[s] = struct{}{}
+	return s
+}
+
+func receive(t *testing.T, s *session) []StreamMessage {
+	var msgs []StreamMessage
+	for len(s.out) > 0 {
+		msg := StreamMessage{}
+		assert.NoError(t, json.Unmarshal(<-s.out, &msg))
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func transferTrace(receiver, actor string) *types.TransactionTrace {
+	act := types.Action{
+		Account:       common.N("eosio.token"),
+		Name:          common.N("transfer"),
+		Authorization: []common.PermissionLevel{{Actor: common.N(actor), Permission: common.N("active")}},
+	}
+	at := types.ActionTrace{}
+	at.Act = act
+	at.Receipt.Receiver = common.N("eosio.token")
+	at.Receipt.AuthSequence = *generated.NewAccountNameUint64Map()
+	at.AccountRamDeltas = *generated.NewAccountDeltaSet()
+	notify := types.ActionTrace{}
+	notify.Act = act
+	notify.Receipt.Receiver = common.N(receiver)
+	notify.Receipt.AuthSequence = *generated.NewAccountNameUint64Map()
+	notify.AccountRamDeltas = *generated.NewAccountDeltaSet()
+	at.InlineTraces = append(at.InlineTraces, notify)
+	return &types.TransactionTrace{BlockNum: 10, ActionTraces: []types.ActionTrace{at}}
+}
+
+func TestFilterEntry(t *testing.T) {
+	trace := transferTrace("bob", "alice")
+	sub := &subscription{}
+	assert.True(t, sub.matchesTrace(trace), "no filter matches everything")
+
+	cases := []struct {
+		filter  FilterEntry
+		matches bool
+	}{
+		{FilterEntry{Contract: common.N("eosio.token")}, true},
+		{FilterEntry{Contract: common.N("eosio")}, false},
+		{FilterEntry{Action: common.N("transfer")}, true},
+		{FilterEntry{Action: common.N("issue")}, false},
+		{FilterEntry{Account: common.N("alice")}, true},
+		{FilterEntry{Account: common.N("bob")}, true},
+		{FilterEntry{Account: common.N("carol")}, false},
+		{FilterEntry{common.N("eosio.token"), common.N("transfer"), common.N("bob")}, true},
+		{FilterEntry{common.N("eosio.token"), common.N("issue"), common.N("bob")}, false},
+	}
+	for _, c := range cases {
+		sub.Filters = []FilterEntry{c.filter}
+		assert.Equal(t, c.matches, sub.matchesTrace(trace), "filter %v", c.filter)
+	}
+}
+
+func TestSubscription_Channels(t *testing.T) {
+	sub := newSubscription(&StreamRequest{Id: "1"})
+	assert.True(t, sub.AcceptedBlocks && sub.IrreversibleBlocks && sub.AppliedTransactions)
+
+	sub = newSubscription(&StreamRequest{Id: "1", Channels: []string{IrreversibleBlockChannel}})
+	assert.False(t, sub.AcceptedBlocks || sub.AppliedTransactions)
+	assert.True(t, sub.IrreversibleBlocks)
+
+	s := newTestSession(8)
+	s.handleRequest([]byte(`{"type":"subscribe","id":"2","channels":["head_block"]}`))
+	msgs := receive(t, s)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, "error", msgs[0].Type)
+	assert.Equal(t, "2", msgs[0].Id)
+
+	s.handleRequest([]byte(`{"type":"unsubscribe","id":"3"}`))
+	msgs = receive(t, s)
+	assert.Equal(t, "error", msgs[0].Type)
+}
+
+func TestSession_LiveEvents(t *testing.T) {
+	s := newTestSession(8)
+	traces := &subscription{Id: "traces", AppliedTransactions: true, live: true,
+		Filters: []FilterEntry{{Account: common.N("bob")}}, backfilled: make(map[uint32]backfilledBlock)}
+	blocks := &subscription{Id: "blocks", AcceptedBlocks: true, IrreversibleBlocks: true, live: true,
+		irreversibleSent: 4, backfilled: make(map[uint32]backfilledBlock)}
+	s.subscriptions[traces.Id] = traces
+	s.subscriptions[blocks.Id] = blocks
+
+	s.onAppliedTransaction(&traceEvent{trace: transferTrace("carol", "alice")})
+	s.onAppliedTransaction(&traceEvent{trace: transferTrace("bob", "alice")})
+	msgs := receive(t, s)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, AppliedTransactionChannel, msgs[0].Type)
+	assert.Equal(t, "traces", msgs[0].Id)
+	assert.Equal(t, uint32(10), msgs[0].BlockNum)
+
+	// block 6 was already sent by the backfill, a block 6 of another fork was not
+	sent := *crypto.Hash256("block 6")
+	blocks.backfilled[6] = backfilledBlock{id: sent, matched: true}
+	s.onAcceptedBlock(newBlockEvent(&types.SignedBlock{}, 6, sent))
+	s.onAcceptedBlock(newBlockEvent(&types.SignedBlock{}, 6, *crypto.Hash256("fork block 6")))
+	msgs = receive(t, s)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, AcceptedBlockChannel, msgs[0].Type)
+	assert.False(t, msgs[0].Backfill)
+
+	s.onIrreversibleBlock(newBlockEvent(&types.SignedBlock{}, 4, *crypto.Hash256("block 4")))
+	s.onIrreversibleBlock(newBlockEvent(&types.SignedBlock{}, 6, sent))
+	msgs = receive(t, s)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, IrreversibleBlockChannel, msgs[0].Type)
+	assert.Equal(t, uint32(6), msgs[0].BlockNum)
+	assert.Equal(t, 0, len(blocks.backfilled))
+}
+
+func TestSession_SlowClient(t *testing.T) {
+	s := newTestSession(2)
+	sub := &subscription{Id: "1", AppliedTransactions: true, live: true, backfilled: make(map[uint32]backfilledBlock)}
+	s.subscriptions[sub.Id] = sub
+
+	for i := 0; i < 3; i++ {
+		s.onAppliedTransaction(&traceEvent{trace: transferTrace("bob", "alice")})
+	}
+	assert.True(t, s.closed)
+	assert.Equal(t, 0, len(s.impl.sessions))
+}
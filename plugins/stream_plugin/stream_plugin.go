@@ -0,0 +1,182 @@
+package stream_plugin
+
+import (
+	"github.com/eosspark/eos-go/chain"
+	"github.com/eosspark/eos-go/chain/types"
+	"github.com/eosspark/eos-go/common"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/libraries/asio"
+	"github.com/eosspark/eos-go/log"
+	. "github.com/eosspark/eos-go/plugins/appbase/app"
+	"github.com/eosspark/eos-go/plugins/chain_interface"
+	"github.com/eosspark/eos-go/plugins/chain_plugin"
+	"github.com/eosspark/eos-go/plugins/http_plugin"
+	"github.com/urfave/cli"
+)
+
+const StreamPlug = PluginTypeName("StreamPlugin")
+
+var streamPlugin = App().RegisterPlugin(StreamPlug, NewStreamPlugin(App().GetIoService()))
+
+/**
+ * stream_plugin forwards the accepted_block, irreversible_block and applied_transaction channels to websocket
+ * clients of http_plugin, see session.go for the protocol.
+ */
+type StreamPlugin struct {
+	AbstractPlugin
+	my *StreamPluginImpl
+}
+
+type StreamPluginImpl struct {
+	io        *asio.IoContext
+	log       log.Logger
+	ChainPlug *chain_plugin.ChainPlugin
+
+	QueueSize  uint32
+	MaxClients uint32
+
+	// sessions are only touched on the io goroutine
+	sessions map[*session]struct{}
+}
+
+func NewStreamPlugin(io *asio.IoContext) *StreamPlugin {
+	plugin := &StreamPlugin{}
+	plugin.my = &StreamPluginImpl{io: io, sessions: make(map[*session]struct{})}
+	plugin.my.log = log.New("stream")
+	plugin.my.log.SetHandler(log.TerminalHandler)
+	return plugin
+}
+
+func (s *StreamPlugin) SetProgramOptions(options *[]cli.Flag) {
+	*options = append(*options,
+		cli.UintFlag{
+			Name:  "stream-queue-size",
+			Usage: "Maximum number of messages queued for a stream client, slower clients are disconnected",
+			Value: 1024,
+		},
+		cli.UintFlag{
+			Name:  "stream-max-clients",
+			Usage: "Maximum number of concurrent stream clients, 0 for no limit",
+			Value: 64,
+		},
+	)
+}
+
+func (s *StreamPlugin) PluginInitialize(options *cli.Context) {
+	Try(func() {
+		s.my.QueueSize = uint32(options.Uint("stream-queue-size"))
+		if s.my.QueueSize < 2 {
+			s.my.QueueSize = 2
+		}
+		s.my.MaxClients = uint32(options.Uint("stream-max-clients"))
+		s.my.ChainPlug = App().GetPlugin(chain_plugin.ChainPlug).(*chain_plugin.ChainPlugin)
+
+		App().GetChannel(chain_interface.AcceptedBlock).Subscribe(&chain_interface.AcceptedBlockCaller{Caller: s.my.OnAcceptedBlock})
+		App().GetChannel(chain_interface.IrreversibleBlock).Subscribe(&chain_interface.IrreversibleBlockCaller{Caller: s.my.OnIrreversibleBlock})
+		App().GetChannel(chain_interface.AppliedTransaction).Subscribe(&chain_interface.AppliedTransactionCaller{Caller: s.my.OnAppliedTransaction})
+	}).FcLogAndRethrow().End()
+}
+
+func (s *StreamPlugin) PluginStartup() {
+	s.my.log.Info("starting stream_plugin")
+
+	httpPlugin := App().GetPlugin(http_plugin.HttpPlug).(*http_plugin.HttpPlugin)
+	httpPlugin.AddStreamHandler(common.StreamFunc, s.my.Serve)
+}
+
+func (s *StreamPlugin) PluginShutdown() {
+	for ss := range s.my.sessions {
+		s.my.closeSession(ss)
+	}
+}
+
+func (impl *StreamPluginImpl) Chain() *chain.Controller {
+	return impl.ChainPlug.Chain()
+}
+
+/**
+ * Serve is the http_plugin stream handler, it runs on the goroutine of the connection and hands every request over
+ * to the io goroutine, the only one that touches the sessions and the chain.
+ */
+func (impl *StreamPluginImpl) Serve(source string, conn *http_plugin.WsConnection) {
+	s := newSession(impl, conn, impl.QueueSize)
+	writerDone := make(chan struct{})
+	go func() {
+		s.writeLoop()
+		close(writerDone)
+	}()
+
+	impl.io.Post(func(err error) {
+		impl.openSession(s)
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			impl.log.Debug("stream client %s disconnected: %s", conn.RemoteAddr(), err.Error())
+			break
+		}
+		impl.io.Post(func(err error) {
+			s.handleRequest(data)
+		})
+	}
+	impl.io.Post(func(err error) {
+		impl.closeSession(s)
+	})
+
+	// the connection is released when Serve returns, the writer must be done with it
+	<-writerDone
+}
+
+func (impl *StreamPluginImpl) openSession(s *session) {
+	if impl.MaxClients > 0 && uint32(len(impl.sessions)) >= impl.MaxClients {
+		impl.log.Warn("stream client %s rejected, %d clients are connected", s.conn.RemoteAddr(), len(impl.sessions))
+		s.sendError("", "too many stream clients")
+		impl.closeSession(s)
+		return
+	}
+	impl.sessions[s] = struct{}{}
+}
+
+func (impl *StreamPluginImpl) closeSession(s *session) {
+	if s.closed {
+		return
+	}
+	delete(impl.sessions, s)
+	s.close()
+}
+
+func (impl *StreamPluginImpl) OnAcceptedBlock(bs *types.BlockState) {
+	if len(impl.sessions) == 0 {
+		return
+	}
+	Try(func() {
+		event := newBlockEvent(bs.SignedBlock, bs.BlockNum, bs.BlockId)
+		for s := range impl.sessions {
+			s.onAcceptedBlock(event)
+		}
+	}).FcLogAndDrop().End()
+}
+
+func (impl *StreamPluginImpl) OnIrreversibleBlock(bs *types.BlockState) {
+	if len(impl.sessions) == 0 {
+		return
+	}
+	Try(func() {
+		event := newBlockEvent(bs.SignedBlock, bs.BlockNum, bs.BlockId)
+		for s := range impl.sessions {
+			s.onIrreversibleBlock(event)
+		}
+	}).FcLogAndDrop().End()
+}
+
+func (impl *StreamPluginImpl) OnAppliedTransaction(trace *types.TransactionTrace) {
+	if len(impl.sessions) == 0 {
+		return
+	}
+	Try(func() {
+		event := &traceEvent{trace: trace}
+		for s := range impl.sessions {
+			s.onAppliedTransaction(event)
+		}
+	}).FcLogAndDrop().End()
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/eosspark/eos-go/common"
-	"github.com/eosspark/eos-go/crypto/ecc"
 	. "github.com/eosspark/eos-go/exception"
 	. "github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/log"
@@ -93,17 +92,13 @@ func (w *WalletApiPlugin) PluginStartup() {
 		}).End()
 	})
 
-	h.AddHandler("/v1/wallet/sign_digest", func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
+	h.AddHandler(common.WalletSignDigest, func(source string, body []byte, cb http_plugin.UrlResponseCallback) {
 		Try(func() {
 			if len(body) == 0 {
 				body = []byte("{}")
 			}
 
-			type signDigest struct {
-				Digest common.DigestType `json:"digest"`
-				Key    ecc.PublicKey     `json:"key"`
-			}
-			var param signDigest
+			var param wallet_plugin.SignDigestParams
 			err := json.Unmarshal(body, &param)
 			if err != nil {
 				EosThrow(&EofException{}, "unmarshal sign_digest params: %s", err.Error())
@@ -111,13 +111,13 @@ func (w *SoftWalletImpl) TryGetPrivateKey(id ecc.PublicKey) *ecc.PrivateKey {
 func (w *SoftWalletImpl) TrySignDigest(digest []byte, publicKey ecc.PublicKey) *ecc.Signature {
 	it, ok := w.Keys[publicKey]
 	if !ok {
-		return ecc.NewSigNil()
+		return nil
 	}
 
 	sig, err := it.Sign(digest)
 	if err != nil {
 		fmt.Println(err)
-		return ecc.NewSigNil()
+		return nil
 	}
 	return &sig
 }
@@ -166,7 +166,7 @@ func (w *SoftWalletImpl) CreateKey(keyType string) string {
 	case "K1":
 		privKey, _ = ecc.NewRandomPrivateKey()
 	case "R1":
-		privKey, _ = ecc.NewRandomPrivateKey() //TODO now not suppoted r1
+		privKey, _ = ecc.NewRandomR1PrivateKey()
 
 	default:
 		EosThrow(&UnsupportedKeyTypeException{}, "Key type %s not supported by software wallet", keyType)
@@ -209,6 +209,10 @@ type SoftWallet struct {
 	my *SoftWalletImpl
 }
 
+func NewSoftWallet() *SoftWallet {
+	return &SoftWallet{my: &SoftWalletImpl{Keys: make(map[ecc.PublicKey]ecc.PrivateKey)}}
+}
+
 func (w *SoftWallet) CopyWalletFile(destinationFilename string) bool {
 	return w.my.CopyWalletFile(destinationFilename)
 }
@@ -350,13 +354,13 @@ func (w *SoftWallet) GetPrivateKey(pubkey ecc.PublicKey) ecc.PrivateKey {
 func (w *SoftWallet) TrySignDigest(digest []byte, publicKey ecc.PublicKey) *ecc.Signature {
 	it, ok := w.my.Keys[publicKey]
 	if !ok {
-		return ecc.NewSigNil()
+		return nil
 	}
 
 	sig, err := it.Sign(digest)
 	if err != nil {
 		fmt.Println(err)
-		return ecc.NewSigNil()
+		return nil
 	}
 	return &sig
 }
@@ -103,7 +103,7 @@ func (wm *WalletManager) Create(name string) string {
 
 	password := genPassword()
 
-	var wallet SoftWallet
+	wallet := NewSoftWallet()
 	wallet.SetPassword(password)
 	walletFileName := fmt.Sprintf("%s/%s%s", wm.dir, name, fileExt)
 	wallet.SetWalletFilename(walletFileName)
@@ -118,7 +118,8 @@ func (wm *WalletManager) Create(name string) string {
 	if _, ok := wm.Wallets[name]; ok {
 		delete(wm.Wallets, name)
 	}
-	wm.Wallets[name] = &wallet
+	wm.Wallets[name] = wallet
+	wm.updateWalletGauges()
 	return password
 }
 
@@ -127,7 +128,7 @@ func (wm *WalletManager) Open(name string) {
 	wm.log.Debug("Opening wallet :   wallet name: %s", name)
 	EosAssert(validFileName(name), &WalletException{}, "Invalid filename, path not allowed in wallet name %s", name)
 
-	var wallet SoftWallet
+	wallet := NewSoftWallet()
 	walletFileName := fmt.Sprintf("%s/%s%s", wm.dir, name, fileExt)
 	wallet.SetWalletFilename(walletFileName)
 	if !wallet.LoadWalletFile("") {
@@ -138,7 +139,8 @@ func (wm *WalletManager) Open(name string) {
 	if _, ok := wm.Wallets[name]; ok {
 		delete(wm.Wallets, name)
 	}
-	wm.Wallets[name] = &wallet
+	wm.Wallets[name] = wallet
+	wm.updateWalletGauges()
 }
 
 func (wm *WalletManager) ListWallets() []string {
@@ -227,6 +229,7 @@ func (wm *WalletManager) lockAll() {
 			wallet.Lock()
 		}
 	}
+	wm.updateWalletGauges()
 }
 
 func (wm *WalletManager) Lock(name string) {
@@ -235,6 +238,7 @@ func (wm *WalletManager) Lock(name string) {
 	}
 	wallet := wm.Wallets[name]
 	wallet.Lock()
+	wm.updateWalletGauges()
 }
 
 type UnlockParams struct {
@@ -261,6 +265,7 @@ func (wm *WalletManager) Unlock(name, password string) {
 		EosThrow(&WalletUnlockedException{}, "Wallet is already unlocked:%s", name)
 	}
 	wallet.Unlock(password)
+	wm.updateWalletGauges()
 	wm.log.Debug("locked :%b", wallet.IsLocked())
 
 }
@@ -356,20 +361,30 @@ func (wm *WalletManager) SignTransaction(txn *types.SignedTransaction, keys []ec
 	return txn
 }
 
+type SignDigestParams struct {
+	Digest common.DigestType `json:"digest"`
+	Key    ecc.PublicKey     `json:"key"`
+}
+
 func (wm *WalletManager) SignDigest(digest common.DigestType, key ecc.PublicKey) (sig ecc.Signature) {
 	wm.checkTimeout()
+	EosAssert(len(wm.Wallets) != 0, &WalletNotAvailableException{}, "You don't have any wallet!")
+	isAllWalletLocked, found := true, false
 	Try(func() {
 		for _, wallet := range wm.Wallets {
 			if !wallet.IsLocked() {
-				sig = *wallet.TrySignDigest(crypto.Sha256(digest).Bytes(), key)
-				if !common.Empty(sig) {
+				isAllWalletLocked = false
+				if signature := wallet.TrySignDigest(crypto.Sha256(digest).Bytes(), key); signature != nil {
+					sig = *signature
+					found = true
 					break
 				}
 			}
 		}
 	}).FcLogAndRethrow().End()
 
-	if common.Empty(sig) {
+	EosAssert(!isAllWalletLocked, &WalletLockedException{}, "You don't have any unlocked wallet!")
+	if !found {
 		EosThrow(&WalletMissingPubKeyException{}, "public key not found in unlocked wallets %s", key)
 	}
 	return
@@ -0,0 +1,26 @@
+package wallet_plugin
+
+import (
+	"github.com/eosspark/eos-go/libraries/metrics"
+)
+
+/**
+ * lock status of the wallets known to the wallet manager
+ */
+var (
+	openWalletsGauge = metrics.NewGauge("eosgo_wallet_open_wallets",
+		"Number of wallets opened by the wallet manager")
+	unlockedWalletsGauge = metrics.NewGauge("eosgo_wallet_unlocked_wallets",
+		"Number of opened wallets that are unlocked")
+)
+
+func (wm *WalletManager) updateWalletGauges() {
+	unlocked := 0
+	for _, wallet := range wm.Wallets {
+		if !wallet.IsLocked() {
+			unlocked++
+		}
+	}
+	openWalletsGauge.Set(float64(len(wm.Wallets)))
+	unlockedWalletsGauge.Set(float64(unlocked))
+}
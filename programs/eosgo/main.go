@@ -14,7 +14,11 @@ import (
 
 	_ "github.com/eosspark/eos-go/plugins/chain_api_plugin"
 	_ "github.com/eosspark/eos-go/plugins/console_plugin"
+	_ "github.com/eosspark/eos-go/plugins/history_api_plugin"
 	_ "github.com/eosspark/eos-go/plugins/net_api_plugin"
+	_ "github.com/eosspark/eos-go/plugins/producer_api_plugin"
+	_ "github.com/eosspark/eos-go/plugins/state_history_plugin"
+	_ "github.com/eosspark/eos-go/plugins/stream_plugin"
 	_ "github.com/eosspark/eos-go/plugins/wallet_api_plugin"
 	_ "github.com/eosspark/eos-go/plugins/wallet_plugin"
 )
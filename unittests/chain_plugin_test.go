@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	. "github.com/eosspark/eos-go/chain"
 	"github.com/eosspark/eos-go/chain/abi_serializer"
 	"github.com/eosspark/eos-go/chain/types"
 	. "github.com/eosspark/eos-go/chain/types/generated_containers"
 	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto/rlp"
 	"github.com/eosspark/eos-go/entity"
 	. "github.com/eosspark/eos-go/exception"
 	. "github.com/eosspark/eos-go/exception/try"
@@ -137,3 +139,83 @@ func TestGetBlockWithInvalidAbi(t *testing.T) {
 		t.Fatal(e.DetailMessage())
 	}) // get_block_with_invalid_abi
 }
+
+func TestSimulateTransaction(t *testing.T) {
+	tester := NewValidatingTesterTrustedProducers(NewAccountNameSet())
+	defer tester.close()
+	tester.ProduceBlocks(2, false)
+	alice, bob := common.N("alice"), common.N("bob")
+	tester.CreateAccounts([]common.AccountName{alice}, false, false)
+	tester.ProduceBlocks(1, false)
+
+	newAccount := func() types.SignedTransaction {
+		data, _ := rlp.EncodeToBytes(NewAccount{
+			Creator: alice,
+			Name:    bob,
+			Owner:   types.NewAuthority(tester.getPublicKey(bob, "owner"), 0),
+			Active:  types.NewAuthority(tester.getPublicKey(bob, "active"), 0),
+		})
+		trx := types.SignedTransaction{}
+		trx.Actions = append(trx.Actions, &types.Action{
+			Account:       common.DefaultConfig.SystemAccountName,
+			Name:          common.N("newaccount"),
+			Authorization: []common.PermissionLevel{{Actor: alice, Permission: common.DefaultConfig.ActiveName}},
+			Data:          data,
+		})
+		tester.SetTransactionHeaders(&trx.Transaction, tester.DefaultExpirationDelta, 0)
+		return trx
+	}
+	simulateWithin := func(trx *types.SignedTransaction, skipSignatures bool, maxTransactionTime common.Microseconds) chain_plugin.SimulateTransactionResult {
+		var variant common.Variant
+		common.ToVariant(trx, &variant)
+		ro := chain_plugin.NewReadOnly(tester.Control, common.MaxMicroseconds())
+		return ro.SimulateTransaction(chain_plugin.SimulateTransactionParams{Transaction: variant, SkipSignatures: skipSignatures}, maxTransactionTime)
+	}
+	simulate := func(trx *types.SignedTransaction, skipSignatures bool) chain_plugin.SimulateTransactionResult {
+		return simulateWithin(trx, skipSignatures, common.Seconds(1))
+	}
+	rm := tester.Control.GetMutableResourceLimitsManager()
+	ramUsage := rm.GetAccountRamUsage(alice)
+
+	unsigned := newAccount()
+	CheckThrowException(t, &TxNoAuths{}, func() { simulate(&unsigned, false) })
+
+	result := simulate(&unsigned, true)
+	assert.Equal(t, "", result.Except)
+	assert.Equal(t, 2, len(result.AccountDeltas))
+	assert.Equal(t, alice, result.AccountDeltas[0].Account)
+	assert.True(t, result.AccountDeltas[0].CpuUsageUs > 0)
+	assert.True(t, result.AccountDeltas[0].NetUsage > 0)
+	assert.Equal(t, bob, result.AccountDeltas[1].Account)
+	assert.True(t, result.AccountDeltas[1].RamDelta > 0)
+
+	// nothing is kept
+	assert.Equal(t, ramUsage, rm.GetAccountRamUsage(alice))
+	assert.Error(t, tester.Control.DataBase().Find("byName", entity.AccountObject{Name: bob}, &entity.AccountObject{}))
+
+	// the signatures are checked unless they are skipped
+	chainId := tester.Control.GetChainId()
+	wrong := newAccount()
+	wrongKey := tester.getPrivateKey(bob, "active")
+	wrong.Sign(&wrongKey, &chainId)
+	result = simulate(&wrong, false)
+	assert.NotEqual(t, "", result.Except)
+	// a failed transaction bills nobody
+	for _, d := range result.AccountDeltas {
+		assert.Equal(t, uint32(0), d.CpuUsageUs)
+		assert.Equal(t, uint64(0), d.NetUsage)
+	}
+	assert.Equal(t, "", simulate(&wrong, true).Except)
+
+	signed := newAccount()
+	key := tester.getPrivateKey(alice, "active")
+	signed.Sign(&key, &chainId)
+	assert.Equal(t, "", simulate(&signed, false).Except)
+
+	// the transaction has the time of a pushed one
+	assert.NotEqual(t, "", simulateWithin(&signed, false, 0).Except)
+
+	// the simulated transaction was not recorded, it can be pushed
+	tester.PushTransaction(&signed, common.MaxTimePoint(), tester.DefaultBilledCpuTimeUs)
+	assert.NoError(t, tester.Control.DataBase().Find("byName", entity.AccountObject{Name: bob}, &entity.AccountObject{}))
+}
@@ -1,12 +1,19 @@
 package unittests
 
 import (
+	"fmt"
 	. "github.com/eosspark/eos-go/chain"
+	"github.com/eosspark/eos-go/chain/abi_serializer"
+	. "github.com/eosspark/eos-go/chain/types/generated_containers"
 	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/common/eos_math"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/entity"
 	"github.com/eosspark/eos-go/plugins/chain_plugin"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"math"
+	"strconv"
 	"testing"
 )
 
@@ -91,4 +98,104 @@ func TestGetScope(t *testing.T) {
 	assert.Equal(t, string(""), result.More)
 
 	tester.close()
-}
\ No newline at end of file
+}
+func TestGetTableRows_Index(t *testing.T) {
+	tester := NewValidatingTesterTrustedProducers(NewAccountNameSet())
+	defer tester.close()
+	tester.ProduceBlocks(2, false)
+	alice := common.N("alice")
+	tester.CreateAccounts([]common.AccountName{alice}, false, false)
+	tester.ProduceBlocks(1, false)
+
+	type item struct {
+		Id    uint64
+		Owner common.AccountName
+		Score float64
+	}
+	items := []item{
+		{1, common.N("dan"), 2.5},
+		{2, common.N("bob"), -1},
+		{3, common.N("erin"), 7},
+		{4, common.N("carol"), 0},
+		{5, common.N("amy"), 3.25},
+	}
+
+	db := tester.Control.DB
+	account := entity.AccountObject{Name: alice}
+	assert.NoError(t, db.Find("byName", account, &account))
+	assert.NoError(t, db.Modify(&account, func(a *entity.AccountObject) {
+		a.SetAbi(&abi_serializer.AbiDef{
+			Version: "eosio::abi/1.0",
+			Structs: []abi_serializer.StructDef{{Name: "item", Fields: []abi_serializer.FieldDef{
+				{Name: "id", Type: "uint64"}, {Name: "owner", Type: "name"}, {Name: "score", Type: "float64"},
+			}}},
+			Tables: []abi_serializer.TableDef{{Name: common.N("items"), IndexType: "i64", Type: "item"}},
+		})
+	}))
+
+	table := func(name common.TableName) common.IdType {
+		tab := entity.TableIdObject{Code: alice, Scope: alice, Table: name, Payer: alice, Count: uint32(len(items))}
+		assert.NoError(t, db.Insert(&tab))
+		return tab.ID
+	}
+	// the first secondary index shares the table of the primary index
+	primaryTid, scoreTid := table(common.N("items")), table(common.N("items")|1)
+	ownerTid := primaryTid
+	for _, i := range items {
+		value, _ := rlp.EncodeToBytes(i)
+		assert.NoError(t, db.Insert(&entity.KeyValueObject{TId: primaryTid, PrimaryKey: i.Id, Payer: alice, Value: value}))
+		assert.NoError(t, db.Insert(&entity.Idx64Object{TId: ownerTid, PrimaryKey: i.Id, SecondaryKey: uint64(i.Owner), Payer: alice}))
+		assert.NoError(t, db.Insert(&entity.IdxDoubleObject{TId: scoreTid, PrimaryKey: i.Id,
+			SecondaryKey: eos_math.Float64(math.Float64bits(i.Score)), Payer: alice}))
+	}
+
+	readOnly := chain_plugin.NewReadOnly(tester.Control, common.Microseconds(math.MaxInt32))
+	ids := func(result chain_plugin.GetTableRowsResult) []uint64 {
+		var ids []uint64
+		for _, row := range result.Rows {
+			id, _ := strconv.ParseUint(fmt.Sprint(row.(common.Variants)["id"]), 10, 64)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	get := func(p chain_plugin.GetTableRowsParams) chain_plugin.GetTableRowsResult {
+		p.JSON, p.Code, p.Scope, p.Table = true, alice, "alice", common.N("items")
+		return readOnly.GetTableRows(p)
+	}
+
+	// primary index, both directions, paging with next_key
+	result := get(chain_plugin.GetTableRowsParams{Limit: 2})
+	assert.Equal(t, []uint64{1, 2}, ids(result))
+	assert.True(t, result.More)
+	assert.Equal(t, "3", result.NextKey)
+	result = get(chain_plugin.GetTableRowsParams{Limit: 5, LowerBound: result.NextKey})
+	assert.Equal(t, []uint64{3, 4, 5}, ids(result))
+	assert.False(t, result.More)
+	result = get(chain_plugin.GetTableRowsParams{Reverse: true, Limit: 2, UpperBound: "4"})
+	assert.Equal(t, []uint64{4, 3}, ids(result))
+	assert.Equal(t, "2", result.NextKey)
+
+	// secondary index of names
+	result = get(chain_plugin.GetTableRowsParams{IndexPosition: "2", KeyType: "name", Limit: 3})
+	assert.Equal(t, []uint64{5, 2, 4}, ids(result))
+	assert.Equal(t, "dan", result.NextKey)
+	result = get(chain_plugin.GetTableRowsParams{IndexPosition: "secondary", KeyType: "name", LowerBound: "bob", UpperBound: "dan", Reverse: true})
+	assert.Equal(t, []uint64{1, 4, 2}, ids(result))
+	assert.False(t, result.More)
+
+	// secondary index of floats, with the payer of the rows
+	result = get(chain_plugin.GetTableRowsParams{IndexPosition: "3", KeyType: "float64", LowerBound: "0", UpperBound: "3.25", ShowPayer: true})
+	assert.Equal(t, 3, len(result.Rows))
+	for i, id := range []uint64{4, 1, 5} {
+		row := result.Rows[i].(common.Variants)
+		assert.Equal(t, alice, row["payer"])
+		assert.Equal(t, fmt.Sprint(id), fmt.Sprint(row["data"].(common.Variants)["id"]))
+	}
+	result = get(chain_plugin.GetTableRowsParams{IndexPosition: "3", KeyType: "float64", Reverse: true, Limit: 1})
+	assert.Equal(t, []uint64{3}, ids(result))
+	assert.Equal(t, "3.25", result.NextKey)
+
+	// inverted bounds
+	result = get(chain_plugin.GetTableRowsParams{LowerBound: "4", UpperBound: "2"})
+	assert.Equal(t, 0, len(result.Rows))
+}
@@ -3,6 +3,7 @@ package unittests
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	. "github.com/eosspark/eos-go/chain"
 	abi "github.com/eosspark/eos-go/chain/abi_serializer"
 	"github.com/eosspark/eos-go/chain/types"
@@ -11,11 +12,13 @@ import (
 	"github.com/eosspark/eos-go/crypto"
 	"github.com/eosspark/eos-go/crypto/ecc"
 	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/database"
 	"github.com/eosspark/eos-go/entity"
 	. "github.com/eosspark/eos-go/exception"
 	. "github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/log"
 	"github.com/eosspark/eos-go/plugins/chain_interface"
+	"github.com/eosspark/eos-go/wasmgo"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"math"
@@ -26,6 +29,10 @@ import (
 	"time"
 )
 
+// wasmRuntime runs the contracts of the tests on another runtime, go test ./unittests -args -wasm-runtime=differential
+// runs every action on wagon and on wasmgo and a tester fails on close when they diverged
+var wasmRuntime = flag.String("wasm-runtime", "wasmgo", "runtime of the contracts: wasmgo, wagon or differential")
+
 var CORE_SYMBOL = common.Symbol{Precision: 4, Symbol: "SYS"}
 var CORE_SYMBOL_NAME = "SYS"
 var EPSINON = float64(0.001)
@@ -152,6 +159,7 @@ func newConfig(readMode DBReadMode) *Config {
 	prefix := tmpdir()
 	cfg.BlocksDir = prefix + "blocks"
 	cfg.StateDir = prefix + "state"
+	cfg.DbBackend = database.MemoryBackend
 	cfg.StateSize = 1024 * 1024 * 8
 	cfg.StateGuardSize = 0
 	cfg.ReversibleCacheSize = 1024 * 1024 * 8
@@ -172,7 +180,9 @@ func newConfig(readMode DBReadMode) *Config {
 	cfg.ResourceGreylist = *NewAccountNameSet()
 	cfg.TrustedProducers = *NewAccountNameSet()
 
-	//cfg.VmType = common.DefaultConfig.DefaultWasmRuntime // TODO
+	vmType, ok := wasmgo.VmTypeFromString(*wasmRuntime)
+	EosAssert(ok, &ChainException{}, "unknown wasm runtime %s", *wasmRuntime)
+	cfg.VmType = vmType
 
 	return cfg
 }
@@ -202,6 +212,12 @@ func (t *BaseTester) acceptedBlock(b *types.BlockState) {
 func (t *BaseTester) close() {
 	t.Control.Close()
 	t.ChainTransactions = make(map[common.BlockIdType]types.TransactionReceipt)
+
+	if differential, ok := t.Control.GetWasmInterface().(*DifferentialRuntime); ok {
+		if divergences := differential.Divergences(); len(divergences) > 0 {
+			EosThrow(&WasmExecutionError{}, "the wasm runtimes diverged %d times, first %s", len(divergences), divergences[0])
+		}
+	}
 }
 
 func (t BaseTester) IsSameChain(other *BaseTester) bool {
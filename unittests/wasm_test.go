@@ -6,16 +6,19 @@ import (
 	//"github.com/eosspark/eos-go/chain/abi_serializer"
 	"github.com/eosspark/eos-go/chain/types"
 	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
 	"github.com/eosspark/eos-go/crypto/rlp"
 	"github.com/eosspark/eos-go/exception"
 	"github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/wasmgo"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 type assertdef struct {
@@ -388,6 +391,375 @@ func TestFloat64Bitwise(t *testing.T) {
 	})
 }
 
+func TestDifferentialRuntime(t *testing.T) {
+	runtime := *wasmRuntime
+	*wasmRuntime = "differential"
+	defer func() { *wasmRuntime = runtime }()
+
+	contracts := map[string]common.AccountName{
+		"test_contracts/f64_test_bitwise.wasm": common.N("f_tests"),
+		"test_contracts/f32_bitwise.wasm":      common.N("f32.tests"),
+	}
+	for wasm, f_tests := range contracts {
+		t.Run(filepath.Base(wasm), func(t *testing.T) {
+			code, err := ioutil.ReadFile(wasm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := newBaseTester(true, chain.SPECULATIVE)
+			differential, ok := b.Control.GetWasmInterface().(*chain.DifferentialRuntime)
+			assert.True(t, ok)
+			b.ProduceBlocks(2, false)
+			b.CreateAccounts([]common.AccountName{f_tests}, false, true)
+			b.ProduceBlocks(1, false)
+			b.SetCode(f_tests, code, nil)
+			b.ProduceBlocks(10, false)
+
+			trx := types.SignedTransaction{}
+			act := types.Action{
+				Account:       f_tests,
+				Name:          common.N(""),
+				Authorization: []common.PermissionLevel{{f_tests, common.DefaultConfig.ActiveName}}}
+			trx.Actions = append(trx.Actions, &act)
+			b.SetTransactionHeaders(&trx.Transaction, b.DefaultExpirationDelta, 0)
+
+			privKey := b.getPrivateKey(f_tests, "active")
+			chainId := b.Control.GetChainId()
+			trx.Sign(&privKey, &chainId)
+			b.PushTransaction(&trx, common.MaxTimePoint(), b.DefaultBilledCpuTimeUs)
+			b.ProduceBlocks(1, false)
+
+			assert.Equal(t, 0, len(differential.Divergences()), "%v", differential.Divergences())
+			b.close()
+		})
+	}
+}
+
+func TestInstructionWeight(t *testing.T) {
+	wasm := "test_contracts/f64_test_bitwise.wasm"
+	code, err := ioutil.ReadFile(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f64_tests := common.N("f_tests")
+
+	b := newBaseTester(true, chain.SPECULATIVE)
+	wasmGo := b.Control.GetWasmInterface().(*wasmgo.WasmGo)
+	wasmGo.SetGasPolicy(compiler.NewGasTable(), 0)
+	defer wasmGo.SetGasPolicy(nil, 0)
+	b.ProduceBlocks(2, false)
+	b.CreateAccounts([]common.AccountName{f64_tests}, false, true)
+	b.ProduceBlocks(1, false)
+	b.SetCode(f64_tests, code, nil)
+	b.ProduceBlocks(10, false)
+
+	pushAction := func(expiration uint32) *types.TransactionTrace {
+		trx := types.SignedTransaction{}
+		act := types.Action{
+			Account:       f64_tests,
+			Name:          common.N(""),
+			Authorization: []common.PermissionLevel{{f64_tests, common.DefaultConfig.ActiveName}}}
+		trx.Actions = append(trx.Actions, &act)
+		b.SetTransactionHeaders(&trx.Transaction, expiration, 0)
+
+		privKey := b.getPrivateKey(f64_tests, "active")
+		chainId := b.Control.GetChainId()
+		trx.Sign(&privKey, &chainId)
+		return b.PushTransaction(&trx, common.MaxTimePoint(), b.DefaultBilledCpuTimeUs)
+	}
+
+	// the weight of the instructions is the same on every run
+	weight := pushAction(b.DefaultExpirationDelta).ActionTraces[0].InstructionWeight
+	assert.True(t, weight > 0)
+	assert.Equal(t, weight, pushAction(b.DefaultExpirationDelta + 1).ActionTraces[0].InstructionWeight)
+
+	wasmGo.SetGasPolicy(compiler.NewGasTable(), weight)
+	assert.Equal(t, weight, pushAction(b.DefaultExpirationDelta + 2).ActionTraces[0].InstructionWeight)
+
+	wasmGo.SetGasPolicy(compiler.NewGasTable(), weight-1)
+	returning := false
+	try.Try(func() {
+		pushAction(b.DefaultExpirationDelta + 3)
+	}).Catch(func(e exception.Exception) {
+		if (e.Code() == exception.WasmGasExceeded{}.Code()) {
+			returning = true
+		}
+	}).End()
+	assert.True(t, returning)
+	b.close()
+}
+
+func TestGasLimitSubjective(t *testing.T) {
+	wasm := "test_contracts/f64_test_bitwise.wasm"
+	code, err := ioutil.ReadFile(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f64_tests := common.N("f_tests")
+
+	// wasmgo is shared by the controllers of the process, each controller has a wagon of its own
+	runtime := *wasmRuntime
+	*wasmRuntime = "wagon"
+	defer func() { *wasmRuntime = runtime }()
+
+	vt := newValidatingTester(true, chain.SPECULATIVE)
+	vt.ValidatingControl.GetWasmInterface().(*wasmgo.Wagon).SetGasPolicy(compiler.NewGasTable(), 1)
+	vt.ProduceBlocks(2, false)
+	vt.CreateAccounts([]common.AccountName{f64_tests}, false, true)
+	vt.ProduceBlocks(1, false)
+	vt.SetCode(f64_tests, code, nil)
+	vt.ProduceBlocks(1, false)
+
+	// the validator applies the block with actions weighing more than its gas limit
+	act := types.Action{Account: f64_tests, Name: common.N("")}
+	assert.Equal(t, vt.Success(), vt.PushAction(&act, f64_tests))
+	assert.Equal(t, vt.Control.HeadBlockId(), vt.ValidatingControl.HeadBlockId())
+
+	// the limit of the validator holds its speculative transactions
+	trx := types.SignedTransaction{}
+	trx.Actions = append(trx.Actions, &types.Action{
+		Account:       f64_tests,
+		Name:          common.N(""),
+		Authorization: []common.PermissionLevel{{f64_tests, common.DefaultConfig.ActiveName}}})
+	vt.SetTransactionHeaders(&trx.Transaction, vt.DefaultExpirationDelta+1, 0)
+	privKey := vt.getPrivateKey(f64_tests, "active")
+	chainId := vt.Control.GetChainId()
+	trx.Sign(&privKey, &chainId)
+	vt.ValidatingControl.AbortBlock()
+	vt.ValidatingControl.StartBlock(types.NewBlockTimeStamp(vt.ValidatingControl.HeadBlockTime()+
+		common.TimePoint(common.Milliseconds(common.DefaultConfig.BlockIntervalMs))), 0)
+	trace := vt.ValidatingControl.PushTransaction(types.NewTransactionMetadataBySignedTrx(&trx, types.CompressionNone),
+		common.MaxTimePoint(), vt.DefaultBilledCpuTimeUs)
+	assert.NotNil(t, trace.Except)
+	assert.Equal(t, exception.WasmGasExceeded{}.Code(), trace.Except.Code())
+	vt.close()
+}
+
+func TestWagonLimits(t *testing.T) {
+	runtime := *wasmRuntime
+	*wasmRuntime = "wagon"
+	defer func() { *wasmRuntime = runtime }()
+
+	loop := common.N("loop")
+	// (module
+	//  (import "env" "require_auth" (func $require_auth (param i64)))
+	//  (memory $0 1)
+	//  (export "apply" (func $apply))
+	//  (func $apply (param $0 i64)(param $1 i64)(param $2 i64)
+	//   (loop $forever (br $forever))))
+	code := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x0b, 0x02, 0x60, 0x01, 0x7e, 0x00, 0x60, 0x03, 0x7e, 0x7e, 0x7e, 0x00,
+		0x02, 0x14, 0x01, 0x03, 'e', 'n', 'v', 0x0c, 'r', 'e', 'q', 'u', 'i', 'r', 'e', '_', 'a', 'u', 't', 'h', 0x00, 0x00,
+		0x03, 0x02, 0x01, 0x01,
+		0x05, 0x03, 0x01, 0x00, 0x01,
+		0x07, 0x09, 0x01, 0x05, 'a', 'p', 'p', 'l', 'y', 0x00, 0x01,
+		0x0a, 0x09, 0x01, 0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b,
+	}
+
+	b := newBaseTester(true, chain.SPECULATIVE)
+	wagon := b.Control.GetWasmInterface().(*wasmgo.Wagon)
+	b.ProduceBlocks(2, false)
+	b.CreateAccounts([]common.AccountName{loop}, false, true)
+	b.ProduceBlocks(1, false)
+	b.SetCode(loop, code, nil)
+	b.ProduceBlocks(10, false)
+
+	pushAction := func(expiration uint32) (code int64) {
+		trx := types.SignedTransaction{}
+		act := types.Action{
+			Account:       loop,
+			Name:          common.N(""),
+			Authorization: []common.PermissionLevel{{loop, common.DefaultConfig.ActiveName}}}
+		trx.Actions = append(trx.Actions, &act)
+		b.SetTransactionHeaders(&trx.Transaction, expiration, 0)
+
+		privKey := b.getPrivateKey(loop, "active")
+		chainId := b.Control.GetChainId()
+		trx.Sign(&privKey, &chainId)
+		try.Try(func() {
+			b.PushTransaction(&trx, common.Now()+common.TimePoint(common.Milliseconds(50)), b.DefaultBilledCpuTimeUs)
+		}).Catch(func(e exception.Exception) {
+			code = e.Code()
+		}).End()
+		return code
+	}
+
+	// the checktime calls injected into the loop stop it at the deadline
+	assert.Equal(t, exception.DeadlineException{}.Code(), pushAction(b.DefaultExpirationDelta))
+
+	wagon.SetGasPolicy(compiler.NewGasTable(), 1000)
+	defer wagon.SetGasPolicy(nil, 0)
+	assert.Equal(t, exception.WasmGasExceeded{}.Code(), pushAction(b.DefaultExpirationDelta+1))
+	b.close()
+}
+
+func TestWasmProfile(t *testing.T) {
+	wasm := "test_contracts/f64_test_bitwise.wasm"
+	code, err := ioutil.ReadFile(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f64_tests := common.N("f_tests")
+
+	b := newBaseTester(true, chain.SPECULATIVE)
+	wasmGo := b.Control.GetWasmInterface().(*wasmgo.WasmGo)
+	wasmGo.SetProfiledAccounts([]common.AccountName{f64_tests})
+	defer wasmGo.SetProfiledAccounts(nil)
+	b.ProduceBlocks(2, false)
+	b.CreateAccounts([]common.AccountName{f64_tests}, false, true)
+	b.ProduceBlocks(1, false)
+	b.SetCode(f64_tests, code, nil)
+	b.ProduceBlocks(10, false)
+	assert.Equal(t, 0, len(b.Control.WasmProfiles()))
+
+	trx := types.SignedTransaction{}
+	act := types.Action{
+		Account:       f64_tests,
+		Name:          common.N(""),
+		Authorization: []common.PermissionLevel{{f64_tests, common.DefaultConfig.ActiveName}}}
+	trx.Actions = append(trx.Actions, &act)
+	b.SetTransactionHeaders(&trx.Transaction, b.DefaultExpirationDelta, 0)
+
+	privKey := b.getPrivateKey(f64_tests, "active")
+	chainId := b.Control.GetChainId()
+	trx.Sign(&privKey, &chainId)
+	b.PushTransaction(&trx, common.MaxTimePoint(), b.DefaultBilledCpuTimeUs)
+	b.ProduceBlocks(1, false)
+
+	profiles := b.Control.WasmProfiles()
+	assert.Equal(t, 1, len(profiles))
+	profile := profiles[0]
+	assert.Equal(t, f64_tests, profile.Account)
+	assert.Equal(t, *crypto.Hash256(code), profile.CodeHash)
+	hostCalls, instructions := uint64(0), uint64(0)
+	for _, f := range profile.Functions {
+		if f.Host {
+			hostCalls += f.Calls
+		}
+		instructions += f.Instructions
+	}
+	assert.True(t, hostCalls > 0)
+	assert.True(t, instructions > 0)
+	for _, stack := range profile.Stacks {
+		assert.Equal(t, "apply", stack.Stack[0])
+	}
+	b.close()
+}
+
+func TestWasmDebugger(t *testing.T) {
+	wasm := "test_contracts/f64_test_bitwise.wasm"
+	code, err := ioutil.ReadFile(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f64_tests := common.N("f_tests")
+
+	b := newBaseTester(true, chain.SPECULATIVE)
+	debugger := wasmgo.NewDebugger()
+	wasmGo := b.Control.GetWasmInterface().(*wasmgo.WasmGo)
+	wasmGo.SetDebugger(debugger)
+	defer wasmGo.SetDebugger(nil)
+	assert.Equal(t, debugger, b.Control.WasmDebugger())
+	b.ProduceBlocks(2, false)
+	b.CreateAccounts([]common.AccountName{f64_tests}, false, true)
+	b.ProduceBlocks(1, false)
+	b.SetCode(f64_tests, code, nil)
+	b.ProduceBlocks(10, false)
+
+	pushAction := func() {
+		trx := types.SignedTransaction{}
+		act := types.Action{
+			Account:       f64_tests,
+			Name:          common.N(""),
+			Authorization: []common.PermissionLevel{{f64_tests, common.DefaultConfig.ActiveName}}}
+		trx.Actions = append(trx.Actions, &act)
+		b.SetTransactionHeaders(&trx.Transaction, b.DefaultExpirationDelta, 0)
+
+		privKey := b.getPrivateKey(f64_tests, "active")
+		chainId := b.Control.GetChainId()
+		trx.Sign(&privKey, &chainId)
+		b.PushTransaction(&trx, common.MaxTimePoint(), b.DefaultBilledCpuTimeUs)
+	}
+	waitPaused := func() *wasmgo.DebugState {
+		for i := 0; i < 10000; i++ {
+			if state := debugger.State(); state != nil {
+				return state
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Error("the contract did not pause")
+		return nil
+	}
+
+	debugger.AddBreakpoint(wasmgo.Breakpoint{Account: f64_tests, Function: "apply"})
+	assert.Equal(t, wasmgo.ErrNotPaused, debugger.Continue())
+	_, err = debugger.ReadMemory(0, 1)
+	assert.Equal(t, wasmgo.ErrNotPaused, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		state := waitPaused()
+		if state == nil {
+			return
+		}
+		assert.Equal(t, "breakpoint apply:0", state.Reason)
+		assert.Equal(t, f64_tests, state.Receiver)
+		assert.Equal(t, f64_tests, state.Account)
+		assert.Equal(t, 1, len(state.Frames))
+		entry := state.Frames[0]
+		assert.Equal(t, "apply", entry.Function)
+		assert.Equal(t, 3, len(entry.Locals)) // receiver, code, action
+
+		instructions, err := debugger.Disassemble("apply")
+		assert.NoError(t, err)
+		assert.True(t, len(instructions) > entry.Instruction)
+		assert.True(t, instructions[entry.Instruction].Compiled)
+		assert.Equal(t, instructions[entry.Instruction].Op, entry.Op)
+
+		memory, err := debugger.ReadMemory(0, 16)
+		assert.NoError(t, err)
+		assert.Equal(t, 16, len(memory))
+		_, err = debugger.ReadMemory(uint32(state.MemorySize), 1)
+		assert.Error(t, err)
+
+		assert.NoError(t, debugger.Step())
+		state = waitPaused()
+		if state == nil {
+			return
+		}
+		assert.Equal(t, "step", state.Reason)
+		assert.True(t, len(state.Frames) > 1 || state.Frames[0].Instruction > entry.Instruction)
+
+		assert.NoError(t, debugger.Continue())
+	}()
+	pushAction()
+	<-done
+	assert.Nil(t, debugger.State())
+	b.ProduceBlocks(1, false)
+
+	// an aborted action fails
+	go func() {
+		if waitPaused() != nil {
+			assert.NoError(t, debugger.Abort())
+		}
+	}()
+	aborted := false
+	try.Try(func() {
+		pushAction()
+	}).Catch(func(e exception.Exception) {
+		aborted = e.Code() == exception.WasmExecutionError{}.Code()
+	}).End()
+	assert.True(t, aborted)
+
+	assert.True(t, debugger.RemoveBreakpoint(wasmgo.Breakpoint{Account: f64_tests, Function: "apply"}))
+	assert.Equal(t, 0, len(debugger.Breakpoints()))
+	pushAction() // the contract runs again after it was aborted
+	b.close()
+}
+
 func wast2wasm(wast []uint8) []uint8 {
 	wastTmp := "wast_tmp.wast"
 	wasmTmp := "wast_tmp.wasm"
@@ -0,0 +1,260 @@
+package wasmgo
+
+import (
+	"container/list"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/crypto/rlp"
+	"github.com/eosspark/eos-go/log"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm"
+)
+
+// codeCacheVersion changes with the compiler output, code compiled by other versions is compiled again
+const codeCacheVersion = 3
+
+// CodeCache keeps the virtual machines of the contracts that ran last, up to a budget of memory. When it has a
+// directory it also writes the compiled code of every contract to it, so that a restarted node loads the code of a
+// contract instead of compiling it again. The gas counters are compiled into the code, so the code is only used with
+// the gas policy it was compiled with.
+type CodeCache struct {
+	budget    uint64
+	used      uint64
+	dir       string
+	gasPolicy crypto.Sha256 // id of the gas policy of the compiled code
+	entries   map[crypto.Sha256]*list.Element
+	lru       *list.List //front is the most recently used
+
+	ilog log.Logger
+}
+
+type codeCacheEntry struct {
+	codeId crypto.Sha256
+	vm     *VirtualMachine
+	size   uint64
+}
+
+// the file of a compiled contract, Functions holds the rlp of the compiled functions and Checksum is their hash
+type compiledCode struct {
+	Version   uint32
+	CodeHash  crypto.Sha256
+	GasPolicy crypto.Sha256
+	Checksum  crypto.Sha256
+	Functions []byte
+}
+
+type compiledFunction struct {
+	NumRegs    uint32
+	NumParams  uint32
+	NumLocals  uint32
+	NumReturns uint32
+	Bytes      []byte
+	SourceMap  []compiler.SourceLocation
+}
+
+func NewCodeCache(budget uint64, dir string, ilog log.Logger) *CodeCache {
+	return &CodeCache{
+		budget:  budget,
+		dir:     dir,
+		entries: make(map[crypto.Sha256]*list.Element),
+		lru:     list.New(),
+		ilog:    ilog,
+	}
+}
+
+func (c *CodeCache) Budget() uint64 {
+	return c.budget
+}
+
+// Used is the memory taken by the virtual machines in the cache
+func (c *CodeCache) Used() uint64 {
+	return c.used
+}
+
+func (c *CodeCache) Len() int {
+	return c.lru.Len()
+}
+
+func (c *CodeCache) Dir() string {
+	return c.dir
+}
+
+func (c *CodeCache) SetBudget(budget uint64) {
+	c.budget = budget
+	c.evict()
+}
+
+func (c *CodeCache) SetDir(dir string) {
+	c.dir = dir
+}
+
+// setGasPolicy drops the virtual machines compiled with another gas policy
+func (c *CodeCache) setGasPolicy(id crypto.Sha256) {
+	if id == c.gasPolicy {
+		return
+	}
+	c.gasPolicy = id
+	c.entries = make(map[crypto.Sha256]*list.Element)
+	c.lru.Init()
+	c.used = 0
+}
+
+func (c *CodeCache) get(codeId *crypto.Sha256) *VirtualMachine {
+	e, ok := c.entries[*codeId]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*codeCacheEntry).vm
+}
+
+// add keeps vm as the most recently used, it stays in the cache even when it alone is over the budget
+func (c *CodeCache) add(codeId *crypto.Sha256, vm *VirtualMachine) {
+	if e, ok := c.entries[*codeId]; ok {
+		c.remove(e)
+	}
+	entry := &codeCacheEntry{codeId: *codeId, vm: vm, size: vmSize(vm)}
+	c.entries[*codeId] = c.lru.PushFront(entry)
+	c.used += entry.size
+	c.evict()
+}
+
+func (c *CodeCache) evict() {
+	for c.used > c.budget && c.lru.Len() > 1 {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *CodeCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*codeCacheEntry)
+	delete(c.entries, entry.codeId)
+	c.used -= entry.size
+}
+
+// vmSize is about the memory a virtual machine takes, its linear memory, compiled code and module
+func vmSize(vm *VirtualMachine) uint64 {
+	size := uint64(cap(vm.Memory)) + uint64(len(vm.Table))*4 + uint64(len(vm.Globals))*8
+	for _, f := range vm.FunctionCode {
+		size += uint64(len(f.Bytes)) + uint64(len(f.SourceMap))*8
+	}
+	for _, f := range vm.Module.Base.FunctionIndexSpace {
+		if f.Body != nil {
+			size += uint64(len(f.Body.Code))
+		}
+	}
+	return size
+}
+
+func (c *CodeCache) path(codeId *crypto.Sha256) string {
+	return filepath.Join(c.dir, codeId.String()+".code")
+}
+
+// load returns the module of code with the compiled functions written by store, false when there are none or they
+// are not the compiled functions of code
+func (c *CodeCache) load(codeId *crypto.Sha256, code []byte, config VMConfig) (*compiler.Module, []compiler.InterpreterCode, bool) {
+	if c.dir == "" {
+		return nil, nil, false
+	}
+	data, err := ioutil.ReadFile(c.path(codeId))
+	if os.IsNotExist(err) {
+		return nil, nil, false
+	}
+	m, functionCode, err := decodeCompiledCode(codeId, code, data, config, c.gasPolicy)
+	if err != nil {
+		c.ilog.Warn("compiled code of %s is not used: %s", codeId, err)
+		return nil, nil, false
+	}
+	return m, functionCode, true
+}
+
+func decodeCompiledCode(codeId *crypto.Sha256, code, data []byte, config VMConfig, gasPolicy crypto.Sha256) (*compiler.Module, []compiler.InterpreterCode, error) {
+	compiled := compiledCode{}
+	if err := rlp.DecodeBytes(data, &compiled); err != nil {
+		return nil, nil, err
+	}
+	if compiled.Version != codeCacheVersion {
+		return nil, nil, fmt.Errorf("compiled by version %d", compiled.Version)
+	}
+	if compiled.CodeHash != *codeId || *crypto.Hash256(code) != *codeId {
+		return nil, nil, fmt.Errorf("code hash mismatch")
+	}
+	if compiled.GasPolicy != gasPolicy {
+		return nil, nil, fmt.Errorf("compiled with another gas policy")
+	}
+	if *crypto.Hash256(compiled.Functions) != compiled.Checksum {
+		return nil, nil, fmt.Errorf("checksum mismatch")
+	}
+	functions := make([]compiledFunction, 0)
+	if err := rlp.DecodeBytes(compiled.Functions, &functions); err != nil {
+		return nil, nil, err
+	}
+
+	m, err := compiler.LoadModule(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	InjectModule(m, false)
+	m.DisableFloatingPoint = config.DisableFloatingPoint
+
+	functionCode := make([]compiler.InterpreterCode, len(functions))
+	for i, f := range functions {
+		if len(f.SourceMap) == 0 {
+			f.SourceMap = nil // the import stubs are not compiled from wasm instructions
+		}
+		functionCode[i] = compiler.InterpreterCode{
+			NumRegs:    int(f.NumRegs),
+			NumParams:  int(f.NumParams),
+			NumLocals:  int(f.NumLocals),
+			NumReturns: int(f.NumReturns),
+			Bytes:      f.Bytes,
+			SourceMap:  f.SourceMap,
+		}
+	}
+	numFunctions := len(m.Base.FunctionIndexSpace)
+	for _, imp := range m.Base.Import.Entries {
+		if imp.Type.Kind() == wasm.ExternalFunction {
+			numFunctions++
+		}
+	}
+	if len(functionCode) != numFunctions {
+		return nil, nil, fmt.Errorf("%d functions compiled for %d", len(functionCode), numFunctions)
+	}
+	return m, functionCode, nil
+}
+
+// store writes the compiled functions of a contract to the directory of the cache, the cache works without it
+// when it cannot be written
+func (c *CodeCache) store(codeId *crypto.Sha256, functionCode []compiler.InterpreterCode) {
+	if c.dir == "" {
+		return
+	}
+	functions := make([]compiledFunction, len(functionCode))
+	for i, f := range functionCode {
+		functions[i] = compiledFunction{uint32(f.NumRegs), uint32(f.NumParams), uint32(f.NumLocals), uint32(f.NumReturns), f.Bytes, f.SourceMap}
+	}
+	err := func() error {
+		encoded, err := rlp.EncodeToBytes(functions)
+		if err != nil {
+			return err
+		}
+		data, err := rlp.EncodeToBytes(compiledCode{codeCacheVersion, *codeId, c.gasPolicy, *crypto.Hash256(encoded), encoded})
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+			return err
+		}
+		tmp := c.path(codeId) + ".tmp"
+		if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+			return err
+		}
+		return os.Rename(tmp, c.path(codeId))
+	}()
+	if err != nil {
+		c.ilog.Warn("cannot write the compiled code of %s: %s", codeId, err)
+	}
+}
@@ -0,0 +1,191 @@
+package wasmgo
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/log"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"github.com/stretchr/testify/assert"
+)
+
+var codeCacheTestConfig = VMConfig{
+	MaxMemoryPages:     MaximumLinearMemory / WasmPageSize,
+	DefaultMemoryPages: 1,
+	DefaultTableSize:   65536,
+}
+
+func readTestContract(t *testing.T, name string) ([]byte, *crypto.Sha256) {
+	code, err := ioutil.ReadFile("testdata_context/" + name + ".wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, crypto.Hash256(code)
+}
+
+func compileTestContract(t *testing.T, code []byte) *VirtualMachine {
+	m, functionCode, err := CompileModule(code, codeCacheTestConfig, nil)
+	assert.NoError(t, err)
+	vm, err := NewCompiledVirtualMachine(nil, m, functionCode, codeCacheTestConfig, new(Resolver))
+	assert.NoError(t, err)
+	return vm
+}
+
+func TestCodeCacheStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "code_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCodeCache(0, dir, log.New("wasmgo"))
+
+	code, codeId := readTestContract(t, "eosio.token")
+	m, functionCode, err := CompileModule(code, codeCacheTestConfig, nil)
+	assert.NoError(t, err)
+	_, _, ok := cache.load(codeId, code, codeCacheTestConfig)
+	assert.False(t, ok)
+	cache.store(codeId, functionCode)
+
+	loaded, loadedCode, ok := cache.load(codeId, code, codeCacheTestConfig)
+	assert.True(t, ok)
+	assert.Equal(t, len(functionCode), len(loadedCode))
+	for i := range functionCode {
+		assert.Equal(t, functionCode[i].Bytes, loadedCode[i].Bytes)
+		assert.Equal(t, functionCode[i].SourceMap, loadedCode[i].SourceMap)
+		assert.Equal(t, functionCode[i].NumRegs, loadedCode[i].NumRegs)
+		assert.Equal(t, functionCode[i].NumLocals, loadedCode[i].NumLocals)
+	}
+	assert.Equal(t, m.Base.Import.Entries, loaded.Base.Import.Entries)
+	assert.Equal(t, m.Base.Export.Entries, loaded.Base.Export.Entries)
+	assert.Equal(t, m.Base.Types.Entries, loaded.Base.Types.Entries)
+	assert.Equal(t, m.Base.Elements, loaded.Base.Elements)
+
+	compiled, err := NewCompiledVirtualMachine(nil, m, functionCode, codeCacheTestConfig, new(Resolver))
+	assert.NoError(t, err)
+	restored, err := NewCompiledVirtualMachine(nil, loaded, loadedCode, codeCacheTestConfig, new(Resolver))
+	assert.NoError(t, err)
+	assert.Equal(t, compiled.Memory, restored.Memory)
+	assert.Equal(t, compiled.Table, restored.Table)
+	assert.Equal(t, compiled.Globals, restored.Globals)
+
+	// the compiled code is only used for the code it was compiled from
+	other, otherId := readTestContract(t, "eosio.bios")
+	_, _, ok = cache.load(codeId, other, codeCacheTestConfig)
+	assert.False(t, ok)
+	_, _, ok = cache.load(otherId, other, codeCacheTestConfig)
+	assert.False(t, ok)
+
+	data, err := ioutil.ReadFile(cache.path(codeId))
+	assert.NoError(t, err)
+	data[len(data)-1]++
+	assert.NoError(t, ioutil.WriteFile(cache.path(codeId), data, 0644))
+	_, _, ok = cache.load(codeId, code, codeCacheTestConfig)
+	assert.False(t, ok)
+}
+
+func TestCodeCacheBudget(t *testing.T) {
+	token, tokenId := readTestContract(t, "eosio.token")
+	bios, biosId := readTestContract(t, "eosio.bios")
+	mem, memId := readTestContract(t, "test_api_mem")
+	tokenVM, biosVM, memVM := compileTestContract(t, token), compileTestContract(t, bios), compileTestContract(t, mem)
+
+	cache := NewCodeCache(vmSize(tokenVM)+vmSize(biosVM), "", log.New("wasmgo"))
+	cache.add(tokenId, tokenVM)
+	cache.add(biosId, biosVM)
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, vmSize(tokenVM)+vmSize(biosVM), cache.Used())
+
+	// token is used last, bios is evicted for mem
+	assert.Equal(t, tokenVM, cache.get(tokenId))
+	cache.add(memId, memVM)
+	assert.Nil(t, cache.get(biosId))
+	assert.Equal(t, memVM, cache.get(memId))
+	assert.True(t, cache.Used() <= cache.Budget() || cache.Len() == 1)
+
+	cache.SetBudget(0) // the last used stays
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, memVM, cache.get(memId))
+	assert.Equal(t, vmSize(memVM), cache.Used())
+}
+
+func TestResetRestoresMemory(t *testing.T) {
+	code, _ := readTestContract(t, "eosio.token")
+	vm := compileTestContract(t, code)
+	memory := append([]byte{}, vm.Memory...)
+	globals := append([]int64{}, vm.Globals...)
+
+	vm.Memory[len(vm.Memory)-1]++
+	vm.Memory = append(vm.Memory, make([]byte, DefaultPageSize)...)
+	vm.Globals = append(vm.Globals, 1)
+	vm.Reset()
+	assert.Equal(t, memory, vm.Memory)
+	assert.Equal(t, globals, vm.Globals)
+}
+
+func TestCodeCacheGasPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "code_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCodeCache(0, dir, log.New("wasmgo"))
+
+	code, codeId := readTestContract(t, "eosio.token")
+	_, functionCode, err := CompileModule(code, codeCacheTestConfig, nil)
+	assert.NoError(t, err)
+	cache.store(codeId, functionCode)
+	cache.add(codeId, compileTestContract(t, code))
+
+	// the code compiled without gas counters is not used by a gas policy
+	table := compiler.NewGasTable()
+	cache.setGasPolicy(gasPolicyId(table))
+	assert.Equal(t, 0, cache.Len())
+	_, _, ok := cache.load(codeId, code, codeCacheTestConfig)
+	assert.False(t, ok)
+
+	_, functionCode, err = CompileModule(code, codeCacheTestConfig, table)
+	assert.NoError(t, err)
+	cache.store(codeId, functionCode)
+	_, _, ok = cache.load(codeId, code, codeCacheTestConfig)
+	assert.True(t, ok)
+
+	other := compiler.NewGasTable()
+	other.Call++
+	assert.NotEqual(t, gasPolicyId(table), gasPolicyId(other))
+	cache.setGasPolicy(gasPolicyId(other))
+	_, _, ok = cache.load(codeId, code, codeCacheTestConfig)
+	assert.False(t, ok)
+}
+
+func TestGasTable(t *testing.T) {
+	table := compiler.NewGasTable()
+	assert.Equal(t, table.Default, table.GetCost("i32.add"))
+	assert.Equal(t, table.Memory, table.GetCost("i64.load32_u"))
+	assert.Equal(t, table.Memory, table.GetCost("f32.store"))
+	assert.Equal(t, table.Call, table.GetCost("call_indirect"))
+	assert.Equal(t, table.DivRem, table.GetCost("i64.rem_s"))
+	assert.Equal(t, table.Float, table.GetCost("f64.mul"))
+	assert.Equal(t, table.Float, table.GetCost("i32.trunc_s/f64"))
+	assert.Equal(t, table.GrowMemory, table.GetCost("grow_memory"))
+
+	f, err := ioutil.TempFile("", "gas_table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"call": 20, "ops": {"i64.div_u": 7}}`)
+	f.Close()
+
+	loaded, err := compiler.LoadGasTable(f.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), loaded.GetCost("call"))
+	assert.Equal(t, int64(7), loaded.GetCost("i64.div_u"))
+	assert.Equal(t, table.DivRem, loaded.GetCost("i64.div_s"))
+	assert.Equal(t, table.Memory, loaded.GetCost("i32.load"))
+
+	assert.NoError(t, ioutil.WriteFile(f.Name(), []byte(`{"memory": -1}`), 0644))
+	_, err = compiler.LoadGasTable(f.Name())
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ type BasicBlock struct {
 
 	JmpCond    TyValueID
 	YieldValue TyValueID
+	JmpSource  int // SourceIndex of the jump ending the block
 }
 
 type TyJmpKind uint8
@@ -63,6 +64,7 @@ func (g *CFGraph) ToInsSeq() []Instr {
 		default:
 			panic("unreachable")
 		}
+		jmpIns.SourceIndex = bb.JmpSource
 	}
 
 	return out
@@ -97,6 +99,9 @@ func (c *SSAFunctionCompiler) NewCFGraph() *CFGraph {
 	}
 
 	g.Blocks = make([]BasicBlock, nextLabel)
+	for i := range g.Blocks {
+		g.Blocks[i].JmpSource = -1
+	}
 	var currentBlock *BasicBlock
 
 	for i, ins := range c.Code {
@@ -109,23 +114,27 @@ func (c *SSAFunctionCompiler) NewCFGraph() *CFGraph {
 		}
 		switch ins.Op {
 		case "jmp":
+			currentBlock.JmpSource = ins.SourceIndex
 			currentBlock.JmpKind = JmpUncond
 			currentBlock.JmpTargets = []int{insLabels[int(ins.Immediates[0])]}
 			currentBlock.YieldValue = ins.Values[0]
 			currentBlock = nil
 		case "jmp_if":
+			currentBlock.JmpSource = ins.SourceIndex
 			currentBlock.JmpKind = JmpEither
 			currentBlock.JmpTargets = []int{insLabels[int(ins.Immediates[0])], insLabels[int(i+1)]}
 			currentBlock.JmpCond = ins.Values[0]
 			currentBlock.YieldValue = ins.Values[1]
 			currentBlock = nil
 		case "jmp_either":
+			currentBlock.JmpSource = ins.SourceIndex
 			currentBlock.JmpKind = JmpEither
 			currentBlock.JmpTargets = []int{insLabels[int(ins.Immediates[0])], insLabels[int(ins.Immediates[1])]}
 			currentBlock.JmpCond = ins.Values[0]
 			currentBlock.YieldValue = ins.Values[1]
 			currentBlock = nil
 		case "jmp_table":
+			currentBlock.JmpSource = ins.SourceIndex
 			currentBlock.JmpKind = JmpTable
 			currentBlock.JmpTargets = make([]int, len(ins.Immediates))
 			for j, imm := range ins.Immediates {
@@ -135,6 +144,7 @@ func (c *SSAFunctionCompiler) NewCFGraph() *CFGraph {
 			currentBlock.YieldValue = ins.Values[1]
 			currentBlock = nil
 		case "return":
+			currentBlock.JmpSource = ins.SourceIndex
 			currentBlock.JmpKind = JmpReturn
 			if len(ins.Values) > 0 {
 				currentBlock.YieldValue = ins.Values[0]
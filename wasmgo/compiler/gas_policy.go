@@ -1,5 +1,12 @@
 package compiler
 
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
 type GasPolicy interface {
 	GetCost(key string) int64
 }
@@ -11,3 +18,106 @@ type SimpleGasPolicy struct {
 func (p *SimpleGasPolicy) GetCost(key string) int64 {
 	return p.GasPerInstruction
 }
+
+// The classes of the instructions a GasTable charges
+const (
+	GasClassMemory     = "memory"      // loads and stores
+	GasClassCall       = "call"        // call and call_indirect
+	GasClassDivRem     = "div_rem"     // integer division and remainder
+	GasClassFloat      = "float"       // floating point arithmetic, comparisons and conversions
+	GasClassGrowMemory = "grow_memory" // grow_memory
+)
+
+// GasTable charges the instructions by their class, the instructions of no class cost Default. Ops overrides the
+// cost of single instructions by their name, like "i64.div_u". The names are the ones of the instructions of the
+// compiler, the control flow is compiled to jmp, jmp_if, jmp_either, jmp_table, phi and return.
+type GasTable struct {
+	Default    int64            `json:"default"`
+	Memory     int64            `json:"memory"`
+	Call       int64            `json:"call"`
+	DivRem     int64            `json:"div_rem"`
+	Float      int64            `json:"float"`
+	GrowMemory int64            `json:"grow_memory"`
+	Ops        map[string]int64 `json:"ops,omitempty"`
+}
+
+func NewGasTable() *GasTable {
+	return &GasTable{
+		Default:    1,
+		Memory:     2,
+		Call:       8,
+		DivRem:     4,
+		Float:      4,
+		GrowMemory: 1024,
+	}
+}
+
+// LoadGasTable reads a GasTable from a json file, the costs missing in the file are the ones of NewGasTable
+func LoadGasTable(path string) (*GasTable, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := NewGasTable()
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("gas table %s: %s", path, err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("gas table %s: %s", path, err)
+	}
+	return t, nil
+}
+
+func (t *GasTable) Validate() error {
+	costs := map[string]int64{"default": t.Default, GasClassMemory: t.Memory, GasClassCall: t.Call,
+		GasClassDivRem: t.DivRem, GasClassFloat: t.Float, GasClassGrowMemory: t.GrowMemory}
+	for op, cost := range t.Ops {
+		costs[op] = cost
+	}
+	for name, cost := range costs {
+		if cost < 0 {
+			return fmt.Errorf("negative cost %d of %s", cost, name)
+		}
+	}
+	return nil
+}
+
+func (t *GasTable) GetCost(op string) int64 {
+	if cost, ok := t.Ops[op]; ok {
+		return cost
+	}
+	switch GasClass(op) {
+	case GasClassMemory:
+		return t.Memory
+	case GasClassCall:
+		return t.Call
+	case GasClassDivRem:
+		return t.DivRem
+	case GasClassFloat:
+		return t.Float
+	case GasClassGrowMemory:
+		return t.GrowMemory
+	default:
+		return t.Default
+	}
+}
+
+// GasClass returns the class of an instruction of the compiler, "" when it has none
+func GasClass(op string) string {
+	switch {
+	case op == "grow_memory":
+		return GasClassGrowMemory
+	case op == "call" || op == "call_indirect":
+		return GasClassCall
+	case strings.Contains(op, ".load") || strings.Contains(op, ".store"):
+		return GasClassMemory
+	case strings.HasPrefix(op, "i32.div") || strings.HasPrefix(op, "i32.rem") ||
+		strings.HasPrefix(op, "i64.div") || strings.HasPrefix(op, "i64.rem"):
+		return GasClassDivRem
+	case strings.HasPrefix(op, "f32.") || strings.HasPrefix(op, "f64.") ||
+		strings.HasSuffix(op, "/f32") || strings.HasSuffix(op, "/f64"):
+		return GasClassFloat
+	default:
+		return ""
+	}
+}
@@ -19,6 +19,7 @@ type Module struct {
 	Base                 *wasm.Module
 	FunctionNames        map[int]string
 	DisableFloatingPoint bool
+	BodiesInjected       bool // whether the function bodies have the code injected by the runtime, the compiled code has it anyway
 }
 
 type InterpreterCode struct {
@@ -27,10 +28,18 @@ type InterpreterCode struct {
 	NumLocals  int
 	NumReturns int
 	Bytes      []byte
+	SourceMap  []SourceLocation // by offset
 	JITInfo    interface{}
 	JITDone    bool
 }
 
+// SourceLocation maps the instruction at an offset of the compiled code of a function to the wasm instruction it is
+// compiled from, by the index of the instruction in the disassembly of the function
+type SourceLocation struct {
+	Offset      uint32
+	SourceIndex uint32
+}
+
 func importer(name string) (*wasm.Module, error) {
 	return nil, errors.New("env module will never be imported")
 }
@@ -53,7 +62,7 @@ func LoadModule(raw []byte) (*Module, error) {
 
 	for _, sec := range m.Customs {
 		if sec.Name == "name" {
-			r := bytes.NewReader(sec.RawSection.Bytes)
+			r := bytes.NewReader(sec.Data) // the subsections follow the name of the section
 			for {
 				ty, err := leb128.ReadVarUint32(r)
 				if err != nil || ty != 1 {
@@ -202,6 +211,7 @@ func (m *Module) CompileForInterpreter(gp GasPolicy) (_retCode []InterpreterCode
 		//fmt.Println(compiler.Code)
 		//fmt.Printf("%+v\n", compiler.NewCFGraph())
 		numRegs := compiler.RegAlloc()
+		code, sourceMap := compiler.SerializeWithSourceMap()
 		//fmt.Println(compiler.Code)
 		numLocals := 0
 		for _, v := range f.Body.Locals {
@@ -212,7 +222,8 @@ func (m *Module) CompileForInterpreter(gp GasPolicy) (_retCode []InterpreterCode
 			NumParams:  len(f.Sig.ParamTypes),
 			NumLocals:  numLocals,
 			NumReturns: len(f.Sig.ReturnTypes),
-			Bytes:      compiler.Serialize(),
+			Bytes:      code,
+			SourceMap:  sourceMap,
 		}
 
 		//index++
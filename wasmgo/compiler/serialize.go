@@ -16,6 +16,13 @@ import (
 // Types are erased in the generated code.
 // Example: float32/float64 are represented as uint32/uint64 respectively.
 func (c *SSAFunctionCompiler) Serialize() []byte {
+	code, _ := c.SerializeWithSourceMap()
+	return code
+}
+
+// SerializeWithSourceMap serializes the instructions like Serialize and maps the offsets of the instructions
+// compiled from a wasm instruction to the index of the wasm instruction in the disassembly of the function.
+func (c *SSAFunctionCompiler) SerializeWithSourceMap() ([]byte, []SourceLocation) {
 	buf := &bytes.Buffer{}
 	insRelocs := make([]int, len(c.Code))
 	reloc32Targets := make([]int, 0)
@@ -748,5 +755,11 @@ func (c *SSAFunctionCompiler) Serialize() []byte {
 		binary.LittleEndian.PutUint32(ret[t:t+4], uint32(insRelocs[insPos]))
 	}
 
-	return ret
+	sourceMap := make([]SourceLocation, 0)
+	for i, ins := range c.Code {
+		if ins.SourceIndex >= 0 {
+			sourceMap = append(sourceMap, SourceLocation{Offset: uint32(insRelocs[i]), SourceIndex: uint32(ins.SourceIndex)})
+		}
+	}
+	return ret, sourceMap
 }
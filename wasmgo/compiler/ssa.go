@@ -53,6 +53,8 @@ type Instr struct {
 	Op         string
 	Immediates []int64
 	Values     []TyValueID
+
+	SourceIndex int // index in the disassembly of the wasm instruction it is compiled from, -1 when none
 }
 
 // NewSSAFunctionCompiler instantiates a compiler which translates a WebAssembly modules
@@ -135,6 +137,7 @@ func (c *SSAFunctionCompiler) FilterFloatingPoint() {
 				continue // whitelist
 			}
 			c.Code[i] = buildInstr(0, "fp_disabled_error", nil, nil)
+			c.Code[i].SourceIndex = ins.SourceIndex
 		}
 	}
 }
@@ -149,9 +152,10 @@ func (c *SSAFunctionCompiler) Compile(importTypeIDs []int) {
 
 	unreachableDepth := 0
 
-	for _, ins := range c.Source.Code {
+	for sourceIndex, ins := range c.Source.Code {
 		//fmt.Printf("num:%d %s %d\n", i, ins.Op.Name, len(c.Stack))
 		wasUnreachable := false
+		compiled := len(c.Code)
 
 		if unreachableDepth != 0 {
 			wasUnreachable = true
@@ -455,21 +459,32 @@ func (c *SSAFunctionCompiler) Compile(importTypeIDs []int) {
 		default:
 			panic(ins.Op.Name)
 		}
+		c.setSourceIndex(compiled, sourceIndex)
 	}
 
+	compiled := len(c.Code)
 	c.FixupLocationRef(c.Locations[0], false)
 	if len(c.Stack) != 0 {
 		c.Code = append(c.Code, buildInstr(0, "return", nil, c.PopStack(1)))
 	} else {
 		c.Code = append(c.Code, buildInstr(0, "return", nil, nil))
 	}
+	c.setSourceIndex(compiled, len(c.Source.Code)-1) // the end of the function
+}
+
+// setSourceIndex sets the wasm instruction the instructions from start on are compiled from
+func (c *SSAFunctionCompiler) setSourceIndex(start, sourceIndex int) {
+	for i := start; i < len(c.Code); i++ {
+		c.Code[i].SourceIndex = sourceIndex
+	}
 }
 
 func buildInstr(target TyValueID, op string, immediates []int64, values []TyValueID) Instr {
 	return Instr{
-		Target:     target,
-		Op:         op,
-		Immediates: immediates,
-		Values:     values,
+		Target:      target,
+		Op:          op,
+		Immediates:  immediates,
+		Values:      values,
+		SourceIndex: -1,
 	}
 }
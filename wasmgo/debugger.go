@@ -0,0 +1,382 @@
+package wasmgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"github.com/eosspark/eos-go/wasmgo/wagon/disasm"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm"
+)
+
+var ErrNotPaused = errors.New("no contract is paused")
+
+// Debugger pauses the contracts on breakpoints and steps through their wasm instructions. A paused contract blocks
+// the thread applying the transaction until the debugger is told to go on, from another goroutine, so it is for local
+// test chains only. The billing timer of the transaction is paused with the contract.
+//
+// The instructions are the ones of the disassembly of the wasm functions, Disassemble lists them. The interpreter
+// keeps the operand stack of a function in registers, the values of a paused frame are its locals and registers.
+type Debugger struct {
+	mu          sync.Mutex
+	breakpoints []Breakpoint
+	paused      *pausedContract
+	commands    chan debugCommand
+
+	// the state of the contract running, only used by the thread applying it
+	context    EnvContext
+	active     []activeBreakpoint
+	statements map[int]map[int]int // by function, the wasm instruction the compiled instruction at an offset starts
+	step       debugCommand
+	stepDepth  int
+	aborted    bool
+}
+
+// Breakpoint pauses the contract of Account, of every account when empty, before the wasm instruction at Instruction
+// in the disassembly of Function, or the next one that is compiled to code. Function is the name or the index of the
+// function, the instruction 0 pauses on the entry of the function.
+type Breakpoint struct {
+	Account     common.AccountName `json:"account"`
+	Function    string             `json:"function"`
+	Instruction int                `json:"instruction"`
+}
+
+type activeBreakpoint struct {
+	Breakpoint
+	functionID int
+}
+
+type pausedContract struct {
+	vm    *VirtualMachine
+	state *DebugState
+}
+
+type debugCommand int
+
+const (
+	debugContinue debugCommand = iota
+	debugStep                  // to the next wasm instruction
+	debugStepOver              // to the next wasm instruction of the function or of the functions calling it
+	debugStepOut               // to the next wasm instruction of the functions calling the function
+	debugAbort
+)
+
+// DebugState is where a contract paused, with the action it applies
+type DebugState struct {
+	Reason     string             `json:"reason"`
+	Receiver   common.AccountName `json:"receiver"`
+	Account    common.AccountName `json:"account"`
+	Action     common.ActionName  `json:"action"`
+	Data       common.HexBytes    `json:"data"`
+	MemorySize int                `json:"memory_size"`
+	Frames     []DebugFrame       `json:"frames"` // the innermost first
+}
+
+type DebugFrame struct {
+	Function    string  `json:"function"`
+	FunctionID  int     `json:"function_id"`
+	Instruction int     `json:"instruction"` // the next one of the innermost frame, the call of the others
+	Op          string  `json:"op"`
+	Locals      []int64 `json:"locals"`
+	Registers   []int64 `json:"registers"`
+}
+
+// DebugInstruction is an instruction of the disassembly of a function
+type DebugInstruction struct {
+	Index      int    `json:"index"`
+	Op         string `json:"op"`
+	Immediates string `json:"immediates,omitempty"`
+	Compiled   bool   `json:"compiled"` // whether a breakpoint on it pauses before it
+}
+
+func NewDebugger() *Debugger {
+	return &Debugger{breakpoints: []Breakpoint{}, commands: make(chan debugCommand)}
+}
+
+func (d *Debugger) AddBreakpoint(b Breakpoint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, breakpoint := range d.breakpoints {
+		if breakpoint == b {
+			return
+		}
+	}
+	d.breakpoints = append(d.breakpoints, b)
+}
+
+// RemoveBreakpoint removes a breakpoint, false when there is no such breakpoint
+func (d *Debugger) RemoveBreakpoint(b Breakpoint) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, breakpoint := range d.breakpoints {
+		if breakpoint == b {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Breakpoint{}, d.breakpoints...)
+}
+
+// State returns where the contract paused, nil when none is paused
+func (d *Debugger) State() *DebugState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		return nil
+	}
+	return d.paused.state
+}
+
+func (d *Debugger) Continue() error { return d.command(debugContinue) }
+func (d *Debugger) Step() error     { return d.command(debugStep) }
+func (d *Debugger) StepOver() error { return d.command(debugStepOver) }
+func (d *Debugger) StepOut() error  { return d.command(debugStepOut) }
+
+// Abort fails the action of the paused contract
+func (d *Debugger) Abort() error { return d.command(debugAbort) }
+
+func (d *Debugger) command(c debugCommand) error {
+	d.mu.Lock()
+	paused := d.paused != nil
+	d.paused = nil
+	d.mu.Unlock()
+	if !paused {
+		return ErrNotPaused
+	}
+	d.commands <- c
+	return nil
+}
+
+// ReadMemory returns length bytes of the linear memory of the paused contract from address
+func (d *Debugger) ReadMemory(address, length uint32) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		return nil, ErrNotPaused
+	}
+	memory := d.paused.vm.Memory
+	if uint64(address)+uint64(length) > uint64(len(memory)) {
+		return nil, fmt.Errorf("memory [%d, %d) out of the %d bytes of the linear memory", address, uint64(address)+uint64(length), len(memory))
+	}
+	return append([]byte{}, memory[address:address+length]...), nil
+}
+
+// Disassemble lists the wasm instructions of a function of the paused contract, by its name or its index
+func (d *Debugger) Disassemble(function string) ([]DebugInstruction, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		return nil, ErrNotPaused
+	}
+	vm := d.paused.vm
+	functionID, ok := resolveFunction(vm, function)
+	if !ok {
+		return nil, fmt.Errorf("no function %s", function)
+	}
+	code, err := disassemble(vm, functionID)
+	if err != nil {
+		return nil, err
+	}
+	compiled := make(map[int]bool)
+	for _, l := range vm.FunctionCode[functionID].SourceMap {
+		compiled[int(l.SourceIndex)] = true
+	}
+	instructions := make([]DebugInstruction, len(code))
+	for i, ins := range code {
+		instructions[i] = DebugInstruction{Index: i, Op: ins.Op.Name, Compiled: compiled[i]}
+		if len(ins.Immediates) > 0 {
+			instructions[i].Immediates = fmt.Sprint(ins.Immediates...)
+		}
+	}
+	return instructions, nil
+}
+
+// attach prepares the debugger for the contract of a virtual machine, before it runs
+func (d *Debugger) attach(vm *VirtualMachine, context EnvContext) {
+	d.context = context
+	d.statements = make(map[int]map[int]int)
+	d.step = debugContinue
+	d.aborted = false
+	d.activate(vm)
+}
+
+// activate resolves the breakpoints on the contract running to its functions
+func (d *Debugger) activate(vm *VirtualMachine) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active = d.active[:0]
+	for _, b := range d.breakpoints {
+		if b.Account != 0 && b.Account != d.context.GetReceiver() {
+			continue
+		}
+		if functionID, ok := resolveFunction(vm, b.Function); ok {
+			d.active = append(d.active, activeBreakpoint{b, functionID})
+		}
+	}
+}
+
+// check pauses the contract before the instruction at the IP of frame when it starts a wasm instruction to pause on
+func (d *Debugger) check(vm *VirtualMachine, frame *Frame) {
+	starts, ok := d.statements[frame.FunctionID]
+	if !ok {
+		// a compiled instruction starts a wasm instruction when the one before it is compiled from another one
+		starts = make(map[int]int)
+		last := -1
+		for _, l := range vm.FunctionCode[frame.FunctionID].SourceMap {
+			if int(l.SourceIndex) != last {
+				starts[int(l.Offset)] = int(l.SourceIndex)
+			}
+			last = int(l.SourceIndex)
+		}
+		d.statements[frame.FunctionID] = starts
+	}
+	sourceIndex, ok := starts[frame.IP]
+	if !ok {
+		return
+	}
+
+	reason := ""
+	switch {
+	case d.step == debugStep,
+		d.step == debugStepOver && vm.CurrentFrame <= d.stepDepth,
+		d.step == debugStepOut && vm.CurrentFrame < d.stepDepth:
+		reason = "step"
+	default:
+		for _, b := range d.active {
+			if b.functionID == frame.FunctionID && sourceIndex == firstCompiled(starts, b.Instruction) {
+				reason = fmt.Sprintf("breakpoint %s:%d", b.Function, b.Instruction)
+				break
+			}
+		}
+	}
+	if reason != "" {
+		d.pause(vm, reason)
+	}
+}
+
+// firstCompiled returns the first wasm instruction from index on that starts compiled code, -1 when none
+func firstCompiled(starts map[int]int, index int) int {
+	first := -1
+	for _, sourceIndex := range starts {
+		if sourceIndex >= index && (first == -1 || sourceIndex < first) {
+			first = sourceIndex
+		}
+	}
+	return first
+}
+
+// pause blocks the contract until the debugger is told to go on
+func (d *Debugger) pause(vm *VirtualMachine, reason string) {
+	d.context.PauseBillingTimer()
+	defer d.context.ResumeBillingTimer()
+
+	d.mu.Lock()
+	d.paused = &pausedContract{vm: vm, state: d.debugState(vm, reason)}
+	d.mu.Unlock()
+
+	c := <-d.commands
+	switch c {
+	case debugAbort:
+		d.aborted = true
+		panic("wasm: aborted by the debugger")
+	case debugStepOver, debugStepOut:
+		d.stepDepth = vm.CurrentFrame
+	}
+	d.step = c
+	d.activate(vm) // with the breakpoints changed while paused
+}
+
+func (d *Debugger) debugState(vm *VirtualMachine, reason string) *DebugState {
+	state := &DebugState{
+		Reason:     reason,
+		Receiver:   d.context.GetReceiver(),
+		Account:    d.context.GetCode(),
+		Action:     d.context.GetAct(),
+		Data:       append([]byte{}, d.context.GetActionData()...),
+		MemorySize: len(vm.Memory),
+		Frames:     make([]DebugFrame, 0, vm.CurrentFrame+1),
+	}
+	for i := vm.CurrentFrame; i >= 0; i-- {
+		frame := &vm.CallStack[i]
+		f := DebugFrame{
+			Function:    functionName(vm.Module, frame.FunctionID),
+			FunctionID:  frame.FunctionID,
+			Instruction: sourceIndexAt(vm.FunctionCode[frame.FunctionID], frame.IP, i != vm.CurrentFrame),
+			Locals:      append([]int64{}, frame.Locals...),
+			Registers:   append([]int64{}, frame.Regs...),
+		}
+		if code, err := disassemble(vm, frame.FunctionID); err == nil && f.Instruction >= 0 && f.Instruction < len(code) {
+			f.Op = code[f.Instruction].Op.Name
+		}
+		state.Frames = append(state.Frames, f)
+	}
+	return state
+}
+
+// sourceIndexAt returns the wasm instruction of the compiled instruction at ip, or before ip when the instruction
+// ran already, -1 when it is not compiled from a wasm instruction
+func sourceIndexAt(code compiler.InterpreterCode, ip int, before bool) int {
+	n := sort.Search(len(code.SourceMap), func(i int) bool {
+		if before {
+			return int(code.SourceMap[i].Offset) >= ip
+		}
+		return int(code.SourceMap[i].Offset) > ip
+	})
+	if n == 0 {
+		return -1
+	}
+	return int(code.SourceMap[n-1].SourceIndex)
+}
+
+// resolveFunction returns the function of a virtual machine by its name or its index
+func resolveFunction(vm *VirtualMachine, function string) (int, bool) {
+	if index, err := strconv.Atoi(function); err == nil {
+		return index, index >= 0 && index < len(vm.FunctionCode)
+	}
+	for functionID := range vm.FunctionCode {
+		if functionName(vm.Module, functionID) == function {
+			return functionID, true
+		}
+	}
+	return -1, false
+}
+
+func disassemble(vm *VirtualMachine, functionID int) ([]disasm.Instr, error) {
+	if functionImport(vm.Module, functionID) != nil {
+		return nil, fmt.Errorf("%s is a host function", functionName(vm.Module, functionID))
+	}
+	f := vm.Module.Base.GetFunction(functionID - numFunctionImports(vm.Module))
+	if f == nil {
+		return nil, fmt.Errorf("no function %d", functionID)
+	}
+	d, err := disasm.Disassemble(*f, vm.Module.Base)
+	if err != nil {
+		return nil, err
+	}
+	if !vm.Module.BodiesInjected {
+		d = InjectBody(d) // the instructions compiled
+	}
+	return d.Code, nil
+}
+
+func numFunctionImports(m *compiler.Module) int {
+	n := 0
+	if m.Base.Import != nil {
+		for _, e := range m.Base.Import.Entries {
+			if e.Type.Kind() == wasm.ExternalFunction {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package wasmgo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceMap(t *testing.T) {
+	code, _ := readTestContract(t, "eosio.token")
+	vm := compileTestContract(t, code)
+
+	for functionID, f := range vm.FunctionCode {
+		if functionImport(vm.Module, functionID) != nil {
+			assert.Nil(t, f.SourceMap)
+			continue
+		}
+		instructions, err := disassemble(vm, functionID)
+		assert.NoError(t, err)
+		assert.NotEqual(t, 0, len(f.SourceMap))
+
+		// the map follows the compiled code, and the last wasm instruction compiles to its return
+		for i := 1; i < len(f.SourceMap); i++ {
+			assert.True(t, f.SourceMap[i-1].Offset < f.SourceMap[i].Offset)
+		}
+		last := f.SourceMap[len(f.SourceMap)-1]
+		assert.Equal(t, len(instructions)-1, int(last.SourceIndex))
+		assert.True(t, int(last.Offset) < len(f.Bytes))
+	}
+
+	apply, _ := vm.GetFunctionExport("apply")
+	functionID, ok := resolveFunction(vm, "apply")
+	assert.True(t, ok)
+	assert.Equal(t, apply, functionID)
+	_, ok = resolveFunction(vm, "no_such_function")
+	assert.False(t, ok)
+}
@@ -153,6 +153,8 @@ type EnvContext interface {
 
 	PauseBillingTimer()
 	ResumeBillingTimer()
+	AddInstructionWeight(weight uint64) //weight of the instructions executed, by the gas policy of the runtime
+	GasLimited() bool                   //whether the action is held to the gas limit of the runtime
 
 	CheckAuthorization(actions []*types.Action, providedKeys *PublicKeySet, providedPermissions *PermissionLevelSet, delayUS uint64)
 	CheckAuthorization2(n common.AccountName, permission common.PermissionName, providedKeys *PublicKeySet, providedPermissions *PermissionLevelSet, delayUS uint64)
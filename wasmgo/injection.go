@@ -2,6 +2,7 @@ package wasmgo
 
 import (
 	//"fmt"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
 	"github.com/eosspark/eos-go/wasmgo/wagon/disasm"
 	"github.com/eosspark/eos-go/wasmgo/wagon/wasm"
 
@@ -11,8 +12,7 @@ import (
 func Inject(m *wasm.Module) {
 
 	//inject checktime
-	importChecktime := wasm.ImportEntry{ModuleName: "env", FieldName: "checktime", Type: wasm.FuncImport{uint32(GetOrCreateCheckTimeSig(m))}}
-	m.Import.Entries = append([]wasm.ImportEntry{importChecktime}, m.Import.Entries[0:]...)
+	injectCheckTimeImport(m)
 
 	for i, f := range m.FunctionIndexSpace {
 		d, err := disasm.Disassemble(f, m)
@@ -24,6 +24,35 @@ func Inject(m *wasm.Module) {
 		m.FunctionIndexSpace[i].Body.Code = code
 	}
 
+	shiftFunctionIndexes(m)
+}
+
+// InjectIndexes makes the same changes to the sections of m as Inject without rewriting the function bodies,
+// it is enough for a module whose compiled code comes from the code cache
+func InjectIndexes(m *wasm.Module) {
+	injectCheckTimeImport(m)
+	shiftFunctionIndexes(m)
+}
+
+// InjectModule injects the checktime calls into a module like Inject, or changes its sections only like
+// InjectIndexes when not bodies, and shifts the names of its functions with their indexes
+func InjectModule(m *compiler.Module, bodies bool) {
+	if bodies {
+		Inject(m.Base)
+	} else {
+		InjectIndexes(m.Base)
+	}
+	m.BodiesInjected = bodies
+	shiftFunctionNames(m)
+}
+
+func injectCheckTimeImport(m *wasm.Module) {
+	importChecktime := wasm.ImportEntry{ModuleName: "env", FieldName: "checktime", Type: wasm.FuncImport{uint32(GetOrCreateCheckTimeSig(m))}}
+	m.Import.Entries = append([]wasm.ImportEntry{importChecktime}, m.Import.Entries[0:]...)
+}
+
+func shiftFunctionIndexes(m *wasm.Module) {
+
 	//shift all exported functions by 1
 	for k, export := range m.Export.Entries {
 		export.Index = export.Index + 1
@@ -0,0 +1,118 @@
+package wasmgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format of pprof, every stack is a sample with the
+// calls, the instructions and the time of its innermost function. `go tool pprof account.pb.gz` reads it.
+func (p *Profile) WritePprof(w io.Writer) error {
+	strings := map[string]int64{"": 0}
+	stringTable := []string{""}
+	str := func(s string) int64 {
+		if i, ok := strings[s]; ok {
+			return i
+		}
+		strings[s] = int64(len(stringTable))
+		stringTable = append(stringTable, s)
+		return strings[s]
+	}
+
+	b := &protoBuffer{}
+	for _, sampleType := range [][2]string{{"calls", "count"}, {"instructions", "count"}, {"time", "nanoseconds"}} {
+		valueType := &protoBuffer{}
+		valueType.int64Field(1, str(sampleType[0]))
+		valueType.int64Field(2, str(sampleType[1]))
+		b.bytesField(1, valueType.Bytes())
+	}
+
+	// a function has one location, both are identified by the index of the function name in the string table
+	functions := []int64{}
+	ids := make(map[string]uint64)
+	for _, stack := range p.Stacks {
+		locations := make([]uint64, len(stack.Stack))
+		for i, name := range stack.Stack {
+			id, ok := ids[name]
+			if !ok {
+				id = uint64(len(ids) + 1)
+				ids[name] = id
+				functions = append(functions, str(name))
+			}
+			locations[len(stack.Stack)-1-i] = id // the innermost first
+		}
+		sample := &protoBuffer{}
+		sample.packedField(1, locations)
+		sample.packedField(2, []uint64{stack.Calls, stack.Instructions, uint64(stack.Time)})
+		b.bytesField(2, sample.Bytes())
+	}
+	for i := range functions {
+		line := &protoBuffer{}
+		line.uint64Field(1, uint64(i+1))
+		location := &protoBuffer{}
+		location.uint64Field(1, uint64(i+1))
+		location.bytesField(4, line.Bytes())
+		b.bytesField(4, location.Bytes())
+	}
+	for i, name := range functions {
+		function := &protoBuffer{}
+		function.uint64Field(1, uint64(i+1))
+		function.int64Field(2, name)
+		function.int64Field(3, name)
+		b.bytesField(5, function.Bytes())
+	}
+	defaultSampleType := str("time")
+	for _, s := range stringTable {
+		b.bytesField(6, []byte(s))
+	}
+	b.int64Field(14, defaultSampleType)
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(b.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protoBuffer encodes the fields of a protocol buffer message
+type protoBuffer struct {
+	bytes.Buffer
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+func (b *protoBuffer) uint64Field(tag int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(tag) << 3)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64Field(tag int, x int64) {
+	b.uint64Field(tag, uint64(x))
+}
+
+func (b *protoBuffer) bytesField(tag int, data []byte) {
+	b.varint(uint64(tag)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
+
+func (b *protoBuffer) packedField(tag int, xs []uint64) {
+	if len(xs) == 0 {
+		return
+	}
+	packed := &protoBuffer{}
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytesField(tag, packed.Bytes())
+}
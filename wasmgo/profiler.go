@@ -0,0 +1,225 @@
+package wasmgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/crypto"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm"
+)
+
+// Profiler records the calls of the functions of a module, the instructions they execute and the time spent in them,
+// the host functions included. It keeps the tree of the call stacks of the module, a node has the values of a
+// function when it is called by the functions of the path to it.
+type Profiler struct {
+	module  *compiler.Module
+	root    *callNode
+	current *callNode
+	last    time.Time
+}
+
+type callNode struct {
+	functionID   int
+	parent       *callNode
+	children     map[int]*callNode
+	calls        uint64
+	instructions uint64
+	nanoseconds  int64
+}
+
+func NewProfiler(module *compiler.Module) *Profiler {
+	root := &callNode{functionID: -1, children: make(map[int]*callNode)}
+	return &Profiler{module: module, root: root, current: root}
+}
+
+// start begins a run of the module
+func (p *Profiler) start() {
+	p.current = p.root
+	p.last = time.Now()
+}
+
+// stop ends a run of the module, whether its functions returned or it failed in one of them
+func (p *Profiler) stop() {
+	p.charge()
+	p.current = p.root
+}
+
+func (p *Profiler) enter(functionID int) {
+	p.charge()
+	node, ok := p.current.children[functionID]
+	if !ok {
+		node = &callNode{functionID: functionID, parent: p.current, children: make(map[int]*callNode)}
+		p.current.children[functionID] = node
+	}
+	node.calls++
+	p.current = node
+}
+
+func (p *Profiler) leave() {
+	p.charge()
+	if p.current.parent != nil {
+		p.current = p.current.parent
+	}
+}
+
+// charge adds the time since the last call or return to the function running
+func (p *Profiler) charge() {
+	now := time.Now()
+	p.current.nanoseconds += now.Sub(p.last).Nanoseconds()
+	p.last = now
+}
+
+// shiftFunctionNames moves the names of the name section with the indexes of their functions, which the injected
+// checktime import shifts by one
+func shiftFunctionNames(m *compiler.Module) {
+	names := make(map[int]string, len(m.FunctionNames))
+	for index, name := range m.FunctionNames {
+		names[index+1] = name
+	}
+	m.FunctionNames = names
+}
+
+// functionName is the name of a function in the name section of the module, when the section has no name for it
+// the imported functions are named after the import and the exported ones after the export
+func functionName(m *compiler.Module, functionID int) string {
+	if name, ok := m.FunctionNames[functionID]; ok {
+		return name
+	}
+	if imp := functionImport(m, functionID); imp != nil {
+		return imp.ModuleName + "." + imp.FieldName
+	}
+	exported := ""
+	if m.Base.Export != nil {
+		for name, e := range m.Base.Export.Entries {
+			if e.Kind == wasm.ExternalFunction && int(e.Index) == functionID && (exported == "" || name < exported) {
+				exported = name
+			}
+		}
+	}
+	if exported != "" {
+		return exported
+	}
+	return fmt.Sprintf("function[%d]", functionID)
+}
+
+// functionImport returns the import of a host function, nil for the functions of the module
+func functionImport(m *compiler.Module, functionID int) *wasm.ImportEntry {
+	if m.Base.Import == nil {
+		return nil
+	}
+	index := 0
+	for i := range m.Base.Import.Entries {
+		e := &m.Base.Import.Entries[i]
+		if e.Type.Kind() != wasm.ExternalFunction {
+			continue
+		}
+		if index == functionID {
+			return e
+		}
+		index++
+	}
+	return nil
+}
+
+// Profile is what a Profiler recorded for the contract of an account, resolved to the names of its functions
+type Profile struct {
+	Account   common.AccountName `json:"account"`
+	CodeHash  crypto.Sha256      `json:"code_hash"`
+	Functions []FunctionProfile  `json:"functions"` // by self time, the slowest first
+	Stacks    []StackProfile     `json:"stacks"`
+}
+
+type FunctionProfile struct {
+	Name         string `json:"name"`
+	Host         bool   `json:"host"`
+	Calls        uint64 `json:"calls"`
+	Instructions uint64 `json:"instructions"`  // executed by the function itself
+	SelfTime     int64  `json:"self_time_ns"`  // spent in the function itself
+	TotalTime    int64  `json:"total_time_ns"` // spent in the function and the functions it called
+}
+
+// StackProfile has the values of a function when called by a stack of functions, the outermost first
+type StackProfile struct {
+	Stack        []string `json:"stack"`
+	Calls        uint64   `json:"calls"`
+	Instructions uint64   `json:"instructions"`
+	Time         int64    `json:"time_ns"`
+}
+
+// Profile resolves what the Profiler recorded so far
+func (p *Profiler) Profile(account common.AccountName, codeHash crypto.Sha256) *Profile {
+	profile := &Profile{Account: account, CodeHash: codeHash, Functions: []FunctionProfile{}, Stacks: []StackProfile{}}
+	functions := make(map[int]*FunctionProfile)
+	function := func(functionID int) *FunctionProfile {
+		f, ok := functions[functionID]
+		if !ok {
+			f = &FunctionProfile{Name: functionName(p.module, functionID), Host: functionImport(p.module, functionID) != nil}
+			functions[functionID] = f
+		}
+		return f
+	}
+
+	var walk func(node *callNode, path []int)
+	walk = func(node *callNode, path []int) {
+		path = append(path, node.functionID)
+		f := function(node.functionID)
+		f.Calls += node.calls
+		f.Instructions += node.instructions
+		f.SelfTime += node.nanoseconds
+
+		// a recursive function is charged the time once, at its outermost call
+		stack := make([]string, len(path))
+		charged := make(map[int]bool, len(path))
+		for i, functionID := range path {
+			stack[i] = functionName(p.module, functionID)
+			if !charged[functionID] {
+				function(functionID).TotalTime += node.nanoseconds
+				charged[functionID] = true
+			}
+		}
+		profile.Stacks = append(profile.Stacks, StackProfile{Stack: stack, Calls: node.calls,
+			Instructions: node.instructions, Time: node.nanoseconds})
+
+		for _, functionID := range sortedChildren(node) {
+			walk(node.children[functionID], path)
+		}
+	}
+	for _, functionID := range sortedChildren(p.root) {
+		walk(p.root.children[functionID], nil)
+	}
+
+	for _, f := range functions {
+		profile.Functions = append(profile.Functions, *f)
+	}
+	sort.Slice(profile.Functions, func(i, j int) bool {
+		a, b := &profile.Functions[i], &profile.Functions[j]
+		if a.SelfTime != b.SelfTime {
+			return a.SelfTime > b.SelfTime
+		}
+		return a.Name < b.Name
+	})
+	return profile
+}
+
+func sortedChildren(node *callNode) []int {
+	ids := make([]int, 0, len(node.children))
+	for functionID := range node.children {
+		ids = append(ids, functionID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (p *Profile) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,165 @@
+package wasmgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/eosspark/eos-go/common"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm/leb128"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfiler(t *testing.T) {
+	code, codeId := readTestContract(t, "eosio.token")
+	m, err := compiler.LoadModule(code)
+	assert.NoError(t, err)
+	p := NewProfiler(m)
+	host := 0 // the imported functions come first
+	apply, _ := (&VirtualMachine{Module: m}).GetFunctionExport("apply")
+	recursive := len(m.Base.Import.Entries) // the first function of the module, the token imports functions only
+	if recursive == apply {
+		recursive++
+	}
+
+	// apply calls the host function and twice the recursive function, which calls itself once
+	p.start()
+	p.enter(apply)
+	p.enter(host)
+	p.leave()
+	for i := 0; i < 2; i++ {
+		p.enter(recursive)
+		p.current.instructions += 3
+		p.enter(recursive)
+		p.leave()
+		p.leave()
+	}
+	p.stop()
+
+	profile := p.Profile(common.N("eosio.token"), *codeId)
+	assert.Equal(t, common.N("eosio.token"), profile.Account)
+	assert.Equal(t, 4, len(profile.Stacks))
+	functions := make(map[string]FunctionProfile)
+	for _, f := range profile.Functions {
+		functions[f.Name] = f
+	}
+	assert.Equal(t, 3, len(functions))
+	hostName := functionName(m, host)
+	assert.True(t, functions[hostName].Host)
+	assert.Equal(t, uint64(1), functions[hostName].Calls)
+	assert.False(t, functions["apply"].Host)
+	assert.Equal(t, uint64(1), functions["apply"].Calls)
+
+	f := functions[functionName(m, recursive)]
+	assert.Equal(t, uint64(4), f.Calls)
+	assert.Equal(t, uint64(6), f.Instructions)
+	assert.True(t, f.TotalTime >= f.SelfTime)
+	total := int64(0)
+	for _, f := range profile.Functions {
+		total += f.SelfTime
+	}
+	assert.Equal(t, total, functions["apply"].TotalTime)
+	for i := 1; i < len(profile.Functions); i++ {
+		assert.True(t, profile.Functions[i-1].SelfTime >= profile.Functions[i].SelfTime)
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, profile.WriteJSON(buf))
+	decoded := Profile{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *profile, decoded)
+
+	buf.Reset()
+	assert.NoError(t, profile.WritePprof(buf))
+	gz, err := gzip.NewReader(buf)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	fields := protoFields(t, data)
+	assert.Equal(t, 3, fields[1]) // sample types
+	assert.Equal(t, 4, fields[2]) // samples
+	assert.Equal(t, 3, fields[4]) // locations
+	assert.Equal(t, 3, fields[5]) // functions
+	assert.True(t, bytes.Contains(data, []byte("apply")))
+}
+
+func TestProfilerFunctionNames(t *testing.T) {
+	code, codeId := readTestContract(t, "eosio.token")
+	loaded, err := compiler.LoadModule(code)
+	assert.NoError(t, err)
+	apply := int(loaded.Base.Export.Entries["apply"].Index)
+
+	// the name section names apply by its index before the checktime import is injected
+	code = append(append([]byte{}, code...), nameSection(apply, "token_apply")...)
+	m, _, err := CompileModule(code, codeCacheTestConfig, nil)
+	assert.NoError(t, err)
+	injected, _ := (&VirtualMachine{Module: m}).GetFunctionExport("apply")
+	assert.Equal(t, apply+1, injected)
+
+	p := NewProfiler(m)
+	p.start()
+	p.enter(injected)
+	p.leave()
+	p.stop()
+	profile := p.Profile(common.N("eosio.token"), *codeId)
+	assert.Equal(t, 1, len(profile.Functions))
+	assert.Equal(t, "token_apply", profile.Functions[0].Name)
+}
+
+// nameSection encodes a custom name section that names the function of index
+func nameSection(index int, name string) []byte {
+	functions := new(bytes.Buffer)
+	leb128.WriteVarUint32(functions, 1)
+	leb128.WriteVarUint32(functions, uint32(index))
+	leb128.WriteVarUint32(functions, uint32(len(name)))
+	functions.WriteString(name)
+
+	payload := new(bytes.Buffer)
+	leb128.WriteVarUint32(payload, 4)
+	payload.WriteString("name")
+	leb128.WriteVarUint32(payload, 1) // the function names subsection
+	leb128.WriteVarUint32(payload, uint32(functions.Len()))
+	functions.WriteTo(payload)
+
+	section := new(bytes.Buffer)
+	leb128.WriteVarUint32(section, 0) // a custom section
+	leb128.WriteVarUint32(section, uint32(payload.Len()))
+	payload.WriteTo(section)
+	return section.Bytes()
+}
+
+// protoFields counts the fields of a protocol buffer message by number
+func protoFields(t *testing.T, data []byte) map[int]int {
+	varint := func() uint64 {
+		x, shift := uint64(0), uint(0)
+		for {
+			if len(data) == 0 {
+				t.Fatal("truncated message")
+			}
+			b := data[0]
+			data = data[1:]
+			x |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				return x
+			}
+			shift += 7
+		}
+	}
+	fields := make(map[int]int)
+	for len(data) > 0 {
+		key := varint()
+		switch key & 7 {
+		case 0:
+			varint()
+		case 2:
+			data = data[varint():]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields[int(key>>3)]++
+	}
+	return fields
+}
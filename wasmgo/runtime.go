@@ -0,0 +1,49 @@
+package wasmgo
+
+import (
+	"github.com/eosspark/eos-go/crypto"
+)
+
+// Runtime runs the apply function of a contract for the action of context. The runtimes implement the same env api
+// with the host functions of wasmgo, a contract does the same on every runtime.
+type Runtime interface {
+	Apply(codeId *crypto.Sha256, code []byte, context EnvContext)
+}
+
+var _ Runtime = (*WasmGo)(nil)
+var _ Runtime = (*Wagon)(nil)
+
+// VmType selects the runtime of the contracts
+type VmType int8
+
+const (
+	WASMGO       = VmType(iota) // the code compiled for the register machine of wasmgo, kept in the code cache
+	WAGON                       // the code interpreted as it is by wagon
+	DIFFERENTIAL                // both, every action runs on wagon and on wasmgo and their results are compared
+)
+
+func (t VmType) String() string {
+	switch t {
+	case WASMGO:
+		return "wasmgo"
+	case WAGON:
+		return "wagon"
+	case DIFFERENTIAL:
+		return "differential"
+	default:
+		return ""
+	}
+}
+
+func VmTypeFromString(s string) (VmType, bool) {
+	switch s {
+	case "WASMGO", "wasmgo":
+		return WASMGO, true
+	case "WAGON", "wagon":
+		return WAGON, true
+	case "DIFFERENTIAL", "differential":
+		return DIFFERENTIAL, true
+	default:
+		return -1, false
+	}
+}
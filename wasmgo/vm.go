@@ -55,6 +55,10 @@ type VirtualMachine struct {
 	ReturnValue      int64
 	Gas              uint64
 	GasLimitExceeded bool
+	Profiler         *Profiler // records the calls of the functions when set
+	Debugger         *Debugger // pauses the execution on its breakpoints when set
+
+	initialGlobals []int64
 }
 
 // VMConfig denotes a set of options passed to a single VirtualMachine insta.ce
@@ -103,13 +107,23 @@ func NewVirtualMachine(
 		fmt.Println("Warning: JIT support is removed.")
 	}
 
-	m, err := compiler.LoadModule(code)
+	m, functionCode, err := CompileModule(code, config, gasPolicy)
 	if err != nil {
 		return nil, err
 	}
+	return NewCompiledVirtualMachine(wasmGo, m, functionCode, config, impResolver)
+}
+
+// CompileModule loads a WebAssembly module, injects the checktime calls into it and compiles its functions
+// for the interpreter.
+func CompileModule(code []byte, config VMConfig, gasPolicy compiler.GasPolicy) (*compiler.Module, []compiler.InterpreterCode, error) {
+	m, err := compiler.LoadModule(code)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	//inject timecheck for infinite loop
-	Inject(m.Base)
+	InjectModule(m, true)
 
 	//buf := new(bytes.Buffer)
 	//wast.WriteTo(buf, m.Base)
@@ -119,8 +133,19 @@ func NewVirtualMachine(
 
 	functionCode, err := m.CompileForInterpreter(gasPolicy)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return m, functionCode, nil
+}
+
+// NewCompiledVirtualMachine instantiates a virtual machine for a module whose functions are already compiled.
+func NewCompiledVirtualMachine(
+	wasmGo *WasmGo,
+	m *compiler.Module,
+	functionCode []compiler.InterpreterCode,
+	config VMConfig,
+	impResolver ImportResolver,
+) (_retVM *VirtualMachine, retErr error) {
 
 	//defer utils.CatchPanic(&retErr)
 
@@ -202,6 +227,30 @@ func NewVirtualMachine(
 	}
 
 	// Load linear memory.
+	memory := initialMemory(m, config, globals)
+
+	if m.Base.Memory == nil {
+		EosAssert(false, &WasmExecutionError{}, "memory section missing")
+	}
+
+	return &VirtualMachine{
+		WasmGo:          wasmGo,
+		Module:          m,
+		Config:          config,
+		FunctionCode:    functionCode,
+		FunctionImports: funcImports,
+		CallStack:       make([]Frame, DefaultCallStackSize),
+		CurrentFrame:    -1,
+		Table:           table,
+		Globals:         globals,
+		Memory:          memory,
+		Exited:          true,
+		initialGlobals:  append([]int64{}, globals...),
+	}, nil
+}
+
+// initialMemory returns the linear memory of a module before it runs, its initial pages with the data segments.
+func initialMemory(m *compiler.Module, config VMConfig, globals []int64) []byte {
 	memory := make([]byte, 0)
 	if m.Base.Memory != nil && len(m.Base.Memory.Entries) > 0 {
 		initialLimit := int(m.Base.Memory.Entries[0].Limits.Initial)
@@ -213,9 +262,6 @@ func NewVirtualMachine(
 
 		// Initialize empty memory.
 		memory = make([]byte, capacity)
-		for i := 0; i < capacity; i++ {
-			memory[i] = 0
-		}
 
 		if m.Base.Data != nil && len(m.Base.Data.Entries) > 0 {
 			for _, e := range m.Base.Data.Entries {
@@ -224,55 +270,24 @@ func NewVirtualMachine(
 			}
 		}
 	}
-
-	if m.Base.Memory == nil {
-		EosAssert(false, &WasmExecutionError{}, "memory section missing")
-	}
-
-	return &VirtualMachine{
-		WasmGo:          wasmGo,
-		Module:          m,
-		Config:          config,
-		FunctionCode:    functionCode,
-		FunctionImports: funcImports,
-		CallStack:       make([]Frame, DefaultCallStackSize),
-		CurrentFrame:    -1,
-		Table:           table,
-		Globals:         globals,
-		Memory:          memory,
-		Exited:          true,
-	}, nil
+	return memory
 }
 
-// GetCurrentFrame returns the current frame.
+// Reset prepares a virtual machine for the next action, every action starts with the memory and globals the
+// module starts with, whether its virtual machine ran before or was just instantiated.
 func (vm *VirtualMachine) Reset() {
-
-	//memory
-	// memory := make([]byte, 0)
-	// if vm.Module.Base.Memory != nil && len(vm.Module.Base.Memory.Entries) > 0 {
-	// 	initialLimit := int(vm.Module.Base.Memory.Entries[0].Limits.Initial)
-	// 	if config.MaxMemoryPages != 0 && initialLimit > config.MaxMemoryPages {
-	// 		panic("max memory exceeded")
-	// 	}
-
-	// 	capacity := initialLimit * DefaultPageSize
-
-	// 	// Initialize empty memory.
-	// 	memory = make([]byte, capacity)
-	// 	if vm.Module.Base.Data != nil && len(vm.Module.Base.Data.Entries) > 0 {
-	// 		for _, e := range vm.Module.Base.Data.Entries {
-	// 			offset := int(execInitExpr(e.Offset, globals))
-	// 			copy(memory[int(offset):], e.Data)
-	// 		}
-	// 	}
-	// }
-	// vm.Memory = memory
+	vm.Globals = append(vm.Globals[:0], vm.initialGlobals...)
+	vm.Memory = initialMemory(vm.Module, vm.Config, vm.Globals)
 
 	vm.CallStack = make([]Frame, DefaultCallStackSize)
 	vm.CurrentFrame = -1
+	vm.NumValueSlots = 0 // of the frames of a run that trapped
 	vm.Exited = true
+	vm.ExitError = nil
 	vm.Delegate = nil
 	vm.InsideExecute = false
+	vm.Gas = 0
+	vm.GasLimitExceeded = false
 }
 
 // Init initializes a frame. Must be called on `call` and `call_indirect`.
@@ -378,6 +393,10 @@ func (vm *VirtualMachine) Ignite(functionID int, params ...int64) {
 		code,
 	)
 	copy(frame.Locals, params)
+	if vm.Profiler != nil {
+		vm.Profiler.start()
+		vm.Profiler.enter(functionID)
+	}
 }
 
 func (vm *VirtualMachine) AddAndCheckGas(delta uint64) bool {
@@ -389,6 +408,7 @@ func (vm *VirtualMachine) AddAndCheckGas(delta uint64) bool {
 		if vm.Config.ReturnOnGasLimitExceeded {
 			return false
 		} else {
+			vm.GasLimitExceeded = true
 			panic("gas limit exceeded")
 		}
 	}
@@ -420,15 +440,26 @@ func (vm *VirtualMachine) Execute() {
 		if err := recover(); err != nil {
 			vm.Exited = true
 			vm.ExitError = err
+			if vm.Profiler != nil {
+				vm.Profiler.stop()
+			}
 		}
 	}()
 
 	frame := vm.GetCurrentFrame()
+	profiler := vm.Profiler
+	debugger := vm.Debugger
 
 	for {
+		if debugger != nil {
+			debugger.check(vm, frame)
+		}
 		valueID := int(LE.Uint32(frame.Code[frame.IP : frame.IP+4]))
 		ins := opcodes.Opcode(frame.Code[frame.IP+4])
 		frame.IP += 5
+		if profiler != nil {
+			profiler.current.instructions++
+		}
 
 		//fmt.Printf("INS: [%d] %s\n", valueID, ins.String())
 
@@ -1375,6 +1406,9 @@ func (vm *VirtualMachine) Execute() {
 		case opcodes.ReturnValue:
 			val := frame.Regs[int(LE.Uint32(frame.Code[frame.IP:frame.IP+4]))]
 			frame.Destroy(vm)
+			if profiler != nil {
+				profiler.leave()
+			}
 			vm.CurrentFrame--
 			if vm.CurrentFrame == -1 {
 				vm.Exited = true
@@ -1387,6 +1421,9 @@ func (vm *VirtualMachine) Execute() {
 			}
 		case opcodes.ReturnVoid:
 			frame.Destroy(vm)
+			if profiler != nil {
+				profiler.leave()
+			}
 			vm.CurrentFrame--
 			if vm.CurrentFrame == -1 {
 				vm.Exited = true
@@ -1430,6 +1467,9 @@ func (vm *VirtualMachine) Execute() {
 			vm.CurrentFrame++
 			frame = vm.GetCurrentFrame()
 			frame.Init(vm, functionID, vm.FunctionCode[functionID])
+			if profiler != nil {
+				profiler.enter(functionID)
+			}
 			for i := 0; i < argCount; i++ {
 				frame.Locals[i] = oldRegs[int(LE.Uint32(argsRaw[i*4:i*4+4]))]
 			}
@@ -1461,6 +1501,9 @@ func (vm *VirtualMachine) Execute() {
 			vm.CurrentFrame++
 			frame = vm.GetCurrentFrame()
 			frame.Init(vm, functionID, code)
+			if profiler != nil {
+				profiler.enter(functionID)
+			}
 			for i := 0; i < argCount; i++ {
 				frame.Locals[i] = oldRegs[int(LE.Uint32(argsRaw[i*4:i*4+4]))]
 			}
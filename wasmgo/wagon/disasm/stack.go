@@ -0,0 +1,251 @@
+// Copyright 2017 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package disasm
+
+import (
+	"errors"
+
+	"github.com/eosspark/eos-go/wasmgo/wagon/internal/stack"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm"
+	ops "github.com/eosspark/eos-go/wasmgo/wagon/wasm/operators"
+)
+
+// DisassembleStack disassembles the given function like Disassemble and then
+// computes the stack of every instruction with ComputeStack. The imports of
+// module must be resolved, since the calls are checked against the signatures
+// of its function index space.
+func DisassembleStack(fn wasm.Function, module *wasm.Module) (*Disassembly, error) {
+	disas, err := Disassemble(fn, module)
+	if err != nil {
+		return nil, err
+	}
+	if err := disas.ComputeStack(fn, module); err != nil {
+		return nil, err
+	}
+	return disas, nil
+}
+
+// ComputeStack walks the disassembled code of fn and sets the stack details
+// the exec compiler needs: the reachability of every instruction, the blocks
+// started and ended, the stacks unwound by the branches and the end of the
+// blocks, and the maximum stack depth of the function.
+func (d *Disassembly) ComputeStack(fn wasm.Function, module *wasm.Module) error {
+	// A stack of int arrays holding indices to instructions that make the stack
+	// polymorphic. Each block has its corresponding array. We start with one
+	// array for the root stack
+	blockPolymorphicOps := [][]int{{}}
+	// a stack of current execution stack depth values, so that the depth for each
+	// stack is maintained independently for calculating discard values
+	stackDepths := &stack.Stack{}
+	stackDepths.Push(0)
+	blockIndices := &stack.Stack{} // a stack of indices to operators which start new blocks
+	var lastOpReturn bool
+
+	d.MaxDepth = 0
+	for curIndex := range d.Code {
+		instr := &d.Code[curIndex]
+		op := instr.Op.Code
+		if op == ops.End || op == ops.Else {
+			// The end/else of a block is unreachable when the instruction
+			// starting the block is unreachable, it is reachable otherwise
+			// even when an instruction of the block makes the stack polymorphic.
+			instr.Unreachable = blockIndices.Len() != len(blockPolymorphicOps)-1
+		} else {
+			instr.Unreachable = !isInstrReachable(blockPolymorphicOps)
+		}
+
+		if !instr.Op.Polymorphic && !instr.Unreachable {
+			top := int(stackDepths.Top())
+			top -= len(instr.Op.Args)
+			if top < -1 {
+				return ErrStackUnderflow
+			}
+			if instr.Op.Returns != wasm.ValueType(wasm.BlockTypeEmpty) {
+				top++
+			}
+			stackDepths.SetTop(uint64(top))
+			d.checkMaxDepth(top)
+		}
+
+		switch op {
+		case ops.Unreachable:
+			pushPolymorphicOp(blockPolymorphicOps, curIndex)
+		case ops.Drop:
+			if !instr.Unreachable {
+				stackDepths.SetTop(stackDepths.Top() - 1)
+			}
+		case ops.Select:
+			if !instr.Unreachable {
+				stackDepths.SetTop(stackDepths.Top() - 2)
+			}
+		case ops.Return:
+			if !instr.Unreachable {
+				stackDepths.SetTop(stackDepths.Top() - uint64(len(fn.Sig.ReturnTypes)))
+			}
+			pushPolymorphicOp(blockPolymorphicOps, curIndex)
+			lastOpReturn = true
+		case ops.End, ops.Else:
+			if blockIndices.Len() == 0 {
+				return ErrStackUnderflow
+			}
+			// the depth reached at the end of the current block
+			curDepth := stackDepths.Top()
+			blockStartIndex := int(blockIndices.Pop())
+			blockSig := d.Code[blockStartIndex].Block.Signature
+			instr.Block = &BlockInfo{
+				Start:     false,
+				Signature: blockSig,
+			}
+			if op == ops.End {
+				instr.Block.BlockStartIndex = blockStartIndex
+				d.Code[blockStartIndex].Block.EndIndex = curIndex
+			} else {
+				instr.Block.ElseIfIndex = blockStartIndex
+				d.Code[blockStartIndex].Block.IfElseIndex = curIndex
+			}
+
+			// the depth before the block started, a block with a signature
+			// leaves its value on top of it
+			prevDepthIndex := stackDepths.Len() - 2
+			prevDepth := stackDepths.Get(prevDepthIndex)
+			if op != ops.Else && blockSig != wasm.BlockTypeEmpty && !instr.Unreachable {
+				stackDepths.Set(prevDepthIndex, prevDepth+1)
+				d.checkMaxDepth(int(stackDepths.Get(prevDepthIndex)))
+			}
+
+			if !lastOpReturn {
+				elemsDiscard := int(curDepth) - int(prevDepth)
+				if elemsDiscard < -1 {
+					return ErrStackUnderflow
+				}
+				instr.NewStack = &StackInfo{
+					StackTopDiff: int64(elemsDiscard),
+					PreserveTop:  blockSig != wasm.BlockTypeEmpty,
+				}
+			} else {
+				instr.NewStack = &StackInfo{}
+			}
+
+			d.Code[blockStartIndex].NewStack = instr.NewStack
+			if !instr.Unreachable {
+				blockPolymorphicOps = blockPolymorphicOps[:len(blockPolymorphicOps)-1]
+			}
+
+			stackDepths.Pop()
+			if op == ops.Else {
+				stackDepths.Push(stackDepths.Top())
+				blockIndices.Push(uint64(curIndex))
+				if !instr.Unreachable {
+					blockPolymorphicOps = append(blockPolymorphicOps, []int{})
+				}
+			}
+		case ops.Block, ops.Loop, ops.If:
+			stackDepths.Push(stackDepths.Top())
+			// The instructions of an unreachable block are unreachable as well,
+			// no array is pushed for it so that isInstrReachable stays false.
+			if !instr.Unreachable {
+				blockPolymorphicOps = append(blockPolymorphicOps, []int{})
+			}
+			instr.Block = &BlockInfo{
+				Start:     true,
+				Signature: instr.Immediates[0].(wasm.BlockType),
+			}
+			blockIndices.Push(uint64(curIndex))
+		case ops.Br, ops.BrIf:
+			if !instr.Unreachable {
+				info, err := d.branchStack(stackDepths, blockIndices, instr.Immediates[0].(uint32))
+				if err != nil {
+					return err
+				}
+				if info.IsReturn {
+					instr.IsReturn = true
+				} else {
+					instr.NewStack = &info
+				}
+			}
+			if op == ops.Br {
+				pushPolymorphicOp(blockPolymorphicOps, curIndex)
+			}
+		case ops.BrTable:
+			if !instr.Unreachable {
+				// the immediates are the number of targets, the targets and the default target
+				for _, target := range instr.Immediates[1:] {
+					info, err := d.branchStack(stackDepths, blockIndices, target.(uint32))
+					if err != nil {
+						return err
+					}
+					instr.Branches = append(instr.Branches, info)
+				}
+			}
+			pushPolymorphicOp(blockPolymorphicOps, curIndex)
+		case ops.Call, ops.CallIndirect:
+			if !instr.Unreachable {
+				index := instr.Immediates[0].(uint32)
+				var sig *wasm.FunctionSig
+				top := int(stackDepths.Top())
+				if op == ops.CallIndirect {
+					if module.Types == nil || int(index) >= len(module.Types.Entries) {
+						return errors.New("disasm: invalid type index")
+					}
+					sig = &module.Types.Entries[index]
+					top--
+				} else {
+					f := module.GetFunction(int(index))
+					if f == nil {
+						return errors.New("disasm: invalid function index")
+					}
+					sig = f.Sig
+				}
+				top -= len(sig.ParamTypes)
+				top += len(sig.ReturnTypes)
+				if top < 0 {
+					return ErrStackUnderflow
+				}
+				stackDepths.SetTop(uint64(top))
+				d.checkMaxDepth(top)
+			}
+		case ops.GetLocal, ops.SetLocal, ops.TeeLocal, ops.GetGlobal, ops.SetGlobal:
+			if !instr.Unreachable {
+				top := stackDepths.Top()
+				switch op {
+				case ops.GetLocal, ops.GetGlobal:
+					top++
+					stackDepths.SetTop(top)
+					d.checkMaxDepth(int(top))
+				case ops.SetLocal, ops.SetGlobal:
+					top--
+					stackDepths.SetTop(top)
+				case ops.TeeLocal:
+					// stack remains unchanged for tee_local
+				}
+			}
+		}
+
+		if op != ops.Return {
+			lastOpReturn = false
+		}
+	}
+	return nil
+}
+
+// branchStack returns how a branch to the block at depth unwinds the stack,
+// a branch past the outermost block returns from the function.
+func (d *Disassembly) branchStack(stackDepths, blockIndices *stack.Stack, depth uint32) (StackInfo, error) {
+	if int(depth) == blockIndices.Len() {
+		return StackInfo{IsReturn: true}, nil
+	}
+	if int(depth) > blockIndices.Len() {
+		return StackInfo{}, ErrStackUnderflow
+	}
+	elemsDiscard := int(stackDepths.Top()) - int(stackDepths.Get(stackDepths.Len()-2-int(depth)))
+	if elemsDiscard < 0 {
+		return StackInfo{}, ErrStackUnderflow
+	}
+	index := blockIndices.Get(blockIndices.Len() - 1 - int(depth))
+	return StackInfo{
+		StackTopDiff: int64(elemsDiscard),
+		PreserveTop:  d.Code[index].Block.Signature != wasm.BlockTypeEmpty,
+	}, nil
+}
@@ -15,6 +15,12 @@ var (
 	// an invalid index to the module's table space is used as an operand to
 	// call_indirect
 	ErrUndefinedElementIndex = errors.New("exec: undefined element index")
+	// ErrCallStackOverflow is the error value used while trapping the VM when
+	// a call is nested deeper than VM.MaxCallDepth.
+	ErrCallStackOverflow = errors.New("exec: call stack overflow")
+	// ErrGasLimitExceeded is the error value used while trapping the VM when
+	// the instructions executed cost more than VM.GasLimit.
+	ErrGasLimitExceeded = errors.New("exec: gas limit exceeded")
 )
 
 func (vm *VM) call() {
@@ -80,6 +80,10 @@ func (fn goFunction) call(vm *VM, index int64) {
 }
 
 func (compiled compiledFunction) call(vm *VM, index int64) {
+	if vm.MaxCallDepth != 0 && vm.depth >= vm.MaxCallDepth {
+		panic(ErrCallStackOverflow)
+	}
+
 	newStack := make([]uint64, compiled.maxDepth)
 	locals := make([]uint64, compiled.totalLocalVars)
 
@@ -98,7 +102,9 @@ func (compiled compiledFunction) call(vm *VM, index int64) {
 		curFunc: index,
 	}
 
+	vm.depth++
 	rtrn := vm.execCode(compiled)
+	vm.depth--
 
 	//restore execution context
 	vm.ctx = prevCtxt
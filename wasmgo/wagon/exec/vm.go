@@ -69,7 +69,23 @@ type VM struct {
 	// or encountering an invalid instruction, e.g. `unreachable`.
 	RecoverPanic bool
 
+	// MaxCallDepth bounds the nested calls of the wasm functions, a call
+	// deeper than it traps with ErrCallStackOverflow. Zero is unbounded.
+	MaxCallDepth int
+
+	// MaxMemoryPages bounds the linear memory, grow_memory past it fails
+	// and returns -1. Zero is unbounded.
+	MaxMemoryPages int
+
+	// OpCost is the gas of each opcode, the instructions executed add their
+	// cost to Gas and an instruction that takes Gas past GasLimit traps with
+	// ErrGasLimitExceeded. Nil does not meter, a GasLimit of zero is unbounded.
+	OpCost   *[256]uint64
+	GasLimit uint64
+	Gas      uint64
+
 	abort bool // Flag for host functions to terminate execution
+	depth int  // nested calls of compiled functions
 }
 
 // As per the WebAssembly spec: https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/Semantics.md#linear-memory
@@ -112,7 +128,7 @@ func NewVM(module *wasm.Module) (*VM, error) {
 			continue
 		}
 
-		disassembly, err := disasm.Disassemble(fn, module)
+		disassembly, err := disasm.DisassembleStack(fn, module)
 		if err != nil {
 			return nil, err
 		}
@@ -391,6 +407,9 @@ outer:
 			vm.ctx.stack = vm.ctx.stack[:len(vm.ctx.stack)-int(place)]
 			vm.pushUint64(top)
 		default:
+			if vm.OpCost != nil {
+				vm.addGas(vm.OpCost[op])
+			}
 			vm.funcTable[op]()
 		}
 	}
@@ -401,6 +420,14 @@ outer:
 	return 0
 }
 
+func (vm *VM) addGas(cost uint64) {
+	gas := vm.Gas + cost
+	if gas < vm.Gas || vm.GasLimit != 0 && gas > vm.GasLimit {
+		panic(ErrGasLimitExceeded)
+	}
+	vm.Gas = gas
+}
+
 // Process is a proxy passed to host functions in order to access
 // things such as memory and control.
 type Process struct {
@@ -456,6 +483,12 @@ func (proc *Process) WriteAt(p []byte, off int64) (int, error) {
 	return length, err
 }
 
+// Memory returns the linear memory space of the VM, host functions read
+// and write it in place.
+func (proc *Process) Memory() []byte {
+	return proc.vm.Memory()
+}
+
 // Terminate stops the execution of the current module.
 func (proc *Process) Terminate() {
 	proc.vm.abort = true
@@ -0,0 +1,257 @@
+package wasmgo
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/eosspark/eos-go/crypto"
+	. "github.com/eosspark/eos-go/exception"
+	. "github.com/eosspark/eos-go/exception/try"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
+	"github.com/eosspark/eos-go/wasmgo/wagon/exec"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm"
+	"github.com/eosspark/eos-go/wasmgo/wagon/wasm/operators"
+)
+
+// Wagon runs the contracts on the wagon interpreter, which executes the wasm code as it is instead of compiling it.
+// It keeps no code, every action decodes and instantiates the code of its contract again, so it is slower than
+// wasmgo and is meant as the reference the compiled code of wasmgo is checked against.
+type Wagon struct {
+	host     *WasmGo // the host functions of wasmgo reach the context and the log through it
+	resolver *Resolver
+	opCost   *[256]uint64 // gas of the wasm opcodes by the gas policy, nil does not meter
+	gasLimit uint64
+}
+
+// hostPanic carries a panic of a host function through the interpreter, the exceptions of the env api are thrown
+// to the caller of Apply while the traps of the interpreter are only logged, as wasmgo does
+type hostPanic struct {
+	e interface{}
+}
+
+func NewWagon() *Wagon {
+	return &Wagon{
+		host:     &WasmGo{ilog: newLogger("wagon")},
+		resolver: new(Resolver),
+	}
+}
+
+// SetGasPolicy meters the instructions of the contracts with gasPolicy and limit as WasmGo.SetGasPolicy does. Wagon
+// charges the wasm instructions it executes while wasmgo charges the ones it compiles them to, so the weights of the
+// two runtimes differ.
+func (w *Wagon) SetGasPolicy(gasPolicy compiler.GasPolicy, limit uint64) {
+	w.gasLimit = limit
+	if gasPolicy == nil {
+		w.opCost = nil
+		return
+	}
+	w.opCost = new([256]uint64)
+	for code := range w.opCost {
+		if op, err := operators.New(byte(code)); err == nil && gasPolicy.GetCost(op.Name) > 0 {
+			w.opCost[code] = uint64(gasPolicy.GetCost(op.Name))
+		}
+	}
+}
+
+func (w *Wagon) Apply(codeId *crypto.Sha256, code []byte, context EnvContext) {
+	w.host.context = context
+
+	vm := &VirtualMachine{WasmGo: w.host, CallStack: make([]Frame, 1), CurrentFrame: 0}
+	defer func() {
+		if e := recover(); e != nil {
+			if h, ok := e.(hostPanic); ok {
+				panic(h.e)
+			}
+			EosAssert(e != exec.ErrGasLimitExceeded, &WasmGasExceeded{}, "%s::%s executed instructions weighing more than %d",
+				context.GetReceiver(), context.GetAct(), gasLimit(context, w.gasLimit))
+			w.host.ilog.Error("vm execute err: %v", e)
+		}
+	}()
+
+	context.PauseBillingTimer()
+	m, err := w.instantiate(vm, code)
+	if err != nil {
+		context.ResumeBillingTimer()
+		EosThrow(&WasmExecutionError{}, "could not create VM: %s", err)
+	}
+	process, err := exec.NewVM(m)
+	context.ResumeBillingTimer()
+	if err != nil {
+		EosThrow(&WasmExecutionError{}, "could not create VM: %s", err)
+	}
+	process.MaxCallDepth = DefaultCallStackSize
+	process.MaxMemoryPages = MaximumLinearMemory / WasmPageSize
+	process.OpCost = w.opCost
+	process.GasLimit = gasLimit(context, w.gasLimit)
+
+	entry, ok := m.Export.Entries["apply"]
+	if !ok || entry.Kind != wasm.ExternalFunction {
+		w.host.ilog.Info("Entry function %s not found", "apply")
+		return
+	}
+	_, err = process.ExecCode(int64(entry.Index),
+		uint64(context.GetReceiver()), uint64(context.GetCode()), uint64(context.GetAct()))
+	if err != nil {
+		w.host.ilog.Error("vm execute err: %v", err)
+	}
+	if w.opCost != nil {
+		context.AddInstructionWeight(process.Gas)
+	}
+}
+
+// instantiate reads the module of code with the checktime calls of wasmgo injected and its imports resolved to the
+// host functions of wasmgo, its linear memory and table are initialized the way wasmgo initializes them
+func (w *Wagon) instantiate(vm *VirtualMachine, code []byte) (*wasm.Module, error) {
+	code, err := injectCheckTime(code)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := wasm.DecodeModule(bytes.NewReader(code))
+	if err != nil {
+		return nil, err
+	}
+	env := &wasm.Module{Export: &wasm.SectionExports{Entries: make(map[string]wasm.ExportEntry)}}
+	if decoded.Import != nil {
+		for _, imp := range decoded.Import.Entries {
+			funcImport, ok := imp.Type.(wasm.FuncImport)
+			if !ok || imp.ModuleName != "env" {
+				continue
+			}
+			if _, ok := env.Export.Entries[imp.FieldName]; ok {
+				continue
+			}
+			if decoded.Types == nil || int(funcImport.Type) >= len(decoded.Types.Entries) {
+				return nil, fmt.Errorf("invalid type %d of %s", funcImport.Type, imp.FieldName)
+			}
+			sig := &decoded.Types.Entries[funcImport.Type]
+			env.Export.Entries[imp.FieldName] = wasm.ExportEntry{
+				FieldStr: imp.FieldName,
+				Kind:     wasm.ExternalFunction,
+				Index:    uint32(len(env.FunctionIndexSpace)),
+			}
+			env.FunctionIndexSpace = append(env.FunctionIndexSpace, wasm.Function{
+				Sig:  sig,
+				Body: &wasm.FunctionBody{},
+				Host: w.hostFunction(vm, imp.FieldName, sig),
+			})
+		}
+	}
+
+	m, err := wasm.ReadModule(bytes.NewReader(code), func(name string) (*wasm.Module, error) {
+		if name != "env" {
+			return nil, fmt.Errorf("unknown module: %s", name)
+		}
+		return env, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m.Memory == nil {
+		EosAssert(false, &WasmExecutionError{}, "memory section missing")
+	}
+
+	globals := make([]int64, 0, len(m.GlobalIndexSpace))
+	for _, entry := range m.GlobalIndexSpace {
+		globals = append(globals, execInitExpr(entry.Init, globals))
+	}
+	if len(m.Memory.Entries) > 0 {
+		initial := int(m.Memory.Entries[0].Limits.Initial)
+		if initial > MaximumLinearMemory/WasmPageSize {
+			return nil, fmt.Errorf("max memory exceeded")
+		}
+		memory := make([]byte, initial*DefaultPageSize)
+		if m.Data != nil {
+			for _, e := range m.Data.Entries {
+				copy(memory[int(execInitExpr(e.Offset, globals)):], e.Data)
+			}
+		}
+		m.LinearMemoryIndexSpace[0] = memory
+	}
+	if m.Table != nil && len(m.Table.Entries) > 0 {
+		table := make([]uint32, int(m.Table.Entries[0].Limits.Initial))
+		for i := range table {
+			table[i] = 0xffffffff
+		}
+		if m.Elements != nil {
+			for _, e := range m.Elements.Entries {
+				copy(table[int(execInitExpr(e.Offset, globals)):], e.Elems)
+			}
+		}
+		m.TableIndexSpace[0] = table
+	}
+	return m, nil
+}
+
+// injectCheckTime injects the checktime calls into code as wasmgo injects them before it compiles the code
+func injectCheckTime(code []byte) ([]byte, error) {
+	m, err := wasm.ReadModule(bytes.NewReader(code), nil)
+	if err != nil {
+		return nil, err
+	}
+	Inject(m)
+
+	injected := new(bytes.Buffer)
+	if err := wasm.EncodeModule(injected, m); err != nil {
+		return nil, err
+	}
+	return injected.Bytes(), nil
+}
+
+// hostFunction adapts the host function of wasmgo for field to a wagon host function of sig. The arguments become
+// the locals of the only frame of vm and the linear memory of the wagon process is its memory, the integers and the
+// bits of the floats are passed as they are.
+func (w *Wagon) hostFunction(vm *VirtualMachine, field string, sig *wasm.FunctionSig) reflect.Value {
+	function := w.resolver.ResolveFunc("env", field)
+	if field == "checktime" { // the injected calls check the deadline of the transaction
+		function = func(vm *VirtualMachine) int64 {
+			vm.WasmGo.context.CheckTime()
+			return 0
+		}
+	}
+
+	in := []reflect.Type{reflect.TypeOf(&exec.Process{})}
+	for _, t := range sig.ParamTypes {
+		in = append(in, wagonValueType(t))
+	}
+	out := make([]reflect.Type, 0, len(sig.ReturnTypes))
+	for _, t := range sig.ReturnTypes {
+		out = append(out, wagonValueType(t))
+	}
+
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		frame := vm.GetCurrentFrame()
+		frame.Locals = frame.Locals[:0]
+		for _, arg := range args[1:] {
+			frame.Locals = append(frame.Locals, int64(arg.Uint()))
+		}
+		vm.Memory = args[0].Interface().(*exec.Process).Memory()
+
+		var ret int64
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					panic(hostPanic{e})
+				}
+			}()
+			ret = function(vm)
+		}()
+
+		results := make([]reflect.Value, len(out))
+		for i, t := range out {
+			results[i] = reflect.New(t).Elem()
+			results[i].SetUint(uint64(ret))
+		}
+		return results
+	})
+}
+
+// wagonValueType is the go type of a wasm value for wagon, the floats are passed as their bits
+func wagonValueType(t wasm.ValueType) reflect.Type {
+	switch t {
+	case wasm.ValueTypeI32, wasm.ValueTypeF32:
+		return reflect.TypeOf(uint32(0))
+	default:
+		return reflect.TypeOf(uint64(0))
+	}
+}
@@ -1,14 +1,18 @@
 package wasmgo
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/eosspark/eos-go/common"
 	"github.com/eosspark/eos-go/common/eos_math"
 	"github.com/eosspark/eos-go/crypto"
 	"github.com/eosspark/eos-go/crypto/rlp"
 	"github.com/eosspark/eos-go/exception"
 	"github.com/eosspark/eos-go/exception/try"
 	"github.com/eosspark/eos-go/log"
+	"github.com/eosspark/eos-go/wasmgo/compiler"
 	"os"
+	"sort"
 	//"time"
 	//"github.com/eosspark/eos-go/wasmgo/wasm"
 )
@@ -37,58 +41,148 @@ var wasmGo *WasmGo
 //type size_t int
 
 type WasmGo struct {
-	context EnvContext
-	vmCache map[crypto.Sha256]*VirtualMachine
+	context   EnvContext
+	codeCache *CodeCache
+	gasPolicy compiler.GasPolicy
+	gasLimit  uint64
+	profiles  map[common.AccountName]*accountProfile
+	debugger  *Debugger
 
 	ilog log.Logger
 }
 
+// accountProfile is the profiler of the contract an account has, it starts again when the account sets another one
+type accountProfile struct {
+	codeId   crypto.Sha256
+	profiler *Profiler
+}
+
 func NewWasmGo() *WasmGo {
 
 	if wasmGo != nil {
 		return wasmGo
 	}
 
-	w := WasmGo{vmCache: make(map[crypto.Sha256]*VirtualMachine)}
+	w := WasmGo{}
 
 	wasmGo = &w
 
-	wasmGo.ilog = log.New("wasmgo")
-	logHandler := log.StreamHandler(os.Stdout, log.TerminalFormat(true))
-	//wasmGo.ilog.SetHandler(log.LvlFilterHandler(log.LvlDebug, logHandler))
-	wasmGo.ilog.SetHandler(log.LvlFilterHandler(log.LvlInfo, logHandler))
+	wasmGo.ilog = newLogger("wasmgo")
+	wasmGo.codeCache = NewCodeCache(common.DefaultConfig.DefaultWasmCacheSize, "", wasmGo.ilog)
 	return wasmGo
 }
 
+func newLogger(name string) log.Logger {
+	ilog := log.New(name)
+	logHandler := log.StreamHandler(os.Stdout, log.TerminalFormat(true))
+	//ilog.SetHandler(log.LvlFilterHandler(log.LvlDebug, logHandler))
+	ilog.SetHandler(log.LvlFilterHandler(log.LvlInfo, logHandler))
+	return ilog
+}
+
+// SetCodeCache sets the memory budget of the compiled contracts and the directory their compiled code is kept in,
+// no directory keeps it in memory only. The contracts already in the cache stay in it within the new budget.
+func (w *WasmGo) SetCodeCache(budget uint64, dir string) {
+	w.codeCache.SetDir(dir)
+	w.codeCache.SetBudget(budget)
+}
+
+func (w *WasmGo) CodeCache() *CodeCache {
+	return w.codeCache
+}
+
+// SetGasPolicy meters the instructions of the contracts with gasPolicy, the weight of the instructions an action
+// executed is added to its context. An action whose context is gas limited fails when its instructions weigh more
+// than limit, zero is unbounded. No gas policy does not meter the contracts.
+func (w *WasmGo) SetGasPolicy(gasPolicy compiler.GasPolicy, limit uint64) {
+	w.gasPolicy = gasPolicy
+	w.gasLimit = limit
+	w.codeCache.setGasPolicy(gasPolicyId(gasPolicy))
+}
+
+func (w *WasmGo) GasPolicy() compiler.GasPolicy {
+	return w.gasPolicy
+}
+
+// gasPolicyId identifies the code compiled with a gas policy by the type and the json of the policy
+func gasPolicyId(gasPolicy compiler.GasPolicy) crypto.Sha256 {
+	if gasPolicy == nil {
+		return crypto.Sha256{}
+	}
+	data, err := json.Marshal(gasPolicy)
+	try.Throw(err)
+	return *crypto.Hash256String(fmt.Sprintf("%T %s", gasPolicy, data))
+}
+
+// SetProfiledAccounts profiles the contracts of accounts, the profiles recorded so far are dropped
+func (w *WasmGo) SetProfiledAccounts(accounts []common.AccountName) {
+	w.profiles = make(map[common.AccountName]*accountProfile, len(accounts))
+	for _, account := range accounts {
+		w.profiles[account] = nil
+	}
+}
+
+// Profiles returns the profiles of the contracts of the profiled accounts that ran
+func (w *WasmGo) Profiles() []*Profile {
+	profiles := make([]*Profile, 0, len(w.profiles))
+	for account, p := range w.profiles {
+		if p != nil {
+			profiles = append(profiles, p.profiler.Profile(account, p.codeId))
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Account < profiles[j].Account })
+	return profiles
+}
+
+// profiler returns the profiler of the contract of an account, nil when the account is not profiled
+func (w *WasmGo) profiler(account common.AccountName, codeId *crypto.Sha256, vm *VirtualMachine) *Profiler {
+	p, ok := w.profiles[account]
+	if !ok {
+		return nil
+	}
+	if p == nil || p.codeId != *codeId {
+		p = &accountProfile{codeId: *codeId, profiler: NewProfiler(vm.Module)}
+		w.profiles[account] = p
+	}
+	return p.profiler
+}
+
+// SetDebugger pauses the contracts on the breakpoints of debugger, no debugger runs them without pausing
+func (w *WasmGo) SetDebugger(debugger *Debugger) {
+	w.debugger = debugger
+}
+
+func (w *WasmGo) Debugger() *Debugger {
+	return w.debugger
+}
+
 func (w *WasmGo) Apply(codeId *crypto.Sha256, code []byte, context EnvContext) {
 	w.context = context
 
-	var vm *VirtualMachine = w.vmCache[*codeId]
+	var vm *VirtualMachine = w.codeCache.get(codeId)
 	if vm != nil {
 		vm.WasmGo = w
 		vm.Reset()
 	} else {
 		context.PauseBillingTimer()
-		//vm, err := NewVirtualMachine(w, code, exec.VMConfig{
-		//	EnableJIT:false,
-		//	MaxMemoryPages: MaximumLinearMemory / WasmPageSize,
-		//	DefaultMemoryPages: 1,
-		//	DefaultTableSize:   65536}, new(Resolver), nil)
 		var err error
-		vm, err = NewVirtualMachine(w, code, VMConfig{
-			EnableJIT:          false,
-			MaxMemoryPages:     MaximumLinearMemory / WasmPageSize,
-			DefaultMemoryPages: 1,
-			DefaultTableSize:   65536,
-		}, new(Resolver), nil)
-
+		vm, err = w.newVirtualMachine(codeId, code)
 		if err != nil {
 			w.ilog.Error("could not create VM: %v", err)
+		} else {
+			w.codeCache.add(codeId, vm)
 		}
-
-		w.vmCache[*codeId] = vm
 		context.ResumeBillingTimer()
 	}
+	vm.Config.GasLimit = gasLimit(context, w.gasLimit)
+	vm.Profiler = w.profiler(context.GetReceiver(), codeId, vm)
+	if vm.Profiler != nil {
+		defer vm.Profiler.stop()
+	}
+	vm.Debugger = w.debugger
+	if vm.Debugger != nil {
+		vm.Debugger.attach(vm, context)
+	}
 
 	//start := time.Now()
 	entryID, ok := vm.GetFunctionExport("apply")
@@ -99,6 +193,8 @@ func (w *WasmGo) Apply(codeId *crypto.Sha256, code []byte, context EnvContext) {
 	if vm.Module.Base.Start != nil {
 		startID := int(vm.Module.Base.Start.Index)
 		_, err := vm.Run(startID)
+		w.checkGas(vm)
+		w.checkAborted(vm)
 		if err != nil {
 			// vm.PrintStackTrace()
 			// panic(err)
@@ -113,6 +209,8 @@ func (w *WasmGo) Apply(codeId *crypto.Sha256, code []byte, context EnvContext) {
 
 	// Run the WebAssembly module's entry function.
 	_, err := vm.Run(entryID, args...)
+	w.checkGas(vm)
+	w.checkAborted(vm)
 	if err != nil {
 		// vm.PrintStackTrace()
 		// panic(err)
@@ -122,6 +220,54 @@ func (w *WasmGo) Apply(codeId *crypto.Sha256, code []byte, context EnvContext) {
 	//w.ilog.Info("return value = %d, duration = %v", ret, end.Sub(start))
 
 	//clear VM status
+	if w.gasPolicy != nil {
+		context.AddInstructionWeight(vm.Gas)
+	}
+}
+
+// gasLimit is the gas limit of the action of context, zero when it is not held to limit
+func gasLimit(context EnvContext, limit uint64) uint64 {
+	if !context.GasLimited() {
+		return 0
+	}
+	return limit
+}
+
+// checkGas fails the action when the instructions it executed weigh more than the gas limit
+func (w *WasmGo) checkGas(vm *VirtualMachine) {
+	if vm.GasLimitExceeded {
+		try.EosThrow(&exception.WasmGasExceeded{}, "%s::%s executed instructions weighing more than %d",
+			w.context.GetReceiver(), w.context.GetAct(), vm.Config.GasLimit)
+	}
+}
+
+// checkAborted fails the action when the debugger aborted it
+func (w *WasmGo) checkAborted(vm *VirtualMachine) {
+	if vm.Debugger != nil && vm.Debugger.aborted {
+		try.EosThrow(&exception.WasmExecutionError{}, "%s::%s aborted by the debugger", w.context.GetReceiver(), w.context.GetAct())
+	}
+}
+
+// newVirtualMachine instantiates the code of a contract with its compiled functions from the code cache, the code
+// is compiled when they are not in it
+func (w *WasmGo) newVirtualMachine(codeId *crypto.Sha256, code []byte) (*VirtualMachine, error) {
+	config := VMConfig{
+		EnableJIT:          false,
+		MaxMemoryPages:     MaximumLinearMemory / WasmPageSize,
+		DefaultMemoryPages: 1,
+		DefaultTableSize:   65536,
+	}
+
+	m, functionCode, ok := w.codeCache.load(codeId, code, config)
+	if !ok {
+		var err error
+		m, functionCode, err = CompileModule(code, config, w.gasPolicy)
+		if err != nil {
+			return nil, err
+		}
+		w.codeCache.store(codeId, functionCode)
+	}
+	return NewCompiledVirtualMachine(w, m, functionCode, config, new(Resolver))
 }
 
 // Resolver defines imports for WebAssembly modules ran in Life.